@@ -3,18 +3,34 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/layered-flow/layered-code/internal/cli"
+	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/constants"
 	"github.com/layered-flow/layered-code/internal/mcp"
+	"github.com/layered-flow/layered-code/internal/tools/git"
 	"github.com/layered-flow/layered-code/internal/update"
 )
 
 // run contains the main application logic
 func run(args []string) error {
-	// Check for updates
-	if hasUpdate, latestVersion, err := update.CheckForUpdate(constants.ProjectVersion); err == nil && hasUpdate {
-		update.DisplayUpdateWarning(latestVersion)
+	// apply --config and --set before anything reads the config
+	rest, err := cli.ParseGlobalOptions(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != len(args) {
+		// subcommands read their arguments from os.Args
+		os.Args = rest
+		args = rest
+	}
+
+	// Check for updates; an invalid config is reported by the command itself
+	if cfg, err := config.Load(); err == nil && cfg.Update.Check {
+		if hasUpdate, latestVersion, err := update.CheckForUpdate(constants.ProjectVersion, time.Duration(cfg.Update.Interval)); err == nil && hasUpdate {
+			update.DisplayUpdateWarning(latestVersion)
+		}
 	}
 
 	// return if no arguments are provided
@@ -26,13 +42,21 @@ func run(args []string) error {
 	// run the MCP server or a tool with a subcommand
 	switch args[1] {
 	case "mcp_server":
-		if err := mcp.StartServer(constants.ProjectName, constants.ProjectVersion); err != nil {
+		pol, err := cli.ParseServerArgs(args[2:])
+		if err != nil {
+			return fmt.Errorf("mcp server error: %w", err)
+		}
+		if err := mcp.StartServer(constants.ProjectName, constants.ProjectVersion, pol); err != nil {
 			return fmt.Errorf("mcp server error: %w", err)
 		}
 	case "tool":
 		if err := cli.RunTool(); err != nil {
 			return fmt.Errorf("tool error: %w", err)
 		}
+	case "config":
+		if err := cli.RunConfig(); err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
 	case "help", "-h", "--help":
 		cli.PrintUsage()
 	case "version", "-v", "--version":
@@ -47,6 +71,12 @@ func run(args []string) error {
 
 // main is the entry point
 func main() {
+	// Git runs this program as GIT_ASKPASS to ask for the token of a git credential
+	if answer, ok := git.Askpass(os.Args); ok {
+		fmt.Println(answer)
+		return
+	}
+
 	if err := run(os.Args); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
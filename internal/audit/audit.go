@@ -0,0 +1,283 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+// Entry is a single tool invocation in the audit log
+type Entry struct {
+	Time       time.Time      `json:"time"`
+	Client     string         `json:"client,omitempty"`
+	Tool       string         `json:"tool"`
+	AppName    string         `json:"app_name,omitempty"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Result     string         `json:"result,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Log is an append-only JSONL audit log that rotates once it grows past MaxSize
+type Log struct {
+	Path       string
+	MaxSize    int64 // Size in bytes at which the log is rotated
+	MaxBackups int   // Number of rotated files to keep (audit.jsonl.1, audit.jsonl.2, ...)
+
+	mu sync.Mutex
+}
+
+// New returns a log writing to path with the default rotation settings
+func New(path string) *Log {
+	return &Log{
+		Path:       path,
+		MaxSize:    constants.AuditLogMaxSize,
+		MaxBackups: constants.AuditLogMaxBackups,
+	}
+}
+
+// Configured returns the log described by the audit settings: audit.log, defaulting to
+// audit.jsonl in the config directory, rotated per audit.max_size and audit.max_backups
+func Configured() (*Log, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	path := cfg.Audit.Log
+	if path == "" {
+		dir, err := config.Dir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config directory: %w", err)
+		}
+		path = filepath.Join(dir, constants.AuditLogFileName)
+	}
+
+	return &Log{
+		Path:       path,
+		MaxSize:    int64(cfg.Audit.MaxSize),
+		MaxBackups: cfg.Audit.MaxBackups,
+	}, nil
+}
+
+// Write appends an entry to the log, rotating it first if it is full
+func (l *Log) Write(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if info, err := os.Stat(l.Path); err == nil && info.Size()+int64(len(data)) > l.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts audit.jsonl to audit.jsonl.1, audit.jsonl.1 to audit.jsonl.2 and so on,
+// dropping the oldest file
+func (l *Log) rotate() error {
+	if l.MaxBackups < 1 {
+		return os.Remove(l.Path)
+	}
+
+	for i := l.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(l.backup(i), l.backup(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.Path, l.backup(1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
+
+// backup returns the path of the nth rotated file
+func (l *Log) backup(n int) string {
+	return l.Path + "." + strconv.Itoa(n)
+}
+
+// Filter selects entries when reading the log; zero fields match everything
+type Filter struct {
+	AppName string
+	Tool    string
+	Since   time.Time
+	Until   time.Time
+}
+
+// matches reports whether the entry passes the filter
+func (f Filter) matches(e Entry) bool {
+	if f.AppName != "" && e.AppName != f.AppName {
+		return false
+	}
+	if f.Tool != "" && e.Tool != f.Tool {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries matching filter from the log and its rotated files, newest first
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	paths := []string{l.Path}
+	for i := 1; i <= l.MaxBackups; i++ {
+		paths = append(paths, l.backup(i))
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry Entry
+			// Skip lines that can't be parsed, such as a line cut short by a crash
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			if filter.matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// Elide returns a copy of the arguments with long strings replaced by their size and hash,
+// so file contents don't end up in the log, and secrets masked in the others
+func Elide(args map[string]any) map[string]any {
+	if args == nil {
+		return nil
+	}
+	elided := make(map[string]any, len(args))
+	for key, value := range args {
+		elided[key] = elideValue(value)
+	}
+	return elided
+}
+
+// elideValue shortens long strings and redacts short ones, descending into arrays and objects
+func elideValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if len(v) <= constants.AuditMaxArgumentLength {
+			return redactString(v)
+		}
+		sum := sha256.Sum256([]byte(v))
+		return fmt.Sprintf("<elided %d bytes sha256:%s>", len(v), hex.EncodeToString(sum[:])[:16])
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = elideValue(item)
+		}
+		return items
+	case map[string]any:
+		return Elide(v)
+	}
+	return value
+}
+
+// redactValue redacts secrets from every string, descending into arrays and objects
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return redactString(v)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = redactValue(item)
+		}
+		return items
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			redacted[key] = redactValue(item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactString masks secrets and credentials in URLs
+func redactString(s string) string {
+	s, _ = secrets.Redact(s)
+	s, _ = secrets.RedactURLs(s)
+	return s
+}
+
+// Summarize returns the JSON encoding of a tool result with secrets redacted, truncated for
+// the log. Strings are redacted before encoding so KEY=value lines are still found at the
+// start of a line.
+func Summarize(result any) string {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return ""
+	}
+	if data, err = json.Marshal(redactValue(decoded)); err != nil {
+		return ""
+	}
+	if len(data) > constants.AuditMaxResultLength {
+		return string(data[:constants.AuditMaxResultLength]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "audit.jsonl"))
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: base, Tool: "lc_read_file", AppName: "app1"},
+		{Time: base.Add(time.Hour), Tool: "git_push", AppName: "app1", Error: "rejected"},
+		{Time: base.Add(2 * time.Hour), Tool: "git_push", AppName: "app2"},
+	}
+	for _, entry := range entries {
+		if err := log.Write(entry); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all newest first", Filter{}, []string{"git_push/app2", "git_push/app1", "lc_read_file/app1"}},
+		{"by app", Filter{AppName: "app1"}, []string{"git_push/app1", "lc_read_file/app1"}},
+		{"by tool", Filter{Tool: "git_push"}, []string{"git_push/app2", "git_push/app1"}},
+		{"since", Filter{Since: base.Add(time.Hour)}, []string{"git_push/app2", "git_push/app1"}},
+		{"until", Filter{Until: base.Add(30 * time.Minute)}, []string{"lc_read_file/app1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := log.Read(tt.filter)
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			var keys []string
+			for _, e := range got {
+				keys = append(keys, e.Tool+"/"+e.AppName)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, keys)
+			}
+		})
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	entries, err := New(filepath.Join(t.TempDir(), "audit.jsonl")).Read(Filter{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected no entries and no error, got %v, %v", entries, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log := &Log{Path: path, MaxSize: 200, MaxBackups: 2}
+
+	for i := 0; i < 10; i++ {
+		if err := log.Write(Entry{Time: time.Now(), Tool: "lc_read_file", AppName: "app"}); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("expected %s to be rotated at 200 bytes, got %d", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+
+	entries, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(entries) == 0 || len(entries) >= 10 {
+		t.Errorf("expected rotated entries to be dropped, got %d", len(entries))
+	}
+}
+
+func TestElide(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+	args := map[string]any{
+		"app_name": "myapp",
+		"content":  long,
+		"files":    []any{"a.txt", long},
+		"limit":    float64(5),
+	}
+
+	elided := Elide(args)
+	if elided["app_name"] != "myapp" || elided["limit"] != float64(5) {
+		t.Errorf("expected short values to be kept, got %v", elided)
+	}
+	content, _ := elided["content"].(string)
+	if !strings.HasPrefix(content, "<elided 1000 bytes sha256:") {
+		t.Errorf("expected content to be elided, got %q", content)
+	}
+	files := elided["files"].([]any)
+	if files[0] != "a.txt" || files[1] != content {
+		t.Errorf("expected array items to be elided, got %v", files)
+	}
+	if args["content"] != long {
+		t.Error("expected original arguments to be unchanged")
+	}
+
+	// Short values are logged, but not the secrets in them
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	elided = Elide(map[string]any{
+		"content": "GITHUB_TOKEN=" + token + "\n",
+		"url":     "https://" + token + "@github.com/user/repo.git",
+		"files":   []any{"API_KEY=" + token},
+	})
+	for _, value := range []string{
+		elided["content"].(string),
+		elided["url"].(string),
+		elided["files"].([]any)[0].(string),
+	} {
+		if strings.Contains(value, token) || !strings.Contains(value, "[REDACTED:") {
+			t.Errorf("expected token to be masked, got %q", value)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if got := Summarize(map[string]bool{"success": true}); got != `{"success":true}` {
+		t.Errorf("unexpected summary: %s", got)
+	}
+	if got := Summarize(strings.Repeat("x", 1000)); len(got) != 512+len("...") {
+		t.Errorf("expected summary to be truncated, got %d bytes", len(got))
+	}
+
+	// Results shown with show_secrets don't leave the secrets in the log
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	got := Summarize(map[string]any{
+		"content": "# Settings\nGITHUB_TOKEN=" + token + "\n",
+		"remotes": []any{"https://" + token + "@github.com/user/repo.git"},
+	})
+	if strings.Contains(got, token) || !strings.Contains(got, "[REDACTED:") {
+		t.Errorf("expected secrets to be redacted from the summary, got %s", got)
+	}
+}
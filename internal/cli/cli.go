@@ -3,55 +3,46 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
-	"github.com/layered-flow/layered-code/internal/tools/git"
-	"github.com/layered-flow/layered-code/internal/tools/lc"
-	"github.com/layered-flow/layered-code/internal/tools/pnpm"
-	"github.com/layered-flow/layered-code/internal/tools/vite"
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/policy"
+	"github.com/layered-flow/layered-code/internal/tools"
 )
 
 // PrintUsage displays the available commands and their usage information
 func PrintUsage() {
-	fmt.Println("Usage: layered-code <command> [args]")
+	fmt.Println("Usage: layered-code [--config <file>] [--set key=value]... <command> [args]")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  mcp_server                Start the MCP server")
+	fmt.Println("    --read-only             Expose only tools that don't modify anything")
+	fmt.Println("    --allow <names>         Comma-separated tools or groups to expose (default: all)")
+	fmt.Println("    --deny <names>          Comma-separated tools or groups to hide")
+	fmt.Println("    --deny-arg <rule>       Forbid an argument, e.g. git_push.force or git_reset.mode=hard")
+	fmt.Println("    --confirm <rule>        Also ask the user before matching calls, e.g. git_branch.delete")
+	fmt.Println("    --no-confirm            Don't ask before destructive calls (hard reset, force push, ...)")
+	fmt.Println("    --policy <file>         Load these settings from a JSON policy file (or $" + constants.PolicyFileEnvVar + ")")
+
 	fmt.Println()
-	fmt.Println("  File Management Tools:")
-	fmt.Println("  tool lc_list_apps         List all available apps")
-	fmt.Println("  tool lc_list_files        List files and directories within an app")
-	fmt.Println("  tool lc_search_text       Search for text patterns in files using ripgrep")
-	fmt.Println("  tool lc_read_file         Read the contents of a file within an app")
-	fmt.Println("  tool lc_write_file        Write or create a file within an app")
-	fmt.Println("  tool lc_edit_file         Edit a file using find-and-replace")
-	fmt.Println("  tool lc_move_file         Move or rename a file within an app")
-	fmt.Println("  tool lc_delete_file       Delete a file within an app")
-	fmt.Println("  tool lc_copy_file         Copy a file within an app")
-	fmt.Println()
-	fmt.Println("  Vite Tools:")
-	fmt.Println("  tool vite_create_app      Create a new Vite app with template")
-	fmt.Println()
-	fmt.Println("  Package Manager Tools:")
-	fmt.Println("  tool pnpm_install         Install dependencies using pnpm (preferred) or npm")
-	fmt.Println("  tool pnpm_add             Add a package using pnpm (preferred) or npm")
-	fmt.Println("  tool pnpm_pm2             Manage Node.js processes with PM2 (auto-detects scripts)")
-	fmt.Println()
-	fmt.Println("  Git Tools:")
-	fmt.Println("  tool git_status           Show the working tree status")
-	fmt.Println("  tool git_diff             Show changes between commits")
-	fmt.Println("  tool git_commit           Create a new commit")
-	fmt.Println("  tool git_log              Show commit logs")
-	fmt.Println("  tool git_branch           List, create, or delete branches")
-	fmt.Println("  tool git_add              Add file contents to staging area")
-	fmt.Println("  tool git_restore          Restore working tree files")
-	fmt.Println("  tool git_stash            Stash changes in working directory")
-	fmt.Println("  tool git_push             Update remote refs")
-	fmt.Println("  tool git_pull             Fetch from and integrate with remote")
-	fmt.Println("  tool git_init             Initialize a new git repository")
-	fmt.Println("  tool git_remote           Manage git remotes (list, add, remove, rename)")
-	fmt.Println("  tool git_reset            Reset HEAD to specified state")
-	fmt.Println("  tool git_revert           Create revert commits")
-	fmt.Println("  tool git_checkout         Switch branches or restore files")
+	fmt.Println("  config get <key>          Show a setting")
+	fmt.Println("  config set <key> <value>  Save a setting to the config file")
+	fmt.Println("  config list               Show all settings and where they come from")
+	fmt.Println("  config path               Show the config file location")
+	fmt.Println("  config encrypt <file>     Encrypt a git token read from stdin into a token_file")
+
+	all := tools.All()
+	for _, group := range tools.Groups {
+		fmt.Println()
+		fmt.Printf("  %s:\n", group.Title)
+		for _, tool := range all {
+			if tool.Group == group.Name {
+				fmt.Printf("  tool %-20s %s\n", tool.Name, tool.Summary)
+			}
+		}
+	}
+
 	fmt.Println()
 	fmt.Println("  help, -h, --help          Show this help message")
 	fmt.Println("  version, -v, --version    Show version information")
@@ -64,72 +55,100 @@ func RunTool() error {
 	}
 
 	subcommand := os.Args[2]
-	switch subcommand {
-	// File management tools
-	case "lc_list_apps":
-		return lc.LcListAppsCli()
-	case "lc_list_files":
-		return lc.LcListFilesCli()
-	case "lc_search_text":
-		return lc.LcSearchTextCli()
-	case "lc_read_file":
-		return lc.LcReadFileCli()
-	case "lc_write_file":
-		return lc.LcWriteFileCli()
-	case "lc_edit_file":
-		return lc.LcEditFileCli()
-	case "lc_move_file":
-		return lc.LcMoveFileCli()
-	case "lc_delete_file":
-		return lc.LcDeleteFileCli()
-	case "lc_copy_file":
-		return lc.LcCopyFileCli()
-
-	// Vite tools
-	case "vite_create_app":
-		return vite.ViteCreateAppCli()
-
-	// Package Manager tools
-	case "pnpm_install":
-		return pnpm.PnpmInstallCli()
-	case "pnpm_add":
-		return pnpm.PnpmAddCli()
-	case "pnpm_pm2":
-		return pnpm.PnpmPm2Cli()
-
-	// Git tools
-	case "git_status":
-		return git.GitStatusCli()
-	case "git_diff":
-		return git.GitDiffCli()
-	case "git_commit":
-		return git.GitCommitCli()
-	case "git_log":
-		return git.GitLogCli()
-	case "git_branch":
-		return git.GitBranchCli()
-	case "git_add":
-		return git.GitAddCli()
-	case "git_restore":
-		return git.GitRestoreCli()
-	case "git_stash":
-		return git.GitStashCli()
-	case "git_push":
-		return git.GitPushCli()
-	case "git_pull":
-		return git.GitPullCli()
-	case "git_init":
-		return git.GitInitCli()
-	case "git_remote":
-		return git.GitRemoteCli()
-	case "git_reset":
-		return git.GitResetCli()
-	case "git_revert":
-		return git.GitRevertCli()
-	case "git_checkout":
-		return git.GitCheckoutCli()
-
-	default:
+	tool, ok := tools.Find(subcommand)
+	if !ok {
 		return fmt.Errorf("unknown tool: %s\nRun 'layered-code help' to see all available tools", subcommand)
 	}
+
+	return tool.RunCLI(os.Args[3:], os.Stdout)
+}
+
+// ParseServerArgs builds the MCP server tool policy from the tools settings, the policy file
+// and mcp_server flags, each adding to the one before.
+func ParseServerArgs(args []string) (policy.Policy, error) {
+	var flags policy.Policy
+	policyFile := os.Getenv(constants.PolicyFileEnvVar)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--read-only":
+			flags.ReadOnly = true
+			continue
+		case "--no-confirm":
+			flags.NoConfirm = true
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--allow", "--deny", "--deny-arg", "--confirm", "--policy":
+		default:
+			return flags, fmt.Errorf("unknown mcp_server option: %s\nRun 'layered-code help' for usage", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--allow":
+			flags.Allow = append(flags.Allow, splitList(value)...)
+		case "--deny":
+			flags.Deny = append(flags.Deny, splitList(value)...)
+		case "--deny-arg":
+			flags.DenyArgs = append(flags.DenyArgs, value)
+		case "--confirm":
+			flags.Confirm = append(flags.Confirm, value)
+		case "--policy":
+			policyFile = value
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return flags, err
+	}
+	pol := policy.Policy{
+		Allow:     cfg.Tools.Allow,
+		Deny:      cfg.Tools.Deny,
+		ReadOnly:  cfg.Tools.ReadOnly,
+		DenyArgs:  cfg.Tools.DenyArgs,
+		Confirm:   cfg.Tools.Confirm,
+		NoConfirm: cfg.Tools.NoConfirm,
+	}
+	for name, ws := range cfg.Workspaces {
+		if pol.Workspaces == nil {
+			pol.Workspaces = make(map[string]policy.Policy)
+		}
+		pol.Workspaces[name] = policy.Policy{
+			Allow:    ws.Allow,
+			Deny:     ws.Deny,
+			ReadOnly: ws.ReadOnly,
+			DenyArgs: ws.DenyArgs,
+		}
+	}
+
+	if policyFile != "" {
+		filePolicy, err := policy.Load(policyFile)
+		if err != nil {
+			return flags, err
+		}
+		pol = pol.Merge(filePolicy)
+	}
+	return pol.Merge(flags), nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -4,9 +4,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestRunTool(t *testing.T) {
+	testutil.Home(t)
+
 	tests := []struct {
 		name    string
 		args    []string
@@ -66,6 +71,8 @@ func TestRunTool(t *testing.T) {
 }
 
 func TestRunToolErrorMessages(t *testing.T) {
+	testutil.Home(t)
+
 	originalArgs := os.Args
 	defer func() { os.Args = originalArgs }()
 
@@ -98,3 +105,83 @@ func TestPrintUsage(t *testing.T) {
 	}()
 	PrintUsage()
 }
+
+func TestParseServerArgs(t *testing.T) {
+	testutil.Home(t)
+
+	pol, err := ParseServerArgs([]string{"--read-only", "--allow", "lc, git", "--deny=git_push", "--deny-arg", "git_reset.mode=hard", "--confirm", "pnpm_pm2.command=stop,target=all", "--no-confirm"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pol.NoConfirm || strings.Join(pol.Confirm, ";") != "pnpm_pm2.command=stop,target=all" {
+		t.Errorf("unexpected confirm settings: %+v", pol)
+	}
+	if !pol.ReadOnly || strings.Join(pol.Allow, ",") != "lc,git" || strings.Join(pol.Deny, ",") != "git_push" || strings.Join(pol.DenyArgs, ",") != "git_reset.mode=hard" {
+		t.Errorf("unexpected policy: %+v", pol)
+	}
+
+	if _, err := ParseServerArgs([]string{"--bogus"}); err == nil || !strings.Contains(err.Error(), "unknown mcp_server option: --bogus") {
+		t.Errorf("expected unknown option error, got %v", err)
+	}
+	if _, err := ParseServerArgs([]string{"--deny"}); err == nil || !strings.Contains(err.Error(), "--deny requires a value") {
+		t.Errorf("expected missing value error, got %v", err)
+	}
+
+	path := t.TempDir() + "/policy.json"
+	if err := os.WriteFile(path, []byte(`{"deny": ["vite"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAYERED_POLICY_FILE", path)
+	pol, err = ParseServerArgs([]string{"--deny", "pnpm"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(pol.Deny, ",") != "vite,pnpm" {
+		t.Errorf("expected file and flag rules to merge, got %v", pol.Deny)
+	}
+
+	configPath := t.TempDir() + "/config.toml"
+	if err := os.WriteFile(configPath, []byte("[workspaces.work]\nroot = \"/srv/work\"\nread_only = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAYERED_CONFIG", configPath)
+	pol, err = ParseServerArgs(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pol.Workspaces["work"].ReadOnly {
+		t.Errorf("expected the work workspace policy from the config file, got %+v", pol.Workspaces)
+	}
+}
+
+func TestParseGlobalOptions(t *testing.T) {
+	testutil.Home(t)
+
+	t.Setenv("LAYERED_CONFIG", "")
+	defer config.ResetFlags()
+
+	path := t.TempDir() + "/custom.toml"
+	rest, err := ParseGlobalOptions([]string{"layered-code", "--config", path, "--set", "files.max_size=1MB", "tool", "lc_list_apps"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(rest, " ") != "layered-code tool lc_list_apps" {
+		t.Errorf("unexpected remaining args: %v", rest)
+	}
+	if got, _ := config.Path(); got != path {
+		t.Errorf("expected config path %s, got %s", path, got)
+	}
+	if config.MaxFileSize() != 1024*1024 {
+		t.Errorf("expected --set to override files.max_size, got %s", config.MaxFileSize())
+	}
+
+	if _, err := ParseGlobalOptions([]string{"layered-code", "--set", "files.max_size"}); err == nil || !strings.Contains(err.Error(), "--set expects key=value") {
+		t.Errorf("expected key=value error, got %v", err)
+	}
+	if _, err := ParseGlobalOptions([]string{"layered-code", "--set", "nope=1"}); err == nil || !strings.Contains(err.Error(), "nope: unknown key") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+	if _, err := ParseGlobalOptions([]string{"layered-code", "--config"}); err == nil {
+		t.Error("expected missing value error")
+	}
+}
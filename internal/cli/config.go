@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/layered-flow/layered-code/internal/config"
+)
+
+// ParseGlobalOptions applies the options given before the command (--config <file> and
+// --set key=value) and returns the remaining arguments, program name first
+func ParseGlobalOptions(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+
+	i := 1
+	for ; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--config" && name != "--set" {
+			break
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		if name == "--config" {
+			config.SetPath(value)
+			continue
+		}
+		key, setting, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("--set expects key=value, got %q", value)
+		}
+		if err := config.Override(key, setting); err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]string{args[0]}, args[i:]...), nil
+}
+
+// RunConfig executes the config subcommand: get, set, list, path or encrypt
+func RunConfig() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("config subcommand is required\nUsage: layered-code config <get|set|list|path|encrypt> [args]")
+	}
+
+	subcommand, args := os.Args[2], os.Args[3:]
+	switch subcommand {
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: layered-code config get <key>")
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: layered-code config set <key> <value>")
+		}
+		if err := config.Set(args[0], args[1]); err != nil {
+			return err
+		}
+		path, err := config.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Set %s in %s\n", args[0], path)
+
+	case "list":
+		settings, err := config.List()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tDESCRIPTION")
+		for _, s := range settings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Key, s.Value, s.Source, s.Description)
+		}
+		tw.Flush()
+
+	case "path":
+		path, err := config.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+
+	case "encrypt":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: layered-code config encrypt <file> (reads the token from stdin)")
+		}
+		token, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(token)) == "" {
+			return fmt.Errorf("no token on stdin")
+		}
+		data, err := config.EncryptToken(strings.TrimSpace(string(token)))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(args[0], data, 0600); err != nil {
+			return err
+		}
+		fmt.Printf("Encrypted the token to %s; use it as git.credentials.<name>.token_file\n", args[0])
+
+	default:
+		return fmt.Errorf("unknown config subcommand: %s\nUsage: layered-code config <get|set|list|path|encrypt> [args]", subcommand)
+	}
+
+	return nil
+}
@@ -19,16 +19,21 @@ var (
 )
 
 // GetAppsDirectory returns the apps directory path.
-// It first checks the LAYERED_APPS_DIRECTORY environment variable,
-// and if not set, defaults to ~/LayeredApps
+// It comes from the apps_directory setting (LAYERED_APPS_DIRECTORY environment variable
+// or config file) and defaults to ~/LayeredApps
 func GetAppsDirectory() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
-	appsDir := os.Getenv(constants.AppsDirectoryEnvVar)
-	if appsDir == "" {
+	cfg, err := Load()
+	if err != nil {
+		return "", err
+	}
+
+	appsDir := cfg.AppsDirectory
+	if appsDir == constants.DefaultAppsDirectory {
 		// Use default directory if not set
 		appsDir = filepath.Join(homeDir, constants.DefaultAppsDirectory)
 	} else {
@@ -91,6 +96,35 @@ func resolveSymlinks(path string) (string, error) {
 	return resolved, nil
 }
 
+// MaxFileSize returns the largest file the file tools read or write.
+// Errors loading the config are reported by GetAppsDirectory, so they fall back to the default here
+func MaxFileSize() Size {
+	cfg, err := Load()
+	if err != nil {
+		return Default().Files.MaxSize
+	}
+	return cfg.Files.MaxSize
+}
+
+// FileSettings returns the files settings, including the denied and protected app paths
+func FileSettings() FilesConfig {
+	cfg, err := Load()
+	if err != nil {
+		return Default().Files
+	}
+	return cfg.Files
+}
+
+// GitSettings returns the git settings: the git timeout and the checks before git_commit and
+// git_push
+func GitSettings() GitConfig {
+	cfg, err := Load()
+	if err != nil {
+		return Default().Git
+	}
+	return cfg.Git
+}
+
 // IsWithinDirectory checks if the target path is within the base directory
 func IsWithinDirectory(targetPath, baseDir string) bool {
 	// Resolve symlinks first to prevent bypass attacks
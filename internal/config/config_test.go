@@ -6,13 +6,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGetAppsDirectory(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("failed to get home directory: %v", err)
-	}
+	homeDir := testutil.Home(t)
 
 	tests := []struct {
 		envVar  string
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"cmp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+// Host key checking policies of git.credentials.<name>.known_hosts
+const (
+	KnownHostsStrict    = "strict"
+	KnownHostsAcceptNew = "accept-new"
+	KnownHostsOff       = "off"
+)
+
+// CredentialFor returns the name of the credential for a remote, given the remote's name and
+// URL, and the credential. One matching the remote's name wins over one matching a prefix of
+// its URL, which wins over one matching its host; the longest URL prefix wins among those.
+// A credential with a token matches a remote by name only if the remote's URL also matches its
+// url, so retargeting the remote doesn't send the token elsewhere.
+func (g GitConfig) CredentialFor(remote, url string) (string, GitCredential, bool) {
+	var found string
+	best := 0
+	for _, name := range slices.Sorted(maps.Keys(g.Credentials)) {
+		credential := g.Credentials[name]
+		match := cmp.Or(credential.Match, name)
+		score := 0
+		switch {
+		case match == remote && remote != "":
+			if !credential.hasToken() || (credential.URL != "" && urlMatches(url, credential.URL) > 0) {
+				score = 1 << 20
+			}
+		default:
+			score = urlMatches(url, match)
+		}
+		if score > best {
+			found, best = name, score
+		}
+	}
+	if found == "" {
+		return "", GitCredential{}, false
+	}
+	return found, g.Credentials[found], true
+}
+
+// urlMatches scores how well match, a host or URL prefix, matches url: 1 plus its length for a
+// URL prefix, 1 for the host and 0 if it doesn't match
+func urlMatches(url, match string) int {
+	switch {
+	case strings.ContainsAny(match, ":/") && urlHasPrefix(url, match):
+		return 1 + len(match)
+	case strings.EqualFold(match, remoteHost(url)):
+		return 1
+	}
+	return 0
+}
+
+// urlHasPrefix reports whether match is a prefix of url ending at a path or port boundary, so
+// https://github.com doesn't match https://github.com.evil.example
+func urlHasPrefix(url, match string) bool {
+	if !strings.HasPrefix(url, match) {
+		return false
+	}
+	rest := url[len(match):]
+	return rest == "" || strings.HasSuffix(match, "/") || rest[0] == '/' || rest[0] == ':'
+}
+
+// remoteHost returns the host of a remote URL, written https://host/path,
+// ssh://user@host:port/path or user@host:path, or "" for a local path
+func remoteHost(url string) string {
+	var host string
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		host, _, _ = strings.Cut(rest, "/")
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+			host = host[:i]
+		}
+		return strings.Trim(host, "[]")
+	}
+
+	// The scp-like syntax has a colon before any slash
+	i := strings.Index(url, ":")
+	if i <= 0 || strings.Contains(url[:i], "/") {
+		return ""
+	}
+	host = url[:i]
+	if j := strings.LastIndex(host, "@"); j >= 0 {
+		host = host[j+1:]
+	}
+	return host
+}
+
+// hasToken reports whether the credential sends a token over HTTPS
+func (c GitCredential) hasToken() bool {
+	return c.TokenEnv != "" || c.TokenFile != ""
+}
+
+// User returns the user name sent with the credential's token
+func (c GitCredential) User() string {
+	return cmp.Or(c.Username, constants.GitCredentialUsername)
+}
+
+// Token returns the credential's HTTPS token from its environment variable, or else from its
+// encrypted file, or "" if it has neither
+func (c GitCredential) Token() (string, error) {
+	if c.TokenEnv != "" {
+		if token := os.Getenv(c.TokenEnv); token != "" {
+			return token, nil
+		}
+		if c.TokenFile == "" {
+			return "", fmt.Errorf("environment variable %s is not set", c.TokenEnv)
+		}
+	}
+	if c.TokenFile == "" {
+		return "", nil
+	}
+
+	path, err := expandHome(c.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token, err := DecryptToken(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return token, nil
+}
+
+// SSHKeyPath returns the path of the credential's SSH key, or "" if it has none
+func (c GitCredential) SSHKeyPath() (string, error) {
+	if c.SSHKey == "" {
+		return "", nil
+	}
+	return expandHome(c.SSHKey)
+}
+
+func (c GitCredential) validate(prefix string) error {
+	switch c.KnownHosts {
+	case "", KnownHostsStrict, KnownHostsAcceptNew, KnownHostsOff:
+	default:
+		return &KeyError{Key: prefix + "known_hosts", Err: fmt.Errorf("must be %s, %s or %s, got %q", KnownHostsStrict, KnownHostsAcceptNew, KnownHostsOff, c.KnownHosts)}
+	}
+	for _, setting := range []struct{ key, path string }{{"token_file", c.TokenFile}, {"ssh_key", c.SSHKey}} {
+		if setting.path != "" && !filepath.IsAbs(setting.path) && !strings.HasPrefix(setting.path, "~/") {
+			return &KeyError{Key: prefix + setting.key, Err: fmt.Errorf("must be an absolute path or start with ~/, got %q", setting.path)}
+		}
+	}
+	return nil
+}
+
+// expandHome resolves a path starting with ~/ against the home directory
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, rest), nil
+}
+
+// EncryptToken encrypts a token for a credential's token_file. The key is derived from
+// LAYERED_CREDENTIAL_KEY if it is set, or else read from credential.key in the config
+// directory, which is created on first use.
+func EncryptToken(token string) ([]byte, error) {
+	gcm, err := credentialCipher(true)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(token), nil)
+	return []byte(base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// DecryptToken decrypts a token written by EncryptToken
+func DecryptToken(data []byte) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", errors.New("not an encrypted token")
+	}
+	gcm, err := credentialCipher(false)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("not an encrypted token")
+	}
+	token, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt the token; it was encrypted with another key")
+	}
+	return string(token), nil
+}
+
+// credentialCipher returns the cipher for token files, creating the key file if asked to
+func credentialCipher(create bool) (cipher.AEAD, error) {
+	key, err := credentialKey(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func credentialKey(create bool) ([]byte, error) {
+	if passphrase := os.Getenv(constants.CredentialKeyEnvVar); passphrase != "" {
+		sum := sha256.Sum256([]byte(passphrase))
+		return sum[:], nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, constants.CredentialKeyFile)
+	key, err := os.ReadFile(path)
+	switch {
+	case err == nil && len(key) != 32:
+		return nil, fmt.Errorf("%s is not a credential key", path)
+	case err == nil:
+		return key, nil
+	case !errors.Is(err, fs.ErrNotExist) || !create:
+		return nil, fmt.Errorf("no credential key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialSettings(t *testing.T) {
+	useConfigFile(t, "config.toml", "[git.credentials.github]\ntoken_env = \"GH_TOKEN\"\n")
+	t.Setenv("LAYERED_GIT_CREDENTIALS_GITHUB_USERNAME", "octocat")
+
+	if err := Set("git.credentials.work.ssh_key", "~/.ssh/work"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := Set("git.credentials.work.match", "git.example.com"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := Set("git.credentials.bad/name.match", "x"); err == nil {
+		t.Error("expected an invalid credential name to be rejected")
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := map[string]GitCredential{
+		"github": {TokenEnv: "GH_TOKEN", Username: "octocat"},
+		"work":   {Match: "git.example.com", SSHKey: "~/.ssh/work"},
+	}
+	if len(cfg.Git.Credentials) != len(want) || cfg.Git.Credentials["github"] != want["github"] || cfg.Git.Credentials["work"] != want["work"] {
+		t.Errorf("expected credentials %+v, got %+v", want, cfg.Git.Credentials)
+	}
+	if value, err := cfg.Get("git.credentials.github.username"); err != nil || value != "octocat" {
+		t.Errorf("expected the username from the environment, got %q, %v", value, err)
+	}
+}
+
+func TestCredentialFor(t *testing.T) {
+	git := GitConfig{Credentials: map[string]GitCredential{
+		"github":   {Match: "github.com"},
+		"upstream": {},
+		"org":      {Match: "https://github.com/acme/"},
+		"team":     {Match: "https://github.com/acme/team"},
+		"work":     {Match: "git.example.com"},
+		"self":     {Match: "https://code.example.org"},
+		"fork":     {TokenEnv: "FORK_TOKEN", URL: "https://github.com/me/"},
+		"mirror":   {TokenEnv: "MIRROR_TOKEN"},
+	}}
+	tests := []struct {
+		remote, url string
+		want        string
+	}{
+		{"origin", "https://github.com/user/repo.git", "github"},
+		{"origin", "git@github.com:user/repo.git", "github"},
+		{"origin", "ssh://git@git.example.com:2222/repo.git", "work"},
+		{"origin", "https://github.com/acme/site.git", "org"},
+		{"origin", "https://github.com/acme/team/site.git", "team"},
+		{"origin", "https://github.com/acme/team-site.git", "org"},
+		{"origin", "https://code.example.org/site.git", "self"},
+		{"origin", "https://code.example.org:8443/site.git", "self"},
+		{"origin", "https://code.example.org.evil.example/site.git", ""},
+		{"upstream", "https://github.com/acme/site.git", "upstream"},
+		{"fork", "https://github.com/me/site.git", "fork"},
+		// A retargeted remote doesn't get the token of the credential named after it
+		{"fork", "https://attacker.example/x", ""},
+		{"mirror", "https://github.com/me/site.git", "github"},
+		{"mirror", "https://attacker.example/x", ""},
+		{"origin", "https://gitlab.com/user/repo.git", ""},
+		{"origin", "/srv/git/repo.git", ""},
+	}
+	for _, tt := range tests {
+		name, _, ok := git.CredentialFor(tt.remote, tt.url)
+		if name != tt.want || ok != (tt.want != "") {
+			t.Errorf("CredentialFor(%q, %q) = %q, %v; want %q", tt.remote, tt.url, name, ok, tt.want)
+		}
+	}
+}
+
+func TestEncryptToken(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("LAYERED_CREDENTIAL_KEY", "")
+
+	data, err := EncryptToken("ghp_secret")
+	if err != nil {
+		t.Fatalf("EncryptToken() failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "layered-code", "credential.key"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected a private key file, got %v, %v", info, err)
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "github.token")
+	os.WriteFile(tokenFile, data, 0600)
+	token, err := GitCredential{TokenFile: tokenFile}.Token()
+	if err != nil || token != "ghp_secret" {
+		t.Errorf("expected the decrypted token, got %q, %v", token, err)
+	}
+
+	// The environment variable wins; the file is the fallback
+	t.Setenv("GH_TOKEN", "from-env")
+	if token, _ := (GitCredential{TokenEnv: "GH_TOKEN", TokenFile: tokenFile}).Token(); token != "from-env" {
+		t.Errorf("expected the token from the environment, got %q", token)
+	}
+	if _, err := (GitCredential{TokenEnv: "NO_SUCH_TOKEN"}).Token(); err == nil {
+		t.Error("expected an unset token variable to fail")
+	}
+
+	// Another key can't decrypt it
+	t.Setenv("LAYERED_CREDENTIAL_KEY", "passphrase")
+	if _, err := DecryptToken(data); err == nil {
+		t.Error("expected decrypting with another key to fail")
+	}
+	data, err = EncryptToken("glpat-secret")
+	if err != nil {
+		t.Fatalf("EncryptToken() failed: %v", err)
+	}
+	if token, err := DecryptToken(data); err != nil || token != "glpat-secret" {
+		t.Errorf("expected the decrypted token, got %q, %v", token, err)
+	}
+}
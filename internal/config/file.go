@@ -0,0 +1,329 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file names, in order of preference
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+var (
+	flagsMu      sync.RWMutex
+	pathFlag     string
+	flagSettings = map[string]string{}
+)
+
+// Dir returns the layered-code config directory: $XDG_CONFIG_HOME/layered-code,
+// defaulting to ~/.config/layered-code
+func Dir() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, constants.ProjectName), nil
+}
+
+// Path returns the config file in use: the --config flag, then LAYERED_CONFIG, then the first
+// existing config.toml, config.yaml or config.yml in Dir, defaulting to config.toml
+func Path() (string, error) {
+	flagsMu.RLock()
+	path := pathFlag
+	flagsMu.RUnlock()
+
+	if path != "" {
+		return path, nil
+	}
+	if path := os.Getenv(constants.ConfigFileEnvVar); path != "" {
+		return path, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return filepath.Join(dir, configFileNames[0]), nil
+}
+
+// SetPath makes the config load from path instead of the default location (--config flag)
+func SetPath(path string) {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	pathFlag = path
+}
+
+// Override sets a value for this run only, taking precedence over the config file and
+// environment (--set key=value flag)
+func Override(key, value string) error {
+	cfg := Default()
+	if err := cfg.assign(key, value); err != nil {
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) {
+			keyErr.Source = "--set"
+		}
+		return err
+	}
+
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	flagSettings[key] = value
+	return nil
+}
+
+// ResetFlags discards SetPath and Override
+func ResetFlags() {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	pathFlag = ""
+	flagSettings = map[string]string{}
+}
+
+// Load returns the settings: defaults, overridden by the config file, then environment
+// variables, then command line flags
+func Load() (Config, error) {
+	cfg, _, err := load()
+	return cfg, err
+}
+
+// load returns the settings and where each one came from
+func load() (Config, map[string]string, error) {
+	cfg := Default()
+	sources := make(map[string]string)
+
+	path, err := Path()
+	if err != nil {
+		return cfg, nil, err
+	}
+	values, err := readFile(path)
+	if err != nil {
+		return cfg, nil, err
+	}
+	for _, key := range sortedKeys(values) {
+		if err := cfg.assign(key, values[key]); err != nil {
+			return cfg, nil, withSource(err, path)
+		}
+		sources[key] = "file"
+	}
+
+	for _, f := range cfg.fields() {
+		value := os.Getenv(envName(f.key))
+		if value == "" {
+			continue
+		}
+		if err := cfg.assign(f.key, value); err != nil {
+			return cfg, nil, withSource(err, envName(f.key))
+		}
+		sources[f.key] = "env"
+	}
+
+	flagsMu.RLock()
+	for key, value := range flagSettings {
+		// Values were checked by Override
+		cfg.assign(key, value)
+		sources[key] = "flag"
+	}
+	flagsMu.RUnlock()
+
+	if err := cfg.validate(); err != nil {
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) {
+			return cfg, nil, err
+		}
+		var source string
+		switch sources[keyErr.Key] {
+		case "file":
+			source = path
+		case "env":
+			source = envName(keyErr.Key)
+		case "flag":
+			source = "--set"
+		}
+		return cfg, nil, withSource(err, source)
+	}
+	return cfg, sources, nil
+}
+
+// withSource records where an invalid value came from
+func withSource(err error, source string) error {
+	var keyErr *KeyError
+	if errors.As(err, &keyErr) && keyErr.Source == "" {
+		keyErr.Source = source
+	}
+	return err
+}
+
+// List returns every setting with its current value and source
+func List() ([]Setting, error) {
+	cfg, sources, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []Setting
+	for _, f := range cfg.fields() {
+		value, _ := cfg.Get(f.key)
+		source := sources[f.key]
+		if source == "" {
+			source = "default"
+		}
+		settings = append(settings, Setting{
+			Key:         f.key,
+			Env:         envName(f.key),
+			Description: f.description,
+			Value:       value,
+			Source:      source,
+		})
+	}
+	return settings, nil
+}
+
+// Set validates a value and saves it to the config file, creating the file if needed
+func Set(key, value string) error {
+	cfg := Default()
+	if err := cfg.assign(key, value); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) && keyErr.Key == key {
+			return err
+		}
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	values, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	f, _ := lookup(key)
+	values[key] = fileValue(cfg.value(f))
+
+	// Make sure the file as a whole still loads before replacing it
+	check := Default()
+	for _, k := range sortedKeys(values) {
+		if err := check.assign(k, values[k]); err != nil {
+			return withSource(err, path)
+		}
+	}
+
+	return writeFile(path, values)
+}
+
+// readFile reads a config file into values keyed by dotted setting key; a missing file is empty
+func readFile(path string) (map[string]any, error) {
+	values := make(map[string]any)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	tree := make(map[string]any)
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, &tree)
+	} else {
+		err = toml.Unmarshal(data, &tree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flatten(tree, "", values)
+	return values, nil
+}
+
+// flatten turns nested tables into dotted keys ({"files": {"max_size": x}} -> "files.max_size")
+func flatten(tree map[string]any, prefix string, values map[string]any) {
+	for key, value := range tree {
+		if table, ok := value.(map[string]any); ok {
+			flatten(table, prefix+key+".", values)
+			continue
+		}
+		values[prefix+key] = value
+	}
+}
+
+// writeFile saves dotted-key values as nested tables in the file's format
+func writeFile(path string, values map[string]any) error {
+	tree := make(map[string]any)
+	for key, value := range values {
+		parts := strings.Split(key, ".")
+		table := tree
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := table[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				table[part] = next
+			}
+			table = next
+		}
+		table[parts[len(parts)-1]] = value
+	}
+
+	var buf bytes.Buffer
+	if isYAML(path) {
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(tree); err != nil {
+			return fmt.Errorf("failed to encode config file: %w", err)
+		}
+	} else {
+		encoder := toml.NewEncoder(&buf)
+		encoder.Indent = ""
+		if err := encoder.Encode(tree); err != nil {
+			return fmt.Errorf("failed to encode config file: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// isYAML reports whether the file is YAML rather than TOML, by extension
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// sortedKeys returns the keys in order so errors are reported deterministically
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Dependencies that identify a framework, most specific first
+var frameworkPackages = []struct {
+	pkg       string
+	framework string
+}{
+	{"next", "next"},
+	{"nuxt", "nuxt"},
+	{"@sveltejs/kit", "sveltekit"},
+	{"astro", "astro"},
+	{"@remix-run/react", "remix"},
+	{"@builder.io/qwik", "qwik"},
+	{"solid-js", "solid"},
+	{"preact", "preact"},
+	{"react", "react"},
+	{"vue", "vue"},
+	{"svelte", "svelte"},
+	{"lit", "lit"},
+}
+
+// Dev server ports of frameworks that don't use Vite's
+var frameworkDevPorts = map[string]int{
+	"next":  3000,
+	"nuxt":  3000,
+	"astro": 4321,
+}
+
+const (
+	viteDevPort = 5173
+	nodeDevPort = 3000 // What most Node servers listen on when PORT isn't set
+)
+
+// DetectFramework names the framework of the app in appDir from package.json dependencies
+func DetectFramework(appDir string) string {
+	deps := packageDependencies(appDir)
+	for _, f := range frameworkPackages {
+		if deps[f.pkg] {
+			return f.framework
+		}
+	}
+	return ""
+}
+
+// DevPort returns the port the dev server of the app in appDir listens on: preview_port, else
+// the default of its framework. Other frameworks and Vite templates use Vite's port, and apps
+// without a known framework the usual Node server port unless they depend on Vite.
+func (m AppManifest) DevPort(appDir string) int {
+	if m.PreviewPort != 0 {
+		return m.PreviewPort
+	}
+	framework := m.Framework
+	if framework == "" {
+		framework = DetectFramework(appDir)
+	}
+	if port, ok := frameworkDevPorts[framework]; ok {
+		return port
+	}
+	if framework != "" || packageDependencies(appDir)["vite"] {
+		return viteDevPort
+	}
+	return nodeDevPort
+}
+
+// packageDependencies returns the dependencies and dev dependencies in the app's package.json
+func packageDependencies(appDir string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(appDir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	deps := make(map[string]bool, len(pkg.Dependencies)+len(pkg.DevDependencies))
+	for name := range pkg.Dependencies {
+		deps[name] = true
+	}
+	for name := range pkg.DevDependencies {
+		deps[name] = true
+	}
+	return deps
+}
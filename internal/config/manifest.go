@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+// AppManifest describes an app: how to run, preview, build and deploy it. It is stored in the
+// app's .layered/app.json; every field is optional and tools fall back to guessing without it.
+type AppManifest struct {
+	Description  string   `json:"description,omitempty" desc:"What the app is"`
+	Framework    string   `json:"framework,omitempty" desc:"Framework or Vite template (e.g. react-ts)"`
+	DevCommand   string   `json:"dev_command,omitempty" desc:"Command that starts the dev server (e.g. pnpm run dev)"`
+	PreviewURL   string   `json:"preview_url,omitempty" desc:"URL of the running app (default: http://localhost:<preview_port>)"`
+	PreviewPort  int      `json:"preview_port,omitempty" desc:"Port of the dev server"`
+	BuildCommand string   `json:"build_command,omitempty" desc:"Command that builds the app (e.g. pnpm run build)"`
+	OutputDir    string   `json:"output_dir,omitempty" desc:"Build output directory, relative to the app"`
+	DeployTarget string   `json:"deploy_target,omitempty" desc:"Where the app is deployed (e.g. netlify, vercel, s3://bucket)"`
+	Ignore       []string `json:"ignore,omitempty" desc:"Paths or glob patterns to ignore for live reload (e.g. node_modules, *.log)"`
+	Tools        []string `json:"tools,omitempty" desc:"Tools or groups the MCP server may use on this app (default: all)"`
+}
+
+// AppManifestPath returns the manifest file of the app in appDir
+func AppManifestPath(appDir string) string {
+	return filepath.Join(appDir, constants.AppManifestDir, constants.AppManifestFile)
+}
+
+// ReadAppManifest reads the manifest of the app in appDir; a missing manifest is empty
+func ReadAppManifest(appDir string) (AppManifest, bool, error) {
+	var m AppManifest
+
+	data, err := os.ReadFile(AppManifestPath(appDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, fmt.Errorf("failed to read app manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, false, fmt.Errorf("failed to parse %s: %w", AppManifestPath(appDir), err)
+	}
+	if err := m.Validate(); err != nil {
+		return m, false, fmt.Errorf("%s: %w", AppManifestPath(appDir), err)
+	}
+	return m, true, nil
+}
+
+// LoadAppManifest reads the manifest of an app by name, which may be qualified as workspace/app
+func LoadAppManifest(appName string) (AppManifest, error) {
+	appDir, err := AppDirectory(appName)
+	if err != nil {
+		return AppManifest{}, err
+	}
+	m, _, err := ReadAppManifest(appDir)
+	return m, err
+}
+
+// WriteAppManifest validates the manifest and saves it in the app in appDir
+func WriteAppManifest(appDir string, m AppManifest) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode app manifest: %w", err)
+	}
+
+	manifestPath := AppManifestPath(appDir)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), constants.AppsDirectoryPerms); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", constants.AppManifestDir, err)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write app manifest: %w", err)
+	}
+	return nil
+}
+
+// Validate checks values whose type alone doesn't rule out mistakes
+func (m AppManifest) Validate() error {
+	if m.PreviewPort < 0 || m.PreviewPort > 65535 {
+		return &KeyError{Key: "preview_port", Err: fmt.Errorf("must be between 1 and 65535, got %d", m.PreviewPort)}
+	}
+	if m.PreviewURL != "" {
+		u, err := url.Parse(m.PreviewURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &KeyError{Key: "preview_url", Err: fmt.Errorf("must be an http or https URL, got %q", m.PreviewURL)}
+		}
+	}
+	if m.OutputDir != "" && (filepath.IsAbs(m.OutputDir) || strings.Contains(m.OutputDir, "..")) {
+		return &KeyError{Key: "output_dir", Err: fmt.Errorf("must be a path inside the app, got %q", m.OutputDir)}
+	}
+	for _, pattern := range m.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &KeyError{Key: "ignore", Err: fmt.Errorf("invalid pattern %q", pattern)}
+		}
+	}
+	return nil
+}
+
+// Preview returns the URL the running app is served at, or "" if the manifest doesn't say
+func (m AppManifest) Preview() string {
+	if m.PreviewURL != "" {
+		return m.PreviewURL
+	}
+	if m.PreviewPort != 0 {
+		return fmt.Sprintf("http://localhost:%d", m.PreviewPort)
+	}
+	return ""
+}
+
+// Ignores reports whether a path relative to the app matches an ignore pattern
+func (m AppManifest) Ignores(relPath string) bool {
+	return MatchPath(m.Ignore, relPath)
+}
+
+// MatchPath reports whether a path relative to an app matches any of the patterns. A pattern
+// matches the path or any directory above it (node_modules matches everything inside), and
+// patterns without a slash also match by file name (*.log).
+func MatchPath(patterns []string, relPath string) bool {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	parts := strings.Split(relPath, "/")
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		for i := range parts {
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, parts[i]); ok && !strings.Contains(pattern, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ManifestKeys lists the manifest keys in declaration order with their descriptions
+func ManifestKeys() [][2]string {
+	t := reflect.TypeOf(AppManifest{})
+	keys := make([][2]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, [2]string{manifestKey(t.Field(i)), t.Field(i).Tag.Get("desc")})
+	}
+	return keys
+}
+
+// Get returns a manifest value formatted as text
+func (m AppManifest) Get(key string) (string, error) {
+	v, ok := manifestField(reflect.ValueOf(&m).Elem(), key)
+	if !ok {
+		return "", &KeyError{Key: key, Err: fmt.Errorf("unknown key")}
+	}
+	if v.Kind() == reflect.Int && v.Int() == 0 {
+		return "", nil
+	}
+	return format(v.Interface()), nil
+}
+
+// Set changes a manifest value given as text; lists are comma-separated and an empty value
+// clears the key
+func (m *AppManifest) Set(key, value string) error {
+	v, ok := manifestField(reflect.ValueOf(m).Elem(), key)
+	if !ok {
+		return &KeyError{Key: key, Err: fmt.Errorf("unknown key")}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		if value == "" {
+			v.SetInt(0)
+			break
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a whole number, got %q", value)}
+		}
+		v.SetInt(int64(n))
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return m.Validate()
+}
+
+// manifestField returns the field of the manifest with the given key
+func manifestField(m reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < m.NumField(); i++ {
+		if manifestKey(m.Type().Field(i)) == key {
+			return m.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// manifestKey returns the JSON name of a manifest field
+func manifestKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppManifestReadWrite(t *testing.T) {
+	appDir := t.TempDir()
+
+	m, exists, err := ReadAppManifest(appDir)
+	if err != nil || exists {
+		t.Fatalf("expected no manifest, got exists=%v err=%v", exists, err)
+	}
+
+	m = AppManifest{Framework: "react-ts", DevCommand: "pnpm run dev", PreviewPort: 5173, Ignore: []string{"node_modules"}}
+	if err := WriteAppManifest(appDir, m); err != nil {
+		t.Fatal(err)
+	}
+
+	got, exists, err := ReadAppManifest(appDir)
+	if err != nil || !exists {
+		t.Fatalf("expected manifest, got exists=%v err=%v", exists, err)
+	}
+	if got.DevCommand != "pnpm run dev" || got.PreviewPort != 5173 || got.Preview() != "http://localhost:5173" {
+		t.Errorf("unexpected manifest: %+v", got)
+	}
+
+	if err := os.WriteFile(AppManifestPath(appDir), []byte(`{"preview_port": 70000}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadAppManifest(appDir); err == nil || !strings.Contains(err.Error(), "preview_port: must be between 1 and 65535") {
+		t.Errorf("expected preview_port error, got %v", err)
+	}
+}
+
+func TestAppManifestSet(t *testing.T) {
+	var m AppManifest
+
+	if err := m.Set("tools", "lc, git_status"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set("preview_url", "http://localhost:3000/app"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := m.Get("tools"); value != "lc,git_status" {
+		t.Errorf("expected lc,git_status, got %q", value)
+	}
+	if m.Preview() != "http://localhost:3000/app" {
+		t.Errorf("expected preview_url to win over the port, got %s", m.Preview())
+	}
+
+	tests := []struct {
+		key, value, errMsg string
+	}{
+		{"preview_port", "abc", "must be a whole number"},
+		{"preview_url", "localhost:3000", "must be an http or https URL"},
+		{"output_dir", "../dist", "must be a path inside the app"},
+		{"nope", "1", "nope: unknown key"},
+	}
+	for _, tt := range tests {
+		m := AppManifest{}
+		if err := m.Set(tt.key, tt.value); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("Set(%s, %s): expected error containing %q, got %v", tt.key, tt.value, tt.errMsg, err)
+		}
+	}
+
+	if err := m.Set("tools", ""); err != nil || m.Tools != nil {
+		t.Errorf("expected an empty value to clear tools, got %v (err %v)", m.Tools, err)
+	}
+}
+
+func TestAppManifestIgnores(t *testing.T) {
+	m := AppManifest{Ignore: []string{"node_modules", "*.log", "build/cache", "dist/"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules/react/index.js", true},
+		{"src/node_modules/x.js", true},
+		{"debug.log", true},
+		{"logs/server.log", true},
+		{"build/cache/a.bin", true},
+		{"build/output.js", false},
+		{"dist/index.html", true},
+		{"src/App.tsx", false},
+		{filepath.Join("src", "main.ts"), false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignores(tt.path); got != tt.want {
+			t.Errorf("Ignores(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAppManifestDevPort(t *testing.T) {
+	tests := []struct {
+		name        string
+		manifest    AppManifest
+		packageJSON string
+		want        int
+	}{
+		{"preview_port", AppManifest{PreviewPort: 4000, Framework: "next"}, "", 4000},
+		{"manifest framework", AppManifest{Framework: "astro"}, "", 4321},
+		{"vite template", AppManifest{Framework: "react-ts"}, "", 5173},
+		{"detected framework", AppManifest{}, `{"dependencies":{"next":"15.0.0","react":"19.0.0"}}`, 3000},
+		{"vite without a framework", AppManifest{}, `{"devDependencies":{"vite":"6.0.0"}}`, 5173},
+		{"node server", AppManifest{}, `{"dependencies":{"express":"5.0.0"}}`, 3000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.packageJSON != "" {
+				os.WriteFile(filepath.Join(dir, "package.json"), []byte(tt.packageJSON), 0644)
+			}
+			if got := tt.manifest.DevPort(dir); got != tt.want {
+				t.Errorf("DevPort() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,582 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+// Config holds the settings from the config file, environment variables and command line flags.
+//
+// Each field is a setting whose key is the dotted path of toml tags (files.max_size) and whose
+// environment variable is LAYERED_ plus the upper-cased key with dots as underscores
+// (LAYERED_FILES_MAX_SIZE). Tables add keys per entry name: workspaces.work.root for a
+// workspace, git.credentials.github.token_env for a git credential.
+type Config struct {
+	AppsDirectory string                     `toml:"apps_directory" desc:"Directory containing your apps, relative to your home directory"`
+	Server        ServerConfig               `toml:"server"`
+	Files         FilesConfig                `toml:"files"`
+	Git           GitConfig                  `toml:"git"`
+	Update        UpdateConfig               `toml:"update"`
+	Tools         ToolsConfig                `toml:"tools"`
+	Audit         AuditConfig                `toml:"audit"`
+	Workspaces    map[string]WorkspaceConfig `toml:"workspaces"`
+}
+
+type ServerConfig struct {
+	WebSocketPort int `toml:"websocket_port" desc:"Port of the WebSocket server used for browser live reload"`
+}
+
+type FilesConfig struct {
+	MaxSize   Size     `toml:"max_size" desc:"Largest file the file tools will read or write (e.g. 10MB)"`
+	Deny      []string `toml:"deny" desc:"Paths or glob patterns in apps that no tool may read or change (e.g. .env, secrets/)"`
+	Protected []string `toml:"protected" desc:"Paths or glob patterns in apps whose contents are hidden unless show_secrets is set"`
+}
+
+// GitConfig is how the git tools run git, the check of staged changes before git_commit and
+// of outgoing commits before git_push, and the rules commit messages must follow
+type GitConfig struct {
+	Timeout             Duration `toml:"timeout" desc:"Longest a git command may run before it is stopped (e.g. 2m)"`
+	MaxFileSize         Size     `toml:"max_file_size" desc:"Largest file git_commit and git_push let through (e.g. 10MB)"`
+	DenyCommit          []string `toml:"deny_commit" desc:"Paths or glob patterns git_commit and git_push refuse to commit or push"`
+	ConventionalCommits bool     `toml:"conventional_commits" desc:"Require commit subjects like feat(scope): description"`
+	MaxSubjectLength    int      `toml:"max_subject_length" desc:"Longest commit subject line git_commit accepts (0 for no limit)"`
+	IssueRef            string   `toml:"issue_ref" desc:"Regular expression for an issue reference every commit message must contain (e.g. #[0-9]+)"`
+	UserName            string   `toml:"user_name" desc:"Author name git_init sets in new repositories when git has none"`
+	UserEmail           string   `toml:"user_email" desc:"Author email git_init sets in new repositories when git has none"`
+
+	Credentials map[string]GitCredential `toml:"credentials"`
+}
+
+// GitCredential is how git authenticates to the remotes it matches: with a token over HTTPS,
+// or with an SSH key over SSH. Without one, git uses its own credential helpers and SSH setup.
+type GitCredential struct {
+	Match      string `toml:"match" desc:"Remote name, host or URL prefix the credential is for (default: the remote named like the credential)"`
+	URL        string `toml:"url" desc:"Host or URL prefix a remote matched by name must point to for the token to be sent"`
+	Username   string `toml:"username" desc:"User name sent with the token over HTTPS (default: x-access-token)"`
+	TokenEnv   string `toml:"token_env" desc:"Environment variable holding the HTTPS token"`
+	TokenFile  string `toml:"token_file" desc:"File holding the HTTPS token encrypted by layered-code config encrypt (absolute or ~/)"`
+	SSHKey     string `toml:"ssh_key" desc:"Private key for SSH remotes (absolute or ~/)"`
+	KnownHosts string `toml:"known_hosts" desc:"Host key checking over SSH: strict, accept-new or off (default: strict)"`
+}
+
+type UpdateConfig struct {
+	Check    bool     `toml:"check" desc:"Check GitHub for a newer release on startup"`
+	Interval Duration `toml:"interval" desc:"Minimum time between update checks (e.g. 24h)"`
+}
+
+// ToolsConfig is the MCP server tool policy; mcp_server flags add to it
+type ToolsConfig struct {
+	Allow     []string `toml:"allow" desc:"Tools or groups the MCP server exposes (default: all)"`
+	Deny      []string `toml:"deny" desc:"Tools or groups the MCP server hides"`
+	ReadOnly  bool     `toml:"read_only" desc:"Expose only tools that don't modify anything"`
+	DenyArgs  []string `toml:"deny_args" desc:"Forbidden tool arguments, e.g. git_push.force"`
+	Confirm   []string `toml:"confirm" desc:"Extra calls that need the user's confirmation"`
+	NoConfirm bool     `toml:"no_confirm" desc:"Run destructive calls without asking"`
+}
+
+type AuditConfig struct {
+	Enabled    bool   `toml:"enabled" desc:"Record MCP tool calls in the audit log"`
+	Log        string `toml:"log" desc:"Audit log file (default: audit.jsonl in the config directory)"`
+	MaxSize    Size   `toml:"max_size" desc:"Size at which the audit log is rotated"`
+	MaxBackups int    `toml:"max_backups" desc:"Number of rotated audit logs to keep"`
+}
+
+// WorkspaceConfig is a named directory of apps with its own tool policy. The default workspace is
+// apps_directory; its policy may be set but not its root.
+type WorkspaceConfig struct {
+	Root     string   `toml:"root" desc:"Directory containing the workspace's apps (absolute or ~/)"`
+	ReadOnly bool     `toml:"read_only" desc:"Allow only tools that don't modify anything for this workspace's apps"`
+	Allow    []string `toml:"allow" desc:"Tools or groups allowed for this workspace's apps (default: all)"`
+	Deny     []string `toml:"deny" desc:"Tools or groups denied for this workspace's apps"`
+	DenyArgs []string `toml:"deny_args" desc:"Forbidden tool arguments for this workspace's apps"`
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	return Config{
+		AppsDirectory: constants.DefaultAppsDirectory,
+		Server:        ServerConfig{WebSocketPort: constants.DefaultWebSocketPort},
+		Files:         FilesConfig{MaxSize: constants.MaxFileSize, Protected: slices.Clone(constants.DefaultProtectedPaths)},
+		Git: GitConfig{
+			Timeout:     Duration(constants.GitTimeout),
+			MaxFileSize: constants.GitMaxFileSize,
+			DenyCommit:  slices.Clone(constants.DefaultCommitDenyPaths),
+		},
+		Update: UpdateConfig{Check: true, Interval: Duration(constants.UpdateCheckInterval)},
+		Audit: AuditConfig{
+			Enabled:    true,
+			MaxSize:    constants.AuditLogMaxSize,
+			MaxBackups: constants.AuditLogMaxBackups,
+		},
+	}
+}
+
+// validate checks settings whose type alone doesn't rule out bad values
+func (c *Config) validate() error {
+	if c.Server.WebSocketPort < 1 || c.Server.WebSocketPort > 65535 {
+		return &KeyError{Key: "server.websocket_port", Err: fmt.Errorf("must be between 1 and 65535, got %d", c.Server.WebSocketPort)}
+	}
+	if c.Files.MaxSize <= 0 {
+		return &KeyError{Key: "files.max_size", Err: fmt.Errorf("must be greater than zero")}
+	}
+	if err := validatePatterns("files.deny", c.Files.Deny); err != nil {
+		return err
+	}
+	if err := validatePatterns("files.protected", c.Files.Protected); err != nil {
+		return err
+	}
+	if c.Git.Timeout <= 0 {
+		return &KeyError{Key: "git.timeout", Err: fmt.Errorf("must be greater than zero")}
+	}
+	if c.Git.MaxFileSize <= 0 {
+		return &KeyError{Key: "git.max_file_size", Err: fmt.Errorf("must be greater than zero")}
+	}
+	if err := validatePatterns("git.deny_commit", c.Git.DenyCommit); err != nil {
+		return err
+	}
+	if c.Git.MaxSubjectLength < 0 {
+		return &KeyError{Key: "git.max_subject_length", Err: fmt.Errorf("must not be negative")}
+	}
+	if _, err := regexp.Compile(c.Git.IssueRef); err != nil {
+		return &KeyError{Key: "git.issue_ref", Err: fmt.Errorf("invalid regular expression %q", c.Git.IssueRef)}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Git.Credentials)) {
+		if err := c.Git.Credentials[name].validate("git.credentials." + name + "."); err != nil {
+			return err
+		}
+	}
+	if c.Update.Interval < 0 {
+		return &KeyError{Key: "update.interval", Err: fmt.Errorf("must not be negative")}
+	}
+	if c.Audit.MaxSize <= 0 {
+		return &KeyError{Key: "audit.max_size", Err: fmt.Errorf("must be greater than zero")}
+	}
+	if c.Audit.MaxBackups < 0 {
+		return &KeyError{Key: "audit.max_backups", Err: fmt.Errorf("must not be negative")}
+	}
+	for _, name := range sortedNames(c.Workspaces) {
+		key := "workspaces." + name + ".root"
+		root := c.Workspaces[name].Root
+		switch {
+		case name == DefaultWorkspace && root != "":
+			return &KeyError{Key: key, Err: fmt.Errorf("the default workspace is apps_directory; set that instead")}
+		case name == DefaultWorkspace:
+		case root == "":
+			return &KeyError{Key: key, Err: fmt.Errorf("must be set")}
+		case strings.Contains(root, ".."):
+			return &KeyError{Key: key, Err: ErrDirectoryTraversal}
+		case !filepath.IsAbs(root) && !strings.HasPrefix(root, "~/"):
+			return &KeyError{Key: key, Err: fmt.Errorf("must be an absolute path or start with ~/, got %q", root)}
+		}
+	}
+	return nil
+}
+
+// validatePatterns checks a setting holding glob patterns of app paths
+func validatePatterns(key string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &KeyError{Key: key, Err: fmt.Errorf("invalid pattern %q", pattern)}
+		}
+	}
+	return nil
+}
+
+// KeyError reports an invalid setting, naming its key
+type KeyError struct {
+	Source string // Where the value came from: a file path, environment variable or flag
+	Key    string
+	Err    error
+}
+
+func (e *KeyError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Source, e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// Size is a number of bytes, written as 10MB, 512KB or a plain byte count
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1024 * 1024 * 1024},
+	{"MB", 1024 * 1024},
+	{"KB", 1024},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as 10MB, 512KB or 1048576
+func ParseSize(s string) (Size, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			if err != nil {
+				break
+			}
+			return Size(n * unit.bytes), nil
+		}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return Size(n), nil
+	}
+	return 0, fmt.Errorf("invalid size %q (use a byte count or a value like 10MB)", s)
+}
+
+// String formats the size with the largest unit that divides it exactly
+func (s Size) String() string {
+	for _, unit := range sizeUnits {
+		if s != 0 && int64(s)%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", int64(s)/unit.bytes, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(s))
+}
+
+// Duration is a time.Duration written as 30s, 5m or 24h
+type Duration time.Duration
+
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Setting describes a config key and its current value
+type Setting struct {
+	Key         string
+	Env         string
+	Description string
+	Value       string
+	Source      string // default, file, env or flag
+}
+
+// field locates a setting within Config, or within an entry of one of its tables
+type field struct {
+	key         string
+	description string
+	index       []int  // Within Config, or within the entry for table settings
+	table       []int  // Table holding the setting within Config, if any
+	name        string // Entry of the table
+}
+
+// table is a setting holding named entries, such as workspaces.<name>.root
+type table struct {
+	key   string
+	index []int
+	entry reflect.Type
+}
+
+// fields lists every setting in declaration order, not including table settings
+func fields() []field {
+	return collectFields(reflect.TypeOf(Config{}), "", nil)
+}
+
+// fields lists every setting including those of the configured table entries
+func (c *Config) fields() []field {
+	result := fields()
+	for _, t := range tables() {
+		m := reflect.ValueOf(c).Elem().FieldByIndex(t.index)
+		for _, name := range mapNames(m) {
+			result = append(result, t.entryFields(name)...)
+		}
+	}
+	return result
+}
+
+// tables lists the tables of named entries in Config
+func tables() []table {
+	return collectTables(reflect.TypeOf(Config{}), "", nil)
+}
+
+// entryFields lists the settings of the named entry of a table
+func (t table) entryFields(name string) []field {
+	result := collectFields(t.entry, t.key+"."+name+".", nil)
+	for i := range result {
+		result[i].table = t.index
+		result[i].name = name
+	}
+	return result
+}
+
+func collectFields(t reflect.Type, prefix string, index []int) []field {
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := prefix + f.Tag.Get("toml")
+		path := append(append([]int(nil), index...), i)
+
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			result = append(result, collectFields(f.Type, key+".", path)...)
+			continue
+		case reflect.Map:
+			// Keyed by name, listed by Config.fields
+			continue
+		}
+		result = append(result, field{key: key, description: f.Tag.Get("desc"), index: path})
+	}
+	return result
+}
+
+func collectTables(t reflect.Type, prefix string, index []int) []table {
+	var result []table
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := prefix + f.Tag.Get("toml")
+		path := append(append([]int(nil), index...), i)
+
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			result = append(result, collectTables(f.Type, key+".", path)...)
+		case reflect.Map:
+			result = append(result, table{key: key, index: path, entry: f.Type.Elem()})
+		}
+	}
+	return result
+}
+
+// lookup returns the setting with the given key
+func lookup(key string) (field, bool) {
+	candidates := fields()
+	for _, t := range tables() {
+		if rest, ok := strings.CutPrefix(key, t.key+"."); ok {
+			name, _, _ := strings.Cut(rest, ".")
+			if ValidateWorkspaceName(name) != nil {
+				return field{}, false
+			}
+			candidates = t.entryFields(name)
+		}
+	}
+
+	for _, f := range candidates {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// mapNames returns the keys of a table in order
+func mapNames(m reflect.Value) []string {
+	names := make([]string, 0, m.Len())
+	for _, key := range m.MapKeys() {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedNames returns the workspace names in order
+func sortedNames(workspaces map[string]WorkspaceConfig) []string {
+	names := make([]string, 0, len(workspaces))
+	for name := range workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// envName returns the environment variable for a key (files.max_size -> LAYERED_FILES_MAX_SIZE)
+func envName(key string) string {
+	return "LAYERED_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// assign sets the field for key from a config file value or a string from the environment or
+// command line; lists given as a string are comma-separated
+func (c *Config) assign(key string, value any) error {
+	f, ok := lookup(key)
+	if !ok {
+		return &KeyError{Key: key, Err: fmt.Errorf("unknown key")}
+	}
+	if f.table == nil {
+		return setValue(reflect.ValueOf(c).Elem().FieldByIndex(f.index), key, value)
+	}
+
+	// Map entries aren't addressable, so update a copy and store it back
+	m := reflect.ValueOf(c).Elem().FieldByIndex(f.table)
+	entry := c.entry(f)
+	if err := setValue(entry.FieldByIndex(f.index), key, value); err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	m.SetMapIndex(reflect.ValueOf(f.name), entry)
+	return nil
+}
+
+// entry returns a copy of the table entry holding a setting, or an empty entry
+func (c *Config) entry(f field) reflect.Value {
+	m := reflect.ValueOf(c).Elem().FieldByIndex(f.table)
+	entry := reflect.New(m.Type().Elem()).Elem()
+	if existing := m.MapIndex(reflect.ValueOf(f.name)); existing.IsValid() {
+		entry.Set(existing)
+	}
+	return entry
+}
+
+// setValue converts value to the type of target and stores it
+func setValue(target reflect.Value, key string, value any) error {
+	switch target.Interface().(type) {
+	case string:
+		s, ok := value.(string)
+		if !ok {
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a string, got %v", value)}
+		}
+		target.SetString(s)
+
+	case bool:
+		switch v := value.(type) {
+		case bool:
+			target.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return &KeyError{Key: key, Err: fmt.Errorf("must be true or false, got %q", v)}
+			}
+			target.SetBool(b)
+		default:
+			return &KeyError{Key: key, Err: fmt.Errorf("must be true or false, got %v", value)}
+		}
+
+	case int:
+		n, err := toInt(value)
+		if err != nil {
+			return &KeyError{Key: key, Err: err}
+		}
+		target.SetInt(n)
+
+	case Size:
+		if s, ok := value.(string); ok {
+			size, err := ParseSize(s)
+			if err != nil {
+				return &KeyError{Key: key, Err: err}
+			}
+			target.SetInt(int64(size))
+			break
+		}
+		n, err := toInt(value)
+		if err != nil {
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a size such as 10MB, got %v", value)}
+		}
+		target.SetInt(n)
+
+	case Duration:
+		s, ok := value.(string)
+		if !ok {
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a duration such as 30s or 24h, got %v", value)}
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a duration such as 30s or 24h, got %q", s)}
+		}
+		target.SetInt(int64(d))
+
+	case []string:
+		var items []string
+		switch v := value.(type) {
+		case string:
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return &KeyError{Key: key, Err: fmt.Errorf("must be a list of strings, got %v", item)}
+				}
+				items = append(items, s)
+			}
+		case []string:
+			items = v
+		default:
+			return &KeyError{Key: key, Err: fmt.Errorf("must be a list of strings, got %v", value)}
+		}
+		target.Set(reflect.ValueOf(items))
+	}
+
+	return nil
+}
+
+// toInt converts a whole number from a config file or the command line
+func toInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("must be a whole number, got %v", value)
+}
+
+// Get returns the value of a setting formatted as text
+func (c *Config) Get(key string) (string, error) {
+	f, ok := lookup(key)
+	if !ok {
+		return "", &KeyError{Key: key, Err: fmt.Errorf("unknown key")}
+	}
+	return format(c.value(f)), nil
+}
+
+// value returns the current value of a setting
+func (c *Config) value(f field) any {
+	if f.table == nil {
+		return reflect.ValueOf(c).Elem().FieldByIndex(f.index).Interface()
+	}
+	return c.entry(f).FieldByIndex(f.index).Interface()
+}
+
+// format writes a setting value the way it is accepted on the command line
+func format(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// fileValue converts a setting value to what is written to a config file
+func fileValue(value any) any {
+	switch v := value.(type) {
+	case Size, Duration:
+		return format(v)
+	case int:
+		return int64(v)
+	case []string:
+		if v == nil {
+			return []string{}
+		}
+	}
+	return value
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// useConfigFile gives the test a home directory of its own and points the config at a file in
+// it; the apps directory is left to the file
+func useConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(testutil.Home(t), name)
+	t.Setenv("LAYERED_APPS_DIRECTORY", "")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("LAYERED_CONFIG", path)
+	return path
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Size
+		wantErr bool
+	}{
+		{"10MB", 10 * 1024 * 1024, false},
+		{"512kb", 512 * 1024, false},
+		{"1GB", 1024 * 1024 * 1024, false},
+		{"100B", 100, false},
+		{"2048", 2048, false},
+		{"10XB", 0, true},
+		{"MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q): wantErr=%v, got=%v", tt.input, tt.wantErr, err)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	if s := Size(10 * 1024 * 1024).String(); s != "10MB" {
+		t.Errorf("expected 10MB, got %s", s)
+	}
+	if s := Size(1500).String(); s != "1500B" {
+		t.Errorf("expected 1500B, got %s", s)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	useConfigFile(t, "config.toml", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Server.WebSocketPort != 8080 || cfg.Files.MaxSize != 10*1024*1024 || !cfg.Update.Check || !cfg.Audit.Enabled {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("toml", func(t *testing.T) {
+		useConfigFile(t, "config.toml", `
+apps_directory = "Projects"
+
+[server]
+websocket_port = 9090
+
+[files]
+max_size = "2MB"
+
+[update]
+interval = "1h"
+
+[tools]
+deny = ["vite"]
+read_only = true
+`)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if cfg.AppsDirectory != "Projects" || cfg.Server.WebSocketPort != 9090 || cfg.Files.MaxSize != 2*1024*1024 ||
+			time.Duration(cfg.Update.Interval) != time.Hour || !cfg.Tools.ReadOnly || strings.Join(cfg.Tools.Deny, ",") != "vite" {
+			t.Errorf("unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		useConfigFile(t, "config.yaml", `
+server:
+  websocket_port: 9091
+files:
+  max_size: 4096
+tools:
+  allow: [lc, git_status]
+`)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if cfg.Server.WebSocketPort != 9091 || cfg.Files.MaxSize != 4096 || strings.Join(cfg.Tools.Allow, ",") != "lc,git_status" {
+			t.Errorf("unexpected config: %+v", cfg)
+		}
+	})
+}
+
+func TestLoadErrorsNameKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unknown key", "config.toml", "[server]\nprot = 1\n", nil, "server.prot: unknown key"},
+		{"wrong type", "config.toml", "[server]\nwebsocket_port = \"abc\"\n", nil, "server.websocket_port: must be a whole number"},
+		{"out of range", "config.yaml", "server:\n  websocket_port: 70000\n", nil, "server.websocket_port: must be between 1 and 65535"},
+		{"bad size", "config.toml", "[files]\nmax_size = \"big\"\n", nil, "files.max_size: invalid size"},
+		{"bad list", "config.toml", "[tools]\ndeny = [1]\n", nil, "tools.deny: must be a list of strings"},
+		{"bad pattern", "config.toml", "[files]\ndeny = [\"[.env\"]\n", nil, "files.deny: invalid pattern"},
+		{"bad protected pattern", "config.toml", "[files]\nprotected = [\"*.pem\", \"[\"]\n", nil, "files.protected: invalid pattern"},
+		{"bad issue ref", "config.toml", "[git]\nissue_ref = \"#(\"\n", nil, "git.issue_ref: invalid regular expression"},
+		{"bad known_hosts", "config.toml", "[git.credentials.github]\nknown_hosts = \"maybe\"\n", nil, "git.credentials.github.known_hosts: must be strict, accept-new or off"},
+		{"relative ssh key", "config.toml", "[git.credentials.github]\nssh_key = \".ssh/id_ed25519\"\n", nil, "git.credentials.github.ssh_key: must be an absolute path"},
+		{"env", "config.toml", "", map[string]string{"LAYERED_UPDATE_CHECK": "maybe"}, "LAYERED_UPDATE_CHECK: update.check: must be true or false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := useConfigFile(t, tt.file, tt.content)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+
+			var keyErr *KeyError
+			if !errors.As(err, &keyErr) {
+				t.Fatalf("expected a KeyError, got %T", err)
+			}
+			if tt.env == nil && keyErr.Source != path {
+				t.Errorf("expected source %s, got %s", path, keyErr.Source)
+			}
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	useConfigFile(t, "config.toml", "[server]\nwebsocket_port = 9090\n")
+	t.Setenv("LAYERED_FILES_MAX_SIZE", "1MB")
+	t.Setenv("LAYERED_SERVER_WEBSOCKET_PORT", "9091")
+	defer ResetFlags()
+
+	if err := Override("server.websocket_port", "9092"); err != nil {
+		t.Fatalf("Override() failed: %v", err)
+	}
+	if err := Override("server.nope", "1"); err == nil || !strings.Contains(err.Error(), "--set: server.nope: unknown key") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	settings, err := List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	sources := make(map[string]string)
+	values := make(map[string]string)
+	for _, s := range settings {
+		sources[s.Key] = s.Source
+		values[s.Key] = s.Value
+	}
+
+	if values["server.websocket_port"] != "9092" || sources["server.websocket_port"] != "flag" {
+		t.Errorf("expected flag to win, got %s from %s", values["server.websocket_port"], sources["server.websocket_port"])
+	}
+	if values["files.max_size"] != "1MB" || sources["files.max_size"] != "env" {
+		t.Errorf("expected env value, got %s from %s", values["files.max_size"], sources["files.max_size"])
+	}
+	if sources["update.check"] != "default" {
+		t.Errorf("expected default source, got %s", sources["update.check"])
+	}
+}
+
+func TestSet(t *testing.T) {
+	for _, name := range []string{"config.toml", "config.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := useConfigFile(t, name, "")
+
+			if err := Set("files.max_size", "2MB"); err != nil {
+				t.Fatalf("Set() failed: %v", err)
+			}
+			if err := Set("tools.deny", "vite, pnpm"); err != nil {
+				t.Fatalf("Set() failed: %v", err)
+			}
+			if err := Set("server.websocket_port", "0"); err == nil || !strings.Contains(err.Error(), "server.websocket_port") {
+				t.Errorf("expected invalid port to be rejected, got %v", err)
+			}
+			if err := Set("server.nope", "1"); err == nil {
+				t.Error("expected unknown key to be rejected")
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.Files.MaxSize != 2*1024*1024 || strings.Join(cfg.Tools.Deny, ",") != "vite,pnpm" || cfg.Server.WebSocketPort != 8080 {
+				t.Errorf("unexpected config after Set: %+v", cfg)
+			}
+
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(string(data), "2MB") {
+				t.Errorf("expected size to be saved as text, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestPathPrefersExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("LAYERED_CONFIG", "")
+
+	path, err := Path()
+	if err != nil || path != filepath.Join(dir, "layered-code", "config.toml") {
+		t.Errorf("expected default config.toml, got %s, %v", path, err)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "layered-code"), 0755)
+	os.WriteFile(filepath.Join(dir, "layered-code", "config.yaml"), []byte("{}"), 0644)
+	path, _ = Path()
+	if path != filepath.Join(dir, "layered-code", "config.yaml") {
+		t.Errorf("expected existing config.yaml, got %s", path)
+	}
+}
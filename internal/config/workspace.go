@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultWorkspace is the name of the workspace rooted at apps_directory
+const DefaultWorkspace = "default"
+
+var ErrInvalidAppName = errors.New("invalid app name")
+
+// Workspace is a named directory of apps
+type Workspace struct {
+	Name string `json:"name"`
+	Root string `json:"root"`
+}
+
+// ValidateWorkspaceName checks that a workspace name uses only letters, digits, '-' and '_'
+func ValidateWorkspaceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("workspace name cannot be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("workspace name %q may only contain letters, digits, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
+// Workspaces returns the default workspace followed by the configured workspaces in name order
+func Workspaces() ([]Workspace, error) {
+	appsDir, err := GetAppsDirectory()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []Workspace{{Name: DefaultWorkspace, Root: appsDir}}
+	for _, name := range sortedNames(cfg.Workspaces) {
+		if name == DefaultWorkspace {
+			continue
+		}
+		root := cfg.Workspaces[name].Root
+		if rest, ok := strings.CutPrefix(root, "~/"); ok {
+			root = filepath.Join(homeDir, rest)
+		}
+		workspaces = append(workspaces, Workspace{Name: name, Root: filepath.Clean(root)})
+	}
+	return workspaces, nil
+}
+
+// SplitAppName splits an app name qualified as workspace/app; unqualified names have no workspace
+func SplitAppName(appName string) (workspace, app string) {
+	if workspace, app, ok := strings.Cut(appName, "/"); ok {
+		return workspace, app
+	}
+	return "", appName
+}
+
+// QualifiedAppName returns the name an app is referred to by: apps in the default workspace
+// are unqualified, others are written workspace/app
+func QualifiedAppName(workspace, app string) string {
+	if workspace == DefaultWorkspace {
+		return app
+	}
+	return workspace + "/" + app
+}
+
+// WorktreeSeparator joins an app's name and the name of one of its git worktrees. A worktree
+// lives next to its app as the app myapp@name, so every tool can address it like any other app.
+const WorktreeSeparator = "@"
+
+// WorktreeAppName returns the name of an app's worktree, keeping the app's workspace
+func WorktreeAppName(appName, worktree string) string {
+	return appName + WorktreeSeparator + worktree
+}
+
+// SplitWorktreeName splits the name of a worktree app into the name of its app and of the
+// worktree; other app names have no worktree
+func SplitWorktreeName(appName string) (app, worktree string) {
+	workspace, name := SplitAppName(appName)
+	name, worktree, _ = strings.Cut(name, WorktreeSeparator)
+	if workspace != "" {
+		name = workspace + "/" + name
+	}
+	return name, worktree
+}
+
+// FindApp returns the workspace an app name refers to and the app's unqualified name.
+// A qualified name picks its workspace. An unqualified name refers to the default workspace,
+// unless the app only exists in one other workspace; if several others have it, it must be
+// qualified.
+func FindApp(appName string) (Workspace, string, error) {
+	workspaces, err := Workspaces()
+	if err != nil {
+		return Workspace{}, "", err
+	}
+
+	workspace, app := SplitAppName(appName)
+	if app == "" || app == "." || app == ".." || strings.ContainsAny(app, `/\`) {
+		return Workspace{}, "", fmt.Errorf("%w: %q", ErrInvalidAppName, appName)
+	}
+
+	if workspace != "" {
+		for _, ws := range workspaces {
+			if ws.Name == workspace {
+				return ws, app, nil
+			}
+		}
+		return Workspace{}, "", fmt.Errorf("unknown workspace %q in app name %q", workspace, appName)
+	}
+
+	if exists(filepath.Join(workspaces[0].Root, app)) {
+		return workspaces[0], app, nil
+	}
+	var found []Workspace
+	for _, ws := range workspaces[1:] {
+		if exists(filepath.Join(ws.Root, app)) {
+			found = append(found, ws)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return workspaces[0], app, nil
+	case 1:
+		return found[0], app, nil
+	}
+
+	var names []string
+	for _, ws := range found {
+		names = append(names, QualifiedAppName(ws.Name, app))
+	}
+	return Workspace{}, "", fmt.Errorf("app %q exists in several workspaces; use one of %s", app, strings.Join(names, ", "))
+}
+
+// WorkspaceOf returns the name of the workspace an app name refers to
+func WorkspaceOf(appName string) (string, error) {
+	ws, _, err := FindApp(appName)
+	return ws.Name, err
+}
+
+// AppDirectory returns the directory of an app, which may be qualified as workspace/app.
+// The default apps directory is created if needed; other workspace roots must exist.
+func AppDirectory(appName string) (string, error) {
+	ws, app, err := FindApp(appName)
+	if err != nil {
+		return "", err
+	}
+
+	root := ws.Root
+	if ws.Name == DefaultWorkspace {
+		if root, err = EnsureAppsDirectory(); err != nil {
+			return "", err
+		}
+	} else {
+		info, err := os.Stat(root)
+		if err != nil {
+			return "", fmt.Errorf("workspace %s: %w", ws.Name, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("workspace %s: %w", ws.Name, ErrNotADirectory)
+		}
+	}
+
+	return filepath.Join(root, app), nil
+}
+
+// exists reports whether a file or directory exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
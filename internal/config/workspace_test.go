@@ -0,0 +1,194 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useWorkspaces sets up a home directory with the default apps directory and two workspaces
+func useWorkspaces(t *testing.T) (home, work, other string) {
+	t.Helper()
+	work = t.TempDir()
+	other = t.TempDir()
+	path := useConfigFile(t, "config.toml", "[workspaces.work]\nroot = \""+filepath.ToSlash(work)+"\"\nread_only = true\n\n"+
+		"[workspaces.other]\nroot = \""+filepath.ToSlash(other)+"\"\n\n[workspaces.default]\ndeny = [\"git\"]\n")
+	home = filepath.Dir(path)
+
+	if err := os.MkdirAll(filepath.Join(home, "LayeredApps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return home, work, other
+}
+
+func mkdirs(t *testing.T, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkspacesLoad(t *testing.T) {
+	home, work, other := useWorkspaces(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Workspaces["work"].ReadOnly || len(cfg.Workspaces["default"].Deny) != 1 {
+		t.Errorf("unexpected workspaces: %+v", cfg.Workspaces)
+	}
+
+	workspaces, err := Workspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Workspace{
+		{Name: "default", Root: filepath.Join(home, "LayeredApps")},
+		{Name: "other", Root: other},
+		{Name: "work", Root: work},
+	}
+	if len(workspaces) != len(want) {
+		t.Fatalf("expected %v, got %v", want, workspaces)
+	}
+	for i := range want {
+		if workspaces[i] != want[i] {
+			t.Errorf("workspace %d: expected %v, got %v", i, want[i], workspaces[i])
+		}
+	}
+
+	settings, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range settings {
+		if s.Key == "workspaces.work.root" {
+			found = s.Value == filepath.ToSlash(work) && s.Source == "file"
+		}
+	}
+	if !found {
+		t.Error("expected workspaces.work.root in the settings list")
+	}
+}
+
+func TestWorkspacesValidation(t *testing.T) {
+	tests := []struct {
+		content string
+		errMsg  string
+	}{
+		{"[workspaces.work]\nread_only = true\n", "workspaces.work.root: must be set"},
+		{"[workspaces.work]\nroot = \"relative/dir\"\n", "must be an absolute path"},
+		{"[workspaces.work]\nroot = \"/srv/../etc\"\n", "directory traversal"},
+		{"[workspaces.default]\nroot = \"/srv/work\"\n", "set that instead"},
+		{"[workspaces.\"bad name\"]\nroot = \"/srv/work\"\n", "unknown key"},
+		{"[workspaces.work]\nbogus = 1\n", "workspaces.work.bogus: unknown key"},
+	}
+
+	for _, tt := range tests {
+		useConfigFile(t, "config.toml", tt.content)
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.content, tt.errMsg, err)
+		}
+	}
+}
+
+func TestSetWorkspace(t *testing.T) {
+	path := useConfigFile(t, "config.toml", "")
+
+	if err := Set("workspaces.work.root", "/srv/work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("workspaces.work.deny", "git,pnpm"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("workspaces.work.root", "relative"); err == nil {
+		t.Error("expected an error for a relative root")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "[workspaces.work]") {
+		t.Errorf("expected a workspaces.work table, got:\n%s", data)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := cfg.Get("workspaces.work.deny"); value != "git,pnpm" {
+		t.Errorf("expected git,pnpm, got %q", value)
+	}
+}
+
+func TestFindApp(t *testing.T) {
+	home, work, other := useWorkspaces(t)
+	apps := filepath.Join(home, "LayeredApps")
+	mkdirs(t,
+		filepath.Join(apps, "shared"), filepath.Join(work, "shared"),
+		filepath.Join(work, "api"),
+		filepath.Join(work, "site"), filepath.Join(other, "site"),
+	)
+
+	tests := []struct {
+		appName   string
+		workspace string
+		app       string
+		errMsg    string
+	}{
+		{"shared", "default", "shared", ""},
+		{"work/shared", "work", "shared", ""},
+		{"api", "work", "api", ""},
+		{"new-app", "default", "new-app", ""},
+		{"default/api", "default", "api", ""},
+		{"site", "", "", "use one of other/site, work/site"},
+		{"nope/api", "", "", "unknown workspace"},
+		{"work/..", "", "", "invalid app name"},
+		{"work/a/b", "", "", "invalid app name"},
+	}
+
+	for _, tt := range tests {
+		ws, app, err := FindApp(tt.appName)
+		if tt.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.appName, tt.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.appName, err)
+			continue
+		}
+		if ws.Name != tt.workspace || app != tt.app {
+			t.Errorf("%s: expected %s/%s, got %s/%s", tt.appName, tt.workspace, tt.app, ws.Name, app)
+		}
+	}
+
+	dir, err := AppDirectory("work/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(work, "api") {
+		t.Errorf("expected %s, got %s", filepath.Join(work, "api"), dir)
+	}
+}
+
+func TestSplitWorktreeName(t *testing.T) {
+	tests := []struct{ name, app, worktree string }{
+		{"myapp", "myapp", ""},
+		{"myapp@experiment", "myapp", "experiment"},
+		{"work/myapp@experiment", "work/myapp", "experiment"},
+	}
+	for _, tt := range tests {
+		app, worktree := SplitWorktreeName(tt.name)
+		if app != tt.app || worktree != tt.worktree {
+			t.Errorf("SplitWorktreeName(%q) = %q, %q; want %q, %q", tt.name, app, worktree, tt.app, tt.worktree)
+		}
+		if tt.worktree != "" && WorktreeAppName(app, worktree) != tt.name {
+			t.Errorf("WorktreeAppName(%q, %q) = %q; want %q", app, worktree, WorktreeAppName(app, worktree), tt.name)
+		}
+	}
+}
@@ -15,6 +15,36 @@ const (
 	DefaultAppsDirectory = "LayeredApps"
 	AppsDirectoryEnvVar  = "LAYERED_APPS_DIRECTORY"
 
+	// Config file configuration
+	ConfigFileEnvVar = "LAYERED_CONFIG"
+
+	// Detailed lc_list_apps: time allowed to gather app details and apps inspected at once
+	AppDetailsTimeout     = 5 * time.Second
+	AppDetailsConcurrency = 8
+
+	// Directory in each workspace that lc_archive_app writes app archives to
+	AppArchiveDir = ".archive"
+
+	// Per-app manifest, relative to the app directory
+	AppManifestDir  = ".layered"
+	AppManifestFile = "app.json"
+
+	// WebSocket server configuration
+	DefaultWebSocketPort = 8080
+
+	// Tool policy configuration
+	PolicyFileEnvVar = "LAYERED_POLICY_FILE"
+
+	// How long a confirmation token for a destructive call stays valid
+	ConfirmationTokenTTL = 5 * time.Minute
+
+	// Audit log configuration
+	AuditLogFileName       = "audit.jsonl"
+	AuditLogMaxSize        = 10 * 1024 * 1024 // 10MB
+	AuditLogMaxBackups     = 3
+	AuditMaxArgumentLength = 256
+	AuditMaxResultLength   = 512
+
 	// File permission constants
 	AppsDirectoryPerms   = 0755
 	OwnerWritePermission = 0200
@@ -23,6 +53,31 @@ const (
 	MaxFileSize        = 10 * 1024 * 1024 // 10MB
 	MaxFileSizeInWords = "10MB"
 
+	// Longest a git command may run by default
+	GitTimeout = 2 * time.Minute
+
+	// Longest asking PM2 for its processes may take when not bounded otherwise
+	Pm2StatusTimeout = 30 * time.Second
+
+	// Largest file git_commit and git_push let through by default
+	GitMaxFileSize = 10 * 1024 * 1024 // 10MB
+
+	// Author git_init sets in new repositories when neither git nor git.user_name and
+	// git.user_email name one
+	GitDefaultUserName  = "Layered Code"
+	GitDefaultUserEmail = "layered-code@localhost"
+
+	// User name git sends with a credential's token when it names none
+	GitCredentialUsername = "x-access-token"
+
+	// Key encrypting git credential token files: the file in the config directory, unless
+	// the environment variable holds a passphrase
+	CredentialKeyFile   = "credential.key"
+	CredentialKeyEnvVar = "LAYERED_CREDENTIAL_KEY"
+
+	// Largest diff git_diff returns before truncating files
+	GitDiffMaxBytes = 100 * 1024 // 100KB
+
 	// Directory traversal constants
 	MaxDirectoryDepth = 10000
 )
@@ -30,4 +85,15 @@ const (
 var (
 	// ProjectVersion will be set at build time via ldflags
 	ProjectVersion = "dev"
+
+	// App paths that file tools won't show without show_secrets
+	DefaultProtectedPaths = []string{".env*", "*.pem", "id_rsa*"}
+
+	// App paths that git_commit and git_push refuse by default: local env files, keys and
+	// installed dependencies
+	DefaultCommitDenyPaths = []string{
+		".env", ".env.local", ".env.*.local", ".env.production",
+		"*.pem", "*.key", "*.p12", "*.pfx", "id_rsa*", "id_ed25519*",
+		"node_modules/",
+	}
 )
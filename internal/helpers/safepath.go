@@ -0,0 +1,446 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+var (
+	ErrOutsideApp  = errors.New("path is outside app directory")
+	ErrDeniedPath  = errors.New("path is denied by the files.deny setting")
+	ErrInvalidPath = errors.New("path contains a NUL byte")
+	ErrManifest    = errors.New("the app manifest can only be changed with lc_app_config")
+)
+
+// maxSymlinks bounds how many symlinks one path may pass through, like the kernel's ELOOP limit
+const maxSymlinks = 40
+
+// SafePath resolves and opens paths given relative to an app directory so that no path can
+// reach outside the app, whether through "..", absolute paths or symlinks, and no path matching
+// the files.deny setting can be used at all. Paths matching files.protected can be used, but
+// tools showing file contents check Protected first.
+//
+// Resolve checks a path and returns where it points, for tools that hand paths to other
+// programs. The file methods go further and open through an os.Root, which resolves every path
+// component relative to the app directory as it opens it (openat), so a symlink swapped in
+// after the check still can't escape.
+type SafePath struct {
+	AppName string
+	Dir     string // App directory with symlinks resolved
+
+	dir     string   // App directory as configured
+	deny    []string // Patterns of denied paths, lower-cased if fold is set
+	protect []string // Patterns of protected paths, lower-cased if fold is set
+	fold    bool     // The app is on a case-insensitive filesystem
+}
+
+// NewSafePath returns the resolver for an existing app. The app name is validated and the app
+// directory must be inside its workspace's apps directory, also after resolving symlinks.
+func NewSafePath(appName string) (*SafePath, error) {
+	if err := ValidateAppName(appName); err != nil {
+		return nil, err
+	}
+
+	appDir, err := config.AppDirectory(appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app directory: %w", err)
+	}
+	realDir, err := filepath.EvalSymlinks(appDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("app '%s' not found in apps directory", appName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error accessing app: %w", err)
+	}
+	if info, err := os.Stat(realDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("app '%s' is not a directory", appName)
+	}
+
+	p := &SafePath{AppName: appName, Dir: realDir, dir: filepath.Clean(appDir), fold: caseInsensitive(realDir)}
+
+	// An app may be a symlink to another app, but not to somewhere else
+	root, err := filepath.EvalSymlinks(filepath.Dir(appDir))
+	if err != nil {
+		return nil, fmt.Errorf("error accessing apps directory: %w", err)
+	}
+	if rel, ok := p.relTo(root, realDir); !ok || rel == "." {
+		return nil, fmt.Errorf("app '%s': %w", appName, ErrOutsideApp)
+	}
+
+	files := config.FileSettings()
+	p.deny = p.patterns(files.Deny)
+	p.protect = p.patterns(files.Protected)
+	return p, nil
+}
+
+// Resolve checks a path relative to the app and returns the absolute path it refers to, with
+// any symlinks inside the app resolved. The path may not exist yet; "" and "." are the app
+// directory itself.
+func (p *SafePath) Resolve(relPath string) (string, error) {
+	rel, err := p.Rel(relPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(p.Dir, rel), nil
+}
+
+// Rel checks a path like Resolve and returns it relative to the app directory
+func (p *SafePath) Rel(relPath string) (string, error) {
+	if strings.ContainsRune(relPath, 0) {
+		return "", ErrInvalidPath
+	}
+
+	rel, err := p.clean(relPath)
+	if err != nil {
+		return "", err
+	}
+	if p.Denied(rel) {
+		return "", fmt.Errorf("%s: %w", relPath, ErrDeniedPath)
+	}
+
+	resolved, err := p.followSymlinks(rel)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", relPath, err)
+	}
+	if p.Denied(resolved) {
+		return "", fmt.Errorf("%s: %w", relPath, ErrDeniedPath)
+	}
+	return resolved, nil
+}
+
+// Denied reports whether a path relative to the app matches the files.deny setting
+func (p *SafePath) Denied(relPath string) bool {
+	return len(p.deny) > 0 && p.match(p.deny, relPath)
+}
+
+// Protected reports whether a path relative to the app matches the files.protected setting,
+// either as given or after resolving symlinks
+func (p *SafePath) Protected(relPath string) bool {
+	if len(p.protect) == 0 {
+		return false
+	}
+	if p.match(p.protect, relPath) {
+		return true
+	}
+	resolved, err := p.Rel(relPath)
+	return err == nil && p.match(p.protect, resolved)
+}
+
+// patterns prepares path patterns from the config for matching
+func (p *SafePath) patterns(patterns []string) []string {
+	var prepared []string
+	for _, pattern := range patterns {
+		if p.fold {
+			pattern = strings.ToLower(pattern)
+		}
+		prepared = append(prepared, pattern)
+	}
+	return prepared
+}
+
+func (p *SafePath) match(patterns []string, relPath string) bool {
+	if p.fold {
+		relPath = strings.ToLower(relPath)
+	}
+	return config.MatchPath(patterns, relPath)
+}
+
+// clean makes a path relative to the app directory without leaving it. Absolute paths are
+// accepted only if they point into the app.
+func (p *SafePath) clean(relPath string) (string, error) {
+	if filepath.IsAbs(relPath) || filepath.VolumeName(relPath) != "" {
+		for _, base := range []string{p.Dir, p.dir} {
+			if rel, ok := p.relTo(base, filepath.Clean(relPath)); ok {
+				return rel, nil
+			}
+		}
+		return "", ErrOutsideApp
+	}
+
+	rel := filepath.Clean(relPath)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideApp
+	}
+	return rel, nil
+}
+
+// followSymlinks resolves the symlinks along a cleaned relative path, one component at a time,
+// and fails if any of them leads outside the app. Components that don't exist are kept as is.
+func (p *SafePath) followSymlinks(rel string) (string, error) {
+	var resolved string
+	remaining := rel
+	for hops := 0; remaining != "." && remaining != ""; {
+		component, rest, _ := strings.Cut(remaining, string(filepath.Separator))
+		next := filepath.Join(resolved, component)
+
+		info, err := os.Lstat(filepath.Join(p.Dir, next))
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			return filepath.Join(next, rest), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved, remaining = next, rest
+			continue
+		}
+
+		if hops++; hops > maxSymlinks {
+			return "", errors.New("too many levels of symbolic links")
+		}
+		target, err := os.Readlink(filepath.Join(p.Dir, next))
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(p.Dir, resolved, target)
+		}
+		targetRel, err := p.clean(target)
+		if err != nil {
+			return "", err
+		}
+
+		// Start over from the app directory with the target in place of the link
+		resolved, remaining = "", filepath.Join(targetRel, rest)
+	}
+	if resolved == "" {
+		return ".", nil
+	}
+	return resolved, nil
+}
+
+// relTo returns target relative to base if it is base or inside it, comparing case-insensitively
+// on case-insensitive filesystems
+func (p *SafePath) relTo(base, target string) (string, bool) {
+	cmpBase, cmpTarget := base, target
+	if p.fold {
+		cmpBase, cmpTarget = strings.ToLower(base), strings.ToLower(target)
+	}
+	rel, err := filepath.Rel(cmpBase, cmpTarget)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if p.fold && rel != "." && len(cmpTarget) == len(target) {
+		// Keep the case the path was given in
+		rel = target[len(target)-len(rel):]
+	}
+	return rel, true
+}
+
+// root opens the app directory for openat-style access
+func (p *SafePath) root() (*os.Root, error) {
+	root, err := os.OpenRoot(p.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open app directory: %w", err)
+	}
+	return root, nil
+}
+
+// open checks a path and calls fn with it relative to the app and the opened app directory
+func (p *SafePath) open(relPath string, fn func(root *os.Root, rel string) error) error {
+	rel, err := p.Rel(relPath)
+	if err != nil {
+		return err
+	}
+	root, err := p.root()
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return fn(root, rel)
+}
+
+// OpenFile opens a file in the app like os.OpenFile; the app manifest can only be opened for
+// reading
+func (p *SafePath) OpenFile(relPath string, flag int, perm os.FileMode) (*os.File, error) {
+	var f *os.File
+	err := p.open(relPath, func(root *os.Root, rel string) error {
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+			if err := p.checkWritable(rel); err != nil {
+				return fmt.Errorf("%s: %w", relPath, err)
+			}
+		}
+		var err error
+		f, err = root.OpenFile(rel, flag, perm)
+		return err
+	})
+	return f, err
+}
+
+// Open opens a file in the app for reading
+func (p *SafePath) Open(relPath string) (*os.File, error) {
+	return p.OpenFile(relPath, os.O_RDONLY, 0)
+}
+
+// ReadFile reads a file in the app like os.ReadFile
+func (p *SafePath) ReadFile(relPath string) ([]byte, error) {
+	f, err := p.Open(relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// WriteFile writes a file in the app like os.WriteFile
+func (p *SafePath) WriteFile(relPath string, data []byte, perm os.FileMode) error {
+	f, err := p.OpenFile(relPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Stat returns information about a file in the app, following symlinks inside it
+func (p *SafePath) Stat(relPath string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := p.open(relPath, func(root *os.Root, rel string) error {
+		var err error
+		info, err = root.Stat(rel)
+		return err
+	})
+	return info, err
+}
+
+// Lstat returns information about a file in the app without following a final symlink
+func (p *SafePath) Lstat(relPath string) (os.FileInfo, error) {
+	rel, err := p.RelNoFollow(relPath)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.root()
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	return root.Lstat(rel)
+}
+
+// RelNoFollow checks a path like Rel but leaves a final symlink unresolved, so it names the
+// link itself, for tools like git that act on symlinks rather than their targets
+func (p *SafePath) RelNoFollow(relPath string) (string, error) {
+	if strings.ContainsRune(relPath, 0) {
+		return "", ErrInvalidPath
+	}
+	rel, err := p.clean(relPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return rel, nil
+	}
+
+	dir, err := p.followSymlinks(filepath.Dir(rel))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", relPath, err)
+	}
+	resolved := filepath.Join(dir, filepath.Base(rel))
+	if p.Denied(rel) || p.Denied(resolved) {
+		return "", fmt.Errorf("%s: %w", relPath, ErrDeniedPath)
+	}
+	return resolved, nil
+}
+
+// MkdirAll creates a directory in the app along with any missing parents
+func (p *SafePath) MkdirAll(relPath string, perm os.FileMode) error {
+	return p.open(relPath, func(root *os.Root, rel string) error {
+		if rel == "." {
+			return nil
+		}
+		var dir string
+		for _, component := range strings.Split(rel, string(filepath.Separator)) {
+			dir = filepath.Join(dir, component)
+			if err := root.Mkdir(dir, perm); err != nil && !errors.Is(err, fs.ErrExist) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Remove removes a file or empty directory in the app; a symlink is removed, not its target
+func (p *SafePath) Remove(relPath string) error {
+	rel, err := p.RelNoFollow(relPath)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return errors.New("cannot remove the app directory")
+	}
+	if err := p.checkWritable(rel); err != nil {
+		return fmt.Errorf("%s: %w", relPath, err)
+	}
+	root, err := p.root()
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return root.Remove(rel)
+}
+
+// Rename moves a file within the app; a symlink is moved, not its target. The paths are
+// checked, but the rename itself isn't relative to the opened app directory.
+func (p *SafePath) Rename(oldPath, newPath string) error {
+	from, err := p.RelNoFollow(oldPath)
+	if err != nil {
+		return err
+	}
+	to, err := p.RelNoFollow(newPath)
+	if err != nil {
+		return err
+	}
+	if err := p.checkWritable(from); err != nil {
+		return fmt.Errorf("%s: %w", oldPath, err)
+	}
+	if err := p.checkWritable(to); err != nil {
+		return fmt.Errorf("%s: %w", newPath, err)
+	}
+	return os.Rename(filepath.Join(p.Dir, from), filepath.Join(p.Dir, to))
+}
+
+// checkWritable rejects changes to the app manifest, or to the directory holding it, through
+// file tools: its tool list limits those tools, so they mustn't be able to widen it
+func (p *SafePath) checkWritable(rel string) error {
+	rel = filepath.ToSlash(rel)
+	dir, file := constants.AppManifestDir, constants.AppManifestDir+"/"+constants.AppManifestFile
+	if p.fold {
+		rel, dir, file = strings.ToLower(rel), strings.ToLower(dir), strings.ToLower(file)
+	}
+	if rel == dir || rel == file {
+		return ErrManifest
+	}
+	return nil
+}
+
+// caseInsensitive reports whether the filesystem holding dir ignores case, by looking the
+// directory up under a different case
+func caseInsensitive(dir string) bool {
+	base := filepath.Base(dir)
+	swapped := strings.ToUpper(base)
+	if swapped == base {
+		swapped = strings.ToLower(base)
+	}
+	if swapped == base {
+		return false
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	other, err := os.Stat(filepath.Join(filepath.Dir(dir), swapped))
+	return err == nil && os.SameFile(info, other)
+}
@@ -0,0 +1,345 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// safePathFixture is an app with symlinks pointing inside it, to another app and outside the
+// apps directory, plus files denied by the config
+type safePathFixture struct {
+	path    *SafePath
+	outside string // A file outside the apps directory
+	other   string // A file in another app
+	env     string // A denied file in the app
+}
+
+func newSafePathFixture(tb testing.TB) safePathFixture {
+	tb.Helper()
+
+	homeDir := testutil.Home(tb)
+	os.WriteFile(filepath.Join(homeDir, "config.toml"), []byte("[files]\ndeny = [\".env\", \"secrets/\"]\n"), 0644)
+
+	appDir := filepath.Join(homeDir, "apps", "testapp")
+	otherDir := filepath.Join(homeDir, "apps", "other")
+	outsideDir := tb.TempDir()
+	for _, dir := range []string{filepath.Join(appDir, "src", "lib"), filepath.Join(appDir, "secrets"), otherDir} {
+		os.MkdirAll(dir, 0755)
+	}
+	files := map[string]string{
+		filepath.Join(appDir, "src", "main.js"):     "main",
+		filepath.Join(appDir, ".env"):               "API_KEY=1",
+		filepath.Join(appDir, "secrets", "key.txt"): "key",
+		filepath.Join(otherDir, "secret.txt"):       "other",
+		filepath.Join(outsideDir, "passwd"):         "root",
+	}
+	for path, content := range files {
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	links := map[string]string{
+		"link-in":      "src",
+		"link-abs-in":  filepath.Join(appDir, "src", "main.js"),
+		"link-out":     outsideDir,
+		"link-other":   filepath.Join("..", "other"),
+		"link-env":     ".env",
+		"link-chain":   "link-in/lib",
+		"loop1":        "loop2",
+		"loop2":        "loop1",
+		"src/link-up":  filepath.Join("..", "..", "other", "secret.txt"),
+		"src/link-dot": "..",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(appDir, name)); err != nil {
+			tb.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	p, err := NewSafePath("testapp")
+	if err != nil {
+		tb.Fatalf("NewSafePath() failed: %v", err)
+	}
+	return safePathFixture{
+		path:    p,
+		outside: filepath.Join(outsideDir, "passwd"),
+		other:   filepath.Join(otherDir, "secret.txt"),
+		env:     filepath.Join(appDir, ".env"),
+	}
+}
+
+func TestSafePathResolve(t *testing.T) {
+	fx := newSafePathFixture(t)
+
+	tests := []struct {
+		path string
+		want string // Path relative to the app, or "" for an error
+		err  error
+	}{
+		{"src/main.js", "src/main.js", nil},
+		{"", ".", nil},
+		{"./src/../src/main.js", "src/main.js", nil},
+		{"new/dir/file.txt", "new/dir/file.txt", nil},
+		{"link-in/main.js", "src/main.js", nil},
+		{"link-abs-in", "src/main.js", nil},
+		{"link-chain/x.js", "src/lib/x.js", nil},
+		{"src/link-dot/src/main.js", "src/main.js", nil},
+		{filepath.Join(fx.path.Dir, "src", "main.js"), "src/main.js", nil},
+		{"src/main.js/nope", "src/main.js/nope", nil},
+		{"foo..bar", "foo..bar", nil},
+		{"../other/secret.txt", "", ErrOutsideApp},
+		{"src/../../other", "", ErrOutsideApp},
+		{"/etc/passwd", "", ErrOutsideApp},
+		{filepath.Join(fx.path.Dir+"-evil", "x"), "", ErrOutsideApp},
+		{"link-out/passwd", "", ErrOutsideApp},
+		{"link-other/secret.txt", "", ErrOutsideApp},
+		{"src/link-up", "", ErrOutsideApp},
+		{".env", "", ErrDeniedPath},
+		{"link-env", "", ErrDeniedPath},
+		{"secrets/key.txt", "", ErrDeniedPath},
+		{"src/../secrets", "", ErrDeniedPath},
+		{"bad\x00path", "", ErrInvalidPath},
+		{"loop1", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := fx.path.Rel(tt.path)
+			switch {
+			case tt.want != "":
+				if err != nil || got != filepath.FromSlash(tt.want) {
+					t.Errorf("Rel(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+				}
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Rel(%q) = %q, %v; want error %v", tt.path, got, err, tt.err)
+				}
+			case err == nil:
+				t.Errorf("Rel(%q) = %q; want an error", tt.path, got)
+			}
+		})
+	}
+}
+
+func TestSafePathFiles(t *testing.T) {
+	fx := newSafePathFixture(t)
+	p := fx.path
+
+	if err := p.MkdirAll("a/b", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := p.WriteFile("a/b/c.txt", []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if data, err := p.ReadFile("a/b/c.txt"); err != nil || string(data) != "hello" {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+
+	if info, err := p.Lstat("link-in"); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Lstat() should not follow the final symlink, got %v, %v", info, err)
+	}
+	if info, err := p.Stat("link-in"); err != nil || !info.IsDir() {
+		t.Errorf("Stat() should follow a symlink inside the app, got %v, %v", info, err)
+	}
+	if _, err := p.Lstat("link-out/passwd"); !errors.Is(err, ErrOutsideApp) {
+		t.Errorf("Lstat() through an escaping symlink: expected ErrOutsideApp, got %v", err)
+	}
+	if err := p.WriteFile("link-out/passwd", []byte("x"), 0644); !errors.Is(err, ErrOutsideApp) {
+		t.Errorf("WriteFile() through an escaping symlink: expected ErrOutsideApp, got %v", err)
+	}
+	if data, _ := os.ReadFile(fx.outside); string(data) != "root" {
+		t.Error("File outside the app was changed")
+	}
+
+	// Removing a symlink that points outside removes the link only
+	if err := p.Remove("link-out"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if _, err := os.Stat(fx.outside); err != nil {
+		t.Error("Symlink target outside the app was removed")
+	}
+	if err := p.Remove("."); err == nil {
+		t.Error("Expected an error removing the app directory")
+	}
+
+	if err := p.Rename("a/b/c.txt", "a/d.txt"); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+	if err := p.Rename("a/d.txt", "../escaped.txt"); !errors.Is(err, ErrOutsideApp) {
+		t.Errorf("Rename() out of the app: expected ErrOutsideApp, got %v", err)
+	}
+
+	// The manifest can be read but not changed, also through a symlink or by moving it
+	if err := p.MkdirAll(".layered", 0755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(p.Dir, ".layered", "app.json"), []byte(`{"tools": ["lc_read_file"]}`), 0644)
+	os.Symlink(filepath.Join(".layered", "app.json"), filepath.Join(p.Dir, "manifest"))
+	if _, err := p.ReadFile(".layered/app.json"); err != nil {
+		t.Errorf("ReadFile() of the manifest failed: %v", err)
+	}
+	for name, err := range map[string]error{
+		"WriteFile":         p.WriteFile(".layered/app.json", []byte("{}"), 0644),
+		"WriteFile symlink": p.WriteFile("manifest", []byte("{}"), 0644),
+		"Remove":            p.Remove(".layered/app.json"),
+		"Rename from":       p.Rename(".layered/app.json", "app.json"),
+		"Rename to":         p.Rename("a/d.txt", ".layered/app.json"),
+		"Rename dir":        p.Rename("a", ".layered"),
+	} {
+		if !errors.Is(err, ErrManifest) {
+			t.Errorf("%s: expected ErrManifest, got %v", name, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(p.Dir, ".layered", "app.json")); string(data) != `{"tools": ["lc_read_file"]}` {
+		t.Errorf("Manifest was changed: %s", data)
+	}
+}
+
+func TestSafePathProtected(t *testing.T) {
+	fx := newSafePathFixture(t)
+	os.WriteFile(filepath.Join(fx.path.Dir, "src", "server.pem"), []byte("key"), 0644)
+	os.Symlink(filepath.Join("src", "server.pem"), filepath.Join(fx.path.Dir, "cert"))
+
+	for path, want := range map[string]bool{
+		".env.local":         true,
+		"src/server.pem":     true,
+		"link-in/server.pem": true,
+		"cert":               true,
+		"keys/id_rsa.pub":    true,
+		"src/main.js":        false,
+		"environment.js":     false,
+	} {
+		if got := fx.path.Protected(path); got != want {
+			t.Errorf("Protected(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestNewSafePath(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appsDir := filepath.Join(homeDir, "apps")
+	os.MkdirAll(filepath.Join(appsDir, "real"), 0755)
+	os.WriteFile(filepath.Join(appsDir, "file"), []byte("x"), 0644)
+	os.Symlink("real", filepath.Join(appsDir, "alias"))
+	os.Symlink(t.TempDir(), filepath.Join(appsDir, "escape"))
+
+	tests := []struct {
+		appName string
+		errMsg  string
+	}{
+		{"real", ""},
+		{"alias", ""},
+		{"missing", "not found"},
+		{"file", "not a directory"},
+		{"escape", "outside app directory"},
+		{"../real", "cannot contain '..'"},
+		{"nope/real", "unknown workspace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.appName, func(t *testing.T) {
+			p, err := NewSafePath(tt.appName)
+			if tt.errMsg == "" {
+				if err != nil || p.Dir != filepath.Join(mustEvalSymlinks(t, appsDir), "real") {
+					t.Errorf("NewSafePath(%q) = %v, %v", tt.appName, p, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("NewSafePath(%q): expected error containing %q, got %v", tt.appName, tt.errMsg, err)
+			}
+		})
+	}
+}
+
+// FuzzSafePathResolve checks that no path resolves outside the app or to a denied file
+func FuzzSafePathResolve(f *testing.F) {
+	fx := newSafePathFixture(f)
+	for _, seed := range []string{
+		"src/main.js", "..", "../other", "/etc/passwd", "link-out/passwd", "link-other/secret.txt",
+		"src/link-up", "src/link-dot/../other", ".env", ".ENV", "link-env", "secrets//key.txt",
+		"./secrets/../secrets/key.txt", "loop1/x", "a/../../b", "link-in/../../other", "\x00",
+		"C:\\Windows", "\\\\server\\share", "link-chain/../../../other",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		resolved, err := fx.path.Resolve(path)
+		if err != nil {
+			return
+		}
+		if _, ok := fx.path.relTo(fx.path.Dir, resolved); !ok {
+			t.Fatalf("Resolve(%q) = %q, outside %s", path, resolved, fx.path.Dir)
+		}
+		if real := evalExisting(resolved); real != "" {
+			if _, ok := fx.path.relTo(fx.path.Dir, real); !ok {
+				t.Fatalf("Resolve(%q) = %q, which really is %q outside the app", path, resolved, real)
+			}
+		}
+		rel, _ := filepath.Rel(fx.path.Dir, resolved)
+		if fx.path.Denied(rel) {
+			t.Fatalf("Resolve(%q) = %q, which is denied", path, resolved)
+		}
+	})
+}
+
+// FuzzSafePathOpen checks that no path opens a file outside the app or a denied file
+func FuzzSafePathOpen(f *testing.F) {
+	fx := newSafePathFixture(f)
+	forbidden := make([]os.FileInfo, 0, 3)
+	for _, path := range []string{fx.outside, fx.other, fx.env} {
+		info, err := os.Stat(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		forbidden = append(forbidden, info)
+	}
+	for _, seed := range []string{
+		"src/main.js", "link-out/passwd", "link-other/secret.txt", "src/link-up", "link-env",
+		".env", "src/link-dot/.env", "link-in/../.env", "../other/secret.txt",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		file, err := fx.path.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return
+		}
+		for _, bad := range forbidden {
+			if os.SameFile(info, bad) {
+				t.Fatalf("Open(%q) opened a forbidden file", path)
+			}
+		}
+	})
+}
+
+// evalExisting resolves the symlinks in the longest existing part of a path
+func evalExisting(path string) string {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			rest, _ := filepath.Rel(dir, path)
+			return filepath.Join(real, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return real
+}
@@ -3,9 +3,12 @@ package helpers
 import (
 	"fmt"
 	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
 )
 
-// ValidateAppName validates that an app name is safe to use
+// ValidateAppName validates that an app name is safe to use.
+// The name may be qualified with a workspace as workspace/app.
 func ValidateAppName(appName string) error {
 	if appName == "" {
 		return fmt.Errorf("app name cannot be empty")
@@ -15,6 +18,17 @@ func ValidateAppName(appName string) error {
 	if strings.Contains(appName, "..") {
 		return fmt.Errorf("app name cannot contain '..'")
 	}
+
+	// Validate the workspace of a qualified name, then the app name itself
+	if workspace, app, ok := strings.Cut(appName, "/"); ok {
+		if err := config.ValidateWorkspaceName(workspace); err != nil {
+			return err
+		}
+		if app == "" {
+			return fmt.Errorf("app name cannot be empty")
+		}
+		appName = app
+	}
 	
 	// Check for hidden directories (starting with period)
 	if strings.HasPrefix(appName, ".") {
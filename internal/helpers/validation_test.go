@@ -36,10 +36,27 @@ func TestValidateAppName(t *testing.T) {
 		},
 		{
 			name:        "Contains forward slash",
-			appName:     "my/app",
+			appName:     "my/nested/app",
 			shouldError: true,
 			errorMsg:    "app name cannot contain '/'",
 		},
+		{
+			name:        "Qualified with workspace",
+			appName:     "work/my-app",
+			shouldError: false,
+		},
+		{
+			name:        "Invalid workspace",
+			appName:     "my work/app",
+			shouldError: true,
+			errorMsg:    "workspace name \"my work\" may only contain letters, digits, '-' and '_'",
+		},
+		{
+			name:        "Empty app in qualified name",
+			appName:     "work/",
+			shouldError: true,
+			errorMsg:    "app name cannot be empty",
+		},
 		{
 			name:        "Contains backslash",
 			appName:     "my\\app",
@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/policy"
+	"github.com/layered-flow/layered-code/internal/registry"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// confirmationTokenParam is the argument the model echoes back when elicitation isn't available
+const confirmationTokenParam = "confirmation_token"
+
+// elicitor sends an elicitation request to the client; *server.MCPServer implements it
+type elicitor interface {
+	RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error)
+}
+
+// confirmer asks the user before destructive calls run.
+//
+// It prefers MCP elicitation so the client shows the user a prompt. Clients without
+// elicitation get an error carrying a one-time token instead: the model must ask the user
+// and repeat the exact same call with that token for it to go through.
+type confirmer struct {
+	elicitor elicitor
+
+	mu     sync.Mutex
+	tokens map[string]pendingConfirmation
+}
+
+// pendingConfirmation is an issued token and the call it confirms
+type pendingConfirmation struct {
+	call    string
+	expires time.Time
+}
+
+// newConfirmer returns a confirmer that elicits through e
+func newConfirmer(e elicitor) *confirmer {
+	return &confirmer{elicitor: e, tokens: make(map[string]pendingConfirmation)}
+}
+
+// protect returns the tool with one confirmation guard covering every rule that applies to it
+func (c *confirmer) protect(tool registry.Tool, rules []policy.Rule) registry.Tool {
+	var matching []policy.Rule
+	for _, rule := range rules {
+		if rule.Tool == tool.Name {
+			matching = append(matching, rule)
+		}
+	}
+	if len(matching) == 0 {
+		return tool
+	}
+
+	return tool.WithGuard(c.guard(tool.Name, matching)).WithParam(registry.Param{
+		Name:        confirmationTokenParam,
+		Type:        registry.String,
+		Description: "Only when a previous call failed asking for confirmation: the token from that error, sent after the user agreed",
+	})
+}
+
+// guard returns a guard that asks for confirmation once for calls matching any of rules
+func (c *confirmer) guard(toolName string, rules []policy.Rule) registry.Guard {
+	return func(ctx context.Context, args map[string]any) error {
+		var conditions []string
+		matched := false
+		for _, rule := range rules {
+			if !rule.Matches(args) {
+				continue
+			}
+			matched = true
+			if description := rule.Describe(); description != "" {
+				conditions = append(conditions, description)
+			}
+		}
+		if !matched {
+			return nil
+		}
+
+		action := toolName
+		if len(conditions) > 0 {
+			action += " with " + strings.Join(conditions, " and ")
+		}
+		call := callKey(toolName, args)
+
+		if token, _ := args[confirmationTokenParam].(string); token != "" {
+			if c.redeem(token, call) {
+				return nil
+			}
+			return fmt.Errorf("confirmation token is invalid, expired or was issued for different arguments")
+		}
+
+		confirmed, err := c.elicit(ctx, action, args)
+		if err == nil {
+			if !confirmed {
+				return fmt.Errorf("%s was not confirmed by the user", action)
+			}
+			return nil
+		}
+		if !errors.Is(err, server.ErrElicitationNotSupported) && !errors.Is(err, server.ErrNoActiveSession) {
+			return fmt.Errorf("failed to ask for confirmation: %w", err)
+		}
+
+		token, err := c.issue(call)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("confirmation required: %s is destructive. Ask the user to confirm, then repeat the call with the same arguments and %s %q (valid for %s)",
+			action, confirmationTokenParam, token, constants.ConfirmationTokenTTL)
+	}
+}
+
+// elicit asks the user through the MCP client; it returns ErrElicitationNotSupported
+// when the client didn't declare the elicitation capability
+func (c *confirmer) elicit(ctx context.Context, action string, args map[string]any) (bool, error) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return false, server.ErrNoActiveSession
+	}
+	if session.GetClientCapabilities().Elicitation == nil {
+		return false, server.ErrElicitationNotSupported
+	}
+
+	details, _ := json.MarshalIndent(withoutToken(args), "", "  ")
+	result, err := c.elicitor.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: fmt.Sprintf("The assistant wants to run %s:\n%s\nAllow it?", action, details),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{
+						"type":        "boolean",
+						"title":       "Allow",
+						"description": "Run " + action,
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+	content, _ := result.Content.(map[string]any)
+	confirmed, _ := content["confirm"].(bool)
+	return confirmed, nil
+}
+
+// issue creates a one-time token for call
+func (c *confirmer) issue(call string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for t, pending := range c.tokens {
+		if now.After(pending.expires) {
+			delete(c.tokens, t)
+		}
+	}
+	c.tokens[token] = pendingConfirmation{call: call, expires: now.Add(constants.ConfirmationTokenTTL)}
+	return token, nil
+}
+
+// redeem consumes token if it was issued for call and hasn't expired
+func (c *confirmer) redeem(token, call string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(c.tokens, token)
+	return pending.call == call && time.Now().Before(pending.expires)
+}
+
+// callKey identifies a call by tool and arguments so a token only confirms that exact call
+func callKey(toolName string, args map[string]any) string {
+	// encoding/json sorts map keys, so equal arguments give equal keys
+	data, _ := json.Marshal(withoutToken(args))
+	return toolName + " " + string(data)
+}
+
+// withoutToken returns args minus the confirmation token
+func withoutToken(args map[string]any) map[string]any {
+	rest := make(map[string]any, len(args))
+	for key, value := range args {
+		if key != confirmationTokenParam {
+			rest[key] = value
+		}
+	}
+	return rest
+}
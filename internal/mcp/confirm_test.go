@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/policy"
+	"github.com/layered-flow/layered-code/internal/registry"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testSession is a client session with configurable capabilities
+type testSession struct {
+	capabilities mcp.ClientCapabilities
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *testSession) SessionID() string                                   { return "test" }
+func (s *testSession) GetClientInfo() mcp.Implementation {
+	return mcp.Implementation{Name: "test-client"}
+}
+func (s *testSession) SetClientInfo(mcp.Implementation)               {}
+func (s *testSession) GetClientCapabilities() mcp.ClientCapabilities  { return s.capabilities }
+func (s *testSession) SetClientCapabilities(c mcp.ClientCapabilities) { s.capabilities = c }
+
+// testElicitor answers elicitation requests with a fixed response
+type testElicitor struct {
+	response mcp.ElicitationResponse
+	requests int
+}
+
+func (e *testElicitor) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	e.requests++
+	return &mcp.ElicitationResult{ElicitationResponse: e.response}, nil
+}
+
+type resetParams struct {
+	AppName string `json:"app_name" required:"true"`
+	Mode    string `json:"mode"`
+}
+
+func protectedResetTool(t *testing.T, c *confirmer) registry.Tool {
+	tool := registry.New(registry.Spec[resetParams, struct{}]{
+		Name: "git_reset",
+		Run: func(ctx context.Context, params resetParams) (struct{}, error) {
+			return struct{}{}, nil
+		},
+	})
+
+	rule, err := policy.ParseRule("git_reset.mode=hard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.protect(tool, []policy.Rule{rule})
+}
+
+func sessionContext(elicitation bool) context.Context {
+	session := &testSession{}
+	if elicitation {
+		session.capabilities.Elicitation = &mcp.ElicitationCapability{}
+	}
+	return server.NewMCPServer("test", "1.0.0").WithContext(context.Background(), session)
+}
+
+// TestConfirmElicitation verifies destructive calls wait for the user's answer
+func TestConfirmElicitation(t *testing.T) {
+	hard := map[string]any{"app_name": "myapp", "mode": "hard"}
+
+	t.Run("accepted", func(t *testing.T) {
+		e := &testElicitor{response: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]any{"confirm": true}}}
+		tool := protectedResetTool(t, newConfirmer(e))
+
+		if _, err := tool.Call(sessionContext(true), hard); err != nil {
+			t.Errorf("expected confirmed call to run, got %v", err)
+		}
+		if e.requests != 1 {
+			t.Errorf("expected 1 elicitation request, got %d", e.requests)
+		}
+	})
+
+	t.Run("unchecked", func(t *testing.T) {
+		e := &testElicitor{response: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]any{"confirm": false}}}
+		tool := protectedResetTool(t, newConfirmer(e))
+
+		_, err := tool.Call(sessionContext(true), hard)
+		if err == nil || !strings.Contains(err.Error(), "was not confirmed by the user") {
+			t.Errorf("expected not confirmed error, got %v", err)
+		}
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		e := &testElicitor{response: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}
+		tool := protectedResetTool(t, newConfirmer(e))
+
+		_, err := tool.Call(sessionContext(true), hard)
+		if err == nil || !strings.Contains(err.Error(), "git_reset with mode=hard was not confirmed") {
+			t.Errorf("expected not confirmed error, got %v", err)
+		}
+	})
+
+	t.Run("non-matching call", func(t *testing.T) {
+		e := &testElicitor{}
+		tool := protectedResetTool(t, newConfirmer(e))
+
+		if _, err := tool.Call(sessionContext(true), map[string]any{"app_name": "myapp", "mode": "soft"}); err != nil {
+			t.Errorf("expected soft reset to run, got %v", err)
+		}
+		if e.requests != 0 {
+			t.Errorf("expected no elicitation for a soft reset")
+		}
+	})
+}
+
+// TestConfirmToken verifies the token fallback for clients without elicitation
+func TestConfirmToken(t *testing.T) {
+	c := newConfirmer(&testElicitor{})
+	tool := protectedResetTool(t, c)
+	ctx := sessionContext(false)
+
+	if _, ok := tool.Param(confirmationTokenParam); !ok {
+		t.Fatal("expected protected tool to accept a confirmation token")
+	}
+
+	_, err := tool.Call(ctx, map[string]any{"app_name": "myapp", "mode": "hard"})
+	if err == nil || !strings.Contains(err.Error(), "confirmation required") {
+		t.Fatalf("expected confirmation required error, got %v", err)
+	}
+	token := regexp.MustCompile(`confirmation_token "([0-9a-f]+)"`).FindStringSubmatch(err.Error())
+	if token == nil {
+		t.Fatalf("expected a token in %q", err.Error())
+	}
+
+	// The token only confirms the exact same call
+	if _, err := tool.Call(ctx, map[string]any{"app_name": "other", "mode": "hard", confirmationTokenParam: token[1]}); err == nil {
+		t.Error("expected token to be rejected for different arguments")
+	}
+
+	_, err = tool.Call(ctx, map[string]any{"app_name": "myapp", "mode": "hard"})
+	token = regexp.MustCompile(`confirmation_token "([0-9a-f]+)"`).FindStringSubmatch(err.Error())
+	args := map[string]any{"app_name": "myapp", "mode": "hard", confirmationTokenParam: token[1]}
+	if _, err := tool.Call(ctx, args); err != nil {
+		t.Errorf("expected call with token to run, got %v", err)
+	}
+	if _, err := tool.Call(ctx, args); err == nil || !strings.Contains(err.Error(), "confirmation token is invalid") {
+		t.Errorf("expected token to be single use, got %v", err)
+	}
+}
+
+// TestProtectUnmatchedTool verifies tools without rules are left unchanged
+func TestProtectUnmatchedTool(t *testing.T) {
+	tool := registry.New(registry.Spec[resetParams, struct{}]{Name: "git_status"})
+	rule, _ := policy.ParseRule("git_reset.mode=hard")
+
+	protected := newConfirmer(&testElicitor{}).protect(tool, []policy.Rule{rule})
+	if _, ok := protected.Param(confirmationTokenParam); ok {
+		t.Error("expected no confirmation token parameter on an unprotected tool")
+	}
+}
+
+type pushParams struct {
+	AppName    string `json:"app_name" required:"true"`
+	Force      bool   `json:"force"`
+	SkipChecks bool   `json:"skip_checks"`
+}
+
+// TestConfirmMultipleRules verifies a call matching several rules is confirmed once
+func TestConfirmMultipleRules(t *testing.T) {
+	var rules []policy.Rule
+	for _, r := range []string{"git_push.force", "git_push.skip_checks"} {
+		rule, err := policy.ParseRule(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+	newTool := func(c *confirmer) registry.Tool {
+		return c.protect(registry.New(registry.Spec[pushParams, struct{}]{
+			Name: "git_push",
+			Run: func(ctx context.Context, params pushParams) (struct{}, error) {
+				return struct{}{}, nil
+			},
+		}), rules)
+	}
+	args := func() map[string]any {
+		return map[string]any{"app_name": "myapp", "force": true, "skip_checks": true}
+	}
+
+	t.Run("elicitation", func(t *testing.T) {
+		e := &testElicitor{response: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]any{"confirm": true}}}
+		if _, err := newTool(newConfirmer(e)).Call(sessionContext(true), args()); err != nil {
+			t.Errorf("expected confirmed call to run, got %v", err)
+		}
+		if e.requests != 1 {
+			t.Errorf("expected 1 elicitation request, got %d", e.requests)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		tool := newTool(newConfirmer(&testElicitor{}))
+		ctx := sessionContext(false)
+
+		_, err := tool.Call(ctx, args())
+		if err == nil || !strings.Contains(err.Error(), "git_push with force and skip_checks") {
+			t.Fatalf("expected both conditions in the error, got %v", err)
+		}
+		token := regexp.MustCompile(`confirmation_token "([0-9a-f]+)"`).FindStringSubmatch(err.Error())
+		if token == nil {
+			t.Fatalf("expected a token in %q", err.Error())
+		}
+
+		confirmed := args()
+		confirmed[confirmationTokenParam] = token[1]
+		if _, err := tool.Call(ctx, confirmed); err != nil {
+			t.Errorf("expected call with token to run, got %v", err)
+		}
+	})
+}
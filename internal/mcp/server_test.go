@@ -1,56 +1,33 @@
 package mcp
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/audit"
+	"github.com/layered-flow/layered-code/internal/testutil"
+	"github.com/layered-flow/layered-code/internal/tools"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// TestRegisterFunctions tests that tool registration functions execute without panicking
-func TestRegisterFunctions(t *testing.T) {
+// TestRegisterTools verifies every tool in the registry is registered under a unique name
+func TestRegisterTools(t *testing.T) {
 	s := server.NewMCPServer("test", "1.0.0")
+	registerTools(s, tools.All())
 
-	tests := []struct {
-		name string
-		fn   func(*server.MCPServer)
-	}{
-		{"registerListAppsTool", registerListAppsTool},
-		{"registerListFilesTool", registerListFilesTool},
-		{"registerSearchTextTool", registerSearchTextTool},
-		{"registerReadFileTool", registerReadFileTool},
-		{"registerWriteFileTool", registerWriteFileTool},
-		{"registerEditFileTool", registerEditFileTool},
-		{"registerViteCreateAppTool", registerViteCreateAppTool},
-		{"registerPnpmInstallTool", registerPnpmInstallTool},
-		{"registerPnpmAddTool", registerPnpmAddTool},
-		{"registerGitStatusTool", registerGitStatusTool},
-		{"registerGitDiffTool", registerGitDiffTool},
-		{"registerGitCommitTool", registerGitCommitTool},
-		{"registerGitLogTool", registerGitLogTool},
-		{"registerGitBranchTool", registerGitBranchTool},
-		{"registerGitAddTool", registerGitAddTool},
-		{"registerGitRestoreTool", registerGitRestoreTool},
-		{"registerGitStashTool", registerGitStashTool},
-		{"registerGitPushTool", registerGitPushTool},
-		{"registerGitPullTool", registerGitPullTool},
-		{"registerGitInitTool", registerGitInitTool},
-		{"registerGitRemoteTool", registerGitRemoteTool},
-		{"registerGitResetTool", registerGitResetTool},
-		{"registerGitRevertTool", registerGitRevertTool},
-		{"registerGitCheckoutTool", registerGitCheckoutTool},
-		{"registerGitShowTool", registerGitShowTool},
-	}
+	seen := make(map[string]bool)
+	for _, tool := range tools.All() {
+		if seen[tool.Name] {
+			t.Errorf("duplicate tool name %s", tool.Name)
+		}
+		seen[tool.Name] = true
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			defer func() {
-				if r := recover(); r != nil {
-					t.Errorf("%s panicked: %v", tt.name, r)
-				}
-			}()
-			tt.fn(s)
-		})
+		if s.GetTool(tool.Name) == nil {
+			t.Errorf("tool %s is not registered", tool.Name)
+		}
 	}
 }
 
@@ -68,3 +45,71 @@ func TestToolCreation(t *testing.T) {
 		t.Errorf("Expected description 'List all available applications', got '%s'", tool.Description)
 	}
 }
+
+// TestToolAnnotations verifies that tools advertise safety hints and output schemas
+func TestToolAnnotations(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0")
+	registerTools(s, tools.All())
+
+	tests := []struct {
+		name        string
+		readOnly    bool
+		destructive bool
+	}{
+		{"lc_read_file", true, false},
+		{"lc_list_apps", true, false},
+		{"git_status", true, false},
+		{"lc_delete_file", false, true},
+		{"git_reset", false, true},
+		{"git_push", false, true},
+		{"git_add", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registered := s.GetTool(tt.name)
+			if registered == nil {
+				t.Fatalf("tool %s is not registered", tt.name)
+			}
+
+			annotations := registered.Tool.Annotations
+			if annotations.ReadOnlyHint == nil || *annotations.ReadOnlyHint != tt.readOnly {
+				t.Errorf("expected readOnlyHint %v", tt.readOnly)
+			}
+			if annotations.DestructiveHint == nil || *annotations.DestructiveHint != tt.destructive {
+				t.Errorf("expected destructiveHint %v", tt.destructive)
+			}
+			if len(registered.Tool.RawOutputSchema) == 0 {
+				t.Error("expected an output schema")
+			}
+		})
+	}
+}
+
+// TestAuditedHandler verifies tool calls are written to the audit log
+func TestAuditedHandler(t *testing.T) {
+	testutil.Home(t)
+
+	original := auditLog
+	defer func() { auditLog = original }()
+	auditLog = audit.New(filepath.Join(t.TempDir(), "audit.jsonl"))
+
+	tool, _ := tools.Find("lc_read_file")
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"app_name": "no-such-app", "file_path": "index.html"}
+
+	if _, err := auditedHandler(tool)(context.Background(), request); err == nil {
+		t.Fatal("expected error reading from a missing app")
+	}
+
+	entries, err := auditLog.Read(audit.Filter{})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].Tool != "lc_read_file" || entries[0].AppName != "no-such-app" || entries[0].Error == "" {
+		t.Errorf("unexpected audit entry: %+v", entries[0])
+	}
+}
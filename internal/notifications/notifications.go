@@ -1,6 +1,11 @@
 package notifications
 
-import "github.com/layered-flow/layered-code/internal/websocket"
+import (
+	"path/filepath"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/websocket"
+)
 
 var hub *websocket.Hub
 
@@ -12,6 +17,22 @@ func SetHub(h *websocket.Hub) {
 // NotifyFileChange sends a file change notification if hub is available
 func NotifyFileChange(filename string, action string) {
 	if hub != nil {
-		hub.NotifyFileChange(filename, action)
+		hub.NotifyFileChange(filename, action, "")
+	}
+}
+
+// NotifyAppFileChange sends a change notification for a file in an app, unless the app's
+// manifest ignores the path. The notification carries the app's preview URL when the manifest
+// declares one, so the browser extension doesn't have to guess which tab to reload.
+func NotifyAppFileChange(appName, filePath string, action string) {
+	if hub == nil {
+		return
 	}
-}
\ No newline at end of file
+
+	// A broken manifest shouldn't stop live reload
+	manifest, _ := config.LoadAppManifest(appName)
+	if manifest.Ignores(filePath) {
+		return
+	}
+	hub.NotifyFileChange(filepath.Join(appName, filePath), action, manifest.Preview())
+}
@@ -0,0 +1,421 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// Policy controls which tools the MCP server exposes and which arguments they accept
+type Policy struct {
+	Allow    []string `json:"allow,omitempty"`     // Tool or group names to expose; empty exposes every tool
+	Deny     []string `json:"deny,omitempty"`      // Tool or group names to hide, applied after allow
+	ReadOnly bool     `json:"read_only,omitempty"` // Expose only tools that don't modify anything
+	DenyArgs []string `json:"deny_args,omitempty"` // Forbidden arguments as tool.param or tool.param=value
+
+	Confirm   []string `json:"confirm,omitempty"`    // Calls needing user confirmation, in addition to DefaultConfirm
+	NoConfirm bool     `json:"no_confirm,omitempty"` // Run every permitted call without asking
+
+	// Further restrictions for calls on apps in a workspace, by workspace name; only allow,
+	// deny, read_only and deny_args apply
+	Workspaces map[string]Policy `json:"workspaces,omitempty"`
+}
+
+// DefaultConfirm lists the destructive calls, and the calls showing secrets or skipping the checks
+// for them, that need the user's confirmation unless NoConfirm is set
+var DefaultConfirm = []string{
+	"git_reset.mode=hard",
+	"git_push.force",
+	"git_stash.action=clear",
+	"git_worktree.action=remove,force",
+	"lc_delete_file",
+	"lc_delete_app",
+	"pnpm_pm2.command=delete,target=all",
+	"lc_read_file.show_secrets",
+	"lc_search_text.show_secrets",
+	"git_diff.show_secrets",
+	"git_show.show_secrets",
+	"git_conflicts.show_secrets",
+	"git_blame.show_secrets",
+	"git_stash.show_secrets",
+	"git_commit.skip_checks",
+	"git_push.skip_checks",
+	"git_tag.skip_checks",
+}
+
+// Load reads a policy from a JSON file
+func Load(path string) (Policy, error) {
+	var p Policy
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// Merge returns the policy with the rules of other added on top
+func (p Policy) Merge(other Policy) Policy {
+	return Policy{
+		Allow:    append(append([]string(nil), p.Allow...), other.Allow...),
+		Deny:     append(append([]string(nil), p.Deny...), other.Deny...),
+		ReadOnly: p.ReadOnly || other.ReadOnly,
+		DenyArgs: append(append([]string(nil), p.DenyArgs...), other.DenyArgs...),
+
+		Confirm:   append(append([]string(nil), p.Confirm...), other.Confirm...),
+		NoConfirm: p.NoConfirm || other.NoConfirm,
+
+		Workspaces: mergeWorkspaces(p.Workspaces, other.Workspaces),
+	}
+}
+
+// mergeWorkspaces merges the workspace policies of two policies
+func mergeWorkspaces(a, b map[string]Policy) map[string]Policy {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	merged := make(map[string]Policy, len(a)+len(b))
+	for name, p := range a {
+		merged[name] = p
+	}
+	for name, p := range b {
+		merged[name] = merged[name].Merge(p)
+	}
+	return merged
+}
+
+// Apply returns the tools permitted by the policy, with argument restrictions attached.
+// Unknown tool, group or parameter names are reported as errors so typos don't silently
+// leave a tool exposed.
+func (p Policy) Apply(all []registry.Tool) ([]registry.Tool, error) {
+	restrictions, err := p.restrictions(all)
+	if err != nil {
+		return nil, err
+	}
+
+	var permitted []registry.Tool
+	for _, tool := range all {
+		if !p.permits(tool) {
+			continue
+		}
+		for _, r := range restrictions[tool.Name] {
+			tool = tool.WithGuard(deny(r))
+		}
+		permitted = append(permitted, tool)
+	}
+
+	return permitted, nil
+}
+
+// restrictions checks the policy's tool names and returns its deny_args rules by tool
+func (p Policy) restrictions(all []registry.Tool) (map[string][]Rule, error) {
+	for _, name := range append(append([]string(nil), p.Allow...), p.Deny...) {
+		if !known(all, name) {
+			return nil, fmt.Errorf("unknown tool or group: %s", name)
+		}
+	}
+
+	restrictions := make(map[string][]Rule)
+	for _, rule := range p.DenyArgs {
+		r, err := ParseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.Conditions) == 0 {
+			return nil, fmt.Errorf("invalid deny_args rule %q: expected tool.param or tool.param=value", rule)
+		}
+		if err := r.validate(all); err != nil {
+			return nil, fmt.Errorf("invalid deny_args rule %q: %w", rule, err)
+		}
+		restrictions[r.Tool] = append(restrictions[r.Tool], r)
+	}
+	return restrictions, nil
+}
+
+// ApplyWorkspaces attaches the workspace policies to the tools that take an app, so calls on an
+// app are also checked against the policy of its workspace. workspaceOf names the workspace an
+// app name refers to.
+func (p Policy) ApplyWorkspaces(toolset, all []registry.Tool, workspaceOf func(appName string) (string, error)) ([]registry.Tool, error) {
+	if len(p.Workspaces) == 0 {
+		return toolset, nil
+	}
+
+	restrictions := make(map[string]map[string][]Rule)
+	for name, wp := range p.Workspaces {
+		r, err := wp.restrictions(all)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %s: %w", name, err)
+		}
+		restrictions[name] = r
+	}
+
+	scoped := make([]registry.Tool, len(toolset))
+	for i, tool := range toolset {
+		scoped[i] = tool
+		if len(tool.AppParams()) == 0 {
+			continue
+		}
+		scoped[i] = tool.WithGuard(p.workspaceGuard(tool, restrictions, workspaceOf))
+	}
+	return scoped, nil
+}
+
+// workspaceGuard returns a guard rejecting calls the policy of an app's workspace doesn't permit.
+// Every parameter naming an app is checked, so an app can't be moved or copied into a workspace
+// that forbids the call; "all" is checked against every workspace.
+func (p Policy) workspaceGuard(tool registry.Tool, restrictions map[string]map[string][]Rule, workspaceOf func(string) (string, error)) registry.Guard {
+	return func(ctx context.Context, args map[string]any) error {
+		for _, param := range tool.AppParams() {
+			appName, _ := args[param.Name].(string)
+			if appName == "" {
+				continue
+			}
+
+			var workspaces []string
+			if param.AllApps && appName == "all" {
+				for workspace := range p.Workspaces {
+					workspaces = append(workspaces, workspace)
+				}
+				sort.Strings(workspaces)
+			} else {
+				workspace, err := workspaceOf(appName)
+				if err != nil {
+					return err
+				}
+				workspaces = []string{workspace}
+			}
+
+			for _, workspace := range workspaces {
+				if err := p.checkWorkspace(tool, workspace, restrictions[workspace], args); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// checkWorkspace rejects the call if the policy of workspace doesn't permit it
+func (p Policy) checkWorkspace(tool registry.Tool, workspace string, restrictions map[string][]Rule, args map[string]any) error {
+	wp, ok := p.Workspaces[workspace]
+	if !ok {
+		return nil
+	}
+
+	if !wp.permits(tool) {
+		return fmt.Errorf("%s is disabled in workspace %s by server policy", tool.Name, workspace)
+	}
+	for _, r := range restrictions[tool.Name] {
+		if r.Matches(args) {
+			return fmt.Errorf("%s is disabled for %s in workspace %s by server policy", r.Describe(), r.Tool, workspace)
+		}
+	}
+	return nil
+}
+
+// ApplyAppTools attaches a guard to the tools that take an app, rejecting calls on apps that
+// limit which tools may be used on them. allowedTools returns an app's tool and group names;
+// an empty list allows every tool.
+func ApplyAppTools(toolset []registry.Tool, allowedTools func(appName string) ([]string, error)) []registry.Tool {
+	guarded := make([]registry.Tool, len(toolset))
+	for i, tool := range toolset {
+		guarded[i] = tool
+		if len(tool.AppParams()) == 0 {
+			continue
+		}
+
+		tool := tool
+		guarded[i] = tool.WithGuard(func(ctx context.Context, args map[string]any) error {
+			for _, param := range tool.AppParams() {
+				appName, _ := args[param.Name].(string)
+				// "all" isn't one app with a manifest
+				if appName == "" || (param.AllApps && appName == "all") {
+					continue
+				}
+				allowed, err := allowedTools(appName)
+				if err != nil {
+					return err
+				}
+				if len(allowed) > 0 && !matches(allowed, tool) {
+					return fmt.Errorf("%s is not allowed for app %s by its manifest", tool.Name, appName)
+				}
+			}
+			return nil
+		})
+	}
+	return guarded
+}
+
+// deny returns a guard rejecting calls that match the rule
+func deny(r Rule) registry.Guard {
+	return func(ctx context.Context, args map[string]any) error {
+		if r.Matches(args) {
+			return fmt.Errorf("%s is disabled for %s by server policy", r.Describe(), r.Tool)
+		}
+		return nil
+	}
+}
+
+// ConfirmRules returns the rules for calls that need the user's confirmation:
+// DefaultConfirm plus the policy's own rules, or none when confirmation is turned off
+func (p Policy) ConfirmRules(all []registry.Tool) ([]Rule, error) {
+	if p.NoConfirm {
+		return nil, nil
+	}
+
+	var rules []Rule
+	for _, rule := range append(append([]string(nil), DefaultConfirm...), p.Confirm...) {
+		r, err := ParseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.validate(all); err != nil {
+			return nil, fmt.Errorf("invalid confirm rule %q: %w", rule, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// permits reports whether the tool is exposed under the policy
+func (p Policy) permits(tool registry.Tool) bool {
+	if p.ReadOnly && !tool.Hints.ReadOnly {
+		return false
+	}
+	if len(p.Allow) > 0 && !matches(p.Allow, tool) {
+		return false
+	}
+	return !matches(p.Deny, tool)
+}
+
+// matches reports whether any name refers to the tool or its group
+func matches(names []string, tool registry.Tool) bool {
+	for _, name := range names {
+		if name == tool.Name || name == tool.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// known reports whether name is a tool or group name
+func known(all []registry.Tool, name string) bool {
+	for _, tool := range all {
+		if name == tool.Name || name == tool.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the tool with the given name
+func find(all []registry.Tool, name string) (registry.Tool, bool) {
+	for _, tool := range all {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return registry.Tool{}, false
+}
+
+// Condition matches one argument: any set value when Value is empty, otherwise that value
+type Condition struct {
+	Param string
+	Value string
+}
+
+// Rule matches calls to a tool, optionally only those whose arguments meet every condition.
+// Rules are written tool, tool.param or tool.param=value, with further conditions after
+// commas: pnpm_pm2.command=delete,target=all
+type Rule struct {
+	Tool       string
+	Conditions []Condition
+}
+
+// ParseRule parses a rule written as tool[.param[=value][,param[=value]...]]
+func ParseRule(rule string) (Rule, error) {
+	tool, conditions, hasConditions := strings.Cut(rule, ".")
+	if tool == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q: missing tool name", rule)
+	}
+
+	r := Rule{Tool: tool}
+	if !hasConditions {
+		return r, nil
+	}
+	for _, condition := range strings.Split(conditions, ",") {
+		param, value, _ := strings.Cut(strings.TrimSpace(condition), "=")
+		if param == "" {
+			return Rule{}, fmt.Errorf("invalid rule %q: missing parameter name", rule)
+		}
+		r.Conditions = append(r.Conditions, Condition{Param: param, Value: value})
+	}
+	return r, nil
+}
+
+// validate checks that the rule names an existing tool and parameters
+func (r Rule) validate(all []registry.Tool) error {
+	tool, ok := find(all, r.Tool)
+	if !ok {
+		return fmt.Errorf("unknown tool: %s", r.Tool)
+	}
+	for _, c := range r.Conditions {
+		if _, ok := tool.Param(c.Param); !ok {
+			return fmt.Errorf("%s has no parameter %s", r.Tool, c.Param)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a call with args meets every condition of the rule
+func (r Rule) Matches(args map[string]any) bool {
+	for _, c := range r.Conditions {
+		if !c.matches(args[c.Param]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe returns the rule's conditions as shown in messages ("force", "mode=hard")
+func (r Rule) Describe() string {
+	var parts []string
+	for _, c := range r.Conditions {
+		if c.Value == "" {
+			parts = append(parts, c.Param)
+		} else {
+			parts = append(parts, c.Param+"="+c.Value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matches reports whether an argument value meets the condition
+func (c Condition) matches(value any) bool {
+	if value == nil {
+		return false
+	}
+
+	if c.Value == "" {
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			return v != ""
+		case float64:
+			return v != 0
+		}
+		return true
+	}
+
+	return strings.EqualFold(fmt.Sprint(value), c.Value)
+}
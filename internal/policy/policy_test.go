@@ -0,0 +1,397 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools"
+)
+
+type pushParams struct {
+	AppName     string `json:"app_name" required:"true"`
+	Force       bool   `json:"force"`
+	Mode        string `json:"mode"`
+	ShowSecrets bool   `json:"show_secrets"`
+	SkipChecks  bool   `json:"skip_checks"`
+}
+
+func testTools() []registry.Tool {
+	run := func(ctx context.Context, params pushParams) (struct{}, error) { return struct{}{}, nil }
+	return []registry.Tool{
+		registry.New(registry.Spec[pushParams, struct{}]{Name: "git_status", Group: "git", Hints: registry.ReadOnly, Run: run}),
+		registry.New(registry.Spec[pushParams, struct{}]{Name: "git_push", Group: "git", Hints: registry.Hints{Destructive: true}, Run: run}),
+		registry.New(registry.Spec[pushParams, struct{}]{Name: "lc_read_file", Group: "lc", Hints: registry.ReadOnly, Run: run}),
+		registry.New(registry.Spec[pushParams, struct{}]{Name: "lc_write_file", Group: "lc", Run: run}),
+	}
+}
+
+func names(tools []registry.Tool) string {
+	var list []string
+	for _, tool := range tools {
+		list = append(list, tool.Name)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		want    string
+		wantErr string
+	}{
+		{"empty policy", Policy{}, "git_status,git_push,lc_read_file,lc_write_file", ""},
+		{"read only", Policy{ReadOnly: true}, "git_status,lc_read_file", ""},
+		{"allow group", Policy{Allow: []string{"lc"}}, "lc_read_file,lc_write_file", ""},
+		{"allow group and tool", Policy{Allow: []string{"lc", "git_status"}}, "git_status,lc_read_file,lc_write_file", ""},
+		{"deny tool", Policy{Deny: []string{"git_push"}}, "git_status,lc_read_file,lc_write_file", ""},
+		{"deny wins over allow", Policy{Allow: []string{"git"}, Deny: []string{"git_push"}}, "git_status", ""},
+		{"read only with allow", Policy{Allow: []string{"lc"}, ReadOnly: true}, "lc_read_file", ""},
+		{"unknown name", Policy{Deny: []string{"git_psuh"}}, "", "unknown tool or group: git_psuh"},
+		{"unknown rule tool", Policy{DenyArgs: []string{"nope.force"}}, "", "unknown tool: nope"},
+		{"unknown rule param", Policy{DenyArgs: []string{"git_push.forse"}}, "", "git_push has no parameter forse"},
+		{"malformed rule", Policy{DenyArgs: []string{"git_push"}}, "", "expected tool.param"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Apply(testTools())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if names(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, names(got))
+			}
+		})
+	}
+}
+
+func TestDenyArgs(t *testing.T) {
+	p := Policy{DenyArgs: []string{"git_push.force", "git_push.mode=hard"}}
+	tools, err := p.Apply(testTools())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var push registry.Tool
+	for _, tool := range tools {
+		if tool.Name == "git_push" {
+			push = tool
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"allowed", map[string]any{"app_name": "myapp"}, ""},
+		{"force false", map[string]any{"app_name": "myapp", "force": false}, ""},
+		{"force true", map[string]any{"app_name": "myapp", "force": true}, "force is disabled for git_push by server policy"},
+		{"other mode", map[string]any{"app_name": "myapp", "mode": "soft"}, ""},
+		{"forbidden mode", map[string]any{"app_name": "myapp", "mode": "HARD"}, "mode=hard is disabled for git_push by server policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := push.Call(context.Background(), tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Restrictions only apply to the tool they name
+	for _, tool := range tools {
+		if tool.Name == "git_status" {
+			if _, err := tool.Call(context.Background(), map[string]any{"app_name": "myapp", "force": true}); err != nil {
+				t.Errorf("expected git_status to be unrestricted, got %v", err)
+			}
+		}
+	}
+}
+
+func TestApplyWorkspaces(t *testing.T) {
+	p := Policy{Workspaces: map[string]Policy{
+		"work":    {ReadOnly: true},
+		"default": {Deny: []string{"git_push"}, DenyArgs: []string{"lc_write_file.mode=overwrite"}},
+	}}
+	workspaceOf := func(appName string) (string, error) {
+		if workspace, _, ok := strings.Cut(appName, "/"); ok {
+			return workspace, nil
+		}
+		return "default", nil
+	}
+
+	tools, err := p.ApplyWorkspaces(testTools(), testTools(), workspaceOf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	find := func(name string) registry.Tool {
+		for _, tool := range tools {
+			if tool.Name == name {
+				return tool
+			}
+		}
+		t.Fatalf("tool %s not found", name)
+		return registry.Tool{}
+	}
+
+	tests := []struct {
+		tool    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"git_status", map[string]any{"app_name": "work/api"}, ""},
+		{"lc_write_file", map[string]any{"app_name": "work/api"}, "lc_write_file is disabled in workspace work by server policy"},
+		{"git_push", map[string]any{"app_name": "myapp"}, "git_push is disabled in workspace default by server policy"},
+		{"lc_write_file", map[string]any{"app_name": "myapp", "mode": "overwrite"}, "mode=overwrite is disabled for lc_write_file in workspace default by server policy"},
+		{"lc_write_file", map[string]any{"app_name": "myapp"}, ""},
+		{"git_push", map[string]any{"app_name": "other/app"}, ""},
+	}
+
+	for _, tt := range tests {
+		_, err := find(tt.tool).Call(context.Background(), tt.args)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %v: unexpected error: %v", tt.tool, tt.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s %v: expected %q, got %v", tt.tool, tt.args, tt.wantErr, err)
+		}
+	}
+
+	bad := Policy{Workspaces: map[string]Policy{"work": {Deny: []string{"git_psuh"}}}}
+	if _, err := bad.ApplyWorkspaces(testTools(), testTools(), workspaceOf); err == nil || err.Error() != "workspace work: unknown tool or group: git_psuh" {
+		t.Errorf("expected unknown tool error, got %v", err)
+	}
+}
+
+func TestApplyAppTools(t *testing.T) {
+	allowed := map[string][]string{"locked": {"lc", "git_status"}}
+	tools := ApplyAppTools(testTools(), func(appName string) ([]string, error) {
+		return allowed[appName], nil
+	})
+
+	for _, tool := range tools {
+		_, err := tool.Call(context.Background(), map[string]any{"app_name": "locked"})
+		if tool.Name == "git_push" {
+			if err == nil || err.Error() != "git_push is not allowed for app locked by its manifest" {
+				t.Errorf("expected git_push to be rejected, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error for %s: %v", tool.Name, err)
+		}
+
+		if _, err := tool.Call(context.Background(), map[string]any{"app_name": "open"}); err != nil {
+			t.Errorf("unexpected error for %s on an app without a tool list: %v", tool.Name, err)
+		}
+	}
+}
+
+// TestAppParams verifies guards check every parameter naming an app, not just app_name
+func TestAppParams(t *testing.T) {
+	all := tools.All()
+	find := func(toolset []registry.Tool, name string) registry.Tool {
+		for _, tool := range toolset {
+			if tool.Name == name {
+				return tool
+			}
+		}
+		t.Fatalf("tool %s not found", name)
+		return registry.Tool{}
+	}
+
+	p := Policy{Workspaces: map[string]Policy{"work": {ReadOnly: true}}}
+	workspaceOf := func(appName string) (string, error) {
+		if workspace, _, ok := strings.Cut(appName, "/"); ok {
+			return workspace, nil
+		}
+		return "default", nil
+	}
+	scoped, err := p.ApplyWorkspaces(all, all, workspaceOf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	workspaceTests := []struct {
+		tool    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"pnpm_pm2", map[string]any{"command": "start", "target": "work/app"}, "pnpm_pm2 is disabled in workspace work by server policy"},
+		{"pnpm_pm2", map[string]any{"command": "stop", "target": "all"}, "pnpm_pm2 is disabled in workspace work by server policy"},
+		{"pnpm_pm2", map[string]any{"command": "start", "target": "app"}, ""},
+		{"lc_rename_app", map[string]any{"app_name": "app", "new_name": "work/app"}, "lc_rename_app is disabled in workspace work by server policy"},
+		{"lc_rename_app", map[string]any{"app_name": "app", "new_name": "renamed"}, ""},
+		{"lc_duplicate_app", map[string]any{"app_name": "app", "new_name": "work/copy"}, "lc_duplicate_app is disabled in workspace work by server policy"},
+		{"lc_duplicate_app", map[string]any{"app_name": "app", "new_name": "copy"}, ""},
+	}
+	for _, tt := range workspaceTests {
+		err := find(scoped, tt.tool).Check(context.Background(), tt.args)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %v: unexpected error: %v", tt.tool, tt.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s %v: expected %q, got %v", tt.tool, tt.args, tt.wantErr, err)
+		}
+	}
+
+	allowed := map[string][]string{"locked": {"lc_read_file"}}
+	guarded := ApplyAppTools(all, func(appName string) ([]string, error) {
+		return allowed[appName], nil
+	})
+
+	appToolTests := []struct {
+		tool    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"pnpm_pm2", map[string]any{"command": "start", "target": "locked"}, "pnpm_pm2 is not allowed for app locked by its manifest"},
+		{"pnpm_pm2", map[string]any{"command": "stop", "target": "all"}, ""},
+		{"lc_rename_app", map[string]any{"app_name": "open", "new_name": "locked"}, "lc_rename_app is not allowed for app locked by its manifest"},
+		{"lc_duplicate_app", map[string]any{"app_name": "open", "new_name": "locked"}, "lc_duplicate_app is not allowed for app locked by its manifest"},
+		{"lc_duplicate_app", map[string]any{"app_name": "open", "new_name": "copy"}, ""},
+	}
+	for _, tt := range appToolTests {
+		err := find(guarded, tt.tool).Check(context.Background(), tt.args)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %v: unexpected error: %v", tt.tool, tt.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s %v: expected %q, got %v", tt.tool, tt.args, tt.wantErr, err)
+		}
+	}
+}
+
+func TestLoadAndMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	content := `{"allow": ["lc"], "deny_args": ["git_push.force"]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merged := p.Merge(Policy{Allow: []string{"git_status"}, ReadOnly: true})
+	if strings.Join(merged.Allow, ",") != "lc,git_status" || !merged.ReadOnly || len(merged.DenyArgs) != 1 {
+		t.Errorf("unexpected merged policy: %+v", merged)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "failed to parse policy file") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    Rule
+		wantErr bool
+	}{
+		{"lc_delete_file", Rule{Tool: "lc_delete_file"}, false},
+		{"git_push.force", Rule{Tool: "git_push", Conditions: []Condition{{Param: "force"}}}, false},
+		{"pnpm_pm2.command=delete,target=all", Rule{Tool: "pnpm_pm2", Conditions: []Condition{{"command", "delete"}, {"target", "all"}}}, false},
+		{".force", Rule{}, true},
+		{"git_push.=true", Rule{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	rule, _ := ParseRule("git_push.mode=hard,force")
+
+	if !rule.Matches(map[string]any{"mode": "hard", "force": true}) {
+		t.Error("expected rule to match when every condition holds")
+	}
+	if rule.Matches(map[string]any{"mode": "hard"}) {
+		t.Error("expected rule not to match when a condition fails")
+	}
+	if rule.Describe() != "mode=hard, force" {
+		t.Errorf("unexpected description: %s", rule.Describe())
+	}
+
+	whole, _ := ParseRule("git_push")
+	if !whole.Matches(map[string]any{}) {
+		t.Error("expected a rule without conditions to match every call")
+	}
+}
+
+func TestConfirmRules(t *testing.T) {
+	all := append(testTools(), registry.New(registry.Spec[struct {
+		Command string `json:"command"`
+		Target  string `json:"target"`
+	}, struct{}]{Name: "pnpm_pm2", Group: "pnpm"}), registry.New(registry.Spec[struct {
+		FilePath string `json:"file_path"`
+	}, struct{}]{Name: "lc_delete_file", Group: "lc"}), registry.New(registry.Spec[struct {
+		AppName string `json:"app_name"`
+	}, struct{}]{Name: "lc_delete_app", Group: "lc"}), registry.New(registry.Spec[pushParams, struct{}]{Name: "git_reset", Group: "git"}), registry.New(registry.Spec[struct {
+		Action      string `json:"action"`
+		ShowSecrets bool   `json:"show_secrets"`
+	}, struct{}]{Name: "git_stash", Group: "git"}), registry.New(registry.Spec[struct {
+		Action string `json:"action"`
+		Force  bool   `json:"force"`
+	}, struct{}]{Name: "git_worktree", Group: "git"}))
+	for _, name := range []string{"lc_search_text", "git_diff", "git_show", "git_conflicts", "git_commit", "git_tag", "git_blame"} {
+		all = append(all, registry.New(registry.Spec[pushParams, struct{}]{Name: name, Group: strings.Split(name, "_")[0]}))
+	}
+
+	rules, err := Policy{Confirm: []string{"lc_write_file"}}.ConfirmRules(all)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != len(DefaultConfirm)+1 || rules[len(rules)-1].Tool != "lc_write_file" {
+		t.Errorf("expected default rules plus lc_write_file, got %+v", rules)
+	}
+
+	rules, err = Policy{NoConfirm: true, Confirm: []string{"lc_write_file"}}.ConfirmRules(all)
+	if err != nil || len(rules) != 0 {
+		t.Errorf("expected no rules with NoConfirm, got %v, %v", rules, err)
+	}
+
+	if _, err := (Policy{Confirm: []string{"git_push.forse"}}).ConfirmRules(all); err == nil || !strings.Contains(err.Error(), "invalid confirm rule") {
+		t.Errorf("expected invalid confirm rule error, got %v", err)
+	}
+}
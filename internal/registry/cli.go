@@ -0,0 +1,367 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type streamingKey struct{}
+
+// WithStreaming marks ctx so long-running tools stream subprocess output to the terminal
+func WithStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// Streaming reports whether subprocess output should be streamed (CLI) or only captured (MCP)
+func Streaming(ctx context.Context) bool {
+	streaming, _ := ctx.Value(streamingKey{}).(bool)
+	return streaming
+}
+
+// confirmInput is where confirmation prompts read their answer from
+var confirmInput io.Reader = os.Stdin
+
+// confirmOutput is where confirmation prompts are written, apart from the result so --json
+// output stays machine-readable
+var confirmOutput io.Writer = os.Stderr
+
+// RunCLI parses command line arguments, runs the tool and writes its output to w.
+//
+// Besides the tool's own parameters every tool accepts --help/-h and --json;
+// tools with a confirmation prompt also accept --force/-f to skip it. The prompt is written
+// to stderr rather than w.
+func (t Tool) RunCLI(args []string, w io.Writer) error {
+	jsonOutput := false
+	force := false
+	var rest []string
+
+	for _, arg := range args {
+		switch {
+		case arg == "--help" || arg == "-h":
+			t.PrintHelp(w)
+			return nil
+		case arg == "--json":
+			jsonOutput = true
+		case (arg == "--force" || arg == "-f") && t.confirm != nil:
+			force = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	values, err := t.ParseArgs(rest)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range t.Params {
+		if !p.Required || !missing(p, values[p.Name]) {
+			continue
+		}
+		if p.FromFile {
+			return fmt.Errorf("either %s or %s-file is required", p.Flag(), p.Flag())
+		}
+		return fmt.Errorf("%s is required", p.Flag())
+	}
+
+	params, err := t.Bind(values)
+	if err != nil {
+		return err
+	}
+	if err := t.Check(context.Background(), values); err != nil {
+		return err
+	}
+
+	// Tools may only ask for some calls; an empty prompt means no confirmation is needed
+	if prompt := t.confirmPrompt(params); prompt != "" && !force {
+		fmt.Fprintf(confirmOutput, "%s [y/N]: ", prompt)
+		response, _ := bufio.NewReader(confirmInput).ReadString('\n')
+		response = strings.TrimSpace(response)
+		if response != "y" && response != "Y" {
+			fmt.Fprintln(confirmOutput, "Cancelled")
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	if !jsonOutput {
+		ctx = WithStreaming(ctx)
+	}
+
+	result, err := t.run(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput || t.render == nil {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	t.render(w, params, result)
+	return nil
+}
+
+// ParseArgs converts command line arguments into tool arguments keyed by parameter name.
+//
+// Parameters are given as --flag value or --flag=value; booleans are set by presence
+// and array flags collect every value up to the next flag. Positional parameters may also be given in declaration order, and a trailing
+// positional array collects the remaining arguments.
+func (t Tool) ParseArgs(args []string) (map[string]any, error) {
+	values := make(map[string]any)
+	fromFile := make(map[string]string)
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, inline, hasInline := strings.Cut(arg, "=")
+		param, isFile, ok := t.lookupFlag(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown option: %s\nRun 'layered-code tool %s --help' for usage", name, t.Name)
+		}
+
+		if param.Type == Boolean && !isFile {
+			if hasInline {
+				b, err := strconv.ParseBool(inline)
+				if err != nil {
+					return nil, fmt.Errorf("%s must be true or false: %w", name, err)
+				}
+				values[param.Name] = b
+			} else {
+				values[param.Name] = true
+			}
+			continue
+		}
+
+		value := inline
+		if !hasInline {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		// Array flags also take the values that follow (--files a.txt b.txt)
+		if param.Type == Array && !hasInline {
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				if err := setValue(values, param, name, value); err != nil {
+					return nil, err
+				}
+				i++
+				value = args[i]
+			}
+		}
+
+		if isFile {
+			fromFile[param.Name] = value
+			continue
+		}
+		if err := setValue(values, param, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.assignPositional(values, positional); err != nil {
+		return nil, err
+	}
+
+	for name, path := range fromFile {
+		param, _ := t.Param(name)
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("cannot use both %s and %s-file", param.Flag(), param.Flag())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s file: %w", strings.ReplaceAll(name, "_", " "), err)
+		}
+		values[name] = string(data)
+	}
+
+	return values, nil
+}
+
+// lookupFlag finds the parameter for a flag, its short form or one of its aliases
+func (t Tool) lookupFlag(flag string) (Param, bool, bool) {
+	for _, p := range t.Params {
+		if flag == p.Flag() || (p.Short != "" && flag == "-"+p.Short) {
+			return p, false, true
+		}
+		for _, alias := range p.Aliases {
+			if flag == "--"+alias {
+				return p, false, true
+			}
+		}
+		if p.FromFile && flag == p.Flag()+"-file" {
+			return p, true, true
+		}
+	}
+	return Param{}, false, false
+}
+
+// assignPositional fills unset positional parameters in declaration order
+func (t Tool) assignPositional(values map[string]any, args []string) error {
+	for _, p := range t.Params {
+		if len(args) == 0 {
+			return nil
+		}
+		if !p.Positional {
+			continue
+		}
+		if p.Type == Array {
+			items, _ := values[p.Name].([]string)
+			values[p.Name] = append(items, args...)
+			return nil
+		}
+		if _, ok := values[p.Name]; ok {
+			continue
+		}
+		if err := setValue(values, p, p.Flag(), args[0]); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument: %s\nRun 'layered-code tool %s --help' for usage", args[0], t.Name)
+	}
+	return nil
+}
+
+// setValue converts a command line value to the parameter's type
+func setValue(values map[string]any, p Param, flag, value string) error {
+	switch p.Type {
+	case Number:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number: %w", flag, errors.Unwrap(err))
+		}
+		values[p.Name] = n
+	case Array:
+		items, _ := values[p.Name].([]string)
+		values[p.Name] = append(items, value)
+	default:
+		values[p.Name] = value
+	}
+	return nil
+}
+
+// PrintHelp writes usage information generated from the tool definition
+func (t Tool) PrintHelp(w io.Writer) {
+	usage := "Usage: layered-code tool " + t.Name
+	for _, p := range t.Params {
+		if !p.Positional {
+			continue
+		}
+		name := "<" + p.Name + ">"
+		if p.Type == Array {
+			name = "<" + p.Name + "...>"
+		}
+		if !p.Required {
+			name = "[" + name + "]"
+		}
+		usage += " " + name
+	}
+	fmt.Fprintln(w, usage+" [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, t.Description)
+
+	var required, optional []Param
+	for _, p := range t.Params {
+		if p.Required {
+			required = append(required, p)
+		} else {
+			optional = append(optional, p)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 4, ' ', 0)
+	if len(required) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Required options:")
+		for _, p := range required {
+			printParam(tw, p)
+		}
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Optional options:")
+	for _, p := range optional {
+		printParam(tw, p)
+	}
+	if t.confirm != nil {
+		fmt.Fprintln(tw, "  --force, -f\tSkip confirmation prompt")
+	}
+	fmt.Fprintln(tw, "  --json\tPrint the result as JSON")
+	fmt.Fprintln(tw, "  --help, -h\tShow this help message")
+	tw.Flush()
+
+	if len(t.Notes) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Notes:")
+		for _, note := range t.Notes {
+			fmt.Fprintf(w, "  - %s\n", note)
+		}
+	}
+
+	if len(t.Examples) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Examples:")
+		for i, example := range t.Examples {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			for _, line := range strings.Split(example, "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+}
+
+// printParam writes a single option line for PrintHelp
+func printParam(w io.Writer, p Param) {
+	flags := p.Flag()
+	if p.Short != "" {
+		flags += ", -" + p.Short
+	}
+	for _, alias := range p.Aliases {
+		flags += ", --" + alias
+	}
+	switch p.Type {
+	case Boolean:
+	case Number:
+		flags += " <n>"
+	case Array:
+		flags += " <value>..."
+	default:
+		flags += " <value>"
+	}
+	fmt.Fprintf(w, "  %s\t%s\n", flags, p.Description)
+	if p.FromFile {
+		fmt.Fprintf(w, "  %s-file <path>\tRead %s from a file\n", p.Flag(), strings.ReplaceAll(p.Name, "_", " "))
+	}
+}
+
+// confirmPrompt returns the confirmation prompt for a call, or "" when it needs none
+func (t Tool) confirmPrompt(params any) string {
+	if t.confirm == nil {
+		return ""
+	}
+	return t.confirm(params)
+}
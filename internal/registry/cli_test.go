@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tool := testTool()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "long flags",
+			args: []string{"--app-name", "myapp", "--message", "hello"},
+			want: map[string]any{"app_name": "myapp", "message": "hello"},
+		},
+		{
+			name: "inline value",
+			args: []string{"--app-name=myapp", "--limit=3"},
+			want: map[string]any{"app_name": "myapp", "limit": float64(3)},
+		},
+		{
+			name: "short flag and alias",
+			args: []string{"myapp", "-m", "hi", "--msg", "bye", "-a"},
+			want: map[string]any{"app_name": "myapp", "message": "bye", "all": true},
+		},
+		{
+			name: "boolean with value",
+			args: []string{"--all=false"},
+			want: map[string]any{"all": false},
+		},
+		{
+			name: "array collects values",
+			args: []string{"--files", "a.txt", "b.txt", "--all", "myapp"},
+			want: map[string]any{"files": []string{"a.txt", "b.txt"}, "all": true, "app_name": "myapp"},
+		},
+		{
+			name:    "unknown option",
+			args:    []string{"--unknown"},
+			wantErr: "unknown option: --unknown",
+		},
+		{
+			name:    "missing value",
+			args:    []string{"--message"},
+			wantErr: "--message requires a value",
+		},
+		{
+			name:    "invalid number",
+			args:    []string{"--limit", "abc"},
+			wantErr: "--limit must be a number",
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"myapp", "extra"},
+			wantErr: "unexpected argument: extra",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.ParseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseArgsFromFile(t *testing.T) {
+	tool := New(Spec[struct {
+		Content string `json:"content" desc:"Content" file:"true"`
+	}, testResult]{Name: "file_tool"})
+
+	path := filepath.Join(t.TempDir(), "content.txt")
+	if err := os.WriteFile(path, []byte("from file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tool.ParseArgs([]string{"--content-file", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["content"] != "from file" {
+		t.Errorf("expected content from file, got %v", got["content"])
+	}
+
+	_, err = tool.ParseArgs([]string{"--content", "x", "--content-file", path})
+	if err == nil || !strings.Contains(err.Error(), "cannot use both --content and --content-file") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	tool := testTool()
+
+	t.Run("required flag", func(t *testing.T) {
+		err := tool.RunCLI([]string{"--content", "x"}, &bytes.Buffer{})
+		if err == nil || err.Error() != "--app-name is required" {
+			t.Errorf("expected '--app-name is required', got %v", err)
+		}
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := tool.RunCLI([]string{"myapp", "--content", "", "--json"}, &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), `"app_name": "myapp"`) {
+			t.Errorf("expected JSON output, got %q", out.String())
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := tool.RunCLI([]string{"--help"}, &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		help := out.String()
+		for _, want := range []string{"Usage: layered-code tool test_tool <app_name> [options]", "Required options:", "--message, -m, --msg <value>", "--json"} {
+			if !strings.Contains(help, want) {
+				t.Errorf("expected help to contain %q, got:\n%s", want, help)
+			}
+		}
+		if strings.Contains(help, "--force") {
+			t.Error("expected no --force option for a tool without confirmation")
+		}
+	})
+}
+
+func TestRunCLIConfirm(t *testing.T) {
+	ran := false
+	tool := New(Spec[testParams, testResult]{
+		Name: "confirm_tool",
+		Run: func(ctx context.Context, params testParams) (testResult, error) {
+			ran = true
+			return testResult{}, nil
+		},
+		Confirm: func(params testParams) string { return "Really?" },
+	})
+
+	originalInput, originalOutput := confirmInput, confirmOutput
+	defer func() { confirmInput, confirmOutput = originalInput, originalOutput }()
+
+	// The prompt goes to its own stream so --json output stays parseable
+	var prompt, out bytes.Buffer
+	confirmInput, confirmOutput = strings.NewReader("n\n"), &prompt
+	if err := tool.RunCLI([]string{"myapp", "--content", "x", "--json"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ran || !strings.Contains(prompt.String(), "Really? [y/N]: Cancelled") {
+		t.Errorf("expected tool to be cancelled, prompt %q", prompt.String())
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no result output, got %q", out.String())
+	}
+
+	confirmInput = strings.NewReader("y\n")
+	if err := tool.RunCLI([]string{"myapp", "--content", "x", "--json"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var result testResult
+	if !ran || json.Unmarshal(out.Bytes(), &result) != nil {
+		t.Errorf("expected confirmed call to print only JSON, got %q", out.String())
+	}
+
+	ran = false
+	if err := tool.RunCLI([]string{"myapp", "--content", "x", "--force"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("expected --force to skip the prompt")
+	}
+
+	// Tools can ask only for some calls
+	ran = false
+	prompt.Reset()
+	conditional := New(Spec[testParams, testResult]{
+		Name: "conditional_tool",
+		Run: func(ctx context.Context, params testParams) (testResult, error) {
+			ran = true
+			return testResult{}, nil
+		},
+		Confirm: func(params testParams) string {
+			if !params.All {
+				return ""
+			}
+			return "Really all?"
+		},
+	})
+	confirmInput = strings.NewReader("n\n")
+	if err := conditional.RunCLI([]string{"myapp", "--content", "x"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ran || prompt.Len() != 0 {
+		t.Errorf("expected call to run without a prompt, prompt %q", prompt.String())
+	}
+	ran = false
+	if err := conditional.RunCLI([]string{"myapp", "--content", "x", "--all"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ran || !strings.Contains(prompt.String(), "Really all? [y/N]: Cancelled") {
+		t.Errorf("expected --all to ask first, prompt %q", prompt.String())
+	}
+}
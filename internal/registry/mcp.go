@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MCPTool builds the MCP tool definition: input schema, safety hints and output schema
+func (t Tool) MCPTool() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription(t.Description),
+	}
+
+	for _, p := range t.Params {
+		propOpts := []mcp.PropertyOption{mcp.Description(p.Description)}
+		if p.Required {
+			propOpts = append(propOpts, mcp.Required())
+		}
+
+		switch p.Type {
+		case Boolean:
+			opts = append(opts, mcp.WithBoolean(p.Name, propOpts...))
+		case Number:
+			opts = append(opts, mcp.WithNumber(p.Name, propOpts...))
+		case Array:
+			opts = append(opts, mcp.WithArray(p.Name, append(propOpts, mcp.WithStringItems())...))
+		default:
+			opts = append(opts, mcp.WithString(p.Name, propOpts...))
+		}
+	}
+
+	opts = append(opts,
+		mcp.WithReadOnlyHintAnnotation(t.Hints.ReadOnly),
+		mcp.WithDestructiveHintAnnotation(t.Hints.Destructive),
+		mcp.WithIdempotentHintAnnotation(t.Hints.Idempotent),
+		mcp.WithOpenWorldHintAnnotation(t.Hints.OpenWorld),
+	)
+	if len(t.OutputSchema) > 0 {
+		opts = append(opts, mcp.WithRawOutputSchema(t.OutputSchema))
+	}
+
+	return mcp.NewTool(t.Name, opts...)
+}
+
+// HandleMCP is the MCP request handler for the tool
+func (t Tool) HandleMCP(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result, err := t.Call(ctx, request.GetArguments())
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return mcp.NewToolResultStructured(result, string(jsonData)), nil
+}
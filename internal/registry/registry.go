@@ -0,0 +1,290 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Hints describes a tool's side effects so MCP clients can decide what is safe to auto-approve
+type Hints struct {
+	ReadOnly    bool
+	Destructive bool
+	Idempotent  bool
+	OpenWorld   bool
+}
+
+// ReadOnly is the hint set for tools that only inspect local state
+var ReadOnly = Hints{ReadOnly: true, Idempotent: true}
+
+// ParamType is the JSON type of a tool parameter
+type ParamType string
+
+const (
+	String  ParamType = "string"
+	Number  ParamType = "number"
+	Boolean ParamType = "boolean"
+	Array   ParamType = "array"
+)
+
+// Param describes a tool parameter derived from a field of the tool's params struct.
+//
+// Fields are declared with struct tags:
+//
+//	json:"app_name"      parameter name (also the CLI flag --app-name)
+//	desc:"..."           description shown in the MCP schema and CLI help
+//	required:"true"      parameter must be present and non-empty
+//	empty:"true"         a required string may be empty (e.g. a replacement text)
+//	short:"m"            single-letter CLI alias (-m)
+//	alias:"from,source"  additional CLI flag names (--from, --source)
+//	file:"true"          CLI also accepts --<flag>-file to read the value from a file
+//	pos:"true"           CLI also accepts the value positionally, in declaration order
+//	app:"true"           value names an app, so app policies apply to it (implied for app_name);
+//	                     app:"all" also lets the value "all" stand for every app
+type Param struct {
+	Name        string
+	Type        ParamType
+	Description string
+	Required    bool
+	AllowEmpty  bool
+	Short       string
+	Aliases     []string
+	FromFile    bool
+	Positional  bool
+	App         bool // Value names an app
+	AllApps     bool // The value "all" stands for every app
+}
+
+// Flag returns the CLI flag for the parameter (app_name -> --app-name)
+func (p Param) Flag() string {
+	return "--" + strings.ReplaceAll(p.Name, "_", "-")
+}
+
+// Spec declares a tool once; P is the params struct and R the result struct
+type Spec[P any, R any] struct {
+	Name        string
+	Group       string
+	Description string
+	Summary     string // Short description for the CLI tool listing (defaults to Description)
+	Hints       Hints
+	Notes       []string // Extra lines for CLI help
+	Examples    []string // Example CLI invocations for CLI help
+	Run         func(ctx context.Context, params P) (R, error)
+	Render      func(w io.Writer, params P, result R) // Human-readable CLI output (defaults to indented JSON)
+	Confirm     func(params P) string                 // CLI confirmation prompt, skipped with --force or when it returns ""
+}
+
+// Tool is a tool definition shared by the MCP server and the CLI
+type Tool struct {
+	Name         string
+	Group        string
+	Description  string
+	Summary      string
+	Hints        Hints
+	Params       []Param
+	Notes        []string
+	Examples     []string
+	OutputSchema json.RawMessage
+
+	bind    func(args map[string]any) (any, error)
+	run     func(ctx context.Context, params any) (any, error)
+	render  func(w io.Writer, params any, result any)
+	confirm func(params any) string
+	guards  []Guard
+}
+
+// Guard inspects the arguments of a call before the tool runs and returns an error to reject it
+type Guard func(ctx context.Context, args map[string]any) error
+
+// New builds a Tool from its spec, deriving params from P and the output schema from R
+func New[P any, R any](spec Spec[P, R]) Tool {
+	summary := spec.Summary
+	if summary == "" {
+		summary = spec.Description
+	}
+
+	tool := Tool{
+		Name:         spec.Name,
+		Group:        spec.Group,
+		Description:  spec.Description,
+		Summary:      summary,
+		Hints:        spec.Hints,
+		Params:       paramsOf(reflect.TypeOf((*P)(nil)).Elem()),
+		Notes:        spec.Notes,
+		Examples:     spec.Examples,
+		OutputSchema: outputSchema[R](),
+	}
+
+	tool.bind = func(args map[string]any) (any, error) {
+		var params P
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+		return params, nil
+	}
+	tool.run = func(ctx context.Context, params any) (any, error) {
+		return spec.Run(ctx, params.(P))
+	}
+	if spec.Render != nil {
+		tool.render = func(w io.Writer, params any, result any) {
+			spec.Render(w, params.(P), result.(R))
+		}
+	}
+	if spec.Confirm != nil {
+		tool.confirm = func(params any) string {
+			return spec.Confirm(params.(P))
+		}
+	}
+
+	return tool
+}
+
+// Param returns the named parameter, if the tool declares it
+func (t Tool) Param(name string) (Param, bool) {
+	for _, p := range t.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// AppParams returns the parameters whose values name apps
+func (t Tool) AppParams() []Param {
+	var params []Param
+	for _, p := range t.Params {
+		if p.App {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// Bind validates required parameters and decodes args into the tool's params struct
+func (t Tool) Bind(args map[string]any) (any, error) {
+	for _, p := range t.Params {
+		if p.Required && missing(p, args[p.Name]) {
+			return nil, fmt.Errorf("%s is required", p.Name)
+		}
+	}
+	return t.bind(args)
+}
+
+// WithGuard returns a copy of the tool that rejects calls for which guard returns an error.
+// Guards run after the arguments are bound and before the tool runs.
+func (t Tool) WithGuard(guard Guard) Tool {
+	t.guards = append(append([]Guard(nil), t.guards...), guard)
+	return t
+}
+
+// WithParam returns a copy of the tool that also accepts p, for arguments consumed by guards
+func (t Tool) WithParam(p Param) Tool {
+	t.Params = append(append([]Param(nil), t.Params...), p)
+	return t
+}
+
+// Check runs the tool's guards against the call arguments
+func (t Tool) Check(ctx context.Context, args map[string]any) error {
+	for _, guard := range t.guards {
+		if err := guard(ctx, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run executes the tool with params previously returned by Bind
+func (t Tool) Run(ctx context.Context, params any) (any, error) {
+	return t.run(ctx, params)
+}
+
+// Call binds args and runs the tool
+func (t Tool) Call(ctx context.Context, args map[string]any) (any, error) {
+	params, err := t.Bind(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Check(ctx, args); err != nil {
+		return nil, err
+	}
+	return t.Run(ctx, params)
+}
+
+// missing reports whether a required parameter value is absent
+func missing(p Param, value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" && !p.AllowEmpty
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// paramsOf derives tool parameters from the fields of a params struct
+func paramsOf(t reflect.Type) []Param {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Param
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		param := Param{
+			Name:        name,
+			Type:        paramType(field.Type),
+			Description: field.Tag.Get("desc"),
+			Required:    field.Tag.Get("required") == "true",
+			AllowEmpty:  field.Tag.Get("empty") == "true",
+			Short:       field.Tag.Get("short"),
+			FromFile:    field.Tag.Get("file") == "true",
+			Positional:  field.Tag.Get("pos") == "true",
+			App:         name == "app_name" || field.Tag.Get("app") != "",
+			AllApps:     field.Tag.Get("app") == "all",
+		}
+		if alias := field.Tag.Get("alias"); alias != "" {
+			param.Aliases = strings.Split(alias, ",")
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// paramType maps a Go field type to its JSON parameter type
+func paramType(t reflect.Type) ParamType {
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Number
+	case reflect.Slice, reflect.Array:
+		return Array
+	case reflect.Pointer:
+		return paramType(t.Elem())
+	}
+	return String
+}
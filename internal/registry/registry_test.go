@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testParams struct {
+	AppName string   `json:"app_name" desc:"Name of the app" required:"true" pos:"true"`
+	Message string   `json:"message" desc:"Message" short:"m" alias:"msg"`
+	Limit   int      `json:"limit" desc:"Limit"`
+	All     bool     `json:"all" desc:"All" short:"a"`
+	Files   []string `json:"files" desc:"Files"`
+	Content string   `json:"content" desc:"Content" required:"true" empty:"true"`
+}
+
+type testResult struct {
+	AppName string `json:"app_name"`
+}
+
+func testTool() Tool {
+	return New(Spec[testParams, testResult]{
+		Name:        "test_tool",
+		Description: "A test tool",
+		Run: func(ctx context.Context, params testParams) (testResult, error) {
+			return testResult{AppName: params.AppName}, nil
+		},
+	})
+}
+
+func TestNewDerivesParams(t *testing.T) {
+	tool := testTool()
+
+	if len(tool.Params) != 6 {
+		t.Fatalf("expected 6 params, got %d", len(tool.Params))
+	}
+
+	tests := []struct {
+		name     string
+		typ      ParamType
+		required bool
+	}{
+		{"app_name", String, true},
+		{"message", String, false},
+		{"limit", Number, false},
+		{"all", Boolean, false},
+		{"files", Array, false},
+		{"content", String, true},
+	}
+
+	for _, tt := range tests {
+		p, ok := tool.Param(tt.name)
+		if !ok {
+			t.Errorf("param %s not found", tt.name)
+			continue
+		}
+		if p.Type != tt.typ {
+			t.Errorf("param %s: expected type %s, got %s", tt.name, tt.typ, p.Type)
+		}
+		if p.Required != tt.required {
+			t.Errorf("param %s: expected required %v", tt.name, tt.required)
+		}
+	}
+
+	if tool.Summary != tool.Description {
+		t.Errorf("expected summary to default to description, got %q", tool.Summary)
+	}
+	if len(tool.OutputSchema) == 0 {
+		t.Error("expected an output schema")
+	}
+}
+
+func TestBind(t *testing.T) {
+	tool := testTool()
+
+	if _, err := tool.Bind(map[string]any{"content": ""}); err == nil || err.Error() != "app_name is required" {
+		t.Errorf("expected 'app_name is required', got %v", err)
+	}
+
+	if _, err := tool.Bind(map[string]any{"app_name": "myapp"}); err == nil || err.Error() != "content is required" {
+		t.Errorf("expected 'content is required', got %v", err)
+	}
+
+	params, err := tool.Bind(map[string]any{"app_name": "myapp", "content": "", "limit": float64(5)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := params.(testParams)
+	if p.AppName != "myapp" || p.Limit != 5 {
+		t.Errorf("unexpected params: %+v", p)
+	}
+}
+
+func TestCall(t *testing.T) {
+	result, err := testTool().Call(context.Background(), map[string]any{"app_name": "myapp", "content": "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := json.Marshal(result)
+	if string(data) != `{"app_name":"myapp"}` {
+		t.Errorf("unexpected result: %s", data)
+	}
+}
+
+// TestOutputSchemaAllowsNullCollections verifies nil slices in results stay schema-valid
+func TestOutputSchemaAllowsNullCollections(t *testing.T) {
+	type result struct {
+		Files []string `json:"files"`
+		Name  string   `json:"name"`
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(outputSchema[result](), &schema); err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]any)
+	files := properties["files"].(map[string]any)
+	types, ok := files["type"].([]any)
+	if !ok || len(types) != 2 || types[0] != "array" || types[1] != "null" {
+		t.Errorf("expected files type [array null], got %v", files["type"])
+	}
+
+	name := properties["name"].(map[string]any)
+	if name["type"] != "string" {
+		t.Errorf("expected name type string, got %v", name["type"])
+	}
+}
+
+func TestWithGuard(t *testing.T) {
+	tool := testTool()
+	guarded := tool.WithGuard(func(ctx context.Context, args map[string]any) error {
+		if args["all"] == true {
+			return errors.New("all is not allowed")
+		}
+		return nil
+	})
+
+	args := map[string]any{"app_name": "myapp", "content": "", "all": true}
+	if _, err := guarded.Call(context.Background(), args); err == nil || err.Error() != "all is not allowed" {
+		t.Errorf("expected guard error, got %v", err)
+	}
+	if _, err := tool.Call(context.Background(), args); err != nil {
+		t.Errorf("expected original tool to be unguarded, got %v", err)
+	}
+}
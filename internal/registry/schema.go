@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"encoding/json"
+
+	"github.com/invopop/jsonschema"
+)
+
+// outputSchema reflects T into a JSON schema suitable for MCP tool output.
+// Go marshals nil slices and maps as null, so array and object properties
+// accept null to keep results like GitStatusResult on non-repos valid.
+func outputSchema[T any]() json.RawMessage {
+	var zero T
+	reflector := jsonschema.Reflector{
+		DoNotReference:            true,
+		Anonymous:                 true,
+		AllowAdditionalProperties: true,
+	}
+	schema := reflector.Reflect(zero)
+	schema.Version = ""
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	allowNullCollections(doc)
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// allowNullCollections walks a schema and widens array/object properties to accept null
+func allowNullCollections(schema map[string]any) {
+	properties, ok := schema["properties"].(map[string]any)
+	if ok {
+		for _, raw := range properties {
+			prop, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			if t, ok := prop["type"].(string); ok && (t == "array" || t == "object") {
+				prop["type"] = []string{t, "null"}
+			}
+			allowNullCollections(prop)
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		allowNullCollections(items)
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+		allowNullCollections(additional)
+	}
+}
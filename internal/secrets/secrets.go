@@ -0,0 +1,226 @@
+// Package secrets finds credentials in text so tools can keep them out of the assistant's
+// transcript
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// markerPrefix starts every redaction; the kind of secret follows: [REDACTED:github-token]
+const markerPrefix = "[REDACTED:"
+
+// Minimum length and bits of entropy per character for a token with no known format to count
+// as a secret. Random base64 scores about 5.5-6, English and identifiers well under 4.
+const (
+	minTokenLength  = 32
+	minTokenEntropy = 4.3
+)
+
+// detector finds one kind of secret; group is the submatch holding the secret itself, or 0 for
+// the whole match
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}
+
+// Known formats first, so a token is named by its format rather than its entropy
+var detectors = []detector{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`), 0},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), 0},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 0},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`), 0},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), 0},
+	{"stripe-key", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`), 0},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`), 0},
+	{"anthropic-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`), 0},
+	{"openai-key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`), 0},
+	{"npm-token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`), 0},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), 0},
+	{"url-password", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`), 1},
+
+	// KEY=value lines of .env files and shell scripts, also as +/- lines of a diff
+	{"secret-assignment", regexp.MustCompile(`(?m)^[+\- ]?[ \t]*(?:export[ \t]+)?[A-Z0-9_]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?|AUTH)[A-Z0-9_]*[ \t]*=[ \t]*["']?([^\s"'#]{4,})`), 1},
+	// Quoted values of secret-sounding keys in code and config: password: "...", "api_key": "..."
+	{"secret-assignment", regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)[a-z0-9_-]*["']?[ \t]*[:=][ \t]*["']([^"'\s]{8,})["']`), 1},
+}
+
+var (
+	// urlUserinfo matches the user info of URLs, with the user name and password as submatches
+	urlUserinfo = regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://([^/\s:@]+)(?::([^/\s@]*))?@`)
+	// tokenPattern matches candidates for high-entropy secrets
+	tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+	// integrityPattern matches subresource integrity hashes, as found in lockfiles
+	integrityPattern = regexp.MustCompile(`^sha(?:1|256|384|512)-`)
+)
+
+// Finding is a secret found in text, without the secret itself
+type Finding struct {
+	Kind string `json:"kind"`
+	Line int    `json:"line"` // 1-based line of text the secret starts on
+}
+
+// span is the byte range of a secret in text
+type span struct {
+	start, end int
+	kind       string
+}
+
+// Redact replaces the secrets in text with [REDACTED:kind] and returns how many it replaced
+func Redact(text string) (string, int) {
+	spans := find(text)
+	if len(spans) == 0 {
+		return text, 0
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.start])
+		b.WriteString(marker(s.kind))
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), len(spans)
+}
+
+// RedactURLs replaces the credentials in the URLs of text with [REDACTED:kind] and returns how
+// many it replaced: passwords, and user names that are tokens, as in https://<token>@host/.
+// Other user names are kept. Use it on text that lists URLs, such as git remotes.
+func RedactURLs(text string) (string, int) {
+	var b strings.Builder
+	last, n := 0, 0
+	for _, m := range urlUserinfo.FindAllStringSubmatchIndex(text, -1) {
+		user := text[m[2]:m[3]]
+		if user+":" == markerPrefix {
+			continue
+		}
+		if len(find(user)) > 0 || len(user) >= minTokenLength {
+			b.WriteString(text[last:m[2]])
+			b.WriteString(marker("url-token"))
+			last = m[3]
+			n++
+		}
+		if m[4] >= 0 && m[4] < m[5] && !strings.HasPrefix(text[m[4]:m[5]], markerPrefix) {
+			b.WriteString(text[last:m[4]])
+			b.WriteString(marker("url-password"))
+			last = m[5]
+			n++
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+// RedactValue replaces a secret known in advance, such as a token a command was given, with
+// [REDACTED:kind] and returns how many times it replaced it
+func RedactValue(text, value, kind string) (string, int) {
+	if value == "" {
+		return text, 0
+	}
+	n := strings.Count(text, value)
+	return strings.ReplaceAll(text, value, marker(kind)), n
+}
+
+// Find returns the secrets in text in order of appearance
+func Find(text string) []Finding {
+	var findings []Finding
+	line, offset := 1, 0
+	for _, s := range find(text) {
+		line += strings.Count(text[offset:s.start], "\n")
+		offset = s.start
+		findings = append(findings, Finding{Kind: s.kind, Line: line})
+	}
+	return findings
+}
+
+// HasRedactions reports whether text contains redaction markers, e.g. because redacted output
+// is about to be written back to a file
+func HasRedactions(text string) bool {
+	return strings.Contains(text, markerPrefix)
+}
+
+// find returns the non-overlapping secrets in text sorted by position. Where matches overlap,
+// the earlier detector wins and high-entropy tokens come last.
+func find(text string) []span {
+	var spans []span
+	for _, d := range detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start >= 0 && !strings.HasPrefix(text[start:end], markerPrefix) {
+				spans = addSpan(spans, span{start, end, d.kind})
+			}
+		}
+	}
+	for _, loc := range tokenPattern.FindAllStringIndex(text, -1) {
+		if highEntropy(text, loc[0], loc[1]) {
+			spans = addSpan(spans, span{loc[0], loc[1], "high-entropy-token"})
+		}
+	}
+	return spans
+}
+
+// addSpan inserts s into spans, which are sorted by position, unless it overlaps one of them
+func addSpan(spans []span, s span) []span {
+	i := sort.Search(len(spans), func(i int) bool { return spans[i].start >= s.start })
+	if (i > 0 && spans[i-1].end > s.start) || (i < len(spans) && spans[i].start < s.end) {
+		return spans
+	}
+	return slices.Insert(spans, i, s)
+}
+
+// highEntropy reports whether the token at text[start:end] looks random enough to be a secret.
+// Hex strings (commit and content hashes) and subresource integrity hashes in lockfiles are
+// left alone, as are tokens inside data: URIs and file paths.
+func highEntropy(text string, start, end int) bool {
+	token := text[start:end]
+	if len(token) < minTokenLength || strings.Contains(token, markerPrefix) {
+		return false
+	}
+	if strings.Trim(token, "0123456789abcdefABCDEF") == "" {
+		return false
+	}
+	if integrityPattern.MatchString(token) || strings.HasSuffix(text[:start], "base64,") ||
+		strings.HasSuffix(text[:start], "/") {
+		return false
+	}
+	if strings.Count(token, "/") > 1 {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && entropy(token) >= minTokenEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var bits float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+func marker(kind string) string {
+	return markerPrefix + kind + "]"
+}
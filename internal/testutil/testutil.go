@@ -0,0 +1,66 @@
+// Package testutil sets up the environment tests of several packages share
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+// Home gives the test a home directory of its own and returns it. The config file
+// (config.toml in it), the apps directory (apps in it), PM2's directory and the XDG
+// directories follow, and LAYERED_* variables from the developer's environment are cleared,
+// so tests neither read the developer's settings nor touch their apps.
+func Home(t testing.TB) string {
+	t.Helper()
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, "LAYERED_") {
+			t.Setenv(name, "")
+		}
+	}
+
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir) // The home directory on Windows
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("PM2_HOME", filepath.Join(homeDir, ".pm2"))
+	t.Setenv(constants.ConfigFileEnvVar, filepath.Join(homeDir, "config.toml"))
+	t.Setenv(constants.AppsDirectoryEnvVar, "apps")
+	return homeDir
+}
+
+// FakePm2 stands in for PM2 with a running daemon: pm2 jlist prints processes, and the
+// arguments of other PM2 commands are returned by the function it returns
+func FakePm2(t *testing.T, processes string) func() []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake PM2 needs a POSIX shell")
+	}
+
+	pm2Home, bin := t.TempDir(), t.TempDir()
+	t.Setenv("PM2_HOME", pm2Home)
+	os.WriteFile(filepath.Join(pm2Home, "pm2.pid"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(pm2Home, "jlist.json"), []byte(processes+"\n"), 0644)
+
+	calls := filepath.Join(pm2Home, "calls")
+	pnpm := "#!/bin/sh\nif [ \"$3\" = jlist ]; then cat \"$PM2_HOME/jlist.json\"; else echo \"$*\" >> \"$PM2_HOME/calls\"; fi\n"
+	// A login shell may reset PATH, so the fake shell puts the fake pnpm first again
+	shell := "#!/bin/sh\nPATH=\"" + bin + ":$PATH\"\nexport PATH\neval \"$3\"\n"
+	os.WriteFile(filepath.Join(bin, "pnpm"), []byte(pnpm), 0755)
+	os.WriteFile(filepath.Join(bin, "shell"), []byte(shell), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SHELL", filepath.Join(bin, "shell"))
+
+	return func() []string {
+		data, _ := os.ReadFile(calls)
+		if len(data) == 0 {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
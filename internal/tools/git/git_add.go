@@ -1,56 +1,43 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitAddResult struct {
 	Success     bool     `json:"success"`
 	FilesAdded  []string `json:"files_added"`
+	HunksStaged int      `json:"hunks_staged,omitempty"`
 	IsRepo      bool     `json:"is_repo"`
 	Message     string   `json:"message,omitempty"`
 	ErrorOutput string   `json:"error_output,omitempty"`
 }
 
-// GitAdd stages files in the specified app directory
-func GitAdd(appName string, files []string, all bool) (GitAddResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitAddResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitAddResult{}, err
-	}
+type GitAddParams struct {
+	AppName string   `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Files   []string `json:"files" pos:"true" desc:"List of files to add (relative to app directory)"`
+	All     bool     `json:"all" short:"A" desc:"Add all changes (equivalent to -A)"`
+	Hunks   []string `json:"hunks" desc:"Stage only these hunks of a single file, by the IDs git_diff --structured lists"`
+	Lines   []string `json:"lines" desc:"Stage only these lines of a single file's hunks, as hunk_id:start-end positions within the hunk (e.g. a1b2c3d4:2-4,7)"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitAdd stages files in the specified app directory
+func GitAdd(ctx context.Context, appName string, files []string, all bool) (GitAddResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitAddResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitAddResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitAddResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -68,12 +55,11 @@ func GitAdd(appName string, files []string, all bool) (GitAddResult, error) {
 	} else {
 		// Validate file paths
 		for _, file := range files {
-			cleanPath := filepath.Clean(file)
-			if strings.HasPrefix(cleanPath, "..") || filepath.IsAbs(cleanPath) {
+			if _, err := repo.Path.RelNoFollow(file); err != nil {
 				return GitAddResult{
 					IsRepo:  true,
 					Success: false,
-					Message: fmt.Sprintf("Invalid file path: %s (must be relative to app directory)", file),
+					Message: fmt.Sprintf("Invalid file path: %s (%v)", file, err),
 				}, nil
 			}
 		}
@@ -81,31 +67,21 @@ func GitAdd(appName string, files []string, all bool) (GitAddResult, error) {
 	}
 
 	// Run git add
-	addCmd := exec.Command("git", args...)
-	addCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	addCmd.Stdout = &outBuf
-	addCmd.Stderr = &errBuf
-	err = addCmd.Run()
-	if err != nil {
+	if _, errOutput, err := repo.Exec(ctx, nil, args...); err != nil {
 		return GitAddResult{
 			IsRepo:      true,
 			Success:     false,
 			Message:     "Git add failed",
-			ErrorOutput: errBuf.String(),
-		}, fmt.Errorf("git add failed: %w - %s", err, strings.TrimSpace(errBuf.String()))
+			ErrorOutput: errOutput,
+		}, err
 	}
 
 	// Get list of staged files
-	statusCmd := exec.Command("git", "diff", "--name-only", "--cached")
-	statusCmd.Dir = appPath
-	var statusBuf bytes.Buffer
-	statusCmd.Stdout = &statusBuf
-	err = statusCmd.Run()
+	staged, err := repo.Run(ctx, "diff", "--name-only", "--cached")
 	
 	var filesAdded []string
 	if err == nil {
-		lines := strings.Split(strings.TrimSpace(statusBuf.String()), "\n")
+		lines := strings.Split(strings.TrimSpace(staged), "\n")
 		for _, line := range lines {
 			if line != "" {
 				filesAdded = append(filesAdded, line)
@@ -121,78 +97,85 @@ func GitAdd(appName string, files []string, all bool) (GitAddResult, error) {
 	}, nil
 }
 
-// CLI
-func GitAddCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_add requires at least 1 argument: app_name\nUsage: layered-code tool git_add <app_name> [files...] [-A|--all]")
-	}
-
-	appName := args[0]
-	var files []string
-	all := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		if args[i] == "-A" || args[i] == "--all" {
-			all = true
-		} else if !strings.HasPrefix(args[i], "-") {
-			files = append(files, args[i])
-		}
+// GitAddHunks stages the selected hunks, or lines of hunks, of one file's unstaged changes
+func GitAddHunks(ctx context.Context, appName, file string, hunks, lines []string) (GitAddResult, error) {
+	selection, err := parseHunkSelection(hunks, lines)
+	if err != nil {
+		return GitAddResult{}, err
 	}
 
-	result, err := GitAdd(appName, files, all)
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return fmt.Errorf("failed to add files: %w", err)
+		return GitAddResult{}, err
 	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+	if !repo.IsRepo() {
+		return GitAddResult{
+			IsRepo:  false,
+			Success: false,
+			Message: repo.notRepoMessage(),
+		}, nil
 	}
 
-	if !result.Success {
-		fmt.Printf("Failed: %s\n", result.Message)
-		return nil
+	rel, err := repo.Path.RelNoFollow(file)
+	if err != nil {
+		return GitAddResult{}, fmt.Errorf("invalid file path: %w", err)
 	}
-
-	fmt.Println(result.Message)
-	if len(result.FilesAdded) > 0 {
-		fmt.Println("Staged files:")
-		for _, file := range result.FilesAdded {
-			fmt.Printf("  %s\n", file)
-		}
+	count, err := applyHunks(ctx, repo, rel, hunksStage, selection)
+	if err != nil {
+		return GitAddResult{}, err
 	}
 
-	return nil
+	return GitAddResult{
+		IsRepo:      true,
+		Success:     true,
+		FilesAdded:  []string{rel},
+		HunksStaged: count,
+		Message:     fmt.Sprintf("Staged %d hunk(s) of %s", count, rel),
+	}, nil
 }
 
-// MCP
-func GitAddMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string   `json:"app_name"`
-		Files   []string `json:"files"`
-		All     bool     `json:"all"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitAdd(args.AppName, args.Files, args.All)
-	if err != nil {
-		return nil, err
-	}
+// Tool
+func gitAddTool() registry.Tool {
+	return registry.New(registry.Spec[GitAddParams, GitAddResult]{
+		Name:        "git_add",
+		Group:       "git",
+		Description: "Add file contents to the staging area (requires git to be installed)",
+		Summary:     "Add file contents to staging area",
+		Hints:       registry.Hints{Idempotent: true},
+		Notes: []string{
+			"--hunks and --lines stage part of one file's changes; list its hunks and their IDs with git_diff --structured first",
+			"A --lines position counts every line of the hunk, context included, starting at 1",
+		},
+		Examples: []string{
+			"# Stage one hunk and two lines of another\nlayered-code tool git_add myapp src/App.jsx --hunks 3f2a9c1e --lines 7b0d4e22:4-5",
+		},
+		Run: func(ctx context.Context, params GitAddParams) (GitAddResult, error) {
+			if len(params.Hunks) > 0 || len(params.Lines) > 0 {
+				if len(params.Files) != 1 || params.All {
+					return GitAddResult{}, fmt.Errorf("hunks and lines need exactly one file")
+				}
+				return GitAddHunks(ctx, params.AppName, params.Files[0], params.Hunks, params.Lines)
+			}
+			return GitAdd(ctx, params.AppName, params.Files, params.All)
+		},
+		Render: func(w io.Writer, _ GitAddParams, result GitAddResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
+			if !result.Success {
+				fmt.Fprintf(w, "Failed: %s\n", result.Message)
+				return
+			}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+			fmt.Fprintln(w, result.Message)
+			if len(result.FilesAdded) > 0 {
+				fmt.Fprintln(w, "Staged files:")
+				for _, file := range result.FilesAdded {
+					fmt.Fprintf(w, "  %s\n", file)
+				}
+			}
+		},
+	})
+}
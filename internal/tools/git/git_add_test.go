@@ -7,9 +7,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitAdd(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-add-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -18,7 +20,7 @@ func TestGitAdd(t *testing.T) {
 	os.MkdirAll(testAppPath, 0755)
 
 	// Non-git repo
-	result, err := GitAdd(testApp, []string{"file.txt"}, false)
+	result, err := GitAdd(t.Context(), testApp, []string{"file.txt"}, false)
 	if err != nil {
 		t.Fatalf("GitAdd failed: %v", err)
 	}
@@ -27,7 +29,7 @@ func TestGitAdd(t *testing.T) {
 	}
 
 	// Empty files list
-	GitInit(testApp, false)
+	GitInit(t.Context(), testApp, false)
 	// Configure git user for commits
 	cmd := exec.Command("git", "config", "user.email", "test@example.com")
 	cmd.Dir = testAppPath
@@ -35,7 +37,7 @@ func TestGitAdd(t *testing.T) {
 	cmd = exec.Command("git", "config", "user.name", "Test User")
 	cmd.Dir = testAppPath
 	cmd.Run()
-	result, err = GitAdd(testApp, []string{}, false)
+	result, err = GitAdd(t.Context(), testApp, []string{}, false)
 	if err != nil {
 		t.Fatalf("GitAdd failed: %v", err)
 	}
@@ -44,7 +46,7 @@ func TestGitAdd(t *testing.T) {
 	}
 
 	// Invalid file path
-	result, err = GitAdd(testApp, []string{"../outside.txt"}, false)
+	result, err = GitAdd(t.Context(), testApp, []string{"../outside.txt"}, false)
 	if err != nil {
 		t.Fatalf("GitAdd failed: %v", err)
 	}
@@ -56,7 +58,7 @@ func TestGitAdd(t *testing.T) {
 	testFile := filepath.Join(testAppPath, "test.txt")
 	os.WriteFile(testFile, []byte("test"), 0644)
 	
-	result, err = GitAdd(testApp, []string{"test.txt"}, false)
+	result, err = GitAdd(t.Context(), testApp, []string{"test.txt"}, false)
 	if err != nil {
 		t.Fatalf("GitAdd failed: %v", err)
 	}
@@ -66,7 +68,7 @@ func TestGitAdd(t *testing.T) {
 
 	// Add all
 	os.WriteFile(filepath.Join(testAppPath, "test2.txt"), []byte("test2"), 0644)
-	result, err = GitAdd(testApp, nil, true)
+	result, err = GitAdd(t.Context(), testApp, nil, true)
 	if err != nil {
 		t.Fatalf("GitAdd all failed: %v", err)
 	}
@@ -0,0 +1,290 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+// Types
+
+// BlameLine is a line of a file with the commit that last changed it
+type BlameLine struct {
+	Line         int    `json:"line"`
+	Commit       string `json:"commit"` // Short hash; 0000000 for uncommitted changes
+	Author       string `json:"author"`
+	Date         string `json:"date"`
+	Summary      string `json:"summary"`
+	OriginalLine int    `json:"original_line"`           // Line number in the commit
+	OriginalPath string `json:"original_path,omitempty"` // Path in the commit, when the line came from another file or name
+	Content      string `json:"content"`
+}
+
+// BlameHunk is a run of consecutive lines last changed by the same commit
+type BlameHunk struct {
+	StartLine    int      `json:"start_line"`
+	EndLine      int      `json:"end_line"`
+	Commit       string   `json:"commit"`
+	Author       string   `json:"author"`
+	Date         string   `json:"date"`
+	Summary      string   `json:"summary"`
+	OriginalPath string   `json:"original_path,omitempty"`
+	Lines        []string `json:"lines"`
+}
+
+type GitBlameResult struct {
+	IsRepo   bool        `json:"is_repo"`
+	File     string      `json:"file,omitempty"`
+	Lines    []BlameLine `json:"lines,omitempty"`
+	Hunks    []BlameHunk `json:"hunks,omitempty"`
+	Redacted int         `json:"redacted,omitempty"`
+	Message  string      `json:"message,omitempty"`
+}
+
+type GitBlameParams struct {
+	AppName          string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	FilePath         string `json:"file_path" required:"true" pos:"true" desc:"File to blame (relative to app directory)"`
+	StartLine        int    `json:"start_line" desc:"First line to blame (default: 1)"`
+	EndLine          int    `json:"end_line" desc:"Last line to blame (default: end of file)"`
+	Ref              string `json:"ref" desc:"Blame the file as of this commit instead of the working tree"`
+	Hunks            bool   `json:"hunks" desc:"Group consecutive lines from the same commit instead of listing every line"`
+	IgnoreWhitespace bool   `json:"ignore_whitespace" short:"w" desc:"Ignore whitespace changes when finding the commit of a line"`
+	DetectMoves      bool   `json:"detect_moves" desc:"Look through lines moved or copied within the file, and from other files of the same commit, for their origin"`
+	ShowSecrets      bool   `json:"show_secrets" desc:"Blame protected files and show secrets instead of redacting them"`
+}
+
+// GitBlameOptions configures GitBlame
+type GitBlameOptions struct {
+	StartLine        int
+	EndLine          int // 0 for the end of the file
+	Ref              string
+	Hunks            bool // Fill in Hunks instead of Lines
+	IgnoreWhitespace bool
+	DetectMoves      bool
+	ShowSecrets      bool // Allow protected files and don't redact secrets
+}
+
+// GitBlame reports the commit that last changed each line of a file. Unless ShowSecrets is set,
+// protected files are refused and secrets in the lines are redacted.
+func GitBlame(ctx context.Context, appName, filePath string, options GitBlameOptions) (GitBlameResult, error) {
+	if filePath == "" {
+		return GitBlameResult{}, fmt.Errorf("file_path is required")
+	}
+	if options.StartLine < 0 || options.EndLine < 0 {
+		return GitBlameResult{}, fmt.Errorf("start_line and end_line must not be negative")
+	}
+	if options.EndLine > 0 && options.StartLine > options.EndLine {
+		return GitBlameResult{}, fmt.Errorf("start_line must not be after end_line")
+	}
+	if err := validateRef(options.Ref); err != nil {
+		return GitBlameResult{}, err
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitBlameResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitBlameResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	rel, err := repo.Path.RelNoFollow(filePath)
+	if err != nil {
+		return GitBlameResult{}, fmt.Errorf("invalid file path: %w", err)
+	}
+	if !options.ShowSecrets && repo.Path.Protected(rel) {
+		return GitBlameResult{}, fmt.Errorf("%s is protected (files.protected); set show_secrets to blame it", rel)
+	}
+
+	args := []string{"-c", "core.quotePath=off", "blame", "--porcelain"}
+	if options.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	if options.DetectMoves {
+		args = append(args, "-M", "-C")
+	}
+	if options.StartLine > 0 || options.EndLine > 0 {
+		start := max(options.StartLine, 1)
+		end := ""
+		if options.EndLine > 0 {
+			end = strconv.Itoa(options.EndLine)
+		}
+		args = append(args, "-L", fmt.Sprintf("%d,%s", start, end))
+	}
+	if options.Ref != "" {
+		args = append(args, options.Ref)
+	}
+	args = append(args, "--", rel)
+
+	output, err := repo.Run(ctx, args...)
+	if err != nil {
+		return GitBlameResult{}, err
+	}
+
+	result := GitBlameResult{IsRepo: true, File: rel}
+	lines := parseBlame(output, rel)
+	if !options.ShowSecrets {
+		for i := range lines {
+			var n int
+			lines[i].Content, n = secrets.Redact(lines[i].Content)
+			result.Redacted += n
+		}
+	}
+	if options.Hunks {
+		result.Hunks = blameHunks(lines)
+		result.Message = fmt.Sprintf("%d line(s) in %d hunk(s)", len(lines), len(result.Hunks))
+	} else {
+		result.Lines = lines
+		result.Message = fmt.Sprintf("%d line(s)", len(lines))
+	}
+	return result, nil
+}
+
+// parseBlame parses git blame --porcelain output. Commit details are printed only the first
+// time a commit appears, so they are remembered by hash.
+func parseBlame(output, path string) []BlameLine {
+	type commitInfo struct{ author, date, summary, filename string }
+	commits := make(map[string]*commitInfo)
+	lines := []BlameLine{}
+
+	var current *BlameLine
+	var info *commitInfo
+	for _, line := range strings.Split(output, "\n") {
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			if current != nil {
+				current.Content = content
+				current.Author, current.Date, current.Summary = info.author, info.date, info.summary
+				if info.filename != path {
+					current.OriginalPath = info.filename
+				}
+				lines = append(lines, *current)
+				current = nil
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if current == nil {
+			// Header: <hash> <original line> <final line> [<lines in group>]
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(fields[0]) < 40 {
+				continue
+			}
+			original, _ := strconv.Atoi(fields[1])
+			final, _ := strconv.Atoi(fields[2])
+			current = &BlameLine{Line: final, Commit: fields[0][:7], OriginalLine: original}
+			if info = commits[fields[0]]; info == nil {
+				info = &commitInfo{filename: path}
+				commits[fields[0]] = info
+			}
+			continue
+		}
+
+		switch key {
+		case "author":
+			info.author = value
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				info.date = time.Unix(seconds, 0).UTC().Format("2006-01-02")
+			}
+		case "summary":
+			info.summary = value
+		case "filename":
+			info.filename = value
+		}
+	}
+	return lines
+}
+
+// blameHunks groups consecutive lines from the same commit and file
+func blameHunks(lines []BlameLine) []BlameHunk {
+	hunks := []BlameHunk{}
+	for _, line := range lines {
+		if n := len(hunks); n > 0 {
+			last := &hunks[n-1]
+			if last.Commit == line.Commit && last.OriginalPath == line.OriginalPath && last.EndLine+1 == line.Line {
+				last.EndLine = line.Line
+				last.Lines = append(last.Lines, line.Content)
+				continue
+			}
+		}
+		hunks = append(hunks, BlameHunk{
+			StartLine:    line.Line,
+			EndLine:      line.Line,
+			Commit:       line.Commit,
+			Author:       line.Author,
+			Date:         line.Date,
+			Summary:      line.Summary,
+			OriginalPath: line.OriginalPath,
+			Lines:        []string{line.Content},
+		})
+	}
+	return hunks
+}
+
+// Tool
+func gitBlameTool() registry.Tool {
+	return registry.New(registry.Spec[GitBlameParams, GitBlameResult]{
+		Name:        "git_blame",
+		Group:       "git",
+		Description: "Show the commit, author, date and summary that last changed each line of a file (requires git to be installed)",
+		Summary:     "Show who last changed each line",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Use --start-line and --end-line to blame part of a file, and --hunks to group lines from the same commit",
+			"--detect-moves follows lines moved or copied from elsewhere to the commit that wrote them; --ignore-whitespace skips whitespace-only changes",
+			"Protected files (files.protected) are refused and secrets are redacted unless --show-secrets is set",
+		},
+		Examples: []string{
+			"# Who changed lines 40-60 and why\nlayered-code tool git_blame myapp src/App.jsx --start-line 40 --end-line 60 --hunks",
+		},
+		Run: func(ctx context.Context, params GitBlameParams) (GitBlameResult, error) {
+			return GitBlame(ctx, params.AppName, params.FilePath, GitBlameOptions{
+				StartLine:        params.StartLine,
+				EndLine:          params.EndLine,
+				Ref:              params.Ref,
+				Hunks:            params.Hunks,
+				IgnoreWhitespace: params.IgnoreWhitespace,
+				DetectMoves:      params.DetectMoves,
+				ShowSecrets:      params.ShowSecrets,
+			})
+		},
+		Render: func(w io.Writer, _ GitBlameParams, result GitBlameResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
+
+			for _, hunk := range result.Hunks {
+				fmt.Fprintf(w, "%s %s %s  lines %d-%d: %s\n", hunk.Commit, hunk.Date, hunk.Author, hunk.StartLine, hunk.EndLine, hunk.Summary)
+				if hunk.OriginalPath != "" {
+					fmt.Fprintf(w, "  (from %s)\n", hunk.OriginalPath)
+				}
+				for i, line := range hunk.Lines {
+					fmt.Fprintf(w, "  %5d  %s\n", hunk.StartLine+i, line)
+				}
+			}
+
+			for _, line := range result.Lines {
+				fmt.Fprintf(w, "%s %s %-16s %5d  %s\n", line.Commit, line.Date, truncateAuthor(line.Author), line.Line, line.Content)
+			}
+		},
+	})
+}
+
+// truncateAuthor keeps blame columns aligned
+func truncateAuthor(author string) string {
+	if runes := []rune(author); len(runes) > 16 {
+		return string(runes[:15]) + "…"
+	}
+	return author
+}
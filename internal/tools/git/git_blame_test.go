@@ -0,0 +1,115 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestParseBlame(t *testing.T) {
+	a := strings.Repeat("a", 40)
+	b := strings.Repeat("b", 40)
+	output := a + " 1 1 2\n" +
+		"author Ann\n" +
+		"author-time 1700000000\n" +
+		"summary Add app\n" +
+		"filename app.js\n" +
+		"\tline one\n" +
+		a + " 2 2\n" +
+		"\tline two\n" +
+		b + " 5 3 1\n" +
+		"author Bob\n" +
+		"author-time 1710000000\n" +
+		"summary Move helper\n" +
+		"previous " + a + " util.js\n" +
+		"filename util.js\n" +
+		"\thelper()\n"
+
+	lines := parseBlame(output, "app.js")
+	if len(lines) != 3 {
+		t.Fatalf("parseBlame() = %+v; want 3 lines", lines)
+	}
+	want := BlameLine{Line: 2, Commit: "aaaaaaa", Author: "Ann", Date: "2023-11-14", Summary: "Add app", OriginalLine: 2, Content: "line two"}
+	if lines[1] != want {
+		t.Errorf("line 2 = %+v; want %+v", lines[1], want)
+	}
+	if lines[2].Author != "Bob" || lines[2].OriginalPath != "util.js" || lines[2].OriginalLine != 5 {
+		t.Errorf("line 3 = %+v; want Bob's line from util.js:5", lines[2])
+	}
+
+	hunks := blameHunks(lines)
+	if len(hunks) != 2 || hunks[0].StartLine != 1 || hunks[0].EndLine != 2 || len(hunks[0].Lines) != 2 || hunks[1].Commit != "bbbbbbb" {
+		t.Errorf("blameHunks() = %+v; want lines 1-2 and 3", hunks)
+	}
+}
+
+func TestGitBlame(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "blamed")
+	os.MkdirAll(appPath, 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(appPath, name), []byte(content), 0644)
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+
+	write("app.js", "const a = 1\nconst b = 2\n")
+	write(".env", "TOKEN=abc\n")
+	git("add", "-A")
+	git("commit", "-m", "Add app")
+	write("app.js", "const a = 1\nconst b = 3\nconst key = 'sk_live_abcdefghijklmnop1234'\n")
+	git("commit", "-am", "Change b", "--author", "Other Dev <other@example.com>")
+	write("app.js", "const a  =  1\nconst b = 3\nconst key = 'sk_live_abcdefghijklmnop1234'\n")
+
+	result, err := GitBlame(t.Context(), "blamed", "app.js", GitBlameOptions{})
+	if err != nil {
+		t.Fatalf("GitBlame failed: %v", err)
+	}
+	if len(result.Lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %+v", result.Lines)
+	}
+	if result.Lines[0].Commit != "0000000" || result.Lines[1].Author != "Other Dev" || result.Lines[1].Summary != "Change b" {
+		t.Errorf("Unexpected blame %+v", result.Lines)
+	}
+	if strings.Contains(result.Lines[2].Content, "sk_live") || result.Redacted != 1 {
+		t.Errorf("Expected the key to be redacted, got %+v", result.Lines[2])
+	}
+
+	// Whitespace-only changes are looked through
+	ignored, err := GitBlame(t.Context(), "blamed", "app.js", GitBlameOptions{StartLine: 1, EndLine: 1, IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("GitBlame -w failed: %v", err)
+	}
+	if len(ignored.Lines) != 1 || ignored.Lines[0].Summary != "Add app" {
+		t.Errorf("Expected line 1 to come from the first commit, got %+v", ignored.Lines)
+	}
+
+	hunks, err := GitBlame(t.Context(), "blamed", "app.js", GitBlameOptions{Ref: "HEAD", Hunks: true})
+	if err != nil {
+		t.Fatalf("GitBlame --hunks failed: %v", err)
+	}
+	if len(hunks.Hunks) != 2 || hunks.Hunks[1].StartLine != 2 || hunks.Hunks[1].EndLine != 3 {
+		t.Errorf("Expected hunks for line 1 and lines 2-3, got %+v", hunks.Hunks)
+	}
+
+	if _, err := GitBlame(t.Context(), "blamed", ".env", GitBlameOptions{}); err == nil {
+		t.Error("Expected blaming a protected file to fail")
+	}
+	if _, err := GitBlame(t.Context(), "blamed", "app.js", GitBlameOptions{StartLine: 3, EndLine: 2}); err == nil {
+		t.Error("Expected an inverted range to fail")
+	}
+}
@@ -1,18 +1,12 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -27,32 +21,24 @@ type GitBranchResult struct {
 	ErrorOutput    string   `json:"error_output,omitempty"`
 }
 
-// GitBranch manages git branches in the specified app directory
-func GitBranch(appName string, createBranch string, switchBranch string, deleteBranch string, listAll bool) (GitBranchResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitBranchResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitBranchResult{}, err
-	}
+type GitBranchParams struct {
+	AppName      string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	CreateBranch string `json:"create_branch" short:"c" alias:"create" desc:"Name of branch to create"`
+	SwitchBranch string `json:"switch_branch" short:"s" alias:"switch" desc:"Name of branch to switch to"`
+	DeleteBranch string `json:"delete_branch" short:"d" alias:"delete" desc:"Name of branch to delete"`
+	ListAll      bool   `json:"list_all" short:"a" alias:"all" desc:"List all branches including remotes"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitBranch manages git branches in the specified app directory
+func GitBranch(ctx context.Context, appName string, createBranch string, switchBranch string, deleteBranch string, listAll bool) (GitBranchResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitBranchResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitBranchResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitBranchResult{
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -60,16 +46,11 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 
 	// Handle create branch
 	if createBranch != "" {
-		cmd := exec.Command("git", "branch", createBranch)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "branch", createBranch)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to create branch: %s", strings.TrimSpace(errBuf.String()))
+			result.Message = fmt.Sprintf("Failed to create branch: %s", strings.TrimSpace(errOutput))
 			result.CreateSuccess = false
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		} else {
 			result.CreateSuccess = true
 		}
@@ -77,16 +58,11 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 
 	// Handle switch branch
 	if switchBranch != "" {
-		cmd := exec.Command("git", "checkout", switchBranch)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "checkout", switchBranch)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to switch branch: %s", strings.TrimSpace(errBuf.String()))
+			result.Message = fmt.Sprintf("Failed to switch branch: %s", strings.TrimSpace(errOutput))
 			result.SwitchSuccess = false
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		} else {
 			result.SwitchSuccess = true
 		}
@@ -94,23 +70,14 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 
 	// Handle delete branch
 	if deleteBranch != "" {
-		cmd := exec.Command("git", "branch", "-d", deleteBranch)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, _, err := repo.Exec(ctx, nil, "branch", "-d", deleteBranch)
 		if err != nil {
 			// Try force delete if regular delete fails
-			cmd = exec.Command("git", "branch", "-D", deleteBranch)
-			cmd.Dir = appPath
-			cmd.Stdout = &outBuf
-			cmd.Stderr = &errBuf
-			err = cmd.Run()
+			_, errOutput, err := repo.Exec(ctx, nil, "branch", "-D", deleteBranch)
 			if err != nil {
-				result.Message = fmt.Sprintf("Failed to delete branch: %s", strings.TrimSpace(errBuf.String()))
+				result.Message = fmt.Sprintf("Failed to delete branch: %s", strings.TrimSpace(errOutput))
 				result.DeleteSuccess = false
-				result.ErrorOutput = errBuf.String()
+				result.ErrorOutput = errOutput
 			} else {
 				result.DeleteSuccess = true
 			}
@@ -120,21 +87,17 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 	}
 
 	// Get current branch
-	branchCmd := exec.Command("git", "branch", "--show-current")
-	branchCmd.Dir = appPath
-	branchOutput, err := branchCmd.Output()
+	branchOutput, err := repo.Run(ctx, "branch", "--show-current")
 	if err != nil {
 		// Try alternative method for detached HEAD
-		branchCmd = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-		branchCmd.Dir = appPath
-		branchOutput, err = branchCmd.Output()
+		branchOutput, err = repo.Run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 		if err != nil {
 			result.CurrentBranch = "unknown"
 		} else {
-			result.CurrentBranch = strings.TrimSpace(string(branchOutput))
+			result.CurrentBranch = strings.TrimSpace(branchOutput)
 		}
 	} else {
-		result.CurrentBranch = strings.TrimSpace(string(branchOutput))
+		result.CurrentBranch = strings.TrimSpace(branchOutput)
 	}
 
 	// List branches
@@ -143,15 +106,13 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 		args = append(args, "-a")
 	}
 
-	listCmd := exec.Command("git", args...)
-	listCmd.Dir = appPath
-	output, err := listCmd.Output()
+	output, err := repo.Run(ctx, args...)
 	if err != nil {
 		return result, nil
 	}
 
 	// Parse branch list
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
@@ -174,121 +135,60 @@ func GitBranch(appName string, createBranch string, switchBranch string, deleteB
 	return result, nil
 }
 
-// CLI
-func GitBranchCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_branch requires at least 1 argument: app_name\nUsage: layered-code tool git_branch <app_name> [-c <branch>] [-s <branch>] [-d <branch>] [-a]")
-	}
-
-	appName := args[0]
-	createBranch := ""
-	switchBranch := ""
-	deleteBranch := ""
-	listAll := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "-c", "--create":
-			if i+1 < len(args) {
-				createBranch = args[i+1]
-				i++
-			}
-		case "-s", "--switch":
-			if i+1 < len(args) {
-				switchBranch = args[i+1]
-				i++
+// Tool
+func gitBranchTool() registry.Tool {
+	return registry.New(registry.Spec[GitBranchParams, GitBranchResult]{
+		Name:        "git_branch",
+		Group:       "git",
+		Description: "List, create, or delete branches (requires git to be installed)",
+		Summary:     "List, create, or delete branches",
+		Hints:       registry.Hints{Destructive: true},
+		Run: func(ctx context.Context, params GitBranchParams) (GitBranchResult, error) {
+			return GitBranch(ctx, params.AppName, params.CreateBranch, params.SwitchBranch, params.DeleteBranch, params.ListAll)
+		},
+		Render: func(w io.Writer, params GitBranchParams, result GitBranchResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
 			}
-		case "-d", "--delete":
-			if i+1 < len(args) {
-				deleteBranch = args[i+1]
-				i++
-			}
-		case "-a", "--all":
-			listAll = true
-		}
-	}
-
-	result, err := GitBranch(appName, createBranch, switchBranch, deleteBranch, listAll)
-	if err != nil {
-		return fmt.Errorf("failed to manage branches: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
-
-	// Show operation results
-	if createBranch != "" {
-		if result.CreateSuccess {
-			fmt.Printf("Created branch: %s\n", createBranch)
-		} else {
-			fmt.Printf("Failed to create branch: %s\n", result.Message)
-		}
-	}
 
-	if switchBranch != "" {
-		if result.SwitchSuccess {
-			fmt.Printf("Switched to branch: %s\n", switchBranch)
-		} else {
-			fmt.Printf("Failed to switch branch: %s\n", result.Message)
-		}
-	}
+			// Show operation results
+			if params.CreateBranch != "" {
+				if result.CreateSuccess {
+					fmt.Fprintf(w, "Created branch: %s\n", params.CreateBranch)
+				} else {
+					fmt.Fprintf(w, "Failed to create branch: %s\n", result.Message)
+				}
+			}
 
-	if deleteBranch != "" {
-		if result.DeleteSuccess {
-			fmt.Printf("Deleted branch: %s\n", deleteBranch)
-		} else {
-			fmt.Printf("Failed to delete branch: %s\n", result.Message)
-		}
-	}
+			if params.SwitchBranch != "" {
+				if result.SwitchSuccess {
+					fmt.Fprintf(w, "Switched to branch: %s\n", params.SwitchBranch)
+				} else {
+					fmt.Fprintf(w, "Failed to switch branch: %s\n", result.Message)
+				}
+			}
 
-	// Show branch list
-	if createBranch == "" && switchBranch == "" && deleteBranch == "" {
-		fmt.Printf("Current branch: %s\n\n", result.CurrentBranch)
-		fmt.Println("Branches:")
-		for _, branch := range result.Branches {
-			if branch == result.CurrentBranch {
-				fmt.Printf("* %s\n", branch)
-			} else {
-				fmt.Printf("  %s\n", branch)
+			if params.DeleteBranch != "" {
+				if result.DeleteSuccess {
+					fmt.Fprintf(w, "Deleted branch: %s\n", params.DeleteBranch)
+				} else {
+					fmt.Fprintf(w, "Failed to delete branch: %s\n", result.Message)
+				}
 			}
-		}
-	}
 
-	return nil
+			// Show branch list
+			if params.CreateBranch == "" && params.SwitchBranch == "" && params.DeleteBranch == "" {
+				fmt.Fprintf(w, "Current branch: %s\n\n", result.CurrentBranch)
+				fmt.Fprintln(w, "Branches:")
+				for _, branch := range result.Branches {
+					if branch == result.CurrentBranch {
+						fmt.Fprintf(w, "* %s\n", branch)
+					} else {
+						fmt.Fprintf(w, "  %s\n", branch)
+					}
+				}
+			}
+		},
+	})
 }
-
-// MCP
-func GitBranchMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName      string `json:"app_name"`
-		CreateBranch string `json:"create_branch"`
-		SwitchBranch string `json:"switch_branch"`
-		DeleteBranch string `json:"delete_branch"`
-		ListAll      bool   `json:"list_all"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitBranch(args.AppName, args.CreateBranch, args.SwitchBranch, args.DeleteBranch, args.ListAll)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
@@ -7,9 +7,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitBranch(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-branch-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -18,7 +20,7 @@ func TestGitBranch(t *testing.T) {
 	os.MkdirAll(testAppPath, 0755)
 
 	// Non-git repo
-	result, err := GitBranch(testApp, "", "", "", false)
+	result, err := GitBranch(t.Context(), testApp, "", "", "", false)
 	if err != nil {
 		t.Fatalf("GitBranch failed: %v", err)
 	}
@@ -27,7 +29,7 @@ func TestGitBranch(t *testing.T) {
 	}
 
 	// Initialize repo with a commit
-	GitInit(testApp, false)
+	GitInit(t.Context(), testApp, false)
 	// Configure git user for commits
 	cmd := exec.Command("git", "config", "user.email", "test@example.com")
 	cmd.Dir = testAppPath
@@ -45,7 +47,7 @@ func TestGitBranch(t *testing.T) {
 	cmd.Run()
 
 	// List branches
-	result, err = GitBranch(testApp, "", "", "", false)
+	result, err = GitBranch(t.Context(), testApp, "", "", "", false)
 	if err != nil {
 		t.Fatalf("GitBranch list failed: %v", err)
 	}
@@ -54,7 +56,7 @@ func TestGitBranch(t *testing.T) {
 	}
 
 	// Create branch
-	result, err = GitBranch(testApp, "feature", "", "", false)
+	result, err = GitBranch(t.Context(), testApp, "feature", "", "", false)
 	if err != nil {
 		t.Fatalf("GitBranch create failed: %v", err)
 	}
@@ -63,7 +65,7 @@ func TestGitBranch(t *testing.T) {
 	}
 
 	// Switch branch
-	result, err = GitBranch(testApp, "", "feature", "", false)
+	result, err = GitBranch(t.Context(), testApp, "", "feature", "", false)
 	if err != nil {
 		t.Fatalf("GitBranch switch failed: %v", err)
 	}
@@ -72,8 +74,8 @@ func TestGitBranch(t *testing.T) {
 	}
 
 	// Delete branch (switch back first)
-	GitBranch(testApp, "", "master", "", false)
-	result, err = GitBranch(testApp, "", "", "feature", false)
+	GitBranch(t.Context(), testApp, "", "master", "", false)
+	result, err = GitBranch(t.Context(), testApp, "", "", "feature", false)
 	if err != nil {
 		t.Fatalf("GitBranch delete failed: %v", err)
 	}
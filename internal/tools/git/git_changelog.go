@@ -0,0 +1,140 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ConventionalCommit is a commit subject in the conventional-commit form
+// "type(scope)!: description"
+type ConventionalCommit struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Breaking    bool   `json:"breaking,omitempty"`
+	Description string `json:"description"`
+}
+
+var conventionalSubject = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+
+// parseConventional parses a conventional-commit subject, reporting false for other subjects
+func parseConventional(subject string) (ConventionalCommit, bool) {
+	m := conventionalSubject.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       strings.TrimSpace(m[2]),
+		Breaking:    m[3] == "!",
+		Description: strings.TrimSpace(m[4]),
+	}, true
+}
+
+type changelogType struct{ Type, Title string }
+
+// changelogTypes are the sections of a changelog, in order. Commits of other types, and
+// commits not following the convention, go under "other".
+var changelogTypes = []changelogType{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"style", "Style"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"chore", "Chores"},
+	{"revert", "Reverts"},
+	{"other", "Other Changes"},
+}
+
+// Changelog lists the commits between two refs grouped by conventional-commit type
+type Changelog struct {
+	From     string             `json:"from,omitempty"` // Empty when the changelog starts at the first commit
+	To       string             `json:"to"`
+	Sections []ChangelogSection `json:"sections"`
+	Breaking []ChangelogEntry   `json:"breaking,omitempty"` // Breaking changes, also listed in their sections
+}
+
+type ChangelogSection struct {
+	Type    string           `json:"type"`
+	Title   string           `json:"title"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+type ChangelogEntry struct {
+	Hash        string `json:"hash"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking,omitempty"`
+}
+
+// buildChangelog groups commits, newest first as git log lists them, by type
+func buildChangelog(from, to string, commits []GitLogEntry) Changelog {
+	changelog := Changelog{From: from, To: to, Sections: []ChangelogSection{}}
+	entries := make(map[string][]ChangelogEntry)
+	for _, commit := range commits {
+		cc, ok := parseConventional(commit.Message)
+		if !ok {
+			cc = ConventionalCommit{Type: "other", Description: commit.Message}
+		}
+		kind := cc.Type
+		if !slices.ContainsFunc(changelogTypes, func(t changelogType) bool { return t.Type == kind }) {
+			kind = "other"
+		}
+		entry := ChangelogEntry{Hash: commit.Hash, Scope: cc.Scope, Description: cc.Description, Breaking: cc.Breaking}
+		entries[kind] = append(entries[kind], entry)
+		if entry.Breaking {
+			changelog.Breaking = append(changelog.Breaking, entry)
+		}
+	}
+
+	for _, section := range changelogTypes {
+		if len(entries[section.Type]) > 0 {
+			changelog.Sections = append(changelog.Sections, ChangelogSection{
+				Type:    section.Type,
+				Title:   section.Title,
+				Entries: entries[section.Type],
+			})
+		}
+	}
+	return changelog
+}
+
+// Markdown formats the changelog for release notes
+func (c Changelog) Markdown() string {
+	var b strings.Builder
+	if c.From != "" {
+		fmt.Fprintf(&b, "## %s (since %s)\n", c.To, c.From)
+	} else {
+		fmt.Fprintf(&b, "## %s\n", c.To)
+	}
+	if len(c.Sections) == 0 {
+		b.WriteString("\nNo changes\n")
+		return b.String()
+	}
+
+	if len(c.Breaking) > 0 {
+		b.WriteString("\n### Breaking Changes\n\n")
+		for _, entry := range c.Breaking {
+			b.WriteString(entry.line())
+		}
+	}
+	for _, section := range c.Sections {
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString(entry.line())
+		}
+	}
+	return b.String()
+}
+
+func (e ChangelogEntry) line() string {
+	if e.Scope != "" {
+		return fmt.Sprintf("- **%s:** %s (%s)\n", e.Scope, e.Description, e.Hash)
+	}
+	return fmt.Sprintf("- %s (%s)\n", e.Description, e.Hash)
+}
@@ -36,7 +36,7 @@ func TestGitToolsWithoutGit(t *testing.T) {
 	
 	// Test that git tools return appropriate errors when git is not available
 	t.Run("GitStatus", func(t *testing.T) {
-		_, err := GitStatus("testapp")
+		_, err := GitStatus(t.Context(), "testapp")
 		if err == nil {
 			t.Error("Expected error when git is not available")
 		}
@@ -46,14 +46,14 @@ func TestGitToolsWithoutGit(t *testing.T) {
 	})
 	
 	t.Run("GitDiff", func(t *testing.T) {
-		_, err := GitDiff("testapp", false, "")
+		_, err := GitDiff(t.Context(), "testapp", GitDiffOptions{})
 		if err == nil {
 			t.Error("Expected error when git is not available")
 		}
 	})
 	
 	t.Run("GitInit", func(t *testing.T) {
-		_, err := GitInit("testapp", false)
+		_, err := GitInit(t.Context(), "testapp", false)
 		if err == nil {
 			t.Error("Expected error when git is not available")
 		}
@@ -28,7 +28,7 @@ func TestCheckout(t *testing.T) {
 		runGitCommand(t, repo, "checkout", defaultBranch)
 		
 		// Use our checkout function
-		output, err := Checkout(repo, "feature-branch", false, nil)
+		output, err := Checkout(t.Context(), repo, "feature-branch", false, nil)
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -53,7 +53,7 @@ func TestCheckout(t *testing.T) {
 		repo := setupTestRepo(t)
 		
 		// Create new branch
-		output, err := Checkout(repo, "new-feature", true, nil)
+		output, err := Checkout(t.Context(), repo, "new-feature", true, nil)
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -85,7 +85,7 @@ func TestCheckout(t *testing.T) {
 		runGitCommand(t, repo, "commit", "-m", "Second commit")
 		
 		// Checkout first commit
-		output, err := Checkout(repo, firstCommit, false, nil)
+		output, err := Checkout(t.Context(), repo, firstCommit, false, nil)
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -119,7 +119,7 @@ func TestCheckout(t *testing.T) {
 		writeTestFile(t, repo, "file1.txt", "modified")
 		
 		// Checkout file from HEAD
-		output, err := Checkout(repo, "", false, []string{"file1.txt"})
+		output, err := Checkout(t.Context(), repo, "", false, []string{"file1.txt"})
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -151,7 +151,7 @@ func TestCheckout(t *testing.T) {
 		runGitCommand(t, repo, "commit", "-m", "Second commit")
 		
 		// Checkout file1 from first commit
-		output, err := Checkout(repo, firstCommit, false, []string{"file1.txt"})
+		output, err := Checkout(t.Context(), repo, firstCommit, false, []string{"file1.txt"})
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -187,7 +187,7 @@ func TestCheckout(t *testing.T) {
 		writeTestFile(t, repo, "file3.txt", "modified3")
 		
 		// Checkout only file1 and file2
-		output, err := Checkout(repo, "", false, []string{"file1.txt", "file2.txt"})
+		output, err := Checkout(t.Context(), repo, "", false, []string{"file1.txt", "file2.txt"})
 		if err != nil {
 			t.Fatalf("checkout failed: %v", err)
 		}
@@ -209,7 +209,7 @@ func TestCheckout(t *testing.T) {
 
 	t.Run("error cases", func(t *testing.T) {
 		// Missing app name
-		_, err := Checkout("", "main", false, nil)
+		_, err := Checkout(t.Context(), "", "main", false, nil)
 		if err == nil {
 			t.Error("expected error for missing app name")
 		}
@@ -218,7 +218,7 @@ func TestCheckout(t *testing.T) {
 		}
 		
 		// No target or files
-		_, err = Checkout("test-app", "", false, nil)
+		_, err = Checkout(t.Context(), "test-app", "", false, nil)
 		if err == nil {
 			t.Error("expected error for no target or files")
 		}
@@ -228,7 +228,7 @@ func TestCheckout(t *testing.T) {
 		
 		// Non-existent branch
 		repo := setupTestRepo(t)
-		_, err = Checkout(repo, "non-existent-branch", false, nil)
+		_, err = Checkout(t.Context(), repo, "non-existent-branch", false, nil)
 		if err == nil {
 			t.Error("expected error for non-existent branch")
 		}
@@ -237,7 +237,7 @@ func TestCheckout(t *testing.T) {
 		}
 		
 		// Non-existent file
-		_, err = Checkout(repo, "", false, []string{"non-existent.txt"})
+		_, err = Checkout(t.Context(), repo, "", false, []string{"non-existent.txt"})
 		if err == nil {
 			t.Error("expected error for non-existent file")
 		}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+type GitCherryPickParams struct {
+	AppName  string   `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Commits  []string `json:"commits" pos:"true" desc:"Commits to apply to the current branch, oldest first (ranges like main..feature work too)"`
+	NoCommit bool     `json:"no_commit" desc:"Apply the changes to the working tree and index without committing"`
+	Continue bool     `json:"continue" desc:"Go on after the conflicts of the current commit are resolved and staged"`
+	Abort    bool     `json:"abort" desc:"Abort the cherry-pick in progress and go back to the state before it"`
+	Skip     bool     `json:"skip" desc:"Leave out the commit the cherry-pick stopped at and go on"`
+}
+
+// GitCherryPick applies the changes of existing commits to the current branch, or continues,
+// aborts or skips a commit of the cherry-pick in progress
+func GitCherryPick(ctx context.Context, appName string, commits []string, noCommit bool, continuePick bool, abort bool, skip bool) (GitOperationResult, error) {
+	step, err := operationStep(continuePick, abort, skip)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if step == "" && len(commits) == 0 {
+		return GitOperationResult{}, fmt.Errorf("commits are required unless continuing, aborting or skipping")
+	}
+	for _, commit := range commits {
+		if err := validateRef(commit); err != nil {
+			return GitOperationResult{}, err
+		}
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitOperationResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	switch step {
+	case "--continue":
+		return runOperation(ctx, repo, "cherry-pick", "Cherry-pick completed", "cherry-pick", "--continue")
+	case "--abort":
+		return runOperation(ctx, repo, "cherry-pick", "Cherry-pick aborted", "cherry-pick", "--abort")
+	case "--skip":
+		return runOperation(ctx, repo, "cherry-pick", "Commit skipped and cherry-pick completed", "cherry-pick", "--skip")
+	}
+
+	args := []string{"cherry-pick"}
+	message := fmt.Sprintf("Applied %d commit(s)", len(commits))
+	if noCommit {
+		args = append(args, "--no-commit")
+		message = "Changes applied and staged; commit them with git_commit"
+	}
+	args = append(args, commits...)
+	return runOperation(ctx, repo, "cherry-pick", message, args...)
+}
+
+// Tool
+func gitCherryPickTool() registry.Tool {
+	return registry.New(registry.Spec[GitCherryPickParams, GitOperationResult]{
+		Name:        "git_cherry_pick",
+		Group:       "git",
+		Description: "Apply the changes introduced by existing commits to the current branch (requires git to be installed)",
+		Summary:     "Apply existing commits",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"A cherry-pick that stops on conflicts lists the conflicted files; resolve them with git_conflicts, then run git_cherry_pick --continue, --skip or --abort",
+		},
+		Examples: []string{
+			"# Apply one commit from another branch\nlayered-code tool git_cherry_pick myapp a1b2c3d",
+			"# Go on after resolving the conflicts\nlayered-code tool git_cherry_pick myapp --continue",
+		},
+		Run: func(ctx context.Context, params GitCherryPickParams) (GitOperationResult, error) {
+			return GitCherryPick(ctx, params.AppName, params.Commits, params.NoCommit, params.Continue, params.Abort, params.Skip)
+		},
+		Render: func(w io.Writer, _ GitCherryPickParams, result GitOperationResult) {
+			renderOperation(w, result)
+		},
+	})
+}
@@ -1,37 +1,40 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
 	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitCommitResult struct {
-	Success     bool   `json:"success"`
-	CommitHash  string `json:"commit_hash"`
-	Message     string `json:"message"`
-	IsRepo      bool   `json:"is_repo"`
-	Error       string `json:"error,omitempty"`
-	ErrorOutput string `json:"error_output,omitempty"`
+	Success     bool         `json:"success"`
+	CommitHash  string       `json:"commit_hash"`
+	Message     string       `json:"message"`
+	IsRepo      bool         `json:"is_repo"`
+	Issues      []CheckIssue `json:"issues,omitempty"`
+	Draft       string       `json:"draft,omitempty"` // Message drafted from the staged changes, for draft mode
+	Error       string       `json:"error,omitempty"`
+	ErrorOutput string       `json:"error_output,omitempty"`
 }
 
-// GitCommit creates a git commit in the specified app directory
-func GitCommit(appName string, message string, amend bool) (GitCommitResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitCommitResult{}, err
-	}
+type GitCommitParams struct {
+	AppName    string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Message    string `json:"message" short:"m" desc:"Commit message (required unless using --amend)"`
+	Amend      bool   `json:"amend" desc:"Amend the previous commit"`
+	SkipChecks bool   `json:"skip_checks" desc:"Commit even if the staged changes contain secrets, large files or paths matching git.deny_commit"`
+	Draft      bool   `json:"draft" desc:"Draft a commit message from the staged changes to refine, instead of committing"`
+}
 
-	if err := helpers.ValidateAppName(appName); err != nil {
+// GitCommit creates a git commit in the specified app directory. Unless skipChecks is set, staged
+// changes containing secrets, large files or denied paths block the commit. Messages breaking the
+// commit message rules of the git settings are rejected with the reason.
+func GitCommit(ctx context.Context, appName string, message string, amend bool, skipChecks bool) (GitCommitResult, error) {
+	if err := EnsureGitAvailable(); err != nil {
 		return GitCommitResult{}, err
 	}
 
@@ -39,30 +42,20 @@ func GitCommit(appName string, message string, amend bool) (GitCommitResult, err
 		return GitCommitResult{}, fmt.Errorf("commit message is required (unless using --amend)")
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitCommitResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitCommitResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitCommitResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
 	// Check if there are staged changes
-	statusCmd := exec.Command("git", "diff", "--staged", "--quiet")
-	statusCmd.Dir = appPath
-	err = statusCmd.Run()
+	_, err = repo.Run(ctx, "diff", "--staged", "--quiet")
 	hasStaged := err != nil // git diff --staged --quiet returns non-zero if there are staged changes
 
 	if !hasStaged && !amend {
@@ -73,6 +66,31 @@ func GitCommit(appName string, message string, amend bool) (GitCommitResult, err
 		}, nil
 	}
 
+	if message != "" {
+		if reason := validateCommitMessage(message, config.GitSettings()); reason != "" {
+			return GitCommitResult{
+				IsRepo:  true,
+				Success: false,
+				Message: "Commit message rejected: " + reason,
+			}, nil
+		}
+	}
+
+	if !skipChecks {
+		issues, err := checkStaged(ctx, repo)
+		if err != nil {
+			return GitCommitResult{}, fmt.Errorf("failed to check staged changes: %w", err)
+		}
+		if len(issues) > 0 {
+			return GitCommitResult{
+				IsRepo:  true,
+				Success: false,
+				Message: fmt.Sprintf("Commit blocked: %d problem(s) in staged changes; unstage or fix them, or set skip_checks", len(issues)),
+				Issues:  issues,
+			}, nil
+		}
+	}
+
 	// Build commit command
 	args := []string{"commit"}
 	if amend {
@@ -87,40 +105,29 @@ func GitCommit(appName string, message string, amend bool) (GitCommitResult, err
 	}
 
 	// Run git commit
-	commitCmd := exec.Command("git", args...)
-	commitCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	commitCmd.Stdout = &outBuf
-	commitCmd.Stderr = &errBuf
-	err = commitCmd.Run()
-	if err != nil {
+	if _, errOutput, err := repo.Exec(ctx, nil, args...); err != nil {
 		return GitCommitResult{
 			IsRepo:      true,
 			Success:     false,
 			Message:     "Git commit failed",
 			Error:       err.Error(),
-			ErrorOutput: errBuf.String(),
-		}, fmt.Errorf("git commit failed: %w - %s", err, strings.TrimSpace(errBuf.String()))
+			ErrorOutput: errOutput,
+		}, err
 	}
 
 	// Get the commit hash
-	hashCmd := exec.Command("git", "rev-parse", "HEAD")
-	hashCmd.Dir = appPath
-	var hashOutBuf, hashErrBuf bytes.Buffer
-	hashCmd.Stdout = &hashOutBuf
-	hashCmd.Stderr = &hashErrBuf
-	err = hashCmd.Run()
+	hash, errOutput, err := repo.Exec(ctx, nil, "rev-parse", "HEAD")
 	if err != nil {
 		return GitCommitResult{
 			IsRepo:      true,
 			Success:     false,
 			Message:     "Failed to get commit hash",
 			Error:       err.Error(),
-			ErrorOutput: hashErrBuf.String(),
+			ErrorOutput: errOutput,
 		}, fmt.Errorf("failed to get commit hash: %w", err)
 	}
 
-	commitHash := strings.TrimSpace(hashOutBuf.String())[:7] // Short hash
+	commitHash := strings.TrimSpace(hash)[:7] // Short hash
 
 	return GitCommitResult{
 		IsRepo:     true,
@@ -130,80 +137,85 @@ func GitCommit(appName string, message string, amend bool) (GitCommitResult, err
 	}, nil
 }
 
-// CLI
-func GitCommitCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_commit requires at least 1 argument: app_name\nUsage: layered-code tool git_commit <app_name> [-m \"message\"] [--amend]")
+// GitCommitDraft drafts a commit message from the staged changes, following the commit message
+// rules of the git settings, for the caller to refine and pass to GitCommit
+func GitCommitDraft(ctx context.Context, appName string) (GitCommitResult, error) {
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitCommitResult{}, err
 	}
-
-	appName := args[0]
-	message := ""
-	amend := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "-m", "--message":
-			if i+1 < len(args) {
-				message = args[i+1]
-				i++ // Skip the message value
-			} else {
-				return fmt.Errorf("-m flag requires a message")
-			}
-		case "--amend":
-			amend = true
-		}
+	if !repo.IsRepo() {
+		return GitCommitResult{
+			IsRepo:  false,
+			Success: false,
+			Message: repo.notRepoMessage(),
+		}, nil
 	}
 
-	result, err := GitCommit(appName, message, amend)
+	output, err := repo.Run(ctx, "-c", "core.quotePath=off", "diff", "--cached", "--no-color", "--no-ext-diff", "-M")
 	if err != nil {
-		return fmt.Errorf("failed to create commit: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+		return GitCommitResult{}, err
 	}
-
-	if !result.Success {
-		fmt.Printf("Commit failed: %s\n", result.Message)
-		if result.Error != "" {
-			fmt.Printf("Error: %s\n", result.Error)
-		}
-		return nil
+	_, files := parseDiff(output)
+	if len(files) == 0 {
+		return GitCommitResult{
+			IsRepo:  true,
+			Success: false,
+			Message: "No staged changes to commit",
+		}, nil
 	}
+	redactFiles(repo.Path, files)
 
-	fmt.Printf("Commit created successfully: %s\n", result.CommitHash)
-	return nil
+	return GitCommitResult{
+		IsRepo:  true,
+		Success: true,
+		Draft:   draftCommitMessage(files, config.GitSettings()),
+		Message: "Draft commit message; say why the change was made and commit it with --message",
+	}, nil
 }
 
-// MCP
-func GitCommitMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-		Message string `json:"message"`
-		Amend   bool   `json:"amend"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitCommit(args.AppName, args.Message, args.Amend)
-	if err != nil {
-		return nil, err
-	}
+// Tool
+func gitCommitTool() registry.Tool {
+	return registry.New(registry.Spec[GitCommitParams, GitCommitResult]{
+		Name:        "git_commit",
+		Group:       "git",
+		Description: "Create a new commit with staged changes (requires git to be installed)",
+		Summary:     "Create a new commit",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"Staged changes are checked for secrets, files over git.max_file_size and paths matching git.deny_commit; use --skip-checks to commit anyway",
+			"Messages are checked against git.conventional_commits, git.max_subject_length and git.issue_ref; a rejected message comes back with the reason",
+			"--draft returns a message drafted from the staged changes, with a bullet per file; the type and summary are guesses to refine",
+		},
+		Examples: []string{
+			"# Draft a message, then commit a refined one\nlayered-code tool git_commit myapp --draft\nlayered-code tool git_commit myapp -m \"feat(header): add search box\"",
+		},
+		Run: func(ctx context.Context, params GitCommitParams) (GitCommitResult, error) {
+			if params.Draft {
+				return GitCommitDraft(ctx, params.AppName)
+			}
+			return GitCommit(ctx, params.AppName, params.Message, params.Amend, params.SkipChecks)
+		},
+		Render: func(w io.Writer, _ GitCommitParams, result GitCommitResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
+			if !result.Success {
+				fmt.Fprintf(w, "Commit failed: %s\n", result.Message)
+				renderIssues(w, result.Issues)
+				if result.Error != "" {
+					fmt.Fprintf(w, "Error: %s\n", result.Error)
+				}
+				return
+			}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+			if result.Draft != "" {
+				fmt.Fprintf(w, "%s\n\n%s", result.Message, result.Draft)
+				return
+			}
+			fmt.Fprintf(w, "Commit created successfully: %s\n", result.CommitHash)
+		},
+	})
+}
@@ -8,10 +8,12 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitCommit(t *testing.T) {
 	// Setup test environment
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("Failed to ensure apps directory: %v", err)
@@ -29,7 +31,7 @@ func TestGitCommit(t *testing.T) {
 
 	// Test 1: Non-git repository
 	t.Run("NonGitRepo", func(t *testing.T) {
-		result, err := GitCommit(testApp, "Test message", false)
+		result, err := GitCommit(t.Context(), testApp, "Test message", false, false)
 		if err != nil {
 			t.Fatalf("GitCommit failed: %v", err)
 		}
@@ -61,7 +63,7 @@ func TestGitCommit(t *testing.T) {
 
 	// Test 2: No staged changes
 	t.Run("NoStagedChanges", func(t *testing.T) {
-		result, err := GitCommit(testApp, "Test message", false)
+		result, err := GitCommit(t.Context(), testApp, "Test message", false, false)
 		if err != nil {
 			t.Fatalf("GitCommit failed: %v", err)
 		}
@@ -81,7 +83,7 @@ func TestGitCommit(t *testing.T) {
 
 	// Test 3: Missing message
 	t.Run("MissingMessage", func(t *testing.T) {
-		_, err := GitCommit(testApp, "", false)
+		_, err := GitCommit(t.Context(), testApp, "", false, false)
 		if err == nil {
 			t.Error("Expected error for missing commit message")
 		}
@@ -101,7 +103,7 @@ func TestGitCommit(t *testing.T) {
 
 	// Test 4: Successful commit
 	t.Run("SuccessfulCommit", func(t *testing.T) {
-		result, err := GitCommit(testApp, "Initial commit", false)
+		result, err := GitCommit(t.Context(), testApp, "Initial commit", false, false)
 		if err != nil {
 			t.Fatalf("GitCommit failed: %v", err)
 		}
@@ -137,7 +139,7 @@ func TestGitCommit(t *testing.T) {
 		addCmd.Run()
 
 		// Amend the commit
-		result, err := GitCommit(testApp, "Amended commit", true)
+		result, err := GitCommit(t.Context(), testApp, "Amended commit", true, false)
 		if err != nil {
 			t.Fatalf("GitCommit failed: %v", err)
 		}
@@ -164,7 +166,7 @@ func TestGitCommit(t *testing.T) {
 		addCmd.Run()
 
 		// Amend without changing message
-		result, err := GitCommit(testApp, "", true)
+		result, err := GitCommit(t.Context(), testApp, "", true, false)
 		if err != nil {
 			t.Fatalf("GitCommit failed: %v", err)
 		}
@@ -0,0 +1,284 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+type GitConflictsResult struct {
+	IsRepo    bool           `json:"is_repo"`
+	Operation string         `json:"operation,omitempty"` // merge, rebase, cherry_pick or revert in progress
+	Files     []ConflictFile `json:"files"`               // Files still conflicted
+	Resolved  int            `json:"resolved,omitempty"`  // Hunks resolved by this call, 1 for a file resolved as a whole
+	Staged    bool           `json:"staged,omitempty"`    // The resolved file had no conflicts left and was staged
+	Redacted  int            `json:"redacted,omitempty"`
+	Message   string         `json:"message,omitempty"`
+}
+
+// ConflictFile is a file with unresolved conflicts. Files deleted on one side, or binary, have
+// no hunks and are resolved as a whole.
+type ConflictFile struct {
+	Path      string         `json:"path"`
+	Conflict  string         `json:"conflict"` // both_modified, both_added, added_by_us, deleted_by_them, ...
+	Hunks     []ConflictHunk `json:"hunks,omitempty"`
+	Protected bool           `json:"protected,omitempty"` // Hunks hidden because the file matches files.protected
+}
+
+type GitConflictsParams struct {
+	AppName     string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	File        string `json:"file" pos:"true" desc:"Conflicted file to resolve (relative to app directory); omit to list the conflicts"`
+	Hunk        int    `json:"hunk" desc:"Number of the hunk in file to resolve (default: all hunks)"`
+	Choice      string `json:"choice" desc:"How to resolve: ours, theirs, base, both (ours followed by theirs) or content"`
+	Content     string `json:"content" file:"true" desc:"Text replacing the hunk, or the whole file when no hunk is given, for choice content"`
+	ShowSecrets bool   `json:"show_secrets" desc:"Show hunks of protected files and secrets instead of redacting them"`
+}
+
+// GitConflicts lists the files with unresolved conflicts and the hunks in each. Unless
+// showSecrets is set, hunks of protected files are hidden and secrets in the rest are redacted.
+func GitConflicts(ctx context.Context, appName string, showSecrets bool) (GitConflictsResult, error) {
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitConflictsResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitConflictsResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	result, err := listConflicts(ctx, repo, showSecrets)
+	if err != nil {
+		return GitConflictsResult{}, err
+	}
+	if len(result.Files) == 0 {
+		result.Message = "No conflicts"
+	} else {
+		result.Message = fmt.Sprintf("%d conflicted file(s)", len(result.Files))
+	}
+	return result, nil
+}
+
+// GitResolveConflict resolves hunk number hunk of a conflicted file, or all of its hunks when
+// hunk is 0, with the side chosen or with content. A file left without conflicts is staged.
+// Files without hunks are resolved as a whole with ours, theirs or content.
+func GitResolveConflict(ctx context.Context, appName, file string, hunk int, choice, content string, showSecrets bool) (GitConflictsResult, error) {
+	if !slices.Contains([]string{ChoiceOurs, ChoiceTheirs, ChoiceBase, ChoiceBoth, ChoiceContent}, choice) {
+		return GitConflictsResult{}, fmt.Errorf("invalid choice: %s (must be ours, theirs, base, both or content)", choice)
+	}
+	if hunk < 0 {
+		return GitConflictsResult{}, fmt.Errorf("hunk must not be negative")
+	}
+	// Redacted output from git_conflicts must not replace the secrets it hid
+	if choice == ChoiceContent && secrets.HasRedactions(content) {
+		return GitConflictsResult{}, fmt.Errorf("content contains redaction markers; write the actual values or choose a side")
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitConflictsResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitConflictsResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	rel, err := repo.Path.RelNoFollow(file)
+	if err != nil {
+		return GitConflictsResult{}, fmt.Errorf("invalid file path: %w", err)
+	}
+	if !slices.Contains(conflictedFiles(ctx, repo), rel) {
+		return GitConflictsResult{}, fmt.Errorf("%s has no unresolved conflicts", rel)
+	}
+
+	var segments []conflictSegment
+	data, err := repo.Path.ReadFile(rel)
+	if err == nil && !bytes.Contains(data, []byte{0}) {
+		segments = parseConflicts(string(data))
+	}
+	hunks := conflictHunks(segments)
+
+	resolved := 0
+	switch {
+	case len(hunks) == 0 || (hunk == 0 && choice == ChoiceContent):
+		if hunk != 0 {
+			return GitConflictsResult{}, fmt.Errorf("%s has no conflict hunks; resolve it as a whole", rel)
+		}
+		if err := resolveFile(ctx, repo, rel, choice, content); err != nil {
+			return GitConflictsResult{}, err
+		}
+		resolved = max(len(hunks), 1)
+	default:
+		text, n, err := resolveHunks(segments, hunk, choice, content)
+		if err != nil {
+			return GitConflictsResult{}, err
+		}
+		if err := writeResolved(repo, rel, text); err != nil {
+			return GitConflictsResult{}, err
+		}
+		resolved = n
+	}
+
+	staged := resolved >= len(hunks)
+	if staged {
+		if _, err := repo.Run(ctx, "add", "-A", "--", rel); err != nil {
+			return GitConflictsResult{}, err
+		}
+	}
+
+	result, err := listConflicts(ctx, repo, showSecrets)
+	if err != nil {
+		return GitConflictsResult{}, err
+	}
+	result.Resolved = resolved
+	result.Staged = staged
+	switch {
+	case !staged:
+		result.Message = fmt.Sprintf("Resolved %d hunk(s) in %s; %d left", resolved, rel, len(hunks)-resolved)
+	case len(result.Files) > 0:
+		result.Message = fmt.Sprintf("Resolved and staged %s; %d conflicted file(s) left", rel, len(result.Files))
+	case result.Operation != "":
+		result.Message = fmt.Sprintf("Resolved and staged %s; all conflicts are resolved, continue the %s", rel, strings.ReplaceAll(result.Operation, "_", "-"))
+	default:
+		result.Message = fmt.Sprintf("Resolved and staged %s; all conflicts are resolved", rel)
+	}
+	return result, nil
+}
+
+// resolveFile resolves a conflicted file as a whole: with content, or with one side's version,
+// removing the file if that side deleted it
+func resolveFile(ctx context.Context, repo *Repo, rel, choice, content string) error {
+	switch choice {
+	case ChoiceContent:
+		return writeResolved(repo, rel, content)
+	case ChoiceOurs, ChoiceTheirs:
+		_, err := repo.Run(ctx, "checkout", "--"+choice, "--", rel)
+		var gitErr *Error
+		if errors.As(err, &gitErr) && strings.Contains(gitErr.Stderr, "does not have") {
+			_, err = repo.Run(ctx, "rm", "-q", "--", rel)
+		}
+		return err
+	}
+	return fmt.Errorf("%s has no conflict hunks; resolve it with ours, theirs or content", rel)
+}
+
+// writeResolved replaces a conflicted file's contents, keeping its permissions
+func writeResolved(repo *Repo, rel, text string) error {
+	perm := os.FileMode(0644)
+	if info, err := repo.Path.Stat(rel); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := repo.Path.WriteFile(rel, []byte(text), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// listConflicts returns the conflicted files with their hunks and the operation in progress
+func listConflicts(ctx context.Context, repo *Repo, showSecrets bool) (GitConflictsResult, error) {
+	output, err := repo.Run(ctx, "status", "--porcelain=v2", "-z")
+	if err != nil {
+		return GitConflictsResult{}, err
+	}
+
+	result := GitConflictsResult{IsRepo: true, Files: []ConflictFile{}, Operation: repo.operation(ctx)}
+	for _, entry := range parseStatus(output).Entries {
+		if entry.Conflict == "" {
+			continue
+		}
+		file := ConflictFile{Path: entry.Path, Conflict: entry.Conflict}
+		if !showSecrets && repo.Path.Protected(entry.Path) {
+			file.Protected = true
+		} else if data, err := repo.Path.ReadFile(entry.Path); err == nil && !bytes.Contains(data, []byte{0}) {
+			file.Hunks = conflictHunks(parseConflicts(string(data)))
+		}
+		if !showSecrets {
+			for i := range file.Hunks {
+				h := &file.Hunks[i]
+				for _, text := range []*string{&h.Ours, &h.Base, &h.Theirs} {
+					var n int
+					*text, n = secrets.Redact(*text)
+					result.Redacted += n
+				}
+			}
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result, nil
+}
+
+// Tool
+func gitConflictsTool() registry.Tool {
+	return registry.New(registry.Spec[GitConflictsParams, GitConflictsResult]{
+		Name:        "git_conflicts",
+		Group:       "git",
+		Description: "List the conflicts a merge, rebase or cherry-pick stopped on, with ours, base and theirs for each hunk, and resolve them hunk by hunk (requires git to be installed)",
+		Summary:     "List and resolve merge conflicts",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"Without --file, lists the conflicted files and their hunks; with --file and --choice, resolves --hunk (or every hunk) and stages the file once no conflicts are left",
+			"Files deleted on one side or binary have no hunks; resolve them as a whole with ours, theirs or content",
+			"Hunks of protected files (files.protected) are hidden and secrets are redacted unless --show-secrets is set",
+		},
+		Examples: []string{
+			"# List the conflicts\nlayered-code tool git_conflicts myapp",
+			"# Keep the incoming version of the second hunk\nlayered-code tool git_conflicts myapp src/App.jsx --hunk 2 --choice theirs",
+			"# Replace a hunk with merged text\nlayered-code tool git_conflicts myapp src/App.jsx --hunk 1 --choice content --content-file merged.txt",
+		},
+		Run: func(ctx context.Context, params GitConflictsParams) (GitConflictsResult, error) {
+			if params.File == "" {
+				if params.Choice != "" || params.Hunk != 0 {
+					return GitConflictsResult{}, fmt.Errorf("file is required to resolve a conflict")
+				}
+				return GitConflicts(ctx, params.AppName, params.ShowSecrets)
+			}
+			if params.Choice == "" {
+				return GitConflictsResult{}, fmt.Errorf("choice is required to resolve a conflict")
+			}
+			return GitResolveConflict(ctx, params.AppName, params.File, params.Hunk, params.Choice, params.Content, params.ShowSecrets)
+		},
+		Render: func(w io.Writer, _ GitConflictsParams, result GitConflictsResult) {
+			fmt.Fprintln(w, result.Message)
+			if !result.IsRepo {
+				return
+			}
+
+			for _, file := range result.Files {
+				fmt.Fprintf(w, "\n%s (%s)\n", file.Path, strings.ReplaceAll(file.Conflict, "_", " "))
+				if file.Protected {
+					fmt.Fprintln(w, "  protected; use --show-secrets to see its hunks")
+				}
+				for _, hunk := range file.Hunks {
+					fmt.Fprintf(w, "  Hunk %d at line %d\n", hunk.Number, hunk.Line)
+					renderSide(w, "ours", hunk.OursLabel, hunk.Ours)
+					if hunk.HasBase {
+						renderSide(w, "base", "", hunk.Base)
+					}
+					renderSide(w, "theirs", hunk.TheirsLabel, hunk.Theirs)
+				}
+			}
+		},
+	})
+}
+
+// renderSide prints one side of a conflict hunk, indented
+func renderSide(w io.Writer, side, label, text string) {
+	if label != "" {
+		side = fmt.Sprintf("%s (%s)", side, label)
+	}
+	fmt.Fprintf(w, "    %s:\n", side)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fmt.Fprintf(w, "      %s\n", line)
+	}
+}
@@ -0,0 +1,183 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ways to resolve a conflict hunk
+const (
+	ChoiceOurs    = "ours"
+	ChoiceTheirs  = "theirs"
+	ChoiceBase    = "base"
+	ChoiceBoth    = "both"
+	ChoiceContent = "content"
+)
+
+// ConflictHunk is a region git couldn't merge, between <<<<<<< and >>>>>>> markers. Ours is the
+// current branch's version, Theirs the version being merged in and Base their common ancestor,
+// which git_merge, git_rebase and git_cherry_pick always record.
+type ConflictHunk struct {
+	Number      int    `json:"number"` // 1-based, in file order
+	Line        int    `json:"line"`   // Line of the <<<<<<< marker
+	OursLabel   string `json:"ours_label,omitempty"`
+	TheirsLabel string `json:"theirs_label,omitempty"`
+	Ours        string `json:"ours"`
+	Base        string `json:"base,omitempty"`
+	Theirs      string `json:"theirs"`
+	HasBase     bool   `json:"has_base,omitempty"`
+}
+
+// conflictSegment is a stretch of a conflicted file: plain text, or a hunk when hunk is set.
+// The text of a hunk is the hunk as it appears in the file, markers included.
+type conflictSegment struct {
+	text string
+	hunk *ConflictHunk
+}
+
+// parseConflicts splits a file into plain text and conflict hunks. Markers that don't form a
+// complete hunk are kept as text.
+func parseConflicts(content string) []conflictSegment {
+	const (
+		inText = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	var segments []conflictSegment
+	var text, raw strings.Builder // raw keeps the lines of the open hunk in case it never closes
+	var hunk *ConflictHunk
+	state, number := inText, 0
+
+	flushText := func() {
+		if text.Len() > 0 {
+			segments = append(segments, conflictSegment{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if state != inText {
+			raw.WriteString(line)
+		}
+
+		switch state {
+		case inText:
+			if label, ok := conflictMarker(line, '<'); ok {
+				number++
+				hunk = &ConflictHunk{Number: number, Line: i + 1, OursLabel: label}
+				raw.Reset()
+				raw.WriteString(line)
+				state = inOurs
+				continue
+			}
+			text.WriteString(line)
+		case inOurs:
+			if _, ok := conflictMarker(line, '|'); ok {
+				hunk.HasBase = true
+				state = inBase
+			} else if isSeparator(line) {
+				state = inTheirs
+			} else {
+				hunk.Ours += line
+			}
+		case inBase:
+			if isSeparator(line) {
+				state = inTheirs
+			} else {
+				hunk.Base += line
+			}
+		case inTheirs:
+			if label, ok := conflictMarker(line, '>'); ok {
+				hunk.TheirsLabel = label
+				flushText()
+				segments = append(segments, conflictSegment{text: raw.String(), hunk: hunk})
+				state = inText
+			} else {
+				hunk.Theirs += line
+			}
+		}
+	}
+
+	if state != inText {
+		text.WriteString(raw.String())
+	}
+	flushText()
+	return segments
+}
+
+// conflictMarker reports whether line is a marker of seven marker characters, optionally
+// followed by a space and a label, and returns the label
+func conflictMarker(line string, marker byte) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) < 7 || line[:7] != strings.Repeat(string(marker), 7) {
+		return "", false
+	}
+	if len(line) > 7 && line[7] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(line[7:]), true
+}
+
+// isSeparator reports whether line is the ======= between the sides of a hunk
+func isSeparator(line string) bool {
+	return strings.TrimRight(line, "\r\n") == "======="
+}
+
+// conflictHunks returns the hunks of a parsed file
+func conflictHunks(segments []conflictSegment) []ConflictHunk {
+	var hunks []ConflictHunk
+	for _, segment := range segments {
+		if segment.hunk != nil {
+			hunks = append(hunks, *segment.hunk)
+		}
+	}
+	return hunks
+}
+
+// resolveHunks replaces hunk number (0 for all of them) with the side chosen, or with content,
+// and returns the new file and the number of hunks resolved
+func resolveHunks(segments []conflictSegment, number int, choice, content string) (string, int, error) {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	var b strings.Builder
+	resolved := 0
+	for _, segment := range segments {
+		hunk := segment.hunk
+		if hunk == nil || (number != 0 && hunk.Number != number) {
+			b.WriteString(segment.text)
+			continue
+		}
+
+		switch choice {
+		case ChoiceOurs:
+			b.WriteString(hunk.Ours)
+		case ChoiceTheirs:
+			b.WriteString(hunk.Theirs)
+		case ChoiceBase:
+			if !hunk.HasBase {
+				return "", 0, fmt.Errorf("hunk %d has no base version", hunk.Number)
+			}
+			b.WriteString(hunk.Base)
+		case ChoiceBoth:
+			b.WriteString(hunk.Ours)
+			b.WriteString(hunk.Theirs)
+		case ChoiceContent:
+			b.WriteString(content)
+		default:
+			return "", 0, fmt.Errorf("invalid choice: %s (must be ours, theirs, base, both or content)", choice)
+		}
+		resolved++
+	}
+
+	if number != 0 && resolved == 0 {
+		return "", 0, fmt.Errorf("no conflict hunk %d", number)
+	}
+	return b.String(), resolved, nil
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const conflicted = "header\n" +
+	"<<<<<<< HEAD\n" +
+	"ours one\n" +
+	"||||||| base\n" +
+	"base one\n" +
+	"=======\n" +
+	"theirs one\n" +
+	">>>>>>> feature\n" +
+	"middle\n" +
+	"<<<<<<< HEAD\n" +
+	"ours two\n" +
+	"=======\n" +
+	"theirs two\n" +
+	"more theirs\n" +
+	">>>>>>> feature\n" +
+	"footer\n"
+
+func TestParseConflicts(t *testing.T) {
+	hunks := conflictHunks(parseConflicts(conflicted))
+	if len(hunks) != 2 {
+		t.Fatalf("conflictHunks() = %+v; want 2 hunks", hunks)
+	}
+
+	want := ConflictHunk{Number: 1, Line: 2, OursLabel: "HEAD", TheirsLabel: "feature",
+		Ours: "ours one\n", Base: "base one\n", Theirs: "theirs one\n", HasBase: true}
+	if hunks[0] != want {
+		t.Errorf("hunk 1 = %+v; want %+v", hunks[0], want)
+	}
+	want = ConflictHunk{Number: 2, Line: 10, OursLabel: "HEAD", TheirsLabel: "feature",
+		Ours: "ours two\n", Theirs: "theirs two\nmore theirs\n"}
+	if hunks[1] != want {
+		t.Errorf("hunk 2 = %+v; want %+v", hunks[1], want)
+	}
+
+	// Unparsed text round-trips
+	var b strings.Builder
+	for _, segment := range parseConflicts(conflicted) {
+		b.WriteString(segment.text)
+	}
+	if b.String() != conflicted {
+		t.Errorf("segments joined = %q; want the original file", b.String())
+	}
+}
+
+func TestParseConflictsIncomplete(t *testing.T) {
+	content := "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n"
+	segments := parseConflicts(content)
+	if hunks := conflictHunks(segments); len(hunks) != 0 {
+		t.Errorf("conflictHunks() = %+v; want none for an unclosed hunk", hunks)
+	}
+	if len(segments) != 1 || segments[0].text != content {
+		t.Errorf("parseConflicts() = %+v; want the file as one text segment", segments)
+	}
+
+	// Lines merely starting with marker characters aren't markers
+	if hunks := conflictHunks(parseConflicts("<<<<<<<<\n=======\n>>>>>>>x\n")); len(hunks) != 0 {
+		t.Errorf("conflictHunks() = %+v; want none", hunks)
+	}
+}
+
+func TestResolveHunks(t *testing.T) {
+	segments := parseConflicts(conflicted)
+	tests := []struct {
+		number   int
+		choice   string
+		content  string
+		want     string
+		resolved int
+	}{
+		{1, ChoiceOurs, "", "header\nours one\nmiddle\n", 1},
+		{1, ChoiceBase, "", "header\nbase one\nmiddle\n", 1},
+		{2, ChoiceTheirs, "", "middle\ntheirs two\nmore theirs\nfooter\n", 1},
+		{2, ChoiceBoth, "", "middle\nours two\ntheirs two\nmore theirs\nfooter\n", 1},
+		{2, ChoiceContent, "merged", "middle\nmerged\nfooter\n", 1},
+		{0, ChoiceTheirs, "", "header\ntheirs one\nmiddle\ntheirs two\nmore theirs\nfooter\n", 2},
+	}
+	for _, tt := range tests {
+		got, resolved, err := resolveHunks(segments, tt.number, tt.choice, tt.content)
+		if err != nil {
+			t.Errorf("resolveHunks(%d, %s) error = %v", tt.number, tt.choice, err)
+			continue
+		}
+		if !strings.Contains(got, tt.want) || resolved != tt.resolved {
+			t.Errorf("resolveHunks(%d, %s) = %q, %d; want it to contain %q, %d", tt.number, tt.choice, got, resolved, tt.want, tt.resolved)
+		}
+		if tt.number == 0 && strings.Contains(got, "<<<<<<<") {
+			t.Errorf("resolveHunks(0, %s) left markers: %q", tt.choice, got)
+		}
+	}
+
+	if _, _, err := resolveHunks(segments, 2, ChoiceBase, ""); err == nil {
+		t.Error("resolveHunks() with base on a hunk without one should fail")
+	}
+	if _, _, err := resolveHunks(segments, 3, ChoiceOurs, ""); err == nil {
+		t.Error("resolveHunks() of a missing hunk should fail")
+	}
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+// Environment through which Exec hands a credential's token to Askpass
+const (
+	askpassEnvVar         = "LAYERED_ASKPASS"
+	askpassUsernameEnvVar = "LAYERED_ASKPASS_USERNAME"
+	askpassPasswordEnvVar = "LAYERED_ASKPASS_PASSWORD"
+)
+
+// networkCommands talk to remotes. They run with the credential of git.credentials matching
+// the remote, and their output is redacted like that of remote.
+var networkCommands = []string{"push", "pull", "fetch", "ls-remote"}
+
+// remoteCredential is what Exec adds to a network command for a credential
+type remoteCredential struct {
+	args  []string // Options before the subcommand
+	env   []string
+	token string // Masked in the output
+}
+
+// Askpass answers git's prompt for a user name or password when git runs this program as
+// GIT_ASKPASS for a credential, and reports whether it did. args are the program's arguments;
+// git passes the prompt as the only one.
+func Askpass(args []string) (string, bool) {
+	if os.Getenv(askpassEnvVar) != "1" || len(args) != 2 {
+		return "", false
+	}
+	switch prompt := args[1]; {
+	case strings.HasPrefix(prompt, "Username for "):
+		return os.Getenv(askpassUsernameEnvVar), true
+	case strings.HasPrefix(prompt, "Password for "):
+		return os.Getenv(askpassPasswordEnvVar), true
+	}
+	return "", false
+}
+
+// credential returns what runs a network command with the credential matching its remote, or
+// nil if git.credentials has none
+func (r *Repo) credential(ctx context.Context, args []string) (*remoteCredential, error) {
+	settings := config.GitSettings()
+	if len(settings.Credentials) == 0 {
+		return nil, nil
+	}
+	remote, url := r.remoteOf(ctx, args)
+	name, credential, ok := settings.CredentialFor(remote, url)
+	if !ok {
+		return nil, nil
+	}
+	result, err := credentialEnvironment(credential, url)
+	if err != nil {
+		return nil, fmt.Errorf("git.credentials.%s: %w", name, err)
+	}
+	return result, nil
+}
+
+// credentialEnvironment returns what runs git with a credential for a remote URL: ssh with the
+// credential's key and host key checking, and for HTTPS its token through Askpass instead of
+// the credential helpers git is configured with
+func credentialEnvironment(credential config.GitCredential, url string) (*remoteCredential, error) {
+	result := &remoteCredential{}
+
+	key, err := credential.SSHKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	if key != "" || credential.KnownHosts != "" {
+		command := "ssh -o BatchMode=yes"
+		if key != "" {
+			command += " -o IdentitiesOnly=yes -i " + shellQuote(key)
+		}
+		switch credential.KnownHosts {
+		case config.KnownHostsAcceptNew:
+			command += " -o StrictHostKeyChecking=accept-new"
+		case config.KnownHostsOff:
+			command += " -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+		default:
+			command += " -o StrictHostKeyChecking=yes"
+		}
+		result.env = append(result.env, "GIT_SSH_COMMAND="+command)
+	}
+
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return result, nil
+	}
+	token, err := credential.Token()
+	if err != nil || token == "" {
+		return result, err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	result.args = []string{"-c", "credential.helper="}
+	result.env = append(result.env,
+		"GIT_ASKPASS="+executable,
+		askpassEnvVar+"=1",
+		askpassUsernameEnvVar+"="+credential.User(),
+		askpassPasswordEnvVar+"="+token,
+	)
+	result.token = token
+	return result, nil
+}
+
+// remoteOf returns the remote a network command talks to and its URL: the first argument after
+// the subcommand that isn't an option, which names a remote or is a URL, or else origin, or
+// else the only remote
+func (r *Repo) remoteOf(ctx context.Context, args []string) (string, string) {
+	direction := "(fetch)"
+	if subcommand(args) == "push" {
+		direction = "(push)"
+	}
+	urls := make(map[string]string)
+	if output, err := r.Run(ctx, "remote", "-v"); err == nil {
+		for _, line := range strings.Split(output, "\n") {
+			if parts := strings.Fields(line); len(parts) == 3 && parts[2] == direction {
+				urls[parts[0]] = parts[1]
+			}
+		}
+	}
+
+	for _, arg := range args[subcommandIndex(args)+1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if url, ok := urls[arg]; ok {
+			return arg, url
+		}
+		return "", arg
+	}
+	if url, ok := urls["origin"]; ok {
+		return "origin", url
+	}
+	if len(urls) == 1 {
+		for name, url := range urls {
+			return name, url
+		}
+	}
+	return "", ""
+}
+
+// redactCredentials masks credentials in the output of a command that talks to remotes or
+// lists them: the token it was given, and passwords and tokens in URLs
+func redactCredentials(text, token string) string {
+	text, _ = secrets.RedactValue(text, token, "git-credential")
+	text, _ = secrets.RedactURLs(text)
+	return text
+}
+
+// redactsCredentials reports whether Exec redacts the output of the subcommand
+func redactsCredentials(subcommand string) bool {
+	return subcommand == "remote" || slices.Contains(networkCommands, subcommand)
+}
+
+// shellQuote quotes s for the shell git runs GIT_SSH_COMMAND with
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
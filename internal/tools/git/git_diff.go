@@ -1,188 +1,215 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitDiffResult struct {
-	Diff        string `json:"diff"`
-	HasDiff     bool   `json:"has_diff"`
-	IsRepo      bool   `json:"is_repo"`
-	Message     string `json:"message,omitempty"`
-	FileCount   int    `json:"file_count"`
-	ErrorOutput string `json:"error_output,omitempty"`
+	Diff        string     `json:"diff,omitempty"`
+	Files       []DiffFile `json:"files,omitempty"`
+	HasDiff     bool       `json:"has_diff"`
+	IsRepo      bool       `json:"is_repo"`
+	Message     string     `json:"message,omitempty"`
+	FileCount   int        `json:"file_count"`
+	Additions   int        `json:"additions"`
+	Deletions   int        `json:"deletions"`
+	Truncated   bool       `json:"truncated,omitempty"`
+	Redacted    int        `json:"redacted,omitempty"`
+	ErrorOutput string     `json:"error_output,omitempty"`
 }
 
-// GitDiff runs git diff command in the specified app directory
-func GitDiff(appName string, staged bool, filePath string) (GitDiffResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitDiffResult{}, err
-	}
+type GitDiffParams struct {
+	AppName     string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Staged      bool   `json:"staged" desc:"Show staged changes instead of unstaged"`
+	FilePath    string `json:"file_path" pos:"true" desc:"Specific file to diff (relative to app directory)"`
+	Ref         string `json:"ref" desc:"Commit or range to diff against instead of the index (e.g. HEAD~3, main..feature, v1.0...HEAD)"`
+	Structured  bool   `json:"structured" desc:"Return per-file entries with status, line counts and parsed hunks instead of diff text"`
+	Stat        bool   `json:"stat" desc:"Only list the changed files with their line counts"`
+	WordDiff    bool   `json:"word_diff" desc:"Show changed words instead of changed lines"`
+	MaxBytes    int    `json:"max_bytes" desc:"Largest diff to return; files that don't fit are truncated (default 100KB)"`
+	ShowSecrets bool   `json:"show_secrets" desc:"Show changes to protected files and secrets instead of redacting them"`
+}
 
-	if err := helpers.ValidateAppName(appName); err != nil {
+// GitDiffOptions configures GitDiff
+type GitDiffOptions struct {
+	Staged      bool
+	FilePath    string
+	Ref         string // Commit or range to diff against
+	Structured  bool   // Return Files with hunks instead of Diff
+	Stat        bool   // Only the changed files and their line counts
+	WordDiff    bool
+	MaxBytes    int  // 0 for constants.GitDiffMaxBytes
+	ShowSecrets bool // Don't hide protected files or redact secrets
+}
+
+// GitDiff runs git diff command in the specified app directory. Unless ShowSecrets is set,
+// changes to protected files are hidden and secrets in the rest are redacted.
+func GitDiff(ctx context.Context, appName string, options GitDiffOptions) (GitDiffResult, error) {
+	if options.WordDiff && (options.Structured || options.Stat) {
+		return GitDiffResult{}, fmt.Errorf("word_diff can't be combined with structured or stat")
+	}
+	if options.MaxBytes < 0 {
+		return GitDiffResult{}, fmt.Errorf("max_bytes must not be negative")
+	}
+	if options.MaxBytes == 0 {
+		options.MaxBytes = constants.GitDiffMaxBytes
+	}
+	if err := validateRef(options.Ref); err != nil {
 		return GitDiffResult{}, err
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitDiffResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitDiffResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitDiffResult{
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
-	// Build git diff command
-	args := []string{"diff"}
-	if staged {
+	// Build git diff command; unquoted paths keep the output parseable
+	args := []string{"-c", "core.quotePath=off", "diff", "--no-color", "--no-ext-diff", "-M"}
+	if options.Staged {
 		args = append(args, "--staged")
 	}
-	
+	if options.WordDiff {
+		args = append(args, "--word-diff=plain")
+	}
+
 	// Add unified context
 	args = append(args, "-U3")
-	
+
+	if options.Ref != "" {
+		args = append(args, options.Ref)
+	}
+
 	// If a specific file path is provided, validate and add it
-	if filePath != "" {
+	if options.FilePath != "" {
 		// Validate the file path to ensure it's within the app directory
-		cleanPath := filepath.Clean(filePath)
-		if strings.HasPrefix(cleanPath, "..") || filepath.IsAbs(cleanPath) {
-			return GitDiffResult{}, fmt.Errorf("invalid file path: must be relative to app directory")
+		rel, err := repo.Path.RelNoFollow(options.FilePath)
+		if err != nil {
+			return GitDiffResult{}, fmt.Errorf("invalid file path: %w", err)
 		}
-		args = append(args, "--", cleanPath)
+		args = append(args, "--", rel)
 	}
 
 	// Run git diff
-	diffCmd := exec.Command("git", args...)
-	diffCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	diffCmd.Stdout = &outBuf
-	diffCmd.Stderr = &errBuf
-	err = diffCmd.Run()
+	output, errOutput, err := repo.Exec(ctx, nil, args...)
 	if err != nil {
 		// Check if it's just an empty diff
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			// Empty diff is not an error
-		} else {
-			return GitDiffResult{}, fmt.Errorf("failed to run git diff: %w", err)
-		}
-	}
-
-	diff := outBuf.String()
-	hasDiff := len(strings.TrimSpace(diff)) > 0
-
-	// Count the number of files in the diff
-	fileCount := 0
-	if hasDiff {
-		lines := strings.Split(diff, "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "diff --git") {
-				fileCount++
-			}
+		var gitErr *Error
+		if !errors.As(err, &gitErr) || gitErr.ExitCode != 1 {
+			return GitDiffResult{}, err
 		}
 	}
 
-	return GitDiffResult{
-		Diff:        diff,
-		HasDiff:     hasDiff,
+	_, files := parseDiff(output)
+	result := GitDiffResult{
+		HasDiff:     len(files) > 0,
 		IsRepo:      true,
-		FileCount:   fileCount,
-		ErrorOutput: errBuf.String(),
-	}, nil
-}
-
-// CLI
-func GitDiffCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_diff requires at least 1 argument: app_name\nUsage: layered-code tool git_diff <app_name> [--staged] [file_path]")
+		FileCount:   len(files),
+		ErrorOutput: errOutput,
 	}
-
-	appName := args[0]
-	staged := false
-	filePath := ""
-
-	// Parse additional arguments
-	for i := 1; i < len(args); i++ {
-		if args[i] == "--staged" {
-			staged = true
-		} else if !strings.HasPrefix(args[i], "--") {
-			filePath = args[i]
-		}
-	}
-
-	result, err := GitDiff(appName, staged, filePath)
-	if err != nil {
-		return fmt.Errorf("failed to get git diff: %w", err)
+	for _, file := range files {
+		result.Additions += file.Additions
+		result.Deletions += file.Deletions
 	}
 
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+	if !options.ShowSecrets {
+		result.Redacted = redactFiles(repo.Path, files)
 	}
-
-	if !result.HasDiff {
-		if staged {
-			fmt.Println("No staged changes")
-		} else {
-			fmt.Println("No changes in working directory")
+	if options.Stat {
+		for i := range files {
+			files[i].Hunks = nil
 		}
-		return nil
-	}
-
-	// Print the diff
-	fmt.Print(result.Diff)
-
-	return nil
-}
-
-// MCP
-func GitDiffMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName  string `json:"app_name"`
-		Staged   bool   `json:"staged"`
-		FilePath string `json:"file_path"`
+	} else {
+		result.Truncated = truncateFiles(files, options.MaxBytes)
 	}
 
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
+	switch {
+	case options.Structured:
+		result.Files = files
+	case options.Stat:
+		result.Diff = formatStat(files)
+	default:
+		result.Diff = formatDiff(files)
 	}
+	return result, nil
+}
 
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
+// validateRef rejects refs git would take for options
+func validateRef(ref string) error {
+	if strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n\x00") {
+		return fmt.Errorf("invalid ref: %q", ref)
 	}
+	return nil
+}
 
-	result, err := GitDiff(args.AppName, args.Staged, args.FilePath)
-	if err != nil {
-		return nil, err
-	}
+// Tool
+func gitDiffTool() registry.Tool {
+	return registry.New(registry.Spec[GitDiffParams, GitDiffResult]{
+		Name:        "git_diff",
+		Group:       "git",
+		Description: "Show changes between commits, commit and working tree, etc (requires git to be installed)",
+		Summary:     "Show changes between commits",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Changes to protected files (files.protected) are hidden and secrets are redacted unless --show-secrets is set",
+			"Files whose changes don't fit in --max-bytes are listed without their hunks and marked truncated",
+			"--structured returns per-file entries with parsed hunks; --stat only the changed files and line counts",
+			"Structured hunks carry IDs that stay the same while other hunks are staged; pass them to git_add or git_restore to stage, unstage or discard single hunks",
+		},
+		Examples: []string{
+			"# Show unstaged changes\nlayered-code tool git_diff myapp",
+			"# Compare a branch with main, files only\nlayered-code tool git_diff myapp --ref main...feature --stat",
+			"# Show word changes in one file since the last commit\nlayered-code tool git_diff myapp src/App.jsx --ref HEAD --word-diff",
+		},
+		Run: func(ctx context.Context, params GitDiffParams) (GitDiffResult, error) {
+			return GitDiff(ctx, params.AppName, GitDiffOptions{
+				Staged:      params.Staged,
+				FilePath:    params.FilePath,
+				Ref:         params.Ref,
+				Structured:  params.Structured,
+				Stat:        params.Stat,
+				WordDiff:    params.WordDiff,
+				MaxBytes:    params.MaxBytes,
+				ShowSecrets: params.ShowSecrets,
+			})
+		},
+		Render: func(w io.Writer, params GitDiffParams, result GitDiffResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
+			if !result.HasDiff {
+				if params.Staged {
+					fmt.Fprintln(w, "No staged changes")
+				} else {
+					fmt.Fprintln(w, "No changes in working directory")
+				}
+				return
+			}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+			if params.Structured {
+				fmt.Fprint(w, formatStat(result.Files))
+				fmt.Fprintf(w, "\n%d file(s) changed, %d insertion(s), %d deletion(s)\n", result.FileCount, result.Additions, result.Deletions)
+				return
+			}
+			fmt.Fprint(w, result.Diff)
+			if result.Truncated {
+				fmt.Fprintln(w, "\nSome files were truncated; diff them alone or raise --max-bytes to see them")
+			}
+		},
+	})
+}
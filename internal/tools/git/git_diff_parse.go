@@ -0,0 +1,282 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/helpers"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+// Statuses of a file in a diff
+const (
+	DiffAdded    = "added"
+	DiffDeleted  = "deleted"
+	DiffModified = "modified"
+	DiffRenamed  = "renamed"
+	DiffCopied   = "copied"
+)
+
+// DiffFile is the change to one file in a diff
+type DiffFile struct {
+	OldPath    string     `json:"old_path,omitempty"`
+	NewPath    string     `json:"new_path,omitempty"`
+	Status     string     `json:"status"`
+	Similarity int        `json:"similarity,omitempty"` // Percent, for renames and copies
+	Binary     bool       `json:"binary,omitempty"`
+	Additions  int        `json:"additions"`
+	Deletions  int        `json:"deletions"`
+	Hunks      []DiffHunk `json:"hunks,omitempty"`
+	Protected  bool       `json:"protected,omitempty"` // Changes hidden by files.protected
+	Truncated  bool       `json:"truncated,omitempty"` // Hunks left out by the size cap
+
+	header   []string // Lines from "diff --git" up to the first hunk
+	redacted bool     // Secrets were redacted from the hunks
+}
+
+// DiffHunk is a block of changed lines with its context. ID is derived from the file and the
+// hunk's lines, so it stays the same while other hunks of the file are staged or discarded.
+type DiffHunk struct {
+	ID       string   `json:"id"`
+	Header   string   `json:"header"`
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// Path returns the path of the file after the change, or before it for deletions
+func (f DiffFile) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// size is the length of the file's hunks in diff output
+func (f DiffFile) size() int {
+	n := 0
+	for _, h := range f.Hunks {
+		n += len(h.Header) + 1
+		for _, line := range h.Lines {
+			n += len(line) + 1
+		}
+	}
+	return n
+}
+
+// parseDiff splits git diff output into whatever precedes the first file (the commit header of
+// git show) and the files. It expects paths unquoted, as with core.quotePath=off.
+func parseDiff(diff string) (string, []DiffFile) {
+	var preamble strings.Builder
+	var files []DiffFile
+	var file *DiffFile
+	var hunk *DiffHunk
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, newDiffFile(line))
+			file, hunk = &files[len(files)-1], nil
+		case file == nil:
+			preamble.WriteString(line + "\n")
+		case strings.HasPrefix(line, "@@ "):
+			file.Hunks = append(file.Hunks, newDiffHunk(line))
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+			if strings.HasPrefix(line, "+") {
+				file.Additions++
+			} else if strings.HasPrefix(line, "-") {
+				file.Deletions++
+			}
+		default:
+			file.header = append(file.header, line)
+			parseDiffHeader(file, line)
+		}
+	}
+	for i := range files {
+		setHunkIDs(&files[i])
+	}
+	return preamble.String(), files
+}
+
+// setHunkIDs names each hunk of a file by a hash of its path and lines; identical hunks are
+// told apart by their order
+func setHunkIDs(file *DiffFile) {
+	seen := make(map[string]int)
+	for i := range file.Hunks {
+		hash := sha1.Sum([]byte(file.Path() + "\x00" + strings.Join(file.Hunks[i].Lines, "\n")))
+		id := hex.EncodeToString(hash[:4])
+		if seen[id]++; seen[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, seen[id])
+		}
+		file.Hunks[i].ID = id
+	}
+}
+
+// newDiffFile starts a file from its "diff --git a/old b/new" line
+func newDiffFile(line string) DiffFile {
+	paths := strings.TrimPrefix(line, "diff --git ")
+	file := DiffFile{Status: DiffModified, header: []string{line}}
+	// The paths are the same unless the file was renamed or copied, whose headers name them
+	if half := len(paths) / 2; len(paths)%2 == 1 && paths[half] == ' ' && strings.HasPrefix(paths, "a/") &&
+		paths[2:half] == strings.TrimPrefix(paths[half+1:], "b/") {
+		file.OldPath, file.NewPath = paths[2:half], paths[2:half]
+	} else if a, b, ok := strings.Cut(paths, " b/"); ok {
+		file.OldPath, file.NewPath = strings.TrimPrefix(a, "a/"), b
+	}
+	return file
+}
+
+// parseDiffHeader reads one extended header line of a file
+func parseDiffHeader(file *DiffFile, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode"):
+		file.Status, file.OldPath = DiffAdded, ""
+	case strings.HasPrefix(line, "deleted file mode"):
+		file.Status, file.NewPath = DiffDeleted, ""
+	case strings.HasPrefix(line, "rename from "):
+		file.Status, file.OldPath = DiffRenamed, strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "copy from "):
+		file.Status, file.OldPath = DiffCopied, strings.TrimPrefix(line, "copy from ")
+	case strings.HasPrefix(line, "copy to "):
+		file.NewPath = strings.TrimPrefix(line, "copy to ")
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+		file.Binary = true
+	}
+}
+
+// newDiffHunk parses a hunk header: @@ -1,2 +3,4 @@ context
+func newDiffHunk(line string) DiffHunk {
+	hunk := DiffHunk{Header: line, OldLines: 1, NewLines: 1}
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return hunk
+	}
+	hunk.OldStart, hunk.OldLines = parseRange(strings.TrimPrefix(fields[1], "-"))
+	hunk.NewStart, hunk.NewLines = parseRange(strings.TrimPrefix(fields[2], "+"))
+	return hunk
+}
+
+// parseRange parses "start,count" or "start", where the count defaults to 1
+func parseRange(s string) (int, int) {
+	startStr, countStr, ok := strings.Cut(s, ",")
+	start, _ := strconv.Atoi(startStr)
+	if !ok {
+		return start, 1
+	}
+	count, _ := strconv.Atoi(countStr)
+	return start, count
+}
+
+// redactFiles hides the hunks of protected files and redacts secrets from the others, returning
+// the number of hidden files and secrets
+func redactFiles(safePath *helpers.SafePath, files []DiffFile) int {
+	count := 0
+	for i := range files {
+		file := &files[i]
+		if safePath.Protected(file.Path()) || (file.OldPath != "" && safePath.Protected(file.OldPath)) {
+			file.Hunks, file.Protected = nil, true
+			count++
+			continue
+		}
+		for h := range file.Hunks {
+			var n int
+			file.Hunks[h].Header, n = redactHunkHeader(file.Hunks[h].Header)
+			count += n
+			file.redacted = file.redacted || n > 0
+			for l, line := range file.Hunks[h].Lines {
+				file.Hunks[h].Lines[l], n = secrets.Redact(line)
+				count += n
+				file.redacted = file.redacted || n > 0
+			}
+		}
+	}
+	return count
+}
+
+// redactHunkHeader redacts the section text git puts after a hunk header. It is the nearest
+// preceding line of the file, so in a .env file it can be a KEY=value line.
+func redactHunkHeader(header string) (string, int) {
+	parts := strings.SplitN(header, "@@", 3)
+	if len(parts) < 3 {
+		return header, 0
+	}
+	section, n := secrets.Redact(parts[2])
+	return parts[0] + "@@" + parts[1] + "@@" + section, n
+}
+
+// truncateFiles leaves out the hunks of files that don't fit in maxBytes, taking files in order
+// so one huge file doesn't hide the smaller ones after it. It reports whether any were left out.
+func truncateFiles(files []DiffFile, maxBytes int) bool {
+	truncated := false
+	remaining := maxBytes
+	for i := range files {
+		size := files[i].size()
+		if size <= remaining {
+			remaining -= size
+			continue
+		}
+		files[i].Hunks, files[i].Truncated = nil, true
+		truncated = true
+	}
+	return truncated
+}
+
+// formatDiff turns parsed files back into diff output, with notes in place of hidden hunks
+func formatDiff(files []DiffFile) string {
+	var b strings.Builder
+	for _, file := range files {
+		for _, line := range file.header {
+			b.WriteString(line + "\n")
+		}
+		switch {
+		case file.Protected:
+			b.WriteString("[protected file: changes hidden]\n")
+		case file.Truncated:
+			fmt.Fprintf(&b, "[diff truncated: +%d -%d lines; diff this file alone or raise max_bytes to see it]\n", file.Additions, file.Deletions)
+		}
+		for _, hunk := range file.Hunks {
+			b.WriteString(hunk.Header + "\n")
+			for _, line := range hunk.Lines {
+				b.WriteString(line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatStat summarizes the files of a diff, one line each
+func formatStat(files []DiffFile) string {
+	var b strings.Builder
+	for _, file := range files {
+		path := file.Path()
+		if file.Status == DiffRenamed || file.Status == DiffCopied {
+			path = file.OldPath + " -> " + file.NewPath
+		}
+		change := fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)
+		if file.Binary {
+			change = "binary"
+		}
+		fmt.Fprintf(&b, "%-9s %s (%s)\n", file.Status, path, change)
+	}
+	return b.String()
+}
+
+// redactDiff hides the changes to protected files in git diff output and redacts secrets from
+// the other changes, returning the number of hidden files and secrets
+func redactDiff(safePath *helpers.SafePath, diff string) (string, int) {
+	preamble, files := parseDiff(diff)
+	redacted, n := secrets.Redact(preamble)
+	n += redactFiles(safePath, files)
+	return redacted + formatDiff(files), n
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/src/app.js b/src/app.js
+index 1111111..2222222 100644
+--- a/src/app.js
++++ b/src/app.js
+@@ -1,3 +1,4 @@ import React
+ const a = 1
+-const b = 2
++const b = 3
++const c = 4
+ export default a
+@@ -10 +11 @@
+-old
++new
+\ No newline at end of file
+diff --git a/new file.txt b/new file.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new file.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-one
+-two
+diff --git a/old/name.js b/new/name.js
+similarity index 90%
+rename from old/name.js
+rename to new/name.js
+index 5555555..6666666 100644
+--- a/old/name.js
++++ b/new/name.js
+@@ -1 +1 @@
+-x
++y
+diff --git a/logo.png b/logo.png
+index 7777777..8888888 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	preamble, files := parseDiff("commit abc\nAuthor: A\n\n" + sampleDiff)
+	if preamble != "commit abc\nAuthor: A\n\n" {
+		t.Errorf("preamble = %q", preamble)
+	}
+
+	want := []DiffFile{
+		{OldPath: "src/app.js", NewPath: "src/app.js", Status: DiffModified, Additions: 3, Deletions: 2},
+		{NewPath: "new file.txt", Status: DiffAdded, Additions: 1},
+		{OldPath: "gone.txt", Status: DiffDeleted, Deletions: 2},
+		{OldPath: "old/name.js", NewPath: "new/name.js", Status: DiffRenamed, Similarity: 90, Additions: 1, Deletions: 1},
+		{OldPath: "logo.png", NewPath: "logo.png", Status: DiffModified, Binary: true},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("parseDiff() returned %d files; want %d", len(files), len(want))
+	}
+	for i, w := range want {
+		got := files[i]
+		if got.OldPath != w.OldPath || got.NewPath != w.NewPath || got.Status != w.Status || got.Similarity != w.Similarity ||
+			got.Binary != w.Binary || got.Additions != w.Additions || got.Deletions != w.Deletions {
+			t.Errorf("file %d = %+v; want %+v", i, got, w)
+		}
+	}
+
+	hunks := files[0].Hunks
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %+v", hunks)
+	}
+	if h := hunks[0]; h.OldStart != 1 || h.OldLines != 3 || h.NewStart != 1 || h.NewLines != 4 || len(h.Lines) != 5 {
+		t.Errorf("hunk 0 = %+v", h)
+	}
+	if h := hunks[1]; h.OldStart != 10 || h.OldLines != 1 || h.NewStart != 11 || h.NewLines != 1 || len(h.Lines) != 3 {
+		t.Errorf("hunk 1 = %+v", h)
+	}
+
+	if got := formatDiff(files); got != sampleDiff {
+		t.Errorf("formatDiff() doesn't reproduce the diff:\n%s", got)
+	}
+}
+
+func TestTruncateFiles(t *testing.T) {
+	_, files := parseDiff(sampleDiff)
+	big := files[0].size()
+
+	if truncateFiles(files, 1<<20) {
+		t.Error("expected nothing truncated with a large cap")
+	}
+
+	// The first file doesn't fit, the small ones after it still do
+	_, files = parseDiff(sampleDiff)
+	if !truncateFiles(files, big-1) {
+		t.Fatal("expected truncation")
+	}
+	if !files[0].Truncated || files[0].Hunks != nil || files[0].Additions != 3 {
+		t.Errorf("first file = %+v; want truncated with its counts", files[0])
+	}
+	for _, file := range files[1:] {
+		if file.Truncated {
+			t.Errorf("%s truncated; want it kept", file.Path())
+		}
+	}
+	if out := formatDiff(files); !strings.Contains(out, "[diff truncated: +3 -2 lines") || !strings.Contains(out, "+hello") {
+		t.Errorf("formatDiff() = %s", out)
+	}
+}
+
+func TestFormatStat(t *testing.T) {
+	_, files := parseDiff(sampleDiff)
+	got := formatStat(files)
+	for _, want := range []string{"modified  src/app.js (+3 -2)", "added     new file.txt (+1 -0)", "renamed   old/name.js -> new/name.js (+1 -1)", "logo.png (binary)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatStat() = %s; want it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/secrets"
+)
+
+// Reasons a check of changes about to be committed or pushed flags a file
+const (
+	ReasonSecret     = "secret"
+	ReasonLargeFile  = "large_file"
+	ReasonDeniedPath = "denied_path"
+)
+
+// CheckIssue is a problem found in changes about to be committed or pushed. Secrets are
+// reported by kind and line, never by value.
+type CheckIssue struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+	Line   int    `json:"line,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// checkStaged checks the staged changes for secrets, files over git.max_file_size and paths
+// matching git.deny_commit
+func checkStaged(ctx context.Context, repo *Repo) ([]CheckIssue, error) {
+	raw, err := repo.Run(ctx, "diff", "--cached", "--raw", "-z", "--no-abbrev", "--diff-filter=ACMRT")
+	if err != nil {
+		return nil, err
+	}
+	var objects strings.Builder
+	for _, blob := range parseRawDiff(raw) {
+		fmt.Fprintf(&objects, "%s %s\n", blob[0], blob[1])
+	}
+	issues, err := checkObjects(ctx, repo, objects.String())
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := repo.Run(ctx, "-c", "core.quotePath=off", "diff", "--cached", "-U0", "--no-color", "--no-ext-diff", "--diff-filter=ACMRT")
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, scanDiff(diff)...), nil
+}
+
+// checkOutgoing checks the commits on ref that the remote doesn't have yet, like checkStaged
+func checkOutgoing(ctx context.Context, repo *Repo, remote, ref string) ([]CheckIssue, error) {
+	notOnRemote := []string{ref, "--not", "--remotes=" + remote}
+
+	objects, err := repo.Run(ctx, append([]string{"rev-list", "--objects"}, notOnRemote...)...)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := checkObjects(ctx, repo, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := repo.Run(ctx, append([]string{"-c", "core.quotePath=off", "log", "-p", "-U0", "--no-color", "--no-ext-diff",
+		"--diff-filter=ACMRT", "--format=%x00%h"}, notOnRemote...)...)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, scanDiff(log)...), nil
+}
+
+// checkObjects checks the sizes and paths of blobs given as "<object> <path>" lines, as printed
+// by git rev-list --objects
+func checkObjects(ctx context.Context, repo *Repo, objects string) ([]CheckIssue, error) {
+	if strings.TrimSpace(objects) == "" {
+		return nil, nil
+	}
+	out, err := repo.RunInput(ctx, strings.NewReader(objects), "cat-file", "--batch-check=%(objecttype) %(objectsize) %(rest)")
+	if err != nil {
+		return nil, err
+	}
+
+	settings := config.GitSettings()
+	var issues []CheckIssue
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 || fields[0] != "blob" || fields[2] == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		size, _ := strconv.ParseInt(fields[1], 10, 64)
+		file := fields[2]
+
+		if config.MatchPath(settings.DenyCommit, file) {
+			issues = append(issues, CheckIssue{File: file, Reason: ReasonDeniedPath, Detail: "matches git.deny_commit"})
+		}
+		if size > int64(settings.MaxFileSize) {
+			issues = append(issues, CheckIssue{
+				File:   file,
+				Reason: ReasonLargeFile,
+				Detail: fmt.Sprintf("%s exceeds git.max_file_size of %s", config.Size(size), settings.MaxFileSize),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// scanDiff reports the secrets on the lines a diff adds. Lines holding a NUL and a commit hash,
+// as printed by git log --format=%x00%h, attribute the following changes to that commit.
+func scanDiff(diff string) []CheckIssue {
+	var issues []CheckIssue
+	var commit, file string
+	var text strings.Builder
+	var lineNumbers []int // Line in the file of each line in text
+	next, inHeader := 0, false
+
+	flush := func() {
+		for _, f := range secrets.Find(text.String()) {
+			issues = append(issues, CheckIssue{File: file, Reason: ReasonSecret, Detail: f.Kind, Line: lineNumbers[f.Line-1], Commit: commit})
+		}
+		text.Reset()
+		lineNumbers = lineNumbers[:0]
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			flush()
+			commit, file = line[1:], ""
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file, inHeader = "", true
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@ "):
+			next, inHeader = hunkStart(line), false
+		case inHeader:
+		case strings.HasPrefix(line, "+") && file != "":
+			text.WriteString(line[1:])
+			text.WriteByte('\n')
+			lineNumbers = append(lineNumbers, next)
+			next++
+		case strings.HasPrefix(line, " "):
+			next++
+		}
+	}
+	flush()
+	return issues
+}
+
+// hunkStart returns the first line of the new file in a hunk header: @@ -1,2 +3,4 @@
+func hunkStart(header string) int {
+	_, after, ok := strings.Cut(header, " +")
+	if !ok {
+		return 0
+	}
+	end := strings.IndexAny(after, ", ")
+	if end < 0 {
+		end = len(after)
+	}
+	n, _ := strconv.Atoi(after[:end])
+	return n
+}
+
+// parseRawDiff returns the new object and path of each entry in git diff --raw -z output
+func parseRawDiff(raw string) [][2]string {
+	var blobs [][2]string
+	fields := strings.Split(raw, "\x00")
+	for i := 0; i < len(fields); i++ {
+		meta := strings.Fields(fields[i])
+		if len(meta) < 5 || !strings.HasPrefix(meta[0], ":") {
+			continue
+		}
+		// Renames and copies list the old path, then the new one
+		if status := meta[4]; strings.HasPrefix(status, "R") || strings.HasPrefix(status, "C") {
+			i++
+		}
+		if i+1 < len(fields) {
+			blobs = append(blobs, [2]string{meta[3], fields[i+1]})
+		}
+		i++
+	}
+	return blobs
+}
+
+// renderIssues lists the problems that blocked a commit or push
+func renderIssues(w io.Writer, issues []CheckIssue) {
+	for _, issue := range issues {
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+		}
+		if issue.Commit != "" {
+			location = issue.Commit + " " + location
+		}
+		fmt.Fprintf(w, "  %s: %s (%s)\n", location, strings.ReplaceAll(issue.Reason, "_", " "), issue.Detail)
+	}
+}
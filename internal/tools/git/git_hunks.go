@@ -0,0 +1,224 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ways to apply a selection of hunks
+const (
+	hunksStage   = "stage"   // Unstaged changes applied to the index
+	hunksUnstage = "unstage" // Staged changes taken back out of the index
+	hunksDiscard = "discard" // Unstaged changes reverted in the working tree
+)
+
+// hunkSelection is what to apply: whole hunks by ID, and lines of hunks by their 1-based
+// position in the hunk's lines
+type hunkSelection struct {
+	hunks map[string]bool
+	lines map[string][][2]int
+}
+
+// parseHunkSelection reads hunk IDs and line selections like "a1b2c3d4:2-4,7"
+func parseHunkSelection(ids, lines []string) (hunkSelection, error) {
+	selection := hunkSelection{hunks: make(map[string]bool), lines: make(map[string][][2]int)}
+	for _, id := range ids {
+		selection.hunks[strings.TrimSpace(id)] = true
+	}
+	for _, spec := range lines {
+		id, ranges, ok := strings.Cut(strings.TrimSpace(spec), ":")
+		if !ok || id == "" {
+			return hunkSelection{}, fmt.Errorf("invalid line selection %q (expected hunk_id:start-end)", spec)
+		}
+		for _, r := range strings.Split(ranges, ",") {
+			startStr, endStr, isRange := strings.Cut(strings.TrimSpace(r), "-")
+			if !isRange {
+				endStr = startStr
+			}
+			start, err1 := strconv.Atoi(startStr)
+			end, err2 := strconv.Atoi(endStr)
+			if err1 != nil || err2 != nil || start < 1 || end < start {
+				return hunkSelection{}, fmt.Errorf("invalid line range %q in %q", r, spec)
+			}
+			selection.lines[id] = append(selection.lines[id], [2]int{start, end})
+		}
+	}
+	if len(selection.hunks) == 0 && len(selection.lines) == 0 {
+		return hunkSelection{}, fmt.Errorf("no hunks or lines selected")
+	}
+	return selection, nil
+}
+
+// selected reports whether line i (1-based) of hunk id is selected
+func (s hunkSelection) selected(id string, i int) bool {
+	if s.hunks[id] {
+		return true
+	}
+	for _, r := range s.lines[id] {
+		if i >= r[0] && i <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// applyHunks stages, unstages or discards the selected hunks and lines of one file by building
+// a patch from the file's diff and applying it with git apply. It returns the number of hunks
+// the patch touched.
+func applyHunks(ctx context.Context, repo *Repo, rel, mode string, selection hunkSelection) (int, error) {
+	args := []string{"-c", "core.quotePath=off", "diff", "--no-color", "--no-ext-diff", "-U3"}
+	source := "unstaged"
+	if mode == hunksUnstage {
+		args = append(args, "--cached")
+		source = "staged"
+	}
+	output, err := repo.Run(ctx, append(args, "--", rel)...)
+	if err != nil {
+		return 0, err
+	}
+
+	_, files := parseDiff(output)
+	var file *DiffFile
+	for i := range files {
+		if files[i].Path() == rel || files[i].OldPath == rel {
+			file = &files[i]
+		}
+	}
+	if file == nil {
+		return 0, fmt.Errorf("%s has no %s changes", rel, source)
+	}
+	if file.Binary {
+		return 0, fmt.Errorf("%s is binary; its changes can only be applied as a whole", rel)
+	}
+
+	known := make(map[string]bool)
+	for _, hunk := range file.Hunks {
+		known[hunk.ID] = true
+	}
+	for id := range selection.hunks {
+		if !known[id] {
+			return 0, fmt.Errorf("no %s hunk %s in %s; list them with git_diff --structured", source, id, rel)
+		}
+	}
+	for id := range selection.lines {
+		if !known[id] {
+			return 0, fmt.Errorf("no %s hunk %s in %s; list them with git_diff --structured", source, id, rel)
+		}
+	}
+
+	patch, count := buildPatch(*file, selection, mode != hunksStage)
+	if count == 0 {
+		return 0, fmt.Errorf("the selection has no changed lines")
+	}
+
+	applyArgs := []string{"apply", "--whitespace=nowarn"}
+	if mode != hunksDiscard {
+		applyArgs = append(applyArgs, "--cached")
+	}
+	if mode != hunksStage {
+		applyArgs = append(applyArgs, "--reverse")
+	}
+	if _, err := repo.RunInput(ctx, strings.NewReader(patch), append(applyArgs, "-")...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// buildPatch makes a patch of the selected hunks and lines of a file. A reverse patch is applied
+// with --reverse, so what it leaves unchanged is the new side: unselected additions become
+// context and unselected removals are dropped, the opposite of a forward patch.
+func buildPatch(file DiffFile, selection hunkSelection, reverse bool) (string, int) {
+	var b strings.Builder
+	for _, line := range file.header {
+		b.WriteString(line + "\n")
+	}
+
+	count, delta := 0, 0
+	for _, hunk := range file.Hunks {
+		var lines []string
+		oldLines, newLines, changed := 0, 0, false
+		kept := false // whether the previous line made it into the patch, for "\ No newline" markers
+		for i, line := range hunk.Lines {
+			if line == "" {
+				line = " "
+			}
+			selected := selection.selected(hunk.ID, i+1)
+			switch line[0] {
+			case '\\':
+				if kept {
+					lines = append(lines, line)
+				}
+				continue
+			case '+':
+				switch {
+				case selected:
+					changed = true
+				case reverse:
+					line = " " + line[1:]
+				default:
+					kept = false
+					continue
+				}
+			case '-':
+				switch {
+				case selected:
+					changed = true
+				case reverse:
+					kept = false
+					continue
+				default:
+					line = " " + line[1:]
+				}
+			}
+			kept = true
+			lines = append(lines, line)
+			if line[0] != '+' {
+				oldLines++
+			}
+			if line[0] != '-' {
+				newLines++
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		// The side the patch is applied to keeps the original numbers; the other side shifts by
+		// the lines the included hunks before this one added or removed
+		var oldStart, newStart int
+		if reverse {
+			newStart = hunk.NewStart
+			oldStart = sideStart(hunkPosition(hunk.NewStart, hunk.NewLines)-delta, oldLines)
+		} else {
+			oldStart = hunk.OldStart
+			newStart = sideStart(hunkPosition(hunk.OldStart, hunk.OldLines)+delta, newLines)
+		}
+		delta += newLines - oldLines
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLines, newStart, newLines)
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+		count++
+	}
+	return b.String(), count
+}
+
+// hunkPosition returns the first line of a hunk's side; a side without lines is numbered by the
+// line before it
+func hunkPosition(start, lines int) int {
+	if lines == 0 {
+		return start + 1
+	}
+	return start
+}
+
+// sideStart is the inverse of hunkPosition
+func sideStart(position, lines int) int {
+	if lines == 0 {
+		return position - 1
+	}
+	return position
+}
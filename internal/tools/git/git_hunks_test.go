@@ -0,0 +1,145 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestBuildPatch(t *testing.T) {
+	file := DiffFile{
+		header: []string{"diff --git a/a.txt b/a.txt", "--- a/a.txt", "+++ b/a.txt"},
+		Hunks: []DiffHunk{
+			{ID: "one", OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 3, Lines: []string{" a", "+b", "+c", " d"}},
+			{ID: "two", OldStart: 10, OldLines: 3, NewStart: 11, NewLines: 2, Lines: []string{" x", "-y", " z"}},
+		},
+	}
+
+	// Staging one of two added lines leaves the other out, which moves the next hunk up by one
+	patch, count := buildPatch(file, hunkSelection{lines: map[string][][2]int{"one": {{2, 2}}}, hunks: map[string]bool{"two": true}}, false)
+	want := "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n" +
+		"@@ -1,2 +1,3 @@\n a\n+b\n d\n" +
+		"@@ -10,3 +11,2 @@\n x\n-y\n z\n"
+	if patch != want || count != 2 {
+		t.Errorf("buildPatch() = %q, %d; want %q, 2", patch, count, want)
+	}
+
+	// In reverse the unselected addition stays as context instead
+	patch, count = buildPatch(file, hunkSelection{lines: map[string][][2]int{"one": {{3, 3}}}}, true)
+	want = "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n" +
+		"@@ -1,3 +1,4 @@\n a\n b\n+c\n d\n"
+	if patch != want || count != 1 {
+		t.Errorf("reverse buildPatch() = %q, %d; want %q, 1", patch, count, want)
+	}
+
+	if _, err := parseHunkSelection(nil, []string{"one:3-2"}); err == nil {
+		t.Error("Expected an inverted line range to fail")
+	}
+}
+
+func TestGitHunks(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "hunky")
+	os.MkdirAll(appPath, 0755)
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line "+string(rune('a'+i-1)))
+	}
+	original := strings.Join(lines, "\n") + "\n"
+	os.WriteFile(filepath.Join(appPath, "list.txt"), []byte(original), 0644)
+	git("add", "-A")
+	git("commit", "-m", "Add list")
+
+	lines[1], lines[17] = "line B", "line R"
+	changed := strings.Join(lines, "\n") + "\n"
+	os.WriteFile(filepath.Join(appPath, "list.txt"), []byte(changed), 0644)
+
+	hunks := func(staged bool) []DiffHunk {
+		t.Helper()
+		result, err := GitDiff(t.Context(), "hunky", GitDiffOptions{FilePath: "list.txt", Structured: true, Staged: staged})
+		if err != nil {
+			t.Fatalf("GitDiff failed: %v", err)
+		}
+		if len(result.Files) == 0 {
+			return nil
+		}
+		return result.Files[0].Hunks
+	}
+
+	unstaged := hunks(false)
+	if len(unstaged) != 2 {
+		t.Fatalf("Expected 2 hunks, got %+v", unstaged)
+	}
+
+	// Stage the second hunk; the first keeps its ID
+	added, err := GitAddHunks(t.Context(), "hunky", "list.txt", []string{unstaged[1].ID}, nil)
+	if err != nil || added.HunksStaged != 1 {
+		t.Fatalf("GitAddHunks = %+v, %v", added, err)
+	}
+	if staged := git("diff", "--cached"); !strings.Contains(staged, "+line R") || strings.Contains(staged, "+line B") {
+		t.Errorf("Expected only the second hunk staged, got:\n%s", staged)
+	}
+	if left := hunks(false); len(left) != 1 || left[0].ID != unstaged[0].ID {
+		t.Errorf("Expected the first hunk to stay unstaged with its ID, got %+v", left)
+	}
+
+	// Unstage it again
+	staged := hunks(true)
+	if _, err := GitRestoreHunks(t.Context(), "hunky", "list.txt", []string{staged[0].ID}, nil, true); err != nil {
+		t.Fatalf("GitRestoreHunks --staged failed: %v", err)
+	}
+	if out := git("diff", "--cached"); out != "" {
+		t.Errorf("Expected nothing staged, got:\n%s", out)
+	}
+
+	// Stage just the added line of the first hunk, keeping the line it replaced
+	first := unstaged[0]
+	plus := 0
+	for i, line := range first.Lines {
+		if strings.HasPrefix(line, "+") {
+			plus = i + 1
+		}
+	}
+	if _, err := GitAddHunks(t.Context(), "hunky", "list.txt", nil, []string{first.ID + ":" + string(rune('0'+plus))}); err != nil {
+		t.Fatalf("GitAddHunks with lines failed: %v", err)
+	}
+	if index := git("show", ":list.txt"); !strings.Contains(index, "line b\nline B\nline c") {
+		t.Errorf("Expected the index to have both lines, got:\n%s", index)
+	}
+
+	// Discard the second hunk from the working tree
+	for _, hunk := range hunks(false) {
+		if strings.Contains(strings.Join(hunk.Lines, "\n"), "+line R") {
+			if _, err := GitRestoreHunks(t.Context(), "hunky", "list.txt", []string{hunk.ID}, nil, false); err != nil {
+				t.Fatalf("GitRestoreHunks failed: %v", err)
+			}
+		}
+	}
+	content, _ := os.ReadFile(filepath.Join(appPath, "list.txt"))
+	if strings.Contains(string(content), "line R") || !strings.Contains(string(content), "line B") {
+		t.Errorf("Expected only the second change discarded, got:\n%s", content)
+	}
+
+	if _, err := GitAddHunks(t.Context(), "hunky", "list.txt", []string{"deadbeef"}, nil); err == nil {
+		t.Error("Expected an unknown hunk ID to fail")
+	}
+}
@@ -1,18 +1,17 @@
 package git
 
 import (
-	"bytes"
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
 	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -21,11 +20,17 @@ type GitInitResult struct {
 	AlreadyExists bool   `json:"already_exists"`
 	Message       string `json:"message"`
 	AppPath       string `json:"app_path"`
+	Identity      string `json:"identity,omitempty"` // Author set up because git had none, as Name <email>
 	ErrorOutput   string `json:"error_output,omitempty"`
 }
 
+type GitInitParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory to initialize (will be created if it doesn't exist)"`
+	Bare    bool   `json:"bare" desc:"Create a bare repository"`
+}
+
 // GitInit initializes a git repository in the specified app directory
-func GitInit(appName string, bare bool) (GitInitResult, error) {
+func GitInit(ctx context.Context, appName string, bare bool) (GitInitResult, error) {
 	if err := EnsureGitAvailable(); err != nil {
 		return GitInitResult{}, err
 	}
@@ -34,12 +39,11 @@ func GitInit(appName string, bare bool) (GitInitResult, error) {
 		return GitInitResult{}, err
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	appPath, err := config.AppDirectory(appName)
 	if err != nil {
-		return GitInitResult{}, fmt.Errorf("failed to get apps directory: %w", err)
+		return GitInitResult{}, fmt.Errorf("failed to get app directory: %w", err)
 	}
 
-	appPath := filepath.Join(appsDir, appName)
 	
 	// Create app directory if it doesn't exist
 	if _, err := os.Stat(appPath); os.IsNotExist(err) {
@@ -48,9 +52,13 @@ func GitInit(appName string, bare bool) (GitInitResult, error) {
 		}
 	}
 
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitInitResult{}, err
+	}
+
 	// Check if it's already a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); err == nil {
+	if repo.IsRepo() {
 		return GitInitResult{
 			Success:       false,
 			AlreadyExists: true,
@@ -66,91 +74,86 @@ func GitInit(appName string, bare bool) (GitInitResult, error) {
 	}
 
 	// Run git init
-	initCmd := exec.Command("git", args...)
-	initCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	initCmd.Stdout = &outBuf
-	initCmd.Stderr = &errBuf
-	err = initCmd.Run()
+	_, errOutput, err := repo.Exec(ctx, nil, args...)
 	if err != nil {
-		return GitInitResult{}, fmt.Errorf("git init failed: %w - %s", err, strings.TrimSpace(errBuf.String()))
+		return GitInitResult{}, err
 	}
 
 	// Configure default branch name to "main"
-	configCmd := exec.Command("git", "config", "init.defaultBranch", "main")
-	configCmd.Dir = appPath
-	configCmd.Run() // Ignore errors for older git versions
+	repo.Run(ctx, "config", "init.defaultBranch", "main") // Ignore errors for older git versions
+
+	identity, err := ensureIdentity(ctx, repo)
+	if err != nil {
+		return GitInitResult{}, fmt.Errorf("initialized git repository but failed to set its author: %w", err)
+	}
 
+	message := fmt.Sprintf("Initialized git repository in '%s'", appName)
+	if identity != "" {
+		message += fmt.Sprintf("; commits are authored as %s", identity)
+	}
 	return GitInitResult{
 		Success:     true,
-		Message:     fmt.Sprintf("Initialized git repository in '%s'", appName),
+		Message:     message,
 		AppPath:     appPath,
-		ErrorOutput: errBuf.String(),
+		Identity:    identity,
+		ErrorOutput: errOutput,
 	}, nil
 }
 
-// CLI
-func GitInitCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_init requires at least 1 argument: app_name\nUsage: layered-code tool git_init <app_name> [--bare]")
-	}
-
-	appName := args[0]
-	bare := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		if args[i] == "--bare" {
-			bare = true
+// ensureIdentity sets the repository's author from git.user_name and git.user_email, or the
+// defaults, where git has no user.name or user.email, so commits don't fail. It returns the
+// author it set up, or "" if git had one.
+func ensureIdentity(ctx context.Context, repo *Repo) (string, error) {
+	settings := config.GitSettings()
+	keys := []struct{ key, value, fallback string }{
+		{"user.name", settings.UserName, constants.GitDefaultUserName},
+		{"user.email", settings.UserEmail, constants.GitDefaultUserEmail},
+	}
+
+	changed := false
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		if current, err := repo.Run(ctx, "config", k.key); err == nil && strings.TrimSpace(current) != "" {
+			values[i] = strings.TrimSpace(current)
+			continue
 		}
+		values[i] = cmp.Or(k.value, k.fallback)
+		if _, err := repo.Run(ctx, "config", k.key, values[i]); err != nil {
+			return "", err
+		}
+		changed = true
 	}
-
-	result, err := GitInit(appName, bare)
-	if err != nil {
-		return fmt.Errorf("failed to initialize repository: %w", err)
-	}
-
-	if result.AlreadyExists {
-		fmt.Println(result.Message)
-		return nil
+	if !changed {
+		return "", nil
 	}
-
-	if result.Success {
-		fmt.Println(result.Message)
-		fmt.Printf("Path: %s\n", result.AppPath)
-	} else {
-		fmt.Printf("Failed: %s\n", result.Message)
-	}
-
-	return nil
+	return fmt.Sprintf("%s <%s>", values[0], values[1]), nil
 }
 
-// MCP
-func GitInitMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-		Bare    bool   `json:"bare"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitInit(args.AppName, args.Bare)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+// Tool
+func gitInitTool() registry.Tool {
+	return registry.New(registry.Spec[GitInitParams, GitInitResult]{
+		Name:        "git_init",
+		Group:       "git",
+		Description: "Initialize a new git repository",
+		Hints:       registry.Hints{Idempotent: true},
+		Notes: []string{
+			"When git has no author, the repository gets git.user_name and git.user_email from the config (default: Layered Code <layered-code@localhost>)",
+		},
+		Run: func(ctx context.Context, params GitInitParams) (GitInitResult, error) {
+			return GitInit(ctx, params.AppName, params.Bare)
+		},
+		Render: func(w io.Writer, _ GitInitParams, result GitInitResult) {
+			if result.AlreadyExists {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
+
+			if result.Success {
+				fmt.Fprintln(w, result.Message)
+				fmt.Fprintf(w, "Path: %s\n", result.AppPath)
+			} else {
+				fmt.Fprintf(w, "Failed: %s\n", result.Message)
+			}
+		},
+	})
+}
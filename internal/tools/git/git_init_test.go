@@ -6,16 +6,18 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitInit(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-init-app"
 	testAppPath := filepath.Join(appsDir, testApp)
 	defer os.RemoveAll(testAppPath)
 
 	// New directory
-	result, err := GitInit(testApp, false)
+	result, err := GitInit(t.Context(), testApp, false)
 	if err != nil {
 		t.Fatalf("GitInit failed: %v", err)
 	}
@@ -30,11 +32,39 @@ func TestGitInit(t *testing.T) {
 	}
 
 	// Already exists
-	result, err = GitInit(testApp, false)
+	result, err = GitInit(t.Context(), testApp, false)
 	if err != nil {
 		t.Fatalf("GitInit on existing failed: %v", err)
 	}
 	if !result.AlreadyExists {
 		t.Error("Expected AlreadyExists to be true")
 	}
-}
\ No newline at end of file
+}
+func TestGitInitIdentity(t *testing.T) {
+	homeDir := testutil.Home(t)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("LAYERED_GIT_USER_NAME", "Ada Lovelace")
+
+	result, err := GitInit(t.Context(), "authored", false)
+	if err != nil {
+		t.Fatalf("GitInit failed: %v", err)
+	}
+	if result.Identity != "Ada Lovelace <layered-code@localhost>" {
+		t.Errorf("Expected the configured name and default email, got %q", result.Identity)
+	}
+
+	repo, err := OpenRepo("authored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := repo.Run(t.Context(), "config", "user.name"); name != "Ada Lovelace\n" {
+		t.Errorf("Expected user.name to be set, got %q", name)
+	}
+
+	// An author git already has is kept
+	os.WriteFile(filepath.Join(homeDir, ".gitconfig"), []byte("[user]\n\tname = Grace\n\temail = grace@example.com\n"), 0644)
+	result, err = GitInit(t.Context(), "global", false)
+	if err != nil || result.Identity != "" {
+		t.Errorf("Expected the global author to be kept, got %+v, %v", result, err)
+	}
+}
@@ -1,235 +1,365 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
+	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitLogEntry struct {
-	Hash    string `json:"hash"`
-	Author  string `json:"author"`
-	Date    string `json:"date"`
-	Message string `json:"message"`
+	Hash    string       `json:"hash"`
+	Parents []string     `json:"parents,omitempty"` // Short hashes; two or more for a merge
+	Author  string       `json:"author"`
+	Date    string       `json:"date"`
+	Message string       `json:"message"`        // Subject line
+	Body    string       `json:"body,omitempty"` // Rest of the message, when requested
+	Files   []GitLogFile `json:"files,omitempty"`
+}
+
+// GitLogFile is a file a commit changed, with stat
+type GitLogFile struct {
+	Path      string `json:"path"` // "old => new" for a rename
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
 }
 
 type GitLogResult struct {
 	Commits     []GitLogEntry `json:"commits"`
 	IsRepo      bool          `json:"is_repo"`
+	NextCursor  string        `json:"next_cursor,omitempty"` // Pass as cursor to get the next page; empty on the last page
 	Message     string        `json:"message,omitempty"`
 	ErrorOutput string        `json:"error_output,omitempty"`
 }
 
-// GitLog retrieves git log for the specified app directory
-func GitLog(appName string, limit int, oneline bool) (GitLogResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitLogResult{}, err
-	}
+type GitLogParams struct {
+	AppName  string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	FilePath string `json:"file_path" pos:"true" desc:"Only show commits changing this file or directory (relative to app directory)"`
+	Limit    int    `json:"limit" short:"n" desc:"Maximum number of commits to show (default: 10)"`
+	Oneline  bool   `json:"oneline" desc:"Show commits in one-line format"`
+	Ref      string `json:"ref" desc:"Commit or range to list (e.g. feature, v1.0..HEAD, main...feature; default: HEAD)"`
+	Author   string `json:"author" desc:"Only show commits whose author name or email matches this pattern"`
+	Since    string `json:"since" desc:"Only show commits after this date (e.g. 2024-05-01, \"2 weeks ago\")"`
+	Until    string `json:"until" desc:"Only show commits before this date"`
+	Grep     string `json:"grep" desc:"Only show commits whose message matches this pattern (case-insensitive)"`
+	Follow   bool   `json:"follow" desc:"Follow file_path across renames"`
+	Stat     bool   `json:"stat" desc:"List the files each commit changed with their line counts"`
+	Body     bool   `json:"body" desc:"Include the full commit message, not just the subject"`
+	Cursor   string `json:"cursor" desc:"next_cursor of the previous page; keep the other filters the same"`
+}
+
+// GitLogOptions configures GitLog
+type GitLogOptions struct {
+	Limit    int // 0 for no limit
+	Oneline  bool
+	FilePath string
+	Ref      string // Commit or range; HEAD when empty
+	Author   string
+	Since    string
+	Until    string
+	Grep     string
+	Follow   bool // Follow FilePath across renames
+	Stat     bool // Fill in the files of each commit
+	Body     bool
+	Cursor   string // NextCursor of the previous page
+}
 
-	if err := helpers.ValidateAppName(appName); err != nil {
+// GitLog retrieves git log for the specified app directory. Pages of Limit commits are pinned
+// to the commits Ref resolved to on the first page, so new commits don't shift later pages.
+func GitLog(ctx context.Context, appName string, options GitLogOptions) (GitLogResult, error) {
+	if options.Limit < 0 {
+		return GitLogResult{}, fmt.Errorf("limit must not be negative")
+	}
+	if options.Follow && options.FilePath == "" {
+		return GitLogResult{}, fmt.Errorf("follow requires file_path")
+	}
+	if err := validateRef(options.Ref); err != nil {
 		return GitLogResult{}, err
 	}
-
-	appsDir, err := config.EnsureAppsDirectory()
+	skip, revisions, err := decodeLogCursor(options.Cursor)
 	if err != nil {
-		return GitLogResult{}, fmt.Errorf("failed to get apps directory: %w", err)
+		return GitLogResult{}, err
 	}
 
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
+	repo, err := OpenRepo(appName)
+	if err != nil {
 		return GitLogResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitLogResult{
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
-	// Build git log command
-	args := []string{"log"}
-	
-	if limit > 0 {
-		args = append(args, "-n", strconv.Itoa(limit))
-	}
-	
-	if oneline {
-		args = append(args, "--oneline")
-	} else {
-		args = append(args, "--pretty=format:%H%x1f%an%x1f%ad%x1f%s", "--date=short")
-	}
-
-	// Run git log
-	logCmd := exec.Command("git", args...)
-	logCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	logCmd.Stdout = &outBuf
-	logCmd.Stderr = &errBuf
-	err = logCmd.Run()
-	if err != nil {
-		// Check if it's just an empty repository
-		errorStr := errBuf.String()
-		if strings.Contains(errorStr, "does not have any commits") || strings.Contains(errorStr, "No commits yet") || strings.Contains(err.Error(), "does not have any commits") {
-			return GitLogResult{
-				IsRepo:      true,
-				Commits:     []GitLogEntry{},
-				Message:     "No commits yet",
-				ErrorOutput: errorStr,
-			}, nil
+	// Pin the revisions so later pages list the same history
+	if revisions == nil {
+		ref := options.Ref
+		if ref == "" {
+			ref = "HEAD"
 		}
-		// Also check for exit status 128 which can indicate empty repo
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 && outBuf.Len() == 0 {
+		output, errOutput, err := repo.Exec(ctx, nil, "rev-parse", ref, "--")
+		if errors.Is(err, ErrNoCommits) {
 			return GitLogResult{
 				IsRepo:      true,
 				Commits:     []GitLogEntry{},
 				Message:     "No commits yet",
-				ErrorOutput: errorStr,
+				ErrorOutput: errOutput,
 			}, nil
 		}
-		return GitLogResult{}, fmt.Errorf("failed to run git log: %w\nError output: %s", err, errorStr)
-	}
-
-	result := GitLogResult{
-		IsRepo:  true,
-		Commits: []GitLogEntry{},
-	}
-
-	// Parse output
-	lines := strings.Split(strings.TrimSpace(outBuf.String()), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
+		if err != nil {
+			return GitLogResult{}, err
 		}
-
-		if oneline {
-			// Parse oneline format
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) >= 2 {
-				result.Commits = append(result.Commits, GitLogEntry{
-					Hash:    parts[0],
-					Message: parts[1],
-				})
-			}
-		} else {
-			// Parse full format
-			parts := strings.Split(line, "\x1f")
-			if len(parts) >= 4 {
-				result.Commits = append(result.Commits, GitLogEntry{
-					Hash:    parts[0][:7], // Short hash
-					Author:  parts[1],
-					Date:    parts[2],
-					Message: parts[3],
-				})
+		for _, revision := range strings.Fields(output) {
+			if revision != "--" {
+				revisions = append(revisions, revision)
 			}
 		}
 	}
 
-	return result, nil
-}
-
-// CLI
-func GitLogCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_log requires at least 1 argument: app_name\nUsage: layered-code tool git_log <app_name> [-n <limit>] [--oneline]")
+	// Build git log command; one commit more than the page tells whether there is a next page
+	args := []string{"-c", "core.quotePath=off", "log", "--no-color"}
+	args = append(args, logFormat...)
+	if options.Limit > 0 {
+		args = append(args, "-n", strconv.Itoa(options.Limit+1))
 	}
-
-	appName := args[0]
-	limit := 10 // Default limit
-	oneline := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "-n", "--limit":
-			if i+1 < len(args) {
-				l, err := strconv.Atoi(args[i+1])
-				if err != nil {
-					return fmt.Errorf("invalid limit: %s", args[i+1])
-				}
-				limit = l
-				i++ // Skip the limit value
-			}
-		case "--oneline":
-			oneline = true
+	if skip > 0 {
+		args = append(args, "--skip", strconv.Itoa(skip))
+	}
+	if options.Author != "" {
+		args = append(args, "--author="+options.Author)
+	}
+	if options.Since != "" {
+		args = append(args, "--since="+options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until="+options.Until)
+	}
+	if options.Grep != "" {
+		args = append(args, "--regexp-ignore-case", "--grep="+options.Grep)
+	}
+	if options.Stat {
+		args = append(args, "--numstat")
+	}
+	if options.Follow {
+		args = append(args, "--follow")
+	}
+	args = append(args, revisions...)
+	if options.FilePath != "" {
+		rel, err := repo.Path.RelNoFollow(options.FilePath)
+		if err != nil {
+			return GitLogResult{}, fmt.Errorf("invalid file path: %w", err)
 		}
+		args = append(args, "--", rel)
 	}
 
-	result, err := GitLog(appName, limit, oneline)
+	// Run git log
+	output, errOutput, err := repo.Exec(ctx, nil, args...)
+	if errors.Is(err, ErrNoCommits) {
+		return GitLogResult{
+			IsRepo:      true,
+			Commits:     []GitLogEntry{},
+			Message:     "No commits yet",
+			ErrorOutput: errOutput,
+		}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get git log: %w", err)
+		return GitLogResult{}, err
 	}
 
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+	result := GitLogResult{
+		IsRepo:  true,
+		Commits: parseLog(output),
 	}
-
-	if len(result.Commits) == 0 {
-		fmt.Println("No commits yet")
-		return nil
+	if options.Limit > 0 && len(result.Commits) > options.Limit {
+		result.Commits = result.Commits[:options.Limit]
+		result.NextCursor = encodeLogCursor(skip+options.Limit, revisions)
 	}
 
-	// Display commits
-	for _, commit := range result.Commits {
-		if oneline {
-			fmt.Printf("%s %s\n", commit.Hash, commit.Message)
-		} else {
-			fmt.Printf("commit %s\n", commit.Hash)
-			fmt.Printf("Author: %s\n", commit.Author)
-			fmt.Printf("Date:   %s\n", commit.Date)
-			fmt.Printf("\n    %s\n\n", commit.Message)
+	for i := range result.Commits {
+		commit := &result.Commits[i]
+		if !options.Body {
+			commit.Body = ""
+		}
+		if options.Oneline {
+			*commit = GitLogEntry{Hash: commit.Hash, Message: commit.Message}
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// MCP
-func GitLogMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-		Limit   int    `json:"limit"`
-		Oneline bool   `json:"oneline"`
-	}
+// logFormat makes git log print the fields parseLog reads: a record per commit, with any
+// --numstat lines after its message
+var logFormat = []string{"--pretty=format:%x1e%H%x1f%P%x1f%an%x1f%ad%x1f%s%x1f%b%x1f", "--date=short"}
 
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
+// parseLog parses git log output printed with logFormat
+func parseLog(output string) []GitLogEntry {
+	commits := []GitLogEntry{}
+	for _, record := range strings.Split(output, "\x1e") {
+		parts := strings.SplitN(record, "\x1f", 7)
+		if len(parts) < 7 || len(parts[0]) < 7 {
+			continue
+		}
+		commit := GitLogEntry{
+			Hash:    parts[0][:7], // Short hash
+			Author:  parts[2],
+			Date:    parts[3],
+			Message: parts[4],
+			Body:    strings.TrimSpace(parts[5]),
+		}
+		for _, parent := range strings.Fields(parts[1]) {
+			commit.Parents = append(commit.Parents, parent[:min(7, len(parent))])
+		}
+		commit.Files = parseNumstat(parts[6])
+		commits = append(commits, commit)
 	}
+	return commits
+}
 
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
+// parseNumstat parses "added<TAB>deleted<TAB>path" lines; binary files have "-" counts
+func parseNumstat(output string) []GitLogFile {
+	var files []GitLogFile
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		file := GitLogFile{Path: fields[2], Binary: fields[0] == "-"}
+		file.Additions, _ = strconv.Atoi(fields[0])
+		file.Deletions, _ = strconv.Atoi(fields[1])
+		files = append(files, file)
 	}
+	return files
+}
 
-	// Default limit if not specified
-	if args.Limit == 0 {
-		args.Limit = 10
-	}
+var cursorRevision = regexp.MustCompile(`^\^?[0-9a-f]{40,64}$`)
 
-	result, err := GitLog(args.AppName, args.Limit, args.Oneline)
-	if err != nil {
-		return nil, err
-	}
+// encodeLogCursor makes the cursor of the page starting after skip commits of revisions
+func encodeLogCursor(skip int, revisions []string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(skip) + " " + strings.Join(revisions, " ")))
+}
 
-	jsonData, err := json.Marshal(result)
+// decodeLogCursor reads a cursor made by encodeLogCursor; an empty cursor is the first page
+func decodeLogCursor(cursor string) (int, []string, error) {
+	if cursor == "" {
+		return 0, nil, nil
+	}
+	invalid := fmt.Errorf("invalid cursor: %q", cursor)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
+		return 0, nil, invalid
 	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, nil, invalid
+	}
+	skip, err := strconv.Atoi(fields[0])
+	if err != nil || skip < 0 {
+		return 0, nil, invalid
+	}
+	for _, revision := range fields[1:] {
+		if !cursorRevision.MatchString(revision) {
+			return 0, nil, invalid
+		}
+	}
+	return skip, fields[1:], nil
+}
+
+// Tool
+func gitLogTool() registry.Tool {
+	return registry.New(registry.Spec[GitLogParams, GitLogResult]{
+		Name:        "git_log",
+		Group:       "git",
+		Description: "Show commit logs (requires git to be installed)",
+		Summary:     "Show commit logs",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Filters combine: --ref, file_path, --author, --since/--until and --grep all narrow the same list",
+			"Each commit lists its parents, so merges and branch structure can be drawn; --stat adds changed files with line counts, --body the full message",
+			"When more commits match than --limit, next_cursor is returned; pass it as --cursor with the same filters for the next page",
+		},
+		Examples: []string{
+			"# History of one file, across renames\nlayered-code tool git_log myapp src/App.jsx --follow --stat",
+			"# Last week's fixes on a branch\nlayered-code tool git_log myapp --ref main..feature --since \"1 week ago\" --grep fix",
+		},
+		Run: func(ctx context.Context, params GitLogParams) (GitLogResult, error) {
+			// Default limit if not specified
+			if params.Limit == 0 {
+				params.Limit = 10
+			}
+			return GitLog(ctx, params.AppName, GitLogOptions{
+				Limit:    params.Limit,
+				Oneline:  params.Oneline,
+				FilePath: params.FilePath,
+				Ref:      params.Ref,
+				Author:   params.Author,
+				Since:    params.Since,
+				Until:    params.Until,
+				Grep:     params.Grep,
+				Follow:   params.Follow,
+				Stat:     params.Stat,
+				Body:     params.Body,
+				Cursor:   params.Cursor,
+			})
+		},
+		Render: func(w io.Writer, params GitLogParams, result GitLogResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+			if len(result.Commits) == 0 {
+				if result.Message != "" {
+					fmt.Fprintln(w, result.Message)
+				} else {
+					fmt.Fprintln(w, "No matching commits")
+				}
+				return
+			}
+
+			for _, commit := range result.Commits {
+				if params.Oneline {
+					fmt.Fprintf(w, "%s %s\n", commit.Hash, commit.Message)
+					continue
+				}
+				fmt.Fprintf(w, "commit %s\n", commit.Hash)
+				if len(commit.Parents) > 1 {
+					fmt.Fprintf(w, "Merge:  %s\n", strings.Join(commit.Parents, " "))
+				}
+				fmt.Fprintf(w, "Author: %s\n", commit.Author)
+				fmt.Fprintf(w, "Date:   %s\n", commit.Date)
+				fmt.Fprintf(w, "\n    %s\n", commit.Message)
+				if commit.Body != "" {
+					fmt.Fprintln(w)
+					for _, line := range strings.Split(commit.Body, "\n") {
+						fmt.Fprintf(w, "    %s\n", line)
+					}
+				}
+				if len(commit.Files) > 0 {
+					fmt.Fprintln(w)
+					for _, file := range commit.Files {
+						if file.Binary {
+							fmt.Fprintf(w, "  %s (binary)\n", file.Path)
+						} else {
+							fmt.Fprintf(w, "  %s +%d -%d\n", file.Path, file.Additions, file.Deletions)
+						}
+					}
+				}
+				fmt.Fprintln(w)
+			}
+
+			if result.NextCursor != "" {
+				fmt.Fprintf(w, "More commits: --cursor %s\n", result.NextCursor)
+			}
+		},
+	})
+}
@@ -5,13 +5,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitLog(t *testing.T) {
 	// Setup test environment
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("Failed to ensure apps directory: %v", err)
@@ -29,7 +32,7 @@ func TestGitLog(t *testing.T) {
 
 	// Test 1: Non-git repository
 	t.Run("NonGitRepo", func(t *testing.T) {
-		result, err := GitLog(testApp, 10, false)
+		result, err := GitLog(t.Context(), testApp, GitLogOptions{Limit: 10})
 		if err != nil {
 			t.Fatalf("GitLog failed: %v", err)
 		}
@@ -57,7 +60,7 @@ func TestGitLog(t *testing.T) {
 
 	// Test 2: Empty repository (no commits)
 	t.Run("EmptyRepo", func(t *testing.T) {
-		result, err := GitLog(testApp, 10, false)
+		result, err := GitLog(t.Context(), testApp, GitLogOptions{Limit: 10})
 		if err != nil {
 			t.Fatalf("GitLog failed: %v", err)
 		}
@@ -94,7 +97,7 @@ func TestGitLog(t *testing.T) {
 
 	// Test 3: Log with limit
 	t.Run("LogWithLimit", func(t *testing.T) {
-		result, err := GitLog(testApp, 3, false)
+		result, err := GitLog(t.Context(), testApp, GitLogOptions{Limit: 3})
 		if err != nil {
 			t.Fatalf("GitLog failed: %v", err)
 		}
@@ -122,7 +125,7 @@ func TestGitLog(t *testing.T) {
 
 	// Test 4: Oneline format
 	t.Run("OnelineFormat", func(t *testing.T) {
-		result, err := GitLog(testApp, 2, true)
+		result, err := GitLog(t.Context(), testApp, GitLogOptions{Limit: 2, Oneline: true})
 		if err != nil {
 			t.Fatalf("GitLog failed: %v", err)
 		}
@@ -148,4 +151,112 @@ func TestGitLog(t *testing.T) {
 			}
 		}
 	})
-}
\ No newline at end of file
+}
+func TestGitLogFiltersAndPages(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "history")
+	os.MkdirAll(appPath, 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(file, content, message string, extra ...string) {
+		t.Helper()
+		os.WriteFile(filepath.Join(appPath, file), []byte(content), 0644)
+		git("add", "-A")
+		git(append([]string{"commit", "-m", message}, extra...)...)
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+
+	commit("app.js", "one\n", "Add app\n\nFirst version of the app.")
+	commit("style.css", "body {}\n", "Add styles")
+	commit("app.js", "one\ntwo\n", "Fix app")
+	git("mv", "app.js", "main.js")
+	git("commit", "-m", "Rename app")
+	commit("main.js", "one\ntwo\nthree\n", "Extend main", "--author", "Other Dev <other@example.com>")
+	git("checkout", "-b", "feature")
+	commit("feature.js", "x\n", "Add feature")
+	git("checkout", "main")
+	git("merge", "--no-ff", "-m", "Merge feature", "feature")
+
+	// Merges list both parents
+	result, err := GitLog(t.Context(), "history", GitLogOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("GitLog failed: %v", err)
+	}
+	if len(result.Commits) != 1 || len(result.Commits[0].Parents) != 2 || result.NextCursor == "" {
+		t.Fatalf("Expected the merge with two parents and a next page, got %+v", result)
+	}
+
+	// A file's history across a rename, with stat and body
+	history, err := GitLog(t.Context(), "history", GitLogOptions{FilePath: "main.js", Follow: true, Stat: true, Body: true})
+	if err != nil {
+		t.Fatalf("GitLog of a file failed: %v", err)
+	}
+	var messages []string
+	for _, commit := range history.Commits {
+		messages = append(messages, commit.Message)
+	}
+	if strings.Join(messages, ",") != "Extend main,Rename app,Fix app,Add app" {
+		t.Fatalf("Expected the file's history across the rename, got %v", messages)
+	}
+	if oldest := history.Commits[3]; oldest.Body != "First version of the app." || len(oldest.Files) != 1 || oldest.Files[0].Additions != 1 {
+		t.Errorf("Expected the body and stat of the first commit, got %+v", oldest)
+	}
+
+	// Filters
+	for _, tt := range []struct {
+		options GitLogOptions
+		want    string
+	}{
+		{GitLogOptions{Author: "other@"}, "Extend main"},
+		{GitLogOptions{Grep: "^fix"}, "Fix app"},
+		{GitLogOptions{Ref: "main^..feature"}, "Add feature"},
+		{GitLogOptions{FilePath: "style.css"}, "Add styles"},
+	} {
+		result, err := GitLog(t.Context(), "history", tt.options)
+		if err != nil {
+			t.Fatalf("GitLog(%+v) failed: %v", tt.options, err)
+		}
+		if len(result.Commits) != 1 || result.Commits[0].Message != tt.want {
+			t.Errorf("GitLog(%+v) = %+v; want only %q", tt.options, result.Commits, tt.want)
+		}
+	}
+
+	// Pages cover the history once, even when commits are added in between
+	var paged []string
+	options := GitLogOptions{Limit: 3}
+	for page := 0; page < 5; page++ {
+		result, err := GitLog(t.Context(), "history", options)
+		if err != nil {
+			t.Fatalf("GitLog page %d failed: %v", page, err)
+		}
+		for _, commit := range result.Commits {
+			paged = append(paged, commit.Message)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		if page == 0 {
+			commit("late.js", "late\n", "Late commit")
+		}
+		options.Cursor = result.NextCursor
+	}
+	if len(paged) != 7 || paged[0] != "Merge feature" || paged[6] != "Add app" {
+		t.Errorf("Expected the 7 commits in order across pages, got %v", paged)
+	}
+
+	if _, err := GitLog(t.Context(), "history", GitLogOptions{Cursor: "bm90IGEgY3Vyc29y"}); err == nil {
+		t.Error("Expected an invalid cursor to fail")
+	}
+	if _, err := GitLog(t.Context(), "history", GitLogOptions{Ref: "nosuch"}); err == nil {
+		t.Error("Expected an unknown ref to fail")
+	}
+}
@@ -13,7 +13,7 @@ func TestGitStatusMcp(t *testing.T) {
 	// Missing app_name
 	req := mcp.CallToolRequest{}
 	req.Params.Arguments = map[string]interface{}{}
-	_, err := GitStatusMcp(ctx, req)
+	_, err := gitStatusTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -27,7 +27,7 @@ func TestGitDiffMcp(t *testing.T) {
 	req.Params.Arguments = map[string]interface{}{
 		"staged": true,
 	}
-	_, err := GitDiffMcp(ctx, req)
+	_, err := gitDiffTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -41,7 +41,7 @@ func TestGitCommitMcp(t *testing.T) {
 	req.Params.Arguments = map[string]interface{}{
 		"message": "test",
 	}
-	_, err := GitCommitMcp(ctx, req)
+	_, err := gitCommitTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -53,7 +53,7 @@ func TestGitLogMcp(t *testing.T) {
 	// Missing app_name
 	req := mcp.CallToolRequest{}
 	req.Params.Arguments = map[string]interface{}{}
-	_, err := GitLogMcp(ctx, req)
+	_, err := gitLogTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -67,7 +67,7 @@ func TestGitAddMcp(t *testing.T) {
 	req.Params.Arguments = map[string]interface{}{
 		"files": []string{"test.txt"},
 	}
-	_, err := GitAddMcp(ctx, req)
+	_, err := gitAddTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -81,7 +81,7 @@ func TestGitInitMcp(t *testing.T) {
 	req.Params.Arguments = map[string]interface{}{
 		"bare": false,
 	}
-	_, err := GitInitMcp(ctx, req)
+	_, err := gitInitTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
@@ -95,7 +95,7 @@ func TestGitShowMcp(t *testing.T) {
 	req.Params.Arguments = map[string]interface{}{
 		"commit_ref": "HEAD",
 	}
-	_, err := GitShowMcp(ctx, req)
+	_, err := gitShowTool().HandleMCP(ctx, req)
 	if err == nil {
 		t.Error("Expected error for missing app_name")
 	}
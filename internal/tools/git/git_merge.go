@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+type GitMergeParams struct {
+	AppName  string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Branch   string `json:"branch" pos:"true" desc:"Branch or commit to merge into the current branch"`
+	Message  string `json:"message" short:"m" desc:"Message of the merge commit (default: git's merge message)"`
+	NoFF     bool   `json:"no_ff" desc:"Create a merge commit even when the merge could fast-forward"`
+	FFOnly   bool   `json:"ff_only" desc:"Merge only if the current branch can fast-forward"`
+	Squash   bool   `json:"squash" desc:"Stage the merged changes without committing or recording a merge"`
+	Continue bool   `json:"continue" desc:"Commit a merge whose conflicts have been resolved and staged"`
+	Abort    bool   `json:"abort" desc:"Abort the merge in progress and go back to the state before it"`
+}
+
+// GitMergeOptions configures GitMerge
+type GitMergeOptions struct {
+	Branch   string
+	Message  string
+	NoFF     bool
+	FFOnly   bool
+	Squash   bool
+	Continue bool
+	Abort    bool
+}
+
+// GitMerge merges a branch into the current branch, or continues or aborts the merge in progress
+func GitMerge(ctx context.Context, appName string, options GitMergeOptions) (GitOperationResult, error) {
+	step, err := operationStep(options.Continue, options.Abort, false)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if step == "" && options.Branch == "" {
+		return GitOperationResult{}, fmt.Errorf("branch is required unless continuing or aborting a merge")
+	}
+	if options.NoFF && (options.FFOnly || options.Squash) {
+		return GitOperationResult{}, fmt.Errorf("no_ff can't be combined with ff_only or squash")
+	}
+	if err := validateRef(options.Branch); err != nil {
+		return GitOperationResult{}, err
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitOperationResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	switch step {
+	case "--continue":
+		return runOperation(ctx, repo, "merge", "Merge completed", "merge", "--continue")
+	case "--abort":
+		return runOperation(ctx, repo, "merge", "Merge aborted", "merge", "--abort")
+	}
+
+	args := []string{"merge"}
+	if options.Message != "" {
+		args = append(args, "-m", options.Message)
+	}
+	if options.NoFF {
+		args = append(args, "--no-ff")
+	}
+	if options.FFOnly {
+		args = append(args, "--ff-only")
+	}
+	if options.Squash {
+		args = append(args, "--squash")
+	}
+	args = append(args, options.Branch)
+
+	message := fmt.Sprintf("Merged '%s'", options.Branch)
+	if options.Squash {
+		message = fmt.Sprintf("Staged the changes of '%s'; commit them with git_commit", options.Branch)
+	}
+	return runOperation(ctx, repo, "merge", message, args...)
+}
+
+// Tool
+func gitMergeTool() registry.Tool {
+	return registry.New(registry.Spec[GitMergeParams, GitOperationResult]{
+		Name:        "git_merge",
+		Group:       "git",
+		Description: "Join another branch into the current branch (requires git to be installed)",
+		Summary:     "Merge a branch into the current branch",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"A merge that stops on conflicts lists the conflicted files; resolve them with git_conflicts, then run git_merge --continue or --abort",
+		},
+		Examples: []string{
+			"# Merge a feature branch\nlayered-code tool git_merge myapp feature",
+			"# Finish the merge after resolving its conflicts\nlayered-code tool git_merge myapp --continue",
+		},
+		Run: func(ctx context.Context, params GitMergeParams) (GitOperationResult, error) {
+			return GitMerge(ctx, params.AppName, GitMergeOptions{
+				Branch:   params.Branch,
+				Message:  params.Message,
+				NoFF:     params.NoFF,
+				FFOnly:   params.FFOnly,
+				Squash:   params.Squash,
+				Continue: params.Continue,
+				Abort:    params.Abort,
+			})
+		},
+		Render: func(w io.Writer, _ GitMergeParams, result GitOperationResult) {
+			renderOperation(w, result)
+		},
+	})
+}
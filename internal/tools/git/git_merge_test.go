@@ -0,0 +1,144 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// setupConflict creates an app whose main and feature branches change the same line of app.js
+func setupConflict(t *testing.T) string {
+	t.Helper()
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "merging")
+	os.MkdirAll(appPath, 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		os.WriteFile(filepath.Join(appPath, "app.js"), []byte(content), 0644)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("const a = 1\nconst color = 'red'\nconst b = 2\n")
+	git("add", "-A")
+	git("commit", "-m", "Initial")
+	git("checkout", "-b", "feature")
+	write("const a = 1\nconst color = 'blue'\nconst b = 2\n")
+	git("commit", "-am", "Blue")
+	git("checkout", "main")
+	write("const a = 1\nconst color = 'green'\nconst b = 2\n")
+	git("commit", "-am", "Green")
+	return appPath
+}
+
+func TestGitMergeConflicts(t *testing.T) {
+	appPath := setupConflict(t)
+
+	result, err := GitMerge(t.Context(), "merging", GitMergeOptions{Branch: "feature"})
+	if err != nil {
+		t.Fatalf("GitMerge failed: %v", err)
+	}
+	if result.Success || result.InProgress != "merge" || len(result.Conflicts) != 1 || result.Conflicts[0] != "app.js" {
+		t.Fatalf("Expected the merge to stop on app.js, got %+v", result)
+	}
+
+	conflicts, err := GitConflicts(t.Context(), "merging", false)
+	if err != nil {
+		t.Fatalf("GitConflicts failed: %v", err)
+	}
+	if len(conflicts.Files) != 1 || conflicts.Operation != "merge" {
+		t.Fatalf("Expected one conflicted file during a merge, got %+v", conflicts)
+	}
+	file := conflicts.Files[0]
+	if file.Conflict != "both_modified" || len(file.Hunks) != 1 {
+		t.Fatalf("Expected one hunk in app.js, got %+v", file)
+	}
+	hunk := file.Hunks[0]
+	if !strings.Contains(hunk.Ours, "green") || !strings.Contains(hunk.Theirs, "blue") || !strings.Contains(hunk.Base, "red") {
+		t.Errorf("Unexpected hunk %+v", hunk)
+	}
+
+	if _, err := GitResolveConflict(t.Context(), "merging", "app.js", 1, "mine", "", false); err == nil {
+		t.Error("Expected an invalid choice to fail")
+	}
+	if _, err := GitResolveConflict(t.Context(), "merging", "app.js", 2, ChoiceOurs, "", false); err == nil {
+		t.Error("Expected a missing hunk to fail")
+	}
+
+	resolved, err := GitResolveConflict(t.Context(), "merging", "app.js", 1, ChoiceTheirs, "", false)
+	if err != nil {
+		t.Fatalf("GitResolveConflict failed: %v", err)
+	}
+	if !resolved.Staged || len(resolved.Files) != 0 {
+		t.Errorf("Expected app.js to be resolved and staged, got %+v", resolved)
+	}
+	data, _ := os.ReadFile(filepath.Join(appPath, "app.js"))
+	if string(data) != "const a = 1\nconst color = 'blue'\nconst b = 2\n" {
+		t.Errorf("Unexpected resolved file %q", data)
+	}
+
+	done, err := GitMerge(t.Context(), "merging", GitMergeOptions{Continue: true})
+	if err != nil || !done.Success || done.InProgress != "" {
+		t.Errorf("GitMerge --continue = %+v, %v", done, err)
+	}
+}
+
+func TestGitRebaseAndCherryPickAbort(t *testing.T) {
+	appPath := setupConflict(t)
+	head := func() string {
+		out, _ := exec.Command("git", "-C", appPath, "rev-parse", "HEAD").Output()
+		return strings.TrimSpace(string(out))
+	}
+	before := head()
+
+	rebase, err := GitRebase(t.Context(), "merging", "feature", false, false, false)
+	if err != nil {
+		t.Fatalf("GitRebase failed: %v", err)
+	}
+	if rebase.Success || rebase.InProgress != "rebase" || len(rebase.Conflicts) != 1 {
+		t.Fatalf("Expected the rebase to stop on a conflict, got %+v", rebase)
+	}
+	if abort, err := GitRebase(t.Context(), "merging", "", false, true, false); err != nil || !abort.Success || abort.InProgress != "" {
+		t.Fatalf("GitRebase --abort = %+v, %v", abort, err)
+	}
+	if head() != before {
+		t.Error("Expected the abort to restore the branch")
+	}
+
+	pick, err := GitCherryPick(t.Context(), "merging", []string{"feature"}, false, false, false, false)
+	if err != nil {
+		t.Fatalf("GitCherryPick failed: %v", err)
+	}
+	if pick.Success || pick.InProgress != "cherry_pick" || len(pick.Conflicts) != 1 {
+		t.Fatalf("Expected the cherry-pick to stop on a conflict, got %+v", pick)
+	}
+
+	// Resolving with new content replaces the whole file
+	if _, err := GitResolveConflict(t.Context(), "merging", "app.js", 0, ChoiceContent, "const color = 'teal'\n", false); err != nil {
+		t.Fatalf("GitResolveConflict failed: %v", err)
+	}
+	if done, err := GitCherryPick(t.Context(), "merging", nil, false, true, false, false); err != nil || !done.Success {
+		t.Fatalf("GitCherryPick --continue = %+v, %v", done, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(appPath, "app.js"))
+	if string(data) != "const color = 'teal'\n" {
+		t.Errorf("Unexpected file after the cherry-pick %q", data)
+	}
+
+	if _, err := GitRebase(t.Context(), "merging", "", true, true, false); err == nil {
+		t.Error("Expected continue and abort together to fail")
+	}
+}
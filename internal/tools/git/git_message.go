@@ -0,0 +1,228 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/layered-flow/layered-code/internal/config"
+)
+
+// validateCommitMessage returns why a commit message breaks the rules of the git settings, or
+// "" if it follows them
+func validateCommitMessage(message string, settings config.GitConfig) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	subject := strings.TrimSpace(lines[0])
+	if n := utf8.RuneCountInString(subject); settings.MaxSubjectLength > 0 && n > settings.MaxSubjectLength {
+		return fmt.Sprintf("the subject line is %d characters; the limit is %d (git.max_subject_length)", n, settings.MaxSubjectLength)
+	}
+
+	if settings.ConventionalCommits {
+		if subject == "" {
+			return "the subject line is empty (git.conventional_commits)"
+		}
+		if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+			return "leave a blank line between the subject and the body (git.conventional_commits)"
+		}
+		cc, ok := parseConventional(subject)
+		if !ok {
+			return "the subject must follow Conventional Commits: type(scope): description, e.g. feat(auth): add login form (git.conventional_commits)"
+		}
+		if !slices.Contains(commitTypes(), cc.Type) {
+			return fmt.Sprintf("unknown commit type %q; use one of %s (git.conventional_commits)", cc.Type, strings.Join(commitTypes(), ", "))
+		}
+	}
+
+	if settings.IssueRef != "" {
+		// The pattern is checked when the config is loaded
+		if re, err := regexp.Compile(settings.IssueRef); err == nil && !re.MatchString(message) {
+			return fmt.Sprintf("the message must reference an issue matching %s (git.issue_ref)", settings.IssueRef)
+		}
+	}
+	return ""
+}
+
+// commitTypes are the conventional-commit types the changelog has sections for
+func commitTypes() []string {
+	var types []string
+	for _, t := range changelogTypes {
+		if t.Type != "other" {
+			types = append(types, t.Type)
+		}
+	}
+	return types
+}
+
+// draftCommitMessage drafts a commit message for changes: a subject naming what changed, and a
+// bullet per file with its line counts and the code the hunks are in. The subject follows the
+// git settings, guessing the conventional-commit type from the kind of files changed.
+func draftCommitMessage(files []DiffFile, settings config.GitConfig) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	// Name less of the change until the subject fits
+	var subject string
+	for detail := 2; detail >= 0; detail-- {
+		subject = draftSubject(files, detail, settings)
+		if settings.MaxSubjectLength == 0 || utf8.RuneCountInString(subject) <= settings.MaxSubjectLength {
+			break
+		}
+	}
+	if runes := []rune(subject); settings.MaxSubjectLength > 0 && len(runes) > settings.MaxSubjectLength {
+		subject = string(runes[:settings.MaxSubjectLength])
+	}
+
+	var b strings.Builder
+	b.WriteString(subject + "\n\n")
+	for _, file := range files {
+		fmt.Fprintf(&b, "- %s\n", describeFileChange(file))
+	}
+	if settings.IssueRef != "" {
+		b.WriteString("\nRefs: <issue>\n")
+	}
+	return b.String()
+}
+
+// draftSubject names the change. Detail 2 names up to three files, 1 counts them in their
+// directory and 0 only counts them.
+func draftSubject(files []DiffFile, detail int, settings config.GitConfig) string {
+	verb := "Update"
+	switch {
+	case allFiles(files, func(f DiffFile) bool { return f.Status == DiffAdded }):
+		verb = "Add"
+	case allFiles(files, func(f DiffFile) bool { return f.Status == DiffDeleted }):
+		verb = "Remove"
+	case allFiles(files, func(f DiffFile) bool { return f.Status == DiffRenamed }):
+		verb = "Rename"
+	}
+
+	dir := commonDir(files)
+	var what string
+	switch {
+	case len(files) == 1 && files[0].Status == DiffRenamed && detail == 2:
+		what = fmt.Sprintf("%s to %s", path.Base(files[0].OldPath), path.Base(files[0].NewPath))
+	case len(files) <= 3 && detail == 2:
+		var names []string
+		for _, file := range files {
+			names = append(names, path.Base(file.Path()))
+		}
+		what = joinNames(names)
+	case dir != "" && detail == 1:
+		what = fmt.Sprintf("%d files in %s", len(files), dir)
+	default:
+		what = fmt.Sprintf("%d files", len(files))
+	}
+
+	if !settings.ConventionalCommits {
+		return verb + " " + what
+	}
+	prefix := guessCommitType(files)
+	if scope := path.Base(dir); dir != "" && scope != "src" {
+		prefix += "(" + scope + ")"
+	}
+	return fmt.Sprintf("%s: %s %s", prefix, strings.ToLower(verb), what)
+}
+
+// describeFileChange is a bullet of a draft: the file, how it changed and the code it changed in
+func describeFileChange(file DiffFile) string {
+	var b strings.Builder
+	if file.Status == DiffRenamed || file.Status == DiffCopied {
+		fmt.Fprintf(&b, "%s: %s from %s", file.Path(), file.Status, file.OldPath)
+	} else {
+		fmt.Fprintf(&b, "%s: %s", file.Path(), file.Status)
+	}
+
+	switch {
+	case file.Binary:
+		b.WriteString(" (binary)")
+	case file.Additions > 0 && file.Deletions > 0:
+		fmt.Fprintf(&b, " (+%d -%d)", file.Additions, file.Deletions)
+	case file.Additions > 0:
+		fmt.Fprintf(&b, " (+%d)", file.Additions)
+	case file.Deletions > 0:
+		fmt.Fprintf(&b, " (-%d)", file.Deletions)
+	}
+
+	// Git names the function or section a hunk is in after its header. That is just the
+	// nearest preceding line, so it's left out for files that had secrets hidden.
+	if file.Protected || file.redacted {
+		return b.String()
+	}
+	var sections []string
+	for _, hunk := range file.Hunks {
+		parts := strings.SplitN(hunk.Header, "@@", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		if section := strings.TrimSpace(parts[2]); section != "" && !slices.Contains(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) > 3 {
+		sections = append(sections[:3], "…")
+	}
+	if len(sections) > 0 && file.Status == DiffModified {
+		b.WriteString(" in " + strings.Join(sections, "; "))
+	}
+	return b.String()
+}
+
+// guessCommitType picks a conventional-commit type from the kind of files changed
+func guessCommitType(files []DiffFile) string {
+	docs := func(f DiffFile) bool {
+		p := f.Path()
+		return strings.HasSuffix(p, ".md") || strings.HasPrefix(p, "docs/")
+	}
+	tests := func(f DiffFile) bool {
+		p := f.Path()
+		return strings.Contains(p, ".test.") || strings.Contains(p, ".spec.") || strings.Contains(p, "_test.") ||
+			strings.Contains("/"+p, "/test/") || strings.Contains("/"+p, "/tests/") || strings.Contains("/"+p, "/__tests__/")
+	}
+	build := func(f DiffFile) bool {
+		name := path.Base(f.Path())
+		return name == "package.json" || strings.HasSuffix(name, "-lock.json") || strings.HasSuffix(name, ".lock") || name == "pnpm-lock.yaml" ||
+			strings.HasPrefix(name, "vite.config.") || strings.HasPrefix(name, "tsconfig") || name == "Dockerfile"
+	}
+
+	switch {
+	case allFiles(files, docs):
+		return "docs"
+	case allFiles(files, tests):
+		return "test"
+	case allFiles(files, build):
+		return "build"
+	case slices.ContainsFunc(files, func(f DiffFile) bool { return f.Status == DiffAdded }):
+		return "feat"
+	}
+	return "chore"
+}
+
+func allFiles(files []DiffFile, match func(DiffFile) bool) bool {
+	return len(files) > 0 && !slices.ContainsFunc(files, func(f DiffFile) bool { return !match(f) })
+}
+
+// commonDir returns the deepest directory holding every file, or "" for the app directory
+func commonDir(files []DiffFile) string {
+	dir := path.Dir(files[0].Path())
+	for _, file := range files[1:] {
+		for dir != "." && !strings.HasPrefix(file.Path(), dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// joinNames lists names as "a, b and c"
+func joinNames(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
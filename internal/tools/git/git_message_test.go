@@ -0,0 +1,129 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestValidateCommitMessage(t *testing.T) {
+	strict := config.GitConfig{ConventionalCommits: true, MaxSubjectLength: 50, IssueRef: `#[0-9]+`}
+	tests := []struct {
+		message  string
+		settings config.GitConfig
+		want     string // Part of the reason, "" for a valid message
+	}{
+		{"Fix the header", config.GitConfig{}, ""},
+		{"Fix the header\nIt was broken", config.GitConfig{}, ""},
+		{"  \n", strict, "subject line is empty"},
+		{"feat: fix the header #12\nIt was broken", strict, "blank line"},
+		{"feat(header): add search box\n\nCloses #12", strict, ""},
+		{"Add search box (#12)", strict, "Conventional Commits"},
+		{"feature: add search box #12", strict, `unknown commit type "feature"`},
+		{"feat: add a search box that finds pages, posts and users #12", strict, "is 60 characters; the limit is 50"},
+		{"feat: add search box", strict, "must reference an issue"},
+	}
+	for _, tt := range tests {
+		got := validateCommitMessage(tt.message, tt.settings)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("validateCommitMessage(%q) = %q; want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDraftCommitMessage(t *testing.T) {
+	files := []DiffFile{
+		{NewPath: "src/header/Search.jsx", Status: DiffAdded, Additions: 40},
+		{OldPath: "src/header/Header.jsx", NewPath: "src/header/Header.jsx", Status: DiffModified, Additions: 3, Deletions: 1,
+			Hunks: []DiffHunk{{Header: "@@ -10,4 +10,6 @@ export function Header() {"}}},
+	}
+
+	draft := draftCommitMessage(files, config.GitConfig{})
+	want := "Update Search.jsx and Header.jsx\n\n" +
+		"- src/header/Search.jsx: added (+40)\n" +
+		"- src/header/Header.jsx: modified (+3 -1) in export function Header() {\n"
+	if draft != want {
+		t.Errorf("draftCommitMessage() = %q; want %q", draft, want)
+	}
+
+	// Section text of files with hidden changes stays out of the draft
+	hidden := []DiffFile{
+		{OldPath: ".env", NewPath: ".env", Status: DiffModified, Additions: 1, Deletions: 1, Protected: true},
+		{OldPath: "app.conf", NewPath: "app.conf", Status: DiffModified, Additions: 1, Deletions: 1, redacted: true,
+			Hunks: []DiffHunk{{Header: "@@ -3,4 +3,4 @@ port=8080"}}},
+	}
+	if draft := draftCommitMessage(hidden, config.GitConfig{}); strings.Contains(draft, " in ") {
+		t.Errorf("Expected no section text in %q", draft)
+	}
+
+	// The draft follows the rules it will be checked against
+	strict := config.GitConfig{ConventionalCommits: true, MaxSubjectLength: 30, IssueRef: `#[0-9]+`}
+	draft = draftCommitMessage(files, strict)
+	subject, _, _ := strings.Cut(draft, "\n")
+	if subject != "feat(header): update 2 files" || !strings.HasSuffix(draft, "Refs: <issue>\n") {
+		t.Errorf("Unexpected strict draft %q", draft)
+	}
+	if reason := validateCommitMessage(strings.Replace(draft, "<issue>", "#7", 1), strict); reason != "" {
+		t.Errorf("Expected the completed draft to be valid, got %s", reason)
+	}
+}
+
+func TestGitCommitDraftAndRules(t *testing.T) {
+	homeDir := testutil.Home(t)
+	t.Setenv("LAYERED_GIT_CONVENTIONAL_COMMITS", "true")
+
+	appPath := filepath.Join(homeDir, "apps", "drafted")
+	os.MkdirAll(filepath.Join(appPath, "docs"), 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+
+	os.WriteFile(filepath.Join(appPath, "docs", "setup.md"), []byte("# Setup\n"), 0644)
+	git("add", "-A")
+
+	draft, err := GitCommitDraft(t.Context(), "drafted")
+	if err != nil || !draft.Success {
+		t.Fatalf("GitCommitDraft = %+v, %v", draft, err)
+	}
+	if !strings.HasPrefix(draft.Draft, "docs(docs): add setup.md\n\n- docs/setup.md: added (+1)") {
+		t.Errorf("Unexpected draft %q", draft.Draft)
+	}
+
+	rejected, err := GitCommit(t.Context(), "drafted", "Add setup docs", false, false)
+	if err != nil || rejected.Success || !strings.Contains(rejected.Message, "Conventional Commits") {
+		t.Errorf("Expected the message to be rejected, got %+v, %v", rejected, err)
+	}
+	committed, err := GitCommit(t.Context(), "drafted", "docs: add setup guide", false, false)
+	if err != nil || !committed.Success {
+		t.Errorf("Expected the commit to succeed, got %+v, %v", committed, err)
+	}
+
+	// Git fills a hunk's section text with the nearest preceding line, here a secret
+	settings := "host=localhost\nDB_PASSWORD=hunter2hunter2\nport=5432\nuser=app\nname=app\ndebug=false\n"
+	os.WriteFile(filepath.Join(appPath, "settings.conf"), []byte(settings), 0644)
+	git("add", "-A")
+	git("commit", "-m", "chore: add settings")
+	os.WriteFile(filepath.Join(appPath, "settings.conf"), []byte(strings.Replace(settings, "debug=false", "debug=true", 1)), 0644)
+	git("add", "-A")
+
+	draft, err = GitCommitDraft(t.Context(), "drafted")
+	if err != nil || !draft.Success {
+		t.Fatalf("GitCommitDraft = %+v, %v", draft, err)
+	}
+	if strings.Contains(draft.Draft, "hunter2") || !strings.Contains(draft.Draft, "- settings.conf: modified (+1 -1)\n") {
+		t.Errorf("Expected the draft to leave out the section text, got %q", draft.Draft)
+	}
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// GitOperationResult is the result of git_merge, git_rebase and git_cherry_pick. When the
+// operation stops on conflicts, Success is false, Conflicts lists the conflicted files and
+// InProgress names the operation waiting to be continued or aborted.
+type GitOperationResult struct {
+	Success     bool     `json:"success"`
+	IsRepo      bool     `json:"is_repo"`
+	Message     string   `json:"message"`
+	Output      string   `json:"output,omitempty"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+	InProgress  string   `json:"in_progress,omitempty"` // merge, rebase or cherry_pick
+	ErrorOutput string   `json:"error_output,omitempty"`
+}
+
+// conflictStyle makes conflict markers include the common ancestor, for git_conflicts
+var conflictStyle = []string{"-c", "merge.conflictStyle=diff3"}
+
+// runOperation runs a git merge, rebase or cherry-pick (name) and reports where it left the
+// repository. Stopping on conflicts isn't an error: the result lists them instead.
+func runOperation(ctx context.Context, repo *Repo, name, successMessage string, args ...string) (GitOperationResult, error) {
+	output, errOutput, err := repo.Exec(ctx, nil, slices.Concat(conflictStyle, args)...)
+	result := GitOperationResult{
+		IsRepo:      true,
+		Output:      strings.TrimSpace(output),
+		ErrorOutput: strings.TrimSpace(errOutput),
+		Conflicts:   conflictedFiles(ctx, repo),
+		InProgress:  repo.operation(ctx),
+	}
+
+	if err != nil {
+		if len(result.Conflicts) == 0 && !errors.Is(err, ErrConflict) {
+			result.Message = fmt.Sprintf("Git %s failed", name)
+			return result, err
+		}
+		result.Message = fmt.Sprintf("%s stopped on conflicts in %d file(s); resolve them with git_conflicts and stage them, then continue, or abort",
+			strings.ToUpper(name[:1])+name[1:], len(result.Conflicts))
+		return result, nil
+	}
+
+	result.Success = true
+	result.Message = successMessage
+	return result, nil
+}
+
+// operationStep returns the flag that continues, aborts or skips the operation in progress, or
+// "" to start a new one
+func operationStep(continueOp, abort, skip bool) (string, error) {
+	var steps []string
+	if continueOp {
+		steps = append(steps, "--continue")
+	}
+	if abort {
+		steps = append(steps, "--abort")
+	}
+	if skip {
+		steps = append(steps, "--skip")
+	}
+	if len(steps) > 1 {
+		return "", fmt.Errorf("only one of continue, abort and skip can be set")
+	}
+	if len(steps) == 0 {
+		return "", nil
+	}
+	return steps[0], nil
+}
+
+// conflictedFiles lists the files with unresolved conflicts
+func conflictedFiles(ctx context.Context, repo *Repo) []string {
+	output, err := repo.Run(ctx, "-c", "core.quotePath=off", "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" && (len(files) == 0 || files[len(files)-1] != line) {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// operation returns the merge, rebase, cherry-pick, revert or bisect the repository is in the
+// middle of
+func (r *Repo) operation(ctx context.Context) string {
+	dir, err := r.Run(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return ""
+	}
+	return operationInProgress(strings.TrimSpace(dir))
+}
+
+// renderOperation prints the result of git_merge, git_rebase or git_cherry_pick
+func renderOperation(w io.Writer, result GitOperationResult) {
+	if !result.IsRepo {
+		fmt.Fprintln(w, result.Message)
+		return
+	}
+
+	fmt.Fprintln(w, result.Message)
+	if result.Output != "" {
+		fmt.Fprintf(w, "\n%s\n", result.Output)
+	}
+	if len(result.Conflicts) > 0 {
+		fmt.Fprintln(w, "\nConflicted files:")
+		for _, file := range result.Conflicts {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+}
@@ -1,57 +1,45 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
+	"slices"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitPullResult struct {
-	Success     bool   `json:"success"`
-	IsRepo      bool   `json:"is_repo"`
-	Message     string `json:"message"`
-	Output      string `json:"output,omitempty"`
-	Updated     bool   `json:"updated"`
-	ErrorOutput string `json:"error_output,omitempty"`
+	Success     bool     `json:"success"`
+	IsRepo      bool     `json:"is_repo"`
+	Message     string   `json:"message"`
+	Output      string   `json:"output,omitempty"`
+	Updated     bool     `json:"updated"`
+	Conflicts   []string `json:"conflicts,omitempty"` // Files left conflicted when the pull stopped
+	ErrorOutput string   `json:"error_output,omitempty"`
 }
 
-// GitPull pulls changes from remote repository
-func GitPull(appName string, remote string, branch string, rebase bool) (GitPullResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitPullResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitPullResult{}, err
-	}
+type GitPullParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Remote  string `json:"remote" pos:"true" desc:"Remote name (default: origin)"`
+	Branch  string `json:"branch" pos:"true" desc:"Branch name to pull"`
+	Rebase  bool   `json:"rebase" desc:"Rebase instead of merge"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitPull pulls changes from remote repository. A pull that stops on conflicts isn't an error:
+// the result lists the conflicted files to resolve with git_conflicts.
+func GitPull(ctx context.Context, appName string, remote string, branch string, rebase bool) (GitPullResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitPullResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitPullResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitPullResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -74,24 +62,33 @@ func GitPull(appName string, remote string, branch string, rebase bool) (GitPull
 	}
 
 	// Run git pull
-	pullCmd := exec.Command("git", args...)
-	pullCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	pullCmd.Stdout = &outBuf
-	pullCmd.Stderr = &errBuf
-	err = pullCmd.Run()
+	output, errOutput, err := repo.Exec(ctx, nil, slices.Concat(conflictStyle, args)...)
 	
-	outputStr := strings.TrimSpace(outBuf.String())
-	errorStr := strings.TrimSpace(errBuf.String())
+	outputStr := strings.TrimSpace(output)
+	errorStr := strings.TrimSpace(errOutput)
 	
 	if err != nil {
+		if conflicts := conflictedFiles(ctx, repo); len(conflicts) > 0 {
+			step := "git_merge"
+			if rebase {
+				step = "git_rebase"
+			}
+			return GitPullResult{
+				IsRepo:      true,
+				Success:     false,
+				Message:     fmt.Sprintf("Pull stopped on conflicts in %d file(s); resolve them with git_conflicts, then run %s --continue, or %s --abort", len(conflicts), step, step),
+				Output:      outputStr,
+				Conflicts:   conflicts,
+				ErrorOutput: errorStr,
+			}, nil
+		}
 		return GitPullResult{
 			IsRepo:      true,
 			Success:     false,
 			Message:     "Git pull failed",
 			Output:      outputStr,
 			ErrorOutput: errorStr,
-		}, fmt.Errorf("git pull failed: %w - %s", err, errorStr)
+		}, err
 	}
 
 	// Check if repository was updated
@@ -106,95 +103,47 @@ func GitPull(appName string, remote string, branch string, rebase bool) (GitPull
 	}, nil
 }
 
-// CLI
-func GitPullCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_pull requires at least 1 argument: app_name\nUsage: layered-code tool git_pull <app_name> [remote] [branch] [--rebase]")
-	}
-
-	appName := args[0]
-	remote := ""
-	branch := ""
-	rebase := false
-
-	// Parse arguments
-	nonFlagArgs := []string{}
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "--rebase":
-			rebase = true
-		default:
-			if !strings.HasPrefix(args[i], "-") {
-				nonFlagArgs = append(nonFlagArgs, args[i])
+// Tool
+func gitPullTool() registry.Tool {
+	return registry.New(registry.Spec[GitPullParams, GitPullResult]{
+		Name:        "git_pull",
+		Group:       "git",
+		Description: "Fetch from and integrate with another repository or local branch (requires git to be installed)",
+		Summary:     "Fetch from and integrate with remote",
+		Hints:       registry.Hints{Idempotent: true, OpenWorld: true},
+		Notes: []string{
+			"The remote is authenticated with the matching git.credentials entry (token over HTTPS, SSH key and known_hosts policy over SSH) if the config has one, else with git's own setup; credentials are masked in the output",
+		},
+		Run: func(ctx context.Context, params GitPullParams) (GitPullResult, error) {
+			return GitPull(ctx, params.AppName, params.Remote, params.Branch, params.Rebase)
+		},
+		Render: func(w io.Writer, _ GitPullParams, result GitPullResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
 			}
-		}
-	}
-
-	// Assign non-flag arguments
-	if len(nonFlagArgs) > 0 {
-		remote = nonFlagArgs[0]
-	}
-	if len(nonFlagArgs) > 1 {
-		branch = nonFlagArgs[1]
-	}
-
-	result, err := GitPull(appName, remote, branch, rebase)
-	if err != nil {
-		return fmt.Errorf("failed to pull: %w", err)
-	}
 
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
-
-	if result.Success {
-		fmt.Println(result.Message)
-		if result.Updated {
-			fmt.Println("Repository updated with new changes")
-		} else {
-			fmt.Println("Already up to date")
-		}
-	} else {
-		fmt.Printf("Pull failed: %s\n", result.Message)
-	}
-
-	if result.Output != "" {
-		fmt.Println("\nOutput:")
-		fmt.Println(result.Output)
-	}
+			if result.Success {
+				fmt.Fprintln(w, result.Message)
+				if result.Updated {
+					fmt.Fprintln(w, "Repository updated with new changes")
+				} else {
+					fmt.Fprintln(w, "Already up to date")
+				}
+			} else {
+				fmt.Fprintf(w, "Pull failed: %s\n", result.Message)
+			}
 
-	return nil
+			if result.Output != "" {
+				fmt.Fprintln(w, "\nOutput:")
+				fmt.Fprintln(w, result.Output)
+			}
+			if len(result.Conflicts) > 0 {
+				fmt.Fprintln(w, "\nConflicted files:")
+				for _, file := range result.Conflicts {
+					fmt.Fprintf(w, "  %s\n", file)
+				}
+			}
+		},
+	})
 }
-
-// MCP
-func GitPullMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-		Remote  string `json:"remote"`
-		Branch  string `json:"branch"`
-		Rebase  bool   `json:"rebase"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitPull(args.AppName, args.Remote, args.Branch, args.Rebase)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
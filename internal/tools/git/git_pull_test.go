@@ -7,9 +7,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitPull(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-pull-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -17,7 +19,7 @@ func TestGitPull(t *testing.T) {
 
 	// Test 1: Non-git repo
 	os.MkdirAll(testAppPath, 0755)
-	result, err := GitPull(testApp, "origin", "main", false)
+	result, err := GitPull(t.Context(), testApp, "origin", "main", false)
 	if err != nil {
 		t.Fatalf("GitPull failed: %v", err)
 	}
@@ -97,7 +99,7 @@ func TestGitPull(t *testing.T) {
 	cmd.Run()
 
 	// Test 2: Pull when already up to date
-	result, err = GitPull(testApp, "origin", "master", false)
+	result, err = GitPull(t.Context(), testApp, "origin", "master", false)
 	if err != nil {
 		t.Fatalf("GitPull failed: %v", err)
 	}
@@ -140,7 +142,7 @@ func TestGitPull(t *testing.T) {
 	cmd.Run()
 
 	// Test 3: Pull with new changes
-	result, err = GitPull(testApp, "origin", "master", false)
+	result, err = GitPull(t.Context(), testApp, "origin", "master", false)
 	if err != nil {
 		t.Fatalf("GitPull failed: %v", err)
 	}
@@ -158,7 +160,7 @@ func TestGitPull(t *testing.T) {
 	}
 
 	// Test 4: Pull with empty remote (should default to origin)
-	result, err = GitPull(testApp, "", "master", false)
+	result, err = GitPull(t.Context(), testApp, "", "master", false)
 	if err != nil {
 		t.Fatalf("GitPull with empty remote failed: %v", err)
 	}
@@ -196,7 +198,7 @@ func TestGitPull(t *testing.T) {
 	cmd.Run()
 
 	// Pull with rebase
-	result, err = GitPull(testApp, "origin", "master", true)
+	result, err = GitPull(t.Context(), testApp, "origin", "master", true)
 	if err != nil {
 		t.Fatalf("GitPull with rebase failed: %v", err)
 	}
@@ -234,7 +236,7 @@ func TestGitPull(t *testing.T) {
 	cmd.Dir = testAppPath
 	cmd.Run()
 
-	result, err = GitPull(testApp, "origin", "feature-branch", false)
+	result, err = GitPull(t.Context(), testApp, "origin", "feature-branch", false)
 	if err != nil {
 		t.Fatalf("GitPull from specific branch failed: %v", err)
 	}
@@ -1,56 +1,59 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitPushResult struct {
-	Success     bool   `json:"success"`
-	IsRepo      bool   `json:"is_repo"`
-	Message     string `json:"message"`
-	Output      string `json:"output,omitempty"`
-	ErrorOutput string `json:"error_output,omitempty"`
+	Success     bool         `json:"success"`
+	IsRepo      bool         `json:"is_repo"`
+	Message     string       `json:"message"`
+	Issues      []CheckIssue `json:"issues,omitempty"`
+	Output      string       `json:"output,omitempty"`
+	ErrorOutput string       `json:"error_output,omitempty"`
 }
 
-// GitPush pushes commits to remote repository
-func GitPush(appName string, remote string, branch string, setUpstream bool, force bool) (GitPushResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
+type GitPushParams struct {
+	AppName     string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Remote      string `json:"remote" pos:"true" desc:"Remote name (default: origin)"`
+	Branch      string `json:"branch" pos:"true" desc:"Branch name to push"`
+	SetUpstream bool   `json:"set_upstream" short:"u" desc:"Set upstream tracking branch"`
+	Force       bool   `json:"force" short:"f" desc:"Force push (use with caution)"`
+	SkipChecks  bool   `json:"skip_checks" desc:"Push even if the outgoing commits contain secrets, large files or paths matching git.deny_commit"`
+}
+
+// GitPush pushes commits to remote repository. Unless skipChecks is set, outgoing commits
+// containing secrets, large files or denied paths block the push.
+func GitPush(ctx context.Context, appName string, remote string, branch string, setUpstream bool, force bool, skipChecks bool) (GitPushResult, error) {
+	if err := validateRef(remote); err != nil {
 		return GitPushResult{}, err
 	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
+	if err := validateRef(branch); err != nil {
 		return GitPushResult{}, err
 	}
-
-	appsDir, err := config.EnsureAppsDirectory()
-	if err != nil {
-		return GitPushResult{}, fmt.Errorf("failed to get apps directory: %w", err)
+	// "+branch" force-pushes and ":branch" deletes, past the force rules and confirmation
+	if strings.HasPrefix(branch, "+") {
+		return GitPushResult{}, fmt.Errorf("invalid branch %q: set force instead of prefixing the refspec with +", branch)
+	}
+	if strings.HasPrefix(branch, ":") {
+		return GitPushResult{}, fmt.Errorf("invalid branch %q: deleting remote branches isn't supported", branch)
 	}
 
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
+	repo, err := OpenRepo(appName)
+	if err != nil {
 		return GitPushResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitPushResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -59,6 +62,26 @@ func GitPush(appName string, remote string, branch string, setUpstream bool, for
 		remote = "origin"
 	}
 
+	// Check what the remote doesn't have yet
+	ref, _, _ := strings.Cut(branch, ":")
+	if branch == "" {
+		ref = "HEAD"
+	}
+	if !skipChecks {
+		issues, err := checkOutgoing(ctx, repo, remote, ref)
+		if err != nil {
+			return GitPushResult{}, fmt.Errorf("failed to check outgoing commits: %w", err)
+		}
+		if len(issues) > 0 {
+			return GitPushResult{
+				IsRepo:  true,
+				Success: false,
+				Message: fmt.Sprintf("Push blocked: %d problem(s) in outgoing commits; fix them, or set skip_checks", len(issues)),
+				Issues:  issues,
+			}, nil
+		}
+	}
+
 	// Build git push command
 	args := []string{"push"}
 	
@@ -77,15 +100,10 @@ func GitPush(appName string, remote string, branch string, setUpstream bool, for
 	}
 
 	// Run git push
-	pushCmd := exec.Command("git", args...)
-	pushCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	pushCmd.Stdout = &outBuf
-	pushCmd.Stderr = &errBuf
-	err = pushCmd.Run()
+	output, errOutput, err := repo.Exec(ctx, nil, args...)
 	
-	outputStr := strings.TrimSpace(outBuf.String())
-	errorStr := strings.TrimSpace(errBuf.String())
+	outputStr := strings.TrimSpace(output)
+	errorStr := strings.TrimSpace(errOutput)
 	
 	if err != nil {
 		return GitPushResult{
@@ -94,7 +112,7 @@ func GitPush(appName string, remote string, branch string, setUpstream bool, for
 			Message:     "Git push failed",
 			Output:      outputStr,
 			ErrorOutput: errorStr,
-		}, fmt.Errorf("git push failed: %w - %s", err, errorStr)
+		}, err
 	}
 
 	return GitPushResult{
@@ -105,93 +123,37 @@ func GitPush(appName string, remote string, branch string, setUpstream bool, for
 	}, nil
 }
 
-// CLI
-func GitPushCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_push requires at least 1 argument: app_name\nUsage: layered-code tool git_push <app_name> [remote] [branch] [-u|--set-upstream] [--force]")
-	}
-
-	appName := args[0]
-	remote := ""
-	branch := ""
-	setUpstream := false
-	force := false
-
-	// Parse arguments
-	nonFlagArgs := []string{}
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "-u", "--set-upstream":
-			setUpstream = true
-		case "--force", "-f":
-			force = true
-		default:
-			if !strings.HasPrefix(args[i], "-") {
-				nonFlagArgs = append(nonFlagArgs, args[i])
+// Tool
+func gitPushTool() registry.Tool {
+	return registry.New(registry.Spec[GitPushParams, GitPushResult]{
+		Name:        "git_push",
+		Group:       "git",
+		Description: "Update remote refs along with associated objects (requires git to be installed)",
+		Summary:     "Update remote refs",
+		Hints:       registry.Hints{Destructive: true, OpenWorld: true},
+		Notes: []string{
+			"Commits the remote doesn't have yet are checked for secrets, files over git.max_file_size and paths matching git.deny_commit; use --skip-checks to push anyway",
+			"The remote is authenticated with the matching git.credentials entry (token over HTTPS, SSH key and known_hosts policy over SSH) if the config has one, else with git's own setup; credentials are masked in the output",
+		},
+		Run: func(ctx context.Context, params GitPushParams) (GitPushResult, error) {
+			return GitPush(ctx, params.AppName, params.Remote, params.Branch, params.SetUpstream, params.Force, params.SkipChecks)
+		},
+		Render: func(w io.Writer, _ GitPushParams, result GitPushResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
 			}
-		}
-	}
 
-	// Assign non-flag arguments
-	if len(nonFlagArgs) > 0 {
-		remote = nonFlagArgs[0]
-	}
-	if len(nonFlagArgs) > 1 {
-		branch = nonFlagArgs[1]
-	}
-
-	result, err := GitPush(appName, remote, branch, setUpstream, force)
-	if err != nil {
-		return fmt.Errorf("failed to push: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
-
-	if result.Success {
-		fmt.Println(result.Message)
-	} else {
-		fmt.Printf("Push failed: %s\n", result.Message)
-	}
-
-	if result.Output != "" {
-		fmt.Println(result.Output)
-	}
+			if result.Success {
+				fmt.Fprintln(w, result.Message)
+			} else {
+				fmt.Fprintf(w, "Push failed: %s\n", result.Message)
+				renderIssues(w, result.Issues)
+			}
 
-	return nil
+			if result.Output != "" {
+				fmt.Fprintln(w, result.Output)
+			}
+		},
+	})
 }
-
-// MCP
-func GitPushMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName     string `json:"app_name"`
-		Remote      string `json:"remote"`
-		Branch      string `json:"branch"`
-		SetUpstream bool   `json:"set_upstream"`
-		Force       bool   `json:"force"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitPush(args.AppName, args.Remote, args.Branch, args.SetUpstream, args.Force)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
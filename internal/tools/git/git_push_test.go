@@ -7,9 +7,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitPush(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-push-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -17,8 +19,21 @@ func TestGitPush(t *testing.T) {
 
 	os.MkdirAll(testAppPath, 0755)
 
+	// Refspecs that force-push or delete, and option-like names, are rejected
+	for _, tt := range []struct{ remote, branch string }{
+		{"origin", "+main"},
+		{"origin", "+HEAD:main"},
+		{"origin", ":main"},
+		{"origin", "--delete"},
+		{"--receive-pack=evil", "main"},
+	} {
+		if _, err := GitPush(t.Context(), testApp, tt.remote, tt.branch, false, false, false); err == nil {
+			t.Errorf("Expected GitPush(%q, %q) to be rejected", tt.remote, tt.branch)
+		}
+	}
+
 	// Test 1: Non-git repo
-	result, err := GitPush(testApp, "origin", "main", false, false)
+	result, err := GitPush(t.Context(), testApp, "origin", "main", false, false, false)
 	if err != nil {
 		t.Fatalf("GitPush failed: %v", err)
 	}
@@ -30,7 +45,7 @@ func TestGitPush(t *testing.T) {
 	}
 
 	// Initialize git repo
-	GitInit(testApp, false)
+	GitInit(t.Context(), testApp, false)
 	
 	// Configure git user for commits
 	cmd := exec.Command("git", "config", "user.email", "test@example.com")
@@ -41,7 +56,7 @@ func TestGitPush(t *testing.T) {
 	cmd.Run()
 
 	// Test 2: Push without remote
-	result, err = GitPush(testApp, "origin", "main", false, false)
+	result, err = GitPush(t.Context(), testApp, "origin", "main", false, false, false)
 	if err == nil {
 		t.Error("Expected error for push without remote")
 	}
@@ -50,7 +65,7 @@ func TestGitPush(t *testing.T) {
 	}
 
 	// Test 3: Push with empty remote (should default to origin)
-	result, err = GitPush(testApp, "", "main", false, false)
+	result, err = GitPush(t.Context(), testApp, "", "main", false, false, false)
 	if err == nil {
 		t.Error("Expected error for push without configured remote")
 	}
@@ -88,7 +103,7 @@ func TestGitPush(t *testing.T) {
 	cmd.Dir = testAppPath
 	cmd.Run()
 	
-	result, err = GitPush(testApp, "origin", "main", false, false)
+	result, err = GitPush(t.Context(), testApp, "origin", "main", false, false, false)
 	if err != nil {
 		t.Fatalf("GitPush failed: %v", err)
 	}
@@ -110,7 +125,7 @@ func TestGitPush(t *testing.T) {
 	cmd.Dir = testAppPath
 	cmd.Run()
 
-	result, err = GitPush(testApp, "origin", "feature-branch", true, false)
+	result, err = GitPush(t.Context(), testApp, "origin", "feature-branch", true, false, false)
 	if err != nil {
 		t.Fatalf("GitPush with set-upstream failed: %v", err)
 	}
@@ -128,7 +143,7 @@ func TestGitPush(t *testing.T) {
 	cmd.Dir = testAppPath
 	cmd.Run()
 
-	result, err = GitPush(testApp, "origin", "feature-branch", false, true)
+	result, err = GitPush(t.Context(), testApp, "origin", "feature-branch", false, true, false)
 	if err != nil {
 		t.Fatalf("GitPush with force failed: %v", err)
 	}
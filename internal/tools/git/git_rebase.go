@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+type GitRebaseParams struct {
+	AppName  string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Upstream string `json:"upstream" pos:"true" desc:"Branch or commit to replay the current branch's commits onto"`
+	Continue bool   `json:"continue" desc:"Go on with the rebase after the conflicts of the current commit are resolved and staged"`
+	Abort    bool   `json:"abort" desc:"Abort the rebase in progress and go back to the branch as it was"`
+	Skip     bool   `json:"skip" desc:"Leave out the commit the rebase stopped at and go on"`
+}
+
+// GitRebase replays the current branch's commits onto upstream, or continues, aborts or skips a
+// commit of the rebase in progress. It never opens an editor.
+func GitRebase(ctx context.Context, appName string, upstream string, continueRebase bool, abort bool, skip bool) (GitOperationResult, error) {
+	step, err := operationStep(continueRebase, abort, skip)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if step == "" && upstream == "" {
+		return GitOperationResult{}, fmt.Errorf("upstream is required unless continuing, aborting or skipping")
+	}
+	if err := validateRef(upstream); err != nil {
+		return GitOperationResult{}, err
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitOperationResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitOperationResult{
+			IsRepo:  false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	switch step {
+	case "--continue":
+		return runOperation(ctx, repo, "rebase", "Rebase completed", "rebase", "--continue")
+	case "--abort":
+		return runOperation(ctx, repo, "rebase", "Rebase aborted", "rebase", "--abort")
+	case "--skip":
+		return runOperation(ctx, repo, "rebase", "Commit skipped and rebase completed", "rebase", "--skip")
+	}
+	return runOperation(ctx, repo, "rebase", fmt.Sprintf("Rebased onto '%s'", upstream), "rebase", upstream)
+}
+
+// Tool
+func gitRebaseTool() registry.Tool {
+	return registry.New(registry.Spec[GitRebaseParams, GitOperationResult]{
+		Name:        "git_rebase",
+		Group:       "git",
+		Description: "Reapply the current branch's commits on top of another branch, non-interactively (requires git to be installed)",
+		Summary:     "Rebase the current branch",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"A rebase that stops on conflicts lists the conflicted files; resolve them with git_conflicts, then run git_rebase --continue, --skip or --abort",
+			"Rebasing rewrites the branch's commits; avoid it on commits others have pulled",
+		},
+		Examples: []string{
+			"# Rebase the current branch onto main\nlayered-code tool git_rebase myapp main",
+			"# Go on after resolving the conflicts\nlayered-code tool git_rebase myapp --continue",
+		},
+		Run: func(ctx context.Context, params GitRebaseParams) (GitOperationResult, error) {
+			return GitRebase(ctx, params.AppName, params.Upstream, params.Continue, params.Abort, params.Skip)
+		},
+		Render: func(w io.Writer, _ GitRebaseParams, result GitOperationResult) {
+			renderOperation(w, result)
+		},
+	})
+}
@@ -1,18 +1,12 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -26,32 +20,27 @@ type GitRemoteResult struct {
 	ErrorOutput   string            `json:"error_output,omitempty"`
 }
 
-// GitRemote manages git remotes in the specified app directory
-func GitRemote(appName string, addName string, addURL string, removeName string, oldName string, newName string, setURL string, setURLName string) (GitRemoteResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitRemoteResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitRemoteResult{}, err
-	}
+type GitRemoteParams struct {
+	AppName    string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	AddName    string `json:"add_name" desc:"Name of remote to add"`
+	AddURL     string `json:"add_url" desc:"URL of remote to add (required with add_name)"`
+	RemoveName string `json:"remove_name" short:"r" alias:"remove" desc:"Name of remote to remove"`
+	OldName    string `json:"old_name" desc:"Current name of remote to rename"`
+	NewName    string `json:"new_name" desc:"New name for remote (required with old_name)"`
+	SetURLName string `json:"set_url_name" desc:"Name of remote to update URL for"`
+	SetURL     string `json:"set_url" desc:"New URL for remote (required with set_url_name)"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitRemote manages git remotes in the specified app directory
+func GitRemote(ctx context.Context, appName string, addName string, addURL string, removeName string, oldName string, newName string, setURL string, setURLName string) (GitRemoteResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitRemoteResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitRemoteResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitRemoteResult{
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -62,16 +51,11 @@ func GitRemote(appName string, addName string, addURL string, removeName string,
 
 	// Handle add remote
 	if addName != "" && addURL != "" {
-		cmd := exec.Command("git", "remote", "add", addName, addURL)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "remote", "add", addName, addURL)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to add remote: %s", strings.TrimSpace(errBuf.String()))
+			result.Message = fmt.Sprintf("Failed to add remote: %s", strings.TrimSpace(errOutput))
 			result.AddSuccess = false
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		} else {
 			result.AddSuccess = true
 		}
@@ -79,16 +63,11 @@ func GitRemote(appName string, addName string, addURL string, removeName string,
 
 	// Handle remove remote
 	if removeName != "" {
-		cmd := exec.Command("git", "remote", "remove", removeName)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "remote", "remove", removeName)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to remove remote: %s", strings.TrimSpace(errBuf.String()))
+			result.Message = fmt.Sprintf("Failed to remove remote: %s", strings.TrimSpace(errOutput))
 			result.RemoveSuccess = false
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		} else {
 			result.RemoveSuccess = true
 		}
@@ -96,16 +75,11 @@ func GitRemote(appName string, addName string, addURL string, removeName string,
 
 	// Handle rename remote
 	if oldName != "" && newName != "" {
-		cmd := exec.Command("git", "remote", "rename", oldName, newName)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "remote", "rename", oldName, newName)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to rename remote: %s", strings.TrimSpace(errBuf.String()))
+			result.Message = fmt.Sprintf("Failed to rename remote: %s", strings.TrimSpace(errOutput))
 			result.RenameSuccess = false
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		} else {
 			result.RenameSuccess = true
 		}
@@ -113,41 +87,32 @@ func GitRemote(appName string, addName string, addURL string, removeName string,
 
 	// Handle set-url
 	if setURLName != "" && setURL != "" {
-		cmd := exec.Command("git", "remote", "set-url", setURLName, setURL)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		_, errOutput, err := repo.Exec(ctx, nil, "remote", "set-url", setURLName, setURL)
 		if err != nil {
-			result.Message = fmt.Sprintf("Failed to set remote URL: %s", strings.TrimSpace(errBuf.String()))
-			result.ErrorOutput = errBuf.String()
+			result.Message = fmt.Sprintf("Failed to set remote URL: %s", strings.TrimSpace(errOutput))
+			result.ErrorOutput = errOutput
 		}
 	}
 
 	// List all remotes with their URLs
-	cmd := exec.Command("git", "remote", "-v")
-	cmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	cmd.Stderr = &errBuf
-	err = cmd.Run()
+	output, errOutput, err := repo.Exec(ctx, nil, "remote", "-v")
 	if err != nil {
 		if result.ErrorOutput == "" {
-			result.ErrorOutput = errBuf.String()
+			result.ErrorOutput = errOutput
 		}
 		return result, nil
 	}
 
 	// Parse remote list
-	lines := strings.Split(outBuf.String(), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
-		// Parse "origin  https://github.com/user/repo.git (fetch)" format
+		// Parse "origin  https://github.com/user/repo.git (fetch)" format; Exec has masked
+		// passwords and tokens in the URLs
 		parts := strings.Fields(line)
 		if len(parts) >= 2 {
 			remoteName := parts[0]
@@ -162,140 +127,74 @@ func GitRemote(appName string, addName string, addURL string, removeName string,
 	return result, nil
 }
 
-// CLI
-func GitRemoteCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_remote requires at least 1 argument: app_name\nUsage: layered-code tool git_remote <app_name> [-a <name> <url>] [-r <name>] [--rename <old> <new>] [--set-url <name> <url>]")
-	}
-
-	appName := args[0]
-	addName := ""
-	addURL := ""
-	removeName := ""
-	oldName := ""
-	newName := ""
-	setURLName := ""
-	setURL := ""
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "-a", "--add":
-			if i+2 < len(args) {
-				addName = args[i+1]
-				addURL = args[i+2]
-				i += 2
-			}
-		case "-r", "--remove":
-			if i+1 < len(args) {
-				removeName = args[i+1]
-				i++
+// Tool
+func gitRemoteTool() registry.Tool {
+	return registry.New(registry.Spec[GitRemoteParams, GitRemoteResult]{
+		Name:        "git_remote",
+		Group:       "git",
+		Description: "Manage git remotes (list, add, remove, rename, set-url) (requires git to be installed)",
+		Summary:     "Manage git remotes (list, add, remove, rename)",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"Passwords and tokens in remote URLs are masked in the listing and in errors; rather than putting them in URLs, set up git.credentials in the config",
+		},
+		Examples: []string{
+			"# Add a remote\nlayered-code tool git_remote myapp --add-name origin --add-url https://github.com/user/repo.git",
+			"# Rename a remote\nlayered-code tool git_remote myapp --old-name origin --new-name upstream",
+		},
+		Run: func(ctx context.Context, params GitRemoteParams) (GitRemoteResult, error) {
+			return GitRemote(ctx, params.AppName, params.AddName, params.AddURL, params.RemoveName, params.OldName, params.NewName, params.SetURL, params.SetURLName)
+		},
+		Render: func(w io.Writer, params GitRemoteParams, result GitRemoteResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
 			}
-		case "--rename":
-			if i+2 < len(args) {
-				oldName = args[i+1]
-				newName = args[i+2]
-				i += 2
-			}
-		case "--set-url":
-			if i+2 < len(args) {
-				setURLName = args[i+1]
-				setURL = args[i+2]
-				i += 2
-			}
-		}
-	}
 
-	result, err := GitRemote(appName, addName, addURL, removeName, oldName, newName, setURL, setURLName)
-	if err != nil {
-		return fmt.Errorf("failed to manage remotes: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
-
-	// Show operation results
-	if addName != "" {
-		if result.AddSuccess {
-			fmt.Printf("Added remote '%s' with URL: %s\n", addName, addURL)
-		} else {
-			fmt.Printf("Failed to add remote: %s\n", result.Message)
-		}
-	}
-
-	if removeName != "" {
-		if result.RemoveSuccess {
-			fmt.Printf("Removed remote: %s\n", removeName)
-		} else {
-			fmt.Printf("Failed to remove remote: %s\n", result.Message)
-		}
-	}
+			// Show operation results
+			if params.AddName != "" {
+				if result.AddSuccess {
+					fmt.Fprintf(w, "Added remote '%s' with URL: %s\n", params.AddName, redactCredentials(params.AddURL, ""))
+				} else {
+					fmt.Fprintf(w, "Failed to add remote: %s\n", result.Message)
+				}
+			}
 
-	if oldName != "" && newName != "" {
-		if result.RenameSuccess {
-			fmt.Printf("Renamed remote from '%s' to '%s'\n", oldName, newName)
-		} else {
-			fmt.Printf("Failed to rename remote: %s\n", result.Message)
-		}
-	}
+			if params.RemoveName != "" {
+				if result.RemoveSuccess {
+					fmt.Fprintf(w, "Removed remote: %s\n", params.RemoveName)
+				} else {
+					fmt.Fprintf(w, "Failed to remove remote: %s\n", result.Message)
+				}
+			}
 
-	if setURLName != "" && setURL != "" {
-		if result.Message == "" {
-			fmt.Printf("Updated URL for remote '%s' to: %s\n", setURLName, setURL)
-		} else {
-			fmt.Printf("Failed to set remote URL: %s\n", result.Message)
-		}
-	}
+			if params.OldName != "" && params.NewName != "" {
+				if result.RenameSuccess {
+					fmt.Fprintf(w, "Renamed remote from '%s' to '%s'\n", params.OldName, params.NewName)
+				} else {
+					fmt.Fprintf(w, "Failed to rename remote: %s\n", result.Message)
+				}
+			}
 
-	// Show remote list if no operation was performed
-	if addName == "" && removeName == "" && oldName == "" && setURLName == "" {
-		fmt.Printf("Git Remotes for '%s':\n", appName)
-		if len(result.Remotes) == 0 {
-			fmt.Println("No remotes configured")
-		} else {
-			for name, url := range result.Remotes {
-				fmt.Printf("  %s\t%s\n", name, url)
+			if params.SetURLName != "" && params.SetURL != "" {
+				if result.Message == "" {
+					fmt.Fprintf(w, "Updated URL for remote '%s' to: %s\n", params.SetURLName, redactCredentials(params.SetURL, ""))
+				} else {
+					fmt.Fprintf(w, "Failed to set remote URL: %s\n", result.Message)
+				}
 			}
-		}
-	}
 
-	return nil
+			// Show remote list if no operation was performed
+			if params.AddName == "" && params.RemoveName == "" && params.OldName == "" && params.SetURLName == "" {
+				fmt.Fprintf(w, "Git Remotes for '%s':\n", params.AppName)
+				if len(result.Remotes) == 0 {
+					fmt.Fprintln(w, "No remotes configured")
+				} else {
+					for name, url := range result.Remotes {
+						fmt.Fprintf(w, "  %s\t%s\n", name, url)
+					}
+				}
+			}
+		},
+	})
 }
-
-// MCP
-func GitRemoteMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName     string `json:"app_name"`
-		AddName     string `json:"add_name"`
-		AddURL      string `json:"add_url"`
-		RemoveName  string `json:"remove_name"`
-		OldName     string `json:"old_name"`
-		NewName     string `json:"new_name"`
-		SetURLName  string `json:"set_url_name"`
-		SetURL      string `json:"set_url"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitRemote(args.AppName, args.AddName, args.AddURL, args.RemoveName, args.OldName, args.NewName, args.SetURL, args.SetURLName)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
@@ -6,10 +6,12 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitRemote(t *testing.T) {
 	// Setup test environment
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("Failed to ensure apps directory: %v", err)
@@ -26,13 +28,13 @@ func TestGitRemote(t *testing.T) {
 	}
 
 	// Initialize a git repository
-	_, err = GitInit(testApp, false)
+	_, err = GitInit(t.Context(), testApp, false)
 	if err != nil {
 		t.Fatalf("Failed to initialize git repository: %v", err)
 	}
 
 	t.Run("list remotes in empty repo", func(t *testing.T) {
-		result, err := GitRemote(testApp, "", "", "", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "", "", "", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -45,7 +47,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("add remote", func(t *testing.T) {
-		result, err := GitRemote(testApp, "origin", "https://github.com/user/repo.git", "", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "origin", "https://github.com/user/repo.git", "", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -64,7 +66,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("add duplicate remote fails", func(t *testing.T) {
-		result, err := GitRemote(testApp, "origin", "https://github.com/user/other.git", "", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "origin", "https://github.com/user/other.git", "", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -80,7 +82,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("add second remote", func(t *testing.T) {
-		result, err := GitRemote(testApp, "upstream", "https://github.com/upstream/repo.git", "", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "upstream", "https://github.com/upstream/repo.git", "", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -99,7 +101,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("set remote URL", func(t *testing.T) {
-		result, err := GitRemote(testApp, "", "", "", "", "", "https://github.com/user/new-repo.git", "origin")
+		result, err := GitRemote(t.Context(), testApp, "", "", "", "", "", "https://github.com/user/new-repo.git", "origin")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -112,7 +114,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("rename remote", func(t *testing.T) {
-		result, err := GitRemote(testApp, "", "", "", "upstream", "backup", "", "")
+		result, err := GitRemote(t.Context(), testApp, "", "", "", "upstream", "backup", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -131,7 +133,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("remove remote", func(t *testing.T) {
-		result, err := GitRemote(testApp, "", "", "backup", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "", "", "backup", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -150,7 +152,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("remove non-existent remote fails", func(t *testing.T) {
-		result, err := GitRemote(testApp, "", "", "nonexistent", "", "", "", "")
+		result, err := GitRemote(t.Context(), testApp, "", "", "nonexistent", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -174,7 +176,7 @@ func TestGitRemote(t *testing.T) {
 		}
 		defer os.RemoveAll(appPath)
 
-		result, err := GitRemote(nonGitApp, "", "", "", "", "", "", "")
+		result, err := GitRemote(t.Context(), nonGitApp, "", "", "", "", "", "", "")
 		if err != nil {
 			t.Fatalf("GitRemote failed: %v", err)
 		}
@@ -187,7 +189,7 @@ func TestGitRemote(t *testing.T) {
 	})
 
 	t.Run("invalid app name", func(t *testing.T) {
-		_, err := GitRemote("../invalid", "", "", "", "", "", "", "")
+		_, err := GitRemote(t.Context(), "../invalid", "", "", "", "", "", "", "")
 		if err == nil {
 			t.Error("Expected error for invalid app name")
 		}
@@ -2,17 +2,26 @@ package git
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
+// GitResetResult represents the result of a git reset operation
+type GitResetResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// GitResetParams represents the parameters for a git reset operation
+type GitResetParams struct {
+	AppName    string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	CommitHash string `json:"commit_hash" required:"true" pos:"true" desc:"Commit hash to reset to"`
+	Mode       string `json:"mode,omitempty" pos:"true" desc:"Reset mode: 'soft', 'mixed' (default), or 'hard'"`
+}
+
 // ResetMode represents the git reset mode
 type ResetMode string
 
@@ -23,7 +32,7 @@ const (
 )
 
 // Reset performs a git reset operation
-func Reset(appName, commitHash string, mode ResetMode) (string, error) {
+func Reset(ctx context.Context, appName, commitHash string, mode ResetMode) (string, error) {
 	if appName == "" {
 		return "", fmt.Errorf("app_name is required")
 	}
@@ -41,131 +50,62 @@ func Reset(appName, commitHash string, mode ResetMode) (string, error) {
 		mode = ResetModeMixed
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get apps directory: %w", err)
+		return "", err
 	}
-	repoPath := filepath.Join(appsDir, appName)
 	
 	// Build command
 	args := []string{"reset", fmt.Sprintf("--%s", mode), commitHash}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = repoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("git reset failed: %w\nOutput: %s", err, string(output))
+	if _, err := repo.Run(ctx, args...); err != nil {
+		return "", err
 	}
 
 	// Get the current status after reset
-	statusCmd := exec.Command("git", "status", "--porcelain", "-uno")
-	statusCmd.Dir = repoPath
-	statusOutput, _ := statusCmd.CombinedOutput()
+	statusOutput, _ := repo.Run(ctx, "status", "--porcelain", "-uno")
 
 	result := fmt.Sprintf("Successfully reset to commit %s using %s mode", commitHash, mode)
 	
 	if len(statusOutput) > 0 {
-		result += fmt.Sprintf("\n\nModified files:\n%s", string(statusOutput))
+		result += fmt.Sprintf("\n\nModified files:\n%s", statusOutput)
 	}
 
 	// Also show the new HEAD commit
-	logCmd := exec.Command("git", "log", "--oneline", "-1")
-	logCmd.Dir = repoPath
-	if logOutput, err := logCmd.CombinedOutput(); err == nil {
-		result += fmt.Sprintf("\n\nNow at: %s", strings.TrimSpace(string(logOutput)))
+	if logOutput, err := repo.Run(ctx, "log", "--oneline", "-1"); err == nil {
+		result += fmt.Sprintf("\n\nNow at: %s", strings.TrimSpace(logOutput))
 	}
 
 	return result, nil
 }
 
-// CLI
-func GitResetCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 2 {
-		return fmt.Errorf("git_reset requires at least 2 arguments: app_name commit_hash [mode]\nUsage: layered-code tool git_reset <app_name> <commit_hash> [soft|mixed|hard]")
-	}
-
-	appName := args[0]
-	commitHash := args[1]
-	
-	mode := ResetModeMixed // default
-	if len(args) > 2 {
-		switch args[2] {
-		case "soft":
-			mode = ResetModeSoft
-		case "mixed":
-			mode = ResetModeMixed
-		case "hard":
-			mode = ResetModeHard
-		default:
-			return fmt.Errorf("invalid reset mode: %s (must be 'soft', 'mixed', or 'hard')", args[2])
-		}
-	}
-
-	result, err := Reset(appName, commitHash, mode)
-	if err != nil {
-		return fmt.Errorf("failed to reset: %w", err)
-	}
-
-	fmt.Println(result)
-	return nil
-}
-
-// MCP
-func GitResetMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName    string `json:"app_name"`
-		CommitHash string `json:"commit_hash"`
-		Mode       string `json:"mode,omitempty"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	// Convert string mode to ResetMode
-	mode := ResetModeMixed
-	if args.Mode != "" {
-		switch args.Mode {
-		case "soft":
-			mode = ResetModeSoft
-		case "mixed":
-			mode = ResetModeMixed
-		case "hard":
-			mode = ResetModeHard
-		default:
-			return nil, fmt.Errorf("invalid reset mode: %s", args.Mode)
-		}
-	}
-
-	result, err := Reset(args.AppName, args.CommitHash, mode)
-	if err != nil {
-		return nil, err
-	}
-
-	// Return structured result
-	type Result struct {
-		Success bool   `json:"success"`
-		Message string `json:"message"`
-	}
-
-	response := Result{
-		Success: true,
-		Message: result,
-	}
-
-	responseBytes, err := json.Marshal(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response: %w", err)
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(responseBytes),
-			},
+// Tool
+func gitResetTool() registry.Tool {
+	return registry.New(registry.Spec[GitResetParams, GitResetResult]{
+		Name:        "git_reset",
+		Group:       "git",
+		Description: "Reset current HEAD to the specified state (requires git to be installed)",
+		Summary:     "Reset HEAD to specified state",
+		Hints:       registry.Hints{Destructive: true, Idempotent: true},
+		Run: func(ctx context.Context, params GitResetParams) (GitResetResult, error) {
+			mode := ResetModeMixed
+			switch params.Mode {
+			case "", "mixed":
+			case "soft":
+				mode = ResetModeSoft
+			case "hard":
+				mode = ResetModeHard
+			default:
+				return GitResetResult{}, fmt.Errorf("invalid reset mode: %s (must be 'soft', 'mixed', or 'hard')", params.Mode)
+			}
+
+			message, err := Reset(ctx, params.AppName, params.CommitHash, mode)
+			if err != nil {
+				return GitResetResult{}, err
+			}
+			return GitResetResult{Success: true, Message: message}, nil
 		},
-	}, nil
-}
\ No newline at end of file
+		Render: func(w io.Writer, _ GitResetParams, result GitResetResult) {
+			fmt.Fprintln(w, result.Message)
+		},
+	})
+}
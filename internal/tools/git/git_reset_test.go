@@ -9,10 +9,12 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 // Test helper functions
 func setupTestRepo(t *testing.T) string {
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("failed to ensure apps directory: %v", err)
@@ -117,7 +119,7 @@ func TestReset(t *testing.T) {
 		runGitCommand(t, repo, "commit", "-m", "Second commit")
 		
 		// Test hard reset
-		output, err := Reset(repo, initialCommit, ResetModeHard)
+		output, err := Reset(t.Context(), repo, initialCommit, ResetModeHard)
 		if err != nil {
 			t.Fatalf("reset failed: %v", err)
 		}
@@ -150,7 +152,7 @@ func TestReset(t *testing.T) {
 		runGitCommand(t, repo, "commit", "-m", "Second commit")
 		
 		// Soft reset
-		output, err := Reset(repo, initialCommit, ResetModeSoft)
+		output, err := Reset(t.Context(), repo, initialCommit, ResetModeSoft)
 		if err != nil {
 			t.Fatalf("reset failed: %v", err)
 		}
@@ -180,7 +182,7 @@ func TestReset(t *testing.T) {
 		runGitCommand(t, repo, "commit", "-m", "Second commit")
 		
 		// Mixed reset (default)
-		output, err := Reset(repo, initialCommit, "")
+		output, err := Reset(t.Context(), repo, initialCommit, "")
 		if err != nil {
 			t.Fatalf("reset failed: %v", err)
 		}
@@ -197,7 +199,7 @@ func TestReset(t *testing.T) {
 
 	t.Run("error cases", func(t *testing.T) {
 		// Missing app name
-		_, err := Reset("", "abc123", ResetModeHard)
+		_, err := Reset(t.Context(), "", "abc123", ResetModeHard)
 		if err == nil {
 			t.Error("expected error for missing app name")
 		}
@@ -206,7 +208,7 @@ func TestReset(t *testing.T) {
 		}
 		
 		// Missing commit hash
-		_, err = Reset("test-app", "", ResetModeHard)
+		_, err = Reset(t.Context(), "test-app", "", ResetModeHard)
 		if err == nil {
 			t.Error("expected error for missing commit hash")
 		}
@@ -215,7 +217,7 @@ func TestReset(t *testing.T) {
 		}
 		
 		// Invalid mode
-		_, err = Reset("test-app", "abc123", "invalid")
+		_, err = Reset(t.Context(), "test-app", "abc123", "invalid")
 		if err == nil {
 			t.Error("expected error for invalid mode")
 		}
@@ -225,7 +227,7 @@ func TestReset(t *testing.T) {
 		
 		// Invalid commit hash
 		repo := setupTestRepo(t)
-		_, err = Reset(repo, "invalid-hash", ResetModeHard)
+		_, err = Reset(t.Context(), repo, "invalid-hash", ResetModeHard)
 		if err == nil {
 			t.Error("expected error for invalid commit hash")
 		}
@@ -1,56 +1,43 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitRestoreResult struct {
 	Success       bool     `json:"success"`
 	FilesRestored []string `json:"files_restored"`
+	HunksRestored int      `json:"hunks_restored,omitempty"`
 	IsRepo        bool     `json:"is_repo"`
 	Message       string   `json:"message,omitempty"`
 	ErrorOutput   string   `json:"error_output,omitempty"`
 }
 
-// GitRestore restores files in the specified app directory
-func GitRestore(appName string, files []string, staged bool) (GitRestoreResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitRestoreResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitRestoreResult{}, err
-	}
+type GitRestoreParams struct {
+	AppName string   `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Files   []string `json:"files" required:"true" pos:"true" desc:"List of files to restore (relative to app directory)"`
+	Staged  bool     `json:"staged" desc:"Restore files in the staging area"`
+	Hunks   []string `json:"hunks" desc:"Restore only these hunks of a single file, by the IDs git_diff --structured lists (--staged for staged hunks)"`
+	Lines   []string `json:"lines" desc:"Restore only these lines of a single file's hunks, as hunk_id:start-end positions within the hunk (e.g. a1b2c3d4:2-4,7)"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitRestore restores files in the specified app directory
+func GitRestore(ctx context.Context, appName string, files []string, staged bool) (GitRestoreResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitRestoreResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitRestoreResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitRestoreResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -64,12 +51,11 @@ func GitRestore(appName string, files []string, staged bool) (GitRestoreResult,
 
 	// Validate file paths
 	for _, file := range files {
-		cleanPath := filepath.Clean(file)
-		if strings.HasPrefix(cleanPath, "..") || filepath.IsAbs(cleanPath) {
+		if _, err := repo.Path.RelNoFollow(file); err != nil {
 			return GitRestoreResult{
 				IsRepo:  true,
 				Success: false,
-				Message: fmt.Sprintf("Invalid file path: %s (must be relative to app directory)", file),
+				Message: fmt.Sprintf("Invalid file path: %s (%v)", file, err),
 			}, nil
 		}
 	}
@@ -82,14 +68,8 @@ func GitRestore(appName string, files []string, staged bool) (GitRestoreResult,
 	args = append(args, files...)
 
 	// Run git restore
-	restoreCmd := exec.Command("git", args...)
-	restoreCmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	restoreCmd.Stdout = &outBuf
-	restoreCmd.Stderr = &errBuf
-	err = restoreCmd.Run()
+	_, errOutput, err := repo.Exec(ctx, nil, args...)
 	if err != nil {
-		errOutput := errBuf.String()
 		// Git restore doesn't exist in older versions, try checkout/reset
 		if strings.Contains(errOutput, "is not a git command") {
 			if staged {
@@ -102,18 +82,12 @@ func GitRestore(appName string, files []string, staged bool) (GitRestoreResult,
 				args = append(args, files...)
 			}
 			
-			fallbackCmd := exec.Command("git", args...)
-			fallbackCmd.Dir = appPath
-			var outBuf2, errBuf2 bytes.Buffer
-			fallbackCmd.Stdout = &outBuf2
-			fallbackCmd.Stderr = &errBuf2
-			err = fallbackCmd.Run()
+			_, errOutput, err = repo.Exec(ctx, nil, args...)
 			if err != nil {
-				return GitRestoreResult{}, fmt.Errorf("git restore (fallback) failed: %w - %s", err, strings.TrimSpace(errBuf2.String()))
+				return GitRestoreResult{}, fmt.Errorf("git restore (fallback) failed: %w", err)
 			}
-			errBuf = errBuf2
 		} else {
-			return GitRestoreResult{}, fmt.Errorf("git restore failed: %w - %s", err, strings.TrimSpace(errOutput))
+			return GitRestoreResult{}, err
 		}
 	}
 
@@ -122,86 +96,94 @@ func GitRestore(appName string, files []string, staged bool) (GitRestoreResult,
 		Success:       true,
 		FilesRestored: files,
 		Message:       "Files restored successfully",
-		ErrorOutput:   errBuf.String(),
+		ErrorOutput:   errOutput,
 	}, nil
 }
 
-// CLI
-func GitRestoreCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 2 {
-		return fmt.Errorf("git_restore requires at least 2 arguments: app_name and files\nUsage: layered-code tool git_restore <app_name> <files...> [--staged]")
-	}
-
-	appName := args[0]
-	var files []string
-	staged := false
-
-	// Parse arguments
-	for i := 1; i < len(args); i++ {
-		if args[i] == "--staged" {
-			staged = true
-		} else if !strings.HasPrefix(args[i], "-") {
-			files = append(files, args[i])
-		}
-	}
-
-	result, err := GitRestore(appName, files, staged)
+// GitRestoreHunks unstages the selected hunks, or lines of hunks, of one file's staged changes,
+// or discards them from the working tree when staged is false
+func GitRestoreHunks(ctx context.Context, appName, file string, hunks, lines []string, staged bool) (GitRestoreResult, error) {
+	selection, err := parseHunkSelection(hunks, lines)
 	if err != nil {
-		return fmt.Errorf("failed to restore files: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+		return GitRestoreResult{}, err
 	}
 
-	if !result.Success {
-		fmt.Printf("Failed: %s\n", result.Message)
-		return nil
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitRestoreResult{}, err
 	}
-
-	fmt.Println(result.Message)
-	if len(result.FilesRestored) > 0 {
-		fmt.Println("Restored files:")
-		for _, file := range result.FilesRestored {
-			fmt.Printf("  %s\n", file)
-		}
+	if !repo.IsRepo() {
+		return GitRestoreResult{
+			IsRepo:  false,
+			Success: false,
+			Message: repo.notRepoMessage(),
+		}, nil
 	}
 
-	return nil
-}
-
-// MCP
-func GitRestoreMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string   `json:"app_name"`
-		Files   []string `json:"files"`
-		Staged  bool     `json:"staged"`
+	rel, err := repo.Path.RelNoFollow(file)
+	if err != nil {
+		return GitRestoreResult{}, fmt.Errorf("invalid file path: %w", err)
 	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
+	mode, verb := hunksDiscard, "Discarded"
+	if staged {
+		mode, verb = hunksUnstage, "Unstaged"
 	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
+	count, err := applyHunks(ctx, repo, rel, mode, selection)
+	if err != nil {
+		return GitRestoreResult{}, err
 	}
 
-	if len(args.Files) == 0 {
-		return nil, fmt.Errorf("files are required")
-	}
+	return GitRestoreResult{
+		IsRepo:        true,
+		Success:       true,
+		FilesRestored: []string{rel},
+		HunksRestored: count,
+		Message:       fmt.Sprintf("%s %d hunk(s) of %s", verb, count, rel),
+	}, nil
+}
 
-	result, err := GitRestore(args.AppName, args.Files, args.Staged)
-	if err != nil {
-		return nil, err
-	}
+// Tool
+func gitRestoreTool() registry.Tool {
+	return registry.New(registry.Spec[GitRestoreParams, GitRestoreResult]{
+		Name:        "git_restore",
+		Group:       "git",
+		Description: "Restore working tree files (requires git to be installed)",
+		Summary:     "Restore working tree files",
+		Hints:       registry.Hints{Destructive: true, Idempotent: true},
+		Notes: []string{
+			"--hunks and --lines restore part of one file's changes: with --staged they are unstaged, otherwise discarded from the working tree",
+			"List the hunks and their IDs with git_diff --structured first, adding --staged to see staged hunks; a --lines position counts every line of the hunk starting at 1",
+		},
+		Examples: []string{
+			"# Unstage one hunk, keeping the change in the working tree\nlayered-code tool git_restore myapp src/App.jsx --staged --hunks 3f2a9c1e",
+		},
+		Run: func(ctx context.Context, params GitRestoreParams) (GitRestoreResult, error) {
+			if len(params.Hunks) > 0 || len(params.Lines) > 0 {
+				if len(params.Files) != 1 {
+					return GitRestoreResult{}, fmt.Errorf("hunks and lines need exactly one file")
+				}
+				return GitRestoreHunks(ctx, params.AppName, params.Files[0], params.Hunks, params.Lines, params.Staged)
+			}
+			return GitRestore(ctx, params.AppName, params.Files, params.Staged)
+		},
+		Render: func(w io.Writer, _ GitRestoreParams, result GitRestoreResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
+			if !result.Success {
+				fmt.Fprintf(w, "Failed: %s\n", result.Message)
+				return
+			}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+			fmt.Fprintln(w, result.Message)
+			if len(result.FilesRestored) > 0 {
+				fmt.Fprintln(w, "Restored files:")
+				for _, file := range result.FilesRestored {
+					fmt.Fprintf(w, "  %s\n", file)
+				}
+			}
+		},
+	})
+}
@@ -7,9 +7,11 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitRestore(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-restore-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -18,7 +20,7 @@ func TestGitRestore(t *testing.T) {
 	os.MkdirAll(testAppPath, 0755)
 
 	// Non-git repo
-	result, err := GitRestore(testApp, []string{"file.txt"}, false)
+	result, err := GitRestore(t.Context(), testApp, []string{"file.txt"}, false)
 	if err != nil {
 		t.Fatalf("GitRestore failed: %v", err)
 	}
@@ -27,7 +29,7 @@ func TestGitRestore(t *testing.T) {
 	}
 
 	// Empty files
-	GitInit(testApp, false)
+	GitInit(t.Context(), testApp, false)
 	// Configure git user for commits
 	cmd := exec.Command("git", "config", "user.email", "test@example.com")
 	cmd.Dir = testAppPath
@@ -35,7 +37,7 @@ func TestGitRestore(t *testing.T) {
 	cmd = exec.Command("git", "config", "user.name", "Test User")
 	cmd.Dir = testAppPath
 	cmd.Run()
-	result, err = GitRestore(testApp, []string{}, false)
+	result, err = GitRestore(t.Context(), testApp, []string{}, false)
 	if err != nil {
 		t.Fatalf("GitRestore failed: %v", err)
 	}
@@ -57,7 +59,7 @@ func TestGitRestore(t *testing.T) {
 	os.WriteFile(testFile, []byte("modified"), 0644)
 
 	// Restore working directory
-	result, err = GitRestore(testApp, []string{"test.txt"}, false)
+	result, err = GitRestore(t.Context(), testApp, []string{"test.txt"}, false)
 	if err != nil {
 		t.Fatalf("GitRestore failed: %v", err)
 	}
@@ -2,19 +2,28 @@ package git
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
+// GitRevertResult represents the result of a git revert operation
+type GitRevertResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// GitRevertParams represents the parameters for a git revert operation
+type GitRevertParams struct {
+	AppName    string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	CommitHash string `json:"commit_hash" required:"true" pos:"true" desc:"Commit hash to revert"`
+	NoCommit   bool   `json:"no_commit,omitempty" desc:"Don't create a commit, just stage the changes"`
+}
+
 // Revert creates a revert commit that undoes changes from a previous commit
-func Revert(appName, commitHash string, noCommit bool) (string, error) {
+func Revert(ctx context.Context, appName, commitHash string, noCommit bool) (string, error) {
 	if appName == "" {
 		return "", fmt.Errorf("app_name is required")
 	}
@@ -22,11 +31,10 @@ func Revert(appName, commitHash string, noCommit bool) (string, error) {
 		return "", fmt.Errorf("commit_hash is required")
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get apps directory: %w", err)
+		return "", err
 	}
-	repoPath := filepath.Join(appsDir, appName)
 	
 	// Build command
 	args := []string{"revert", "--no-edit"}
@@ -35,111 +43,53 @@ func Revert(appName, commitHash string, noCommit bool) (string, error) {
 	}
 	args = append(args, commitHash)
 	
-	cmd := exec.Command("git", args...)
-	cmd.Dir = repoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("git revert failed: %w\nOutput: %s", err, string(output))
+	if _, err := repo.Run(ctx, args...); err != nil {
+		return "", err
 	}
 
 	var result string
 	if noCommit {
 		// Show what was reverted but not committed
-		statusCmd := exec.Command("git", "status", "--porcelain")
-		statusCmd.Dir = repoPath
-		statusOutput, _ := statusCmd.CombinedOutput()
+		statusOutput, _ := repo.Run(ctx, "status", "--porcelain")
 		
 		result = fmt.Sprintf("Successfully reverted commit %s (changes staged but not committed)", commitHash)
 		if len(statusOutput) > 0 {
-			result += fmt.Sprintf("\n\nStaged changes:\n%s", string(statusOutput))
+			result += fmt.Sprintf("\n\nStaged changes:\n%s", statusOutput)
 		}
 	} else {
 		// Show the new revert commit
-		logCmd := exec.Command("git", "log", "--oneline", "-1")
-		logCmd.Dir = repoPath
-		logOutput, _ := logCmd.CombinedOutput()
+		logOutput, _ := repo.Run(ctx, "log", "--oneline", "-1")
 		
 		result = fmt.Sprintf("Successfully created revert commit for %s", commitHash)
 		if len(logOutput) > 0 {
-			result += fmt.Sprintf("\n\nNew commit: %s", strings.TrimSpace(string(logOutput)))
+			result += fmt.Sprintf("\n\nNew commit: %s", strings.TrimSpace(logOutput))
 		}
 		
 		// Also show what was reverted
-		diffCmd := exec.Command("git", "diff", "HEAD~1", "HEAD", "--stat")
-		diffCmd.Dir = repoPath
-		if diffOutput, err := diffCmd.CombinedOutput(); err == nil && len(diffOutput) > 0 {
-			result += fmt.Sprintf("\n\nChanges reverted:\n%s", string(diffOutput))
+		if diffOutput, err := repo.Run(ctx, "diff", "HEAD~1", "HEAD", "--stat"); err == nil && len(diffOutput) > 0 {
+			result += fmt.Sprintf("\n\nChanges reverted:\n%s", diffOutput)
 		}
 	}
 
 	return result, nil
 }
 
-// CLI
-func GitRevertCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 2 {
-		return fmt.Errorf("git_revert requires at least 2 arguments: app_name commit_hash [--no-commit]\nUsage: layered-code tool git_revert <app_name> <commit_hash> [--no-commit]")
-	}
-
-	appName := args[0]
-	commitHash := args[1]
-	
-	noCommit := false
-	if len(args) > 2 && args[2] == "--no-commit" {
-		noCommit = true
-	}
-
-	result, err := Revert(appName, commitHash, noCommit)
-	if err != nil {
-		return fmt.Errorf("failed to revert: %w", err)
-	}
-
-	fmt.Println(result)
-	return nil
-}
-
-// MCP
-func GitRevertMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName    string `json:"app_name"`
-		CommitHash string `json:"commit_hash"`
-		NoCommit   bool   `json:"no_commit,omitempty"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	result, err := Revert(args.AppName, args.CommitHash, args.NoCommit)
-	if err != nil {
-		return nil, err
-	}
-
-	// Return structured result
-	type Result struct {
-		Success bool   `json:"success"`
-		Message string `json:"message"`
-	}
-
-	response := Result{
-		Success: true,
-		Message: result,
-	}
-
-	responseBytes, err := json.Marshal(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response: %w", err)
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(responseBytes),
-			},
+// Tool
+func gitRevertTool() registry.Tool {
+	return registry.New(registry.Spec[GitRevertParams, GitRevertResult]{
+		Name:        "git_revert",
+		Group:       "git",
+		Description: "Create a new commit that undoes changes from a previous commit (requires git to be installed)",
+		Summary:     "Create revert commits",
+		Run: func(ctx context.Context, params GitRevertParams) (GitRevertResult, error) {
+			message, err := Revert(ctx, params.AppName, params.CommitHash, params.NoCommit)
+			if err != nil {
+				return GitRevertResult{}, err
+			}
+			return GitRevertResult{Success: true, Message: message}, nil
 		},
-	}, nil
-}
\ No newline at end of file
+		Render: func(w io.Writer, _ GitRevertParams, result GitRevertResult) {
+			fmt.Fprintln(w, result.Message)
+		},
+	})
+}
@@ -21,7 +21,7 @@ func TestRevert(t *testing.T) {
 		commitToRevert := getCommitHash(t, repo)
 		
 		// Revert the commit
-		output, err := Revert(repo, commitToRevert, false)
+		output, err := Revert(t.Context(), repo, commitToRevert, false)
 		if err != nil {
 			t.Fatalf("revert failed: %v", err)
 		}
@@ -60,7 +60,7 @@ func TestRevert(t *testing.T) {
 		commitToRevert := getCommitHash(t, repo)
 		
 		// Revert without committing
-		output, err := Revert(repo, commitToRevert, true)
+		output, err := Revert(t.Context(), repo, commitToRevert, true)
 		if err != nil {
 			t.Fatalf("revert failed: %v", err)
 		}
@@ -98,7 +98,7 @@ func TestRevert(t *testing.T) {
 		commitToRevert := getCommitHash(t, repo)
 		
 		// Revert
-		output, err := Revert(repo, commitToRevert, false)
+		output, err := Revert(t.Context(), repo, commitToRevert, false)
 		if err != nil {
 			t.Fatalf("revert failed: %v", err)
 		}
@@ -119,7 +119,7 @@ func TestRevert(t *testing.T) {
 
 	t.Run("error cases", func(t *testing.T) {
 		// Missing app name
-		_, err := Revert("", "abc123", false)
+		_, err := Revert(t.Context(), "", "abc123", false)
 		if err == nil {
 			t.Error("expected error for missing app name")
 		}
@@ -128,7 +128,7 @@ func TestRevert(t *testing.T) {
 		}
 		
 		// Missing commit hash
-		_, err = Revert("test-app", "", false)
+		_, err = Revert(t.Context(), "test-app", "", false)
 		if err == nil {
 			t.Error("expected error for missing commit hash")
 		}
@@ -142,7 +142,7 @@ func TestRevert(t *testing.T) {
 		runGitCommand(t, repo, "add", "file1.txt")
 		runGitCommand(t, repo, "commit", "-m", "Initial")
 		
-		_, err = Revert(repo, "invalid-hash", false)
+		_, err = Revert(t.Context(), repo, "invalid-hash", false)
 		if err == nil {
 			t.Error("expected error for invalid commit hash")
 		}
@@ -1,18 +1,13 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 type GitShowResult struct {
@@ -25,34 +20,28 @@ type GitShowResult struct {
 	Date        string `json:"date,omitempty"`
 	Subject     string `json:"subject,omitempty"`
 	CommitRef   string `json:"commit_ref,omitempty"`
+	Redacted    int    `json:"redacted,omitempty"`
 	ErrorOutput string `json:"error_output,omitempty"`
 }
 
-func GitShow(appName, commitRef string) (GitShowResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitShowResult{}, err
-	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitShowResult{}, err
-	}
+type GitShowParams struct {
+	AppName     string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	CommitRef   string `json:"commit_ref,omitempty" pos:"true" desc:"Commit reference to show (hash, branch, tag, etc.). Defaults to HEAD if not specified"`
+	ShowSecrets bool   `json:"show_secrets" desc:"Show changes to protected files and secrets instead of redacting them"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
+// GitShow shows a commit. Unless showSecrets is set, changes to protected files are hidden and
+// secrets in the rest are redacted.
+func GitShow(ctx context.Context, appName, commitRef string, showSecrets bool) (GitShowResult, error) {
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitShowResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitShowResult{}, err
 	}
-
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitShowResult{
 			Success: false,
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -63,17 +52,10 @@ func GitShow(appName, commitRef string) (GitShowResult, error) {
 	}
 
 	args := []string{"show", "--format=fuller", commitRef}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = appPath
-	var outBuf, errBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	cmd.Stderr = &errBuf
-
-	err = cmd.Run()
+	output, errOutput, err := repo.Exec(ctx, nil, args...)
 	if err != nil {
-		errOutput := errBuf.String()
 		// Check if it's because there are no commits yet (empty repo) or commit not found
-		if strings.Contains(errOutput, "does not have any commits") || 
+		if errors.Is(err, ErrNoCommits) ||
 			strings.Contains(errOutput, "unknown revision") ||
 			strings.Contains(errOutput, "bad object") ||
 			strings.Contains(errOutput, "Not a valid object name") ||
@@ -88,14 +70,19 @@ func GitShow(appName, commitRef string) (GitShowResult, error) {
 			}, nil
 		}
 		// For all other unexpected errors, return actual error
-		return GitShowResult{}, fmt.Errorf("git show failed: %w - %s", err, strings.TrimSpace(errOutput))
+		return GitShowResult{}, err
 	}
 
-	outputStr := strings.TrimSpace(outBuf.String())
+	outputStr := strings.TrimSpace(output)
 	if outputStr == "" {
 		return GitShowResult{}, fmt.Errorf("git show returned empty output for commit '%s'", commitRef)
 	}
 
+	redacted := 0
+	if !showSecrets {
+		outputStr, redacted = redactDiff(repo.Path, outputStr)
+	}
+
 	hash, author, date, subject := parseCommitInfo(outputStr)
 
 	return GitShowResult{
@@ -108,7 +95,8 @@ func GitShow(appName, commitRef string) (GitShowResult, error) {
 		Date:        date,
 		Subject:     subject,
 		CommitRef:   originalCommitRef,
-		ErrorOutput: errBuf.String(),
+		Redacted:    redacted,
+		ErrorOutput: errOutput,
 	}, nil
 }
 
@@ -135,61 +123,32 @@ func parseCommitInfo(output string) (hash, author, date, subject string) {
 	return hash, author, date, subject
 }
 
-func GitShowCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("usage: layered-code git show <app_name> [commit_ref]")
-	}
-
-	appName := args[0]
-	commitRef := ""
-	if len(args) > 1 {
-		commitRef = args[1]
-	}
-
-	result, err := GitShow(appName, commitRef)
-	if err != nil {
-		return fmt.Errorf("failed to show commit: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
-
-	if !result.Success {
-		fmt.Printf("Error: %s\n", result.Message)
-		return nil
-	}
-
-	fmt.Print(result.Content)
-	return nil
+// Tool
+func gitShowTool() registry.Tool {
+	return registry.New(registry.Spec[GitShowParams, GitShowResult]{
+		Name:        "git_show",
+		Group:       "git",
+		Description: "Show various types of objects (commits, trees, blobs) with their content (requires git to be installed)",
+		Summary:     "Show commits and other objects",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Changes to protected files (files.protected) are hidden and secrets are redacted unless --show-secrets is set",
+		},
+		Run: func(ctx context.Context, params GitShowParams) (GitShowResult, error) {
+			return GitShow(ctx, params.AppName, params.CommitRef, params.ShowSecrets)
+		},
+		Render: func(w io.Writer, _ GitShowParams, result GitShowResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
+
+			if !result.Success {
+				fmt.Fprintf(w, "Error: %s\n", result.Message)
+				return
+			}
+
+			fmt.Fprint(w, result.Content)
+		},
+	})
 }
-
-func GitShowMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName   string `json:"app_name"`
-		CommitRef string `json:"commit_ref,omitempty"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	result, err := GitShow(args.AppName, args.CommitRef)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
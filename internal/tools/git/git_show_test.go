@@ -8,10 +8,12 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitShow(t *testing.T) {
 	// Setup test environment
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("Failed to ensure apps directory: %v", err)
@@ -29,7 +31,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 1: Non-git repository
 	t.Run("NonGitRepo", func(t *testing.T) {
-		result, err := GitShow(testApp, "HEAD")
+		result, err := GitShow(t.Context(), testApp, "HEAD", false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -65,7 +67,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 2: Empty repository (no commits)
 	t.Run("EmptyRepo", func(t *testing.T) {
-		result, err := GitShow(testApp, "HEAD")
+		result, err := GitShow(t.Context(), testApp, "HEAD", false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -103,7 +105,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 3: Show HEAD commit
 	t.Run("ShowHEAD", func(t *testing.T) {
-		result, err := GitShow(testApp, "HEAD")
+		result, err := GitShow(t.Context(), testApp, "HEAD", false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -152,7 +154,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 4: Show with default (empty) commit ref
 	t.Run("ShowDefault", func(t *testing.T) {
-		result, err := GitShow(testApp, "")
+		result, err := GitShow(t.Context(), testApp, "", false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -201,7 +203,7 @@ func TestGitShow(t *testing.T) {
 		firstCommitHash := strings.Fields(lines[0])[0]
 		shortHash := firstCommitHash[:7] // Use short hash
 
-		result, err := GitShow(testApp, shortHash)
+		result, err := GitShow(t.Context(), testApp, shortHash, false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -221,7 +223,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 6: Show invalid commit
 	t.Run("ShowInvalidCommit", func(t *testing.T) {
-		result, err := GitShow(testApp, "invalid-commit-hash")
+		result, err := GitShow(t.Context(), testApp, "invalid-commit-hash", false)
 		if err != nil {
 			t.Fatalf("GitShow failed: %v", err)
 		}
@@ -245,7 +247,7 @@ func TestGitShow(t *testing.T) {
 
 	// Test 7: Invalid app name
 	t.Run("InvalidAppName", func(t *testing.T) {
-		_, err := GitShow("../invalid-app", "HEAD")
+		_, err := GitShow(t.Context(), "../invalid-app", "HEAD", false)
 		if err == nil {
 			t.Error("Expected error for invalid app name")
 		}
@@ -1,18 +1,14 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
+	"slices"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -27,36 +23,54 @@ type GitStashResult struct {
 	IsRepo      bool            `json:"is_repo"`
 	Message     string          `json:"message,omitempty"`
 	Action      string          `json:"action,omitempty"`
+	Diff        string          `json:"diff,omitempty"`      // Changes in the stash, for show
+	Conflicts   []string        `json:"conflicts,omitempty"` // Files left conflicted by pop or apply
+	Redacted    int             `json:"redacted,omitempty"`
 	ErrorOutput string          `json:"error_output,omitempty"`
 }
 
+type GitStashParams struct {
+	AppName          string   `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Action           string   `json:"action" pos:"true" desc:"Action to perform: push, pop, apply, drop, show, branch, clear, list (default: list)"`
+	Message          string   `json:"message" short:"m" desc:"Stash message (for push action)"`
+	Index            int      `json:"index" desc:"Stash to pop, apply, drop, show or branch from, as numbered by list (default: 0, the latest)"`
+	IncludeUntracked bool     `json:"include_untracked" short:"u" desc:"Also stash untracked files (for push action)"`
+	Paths            []string `json:"paths" desc:"Only stash changes to these files or directories (for push action)"`
+	Branch           string   `json:"branch" desc:"Branch to create from the stash's commit, with the stash applied (for branch action)"`
+	ShowSecrets      bool     `json:"show_secrets" desc:"Show changes to protected files and secrets instead of redacting them (for show action)"`
+}
+
+// GitStashOptions configures GitStash
+type GitStashOptions struct {
+	Message          string
+	Index            int // Stash to act on; 0 is the latest
+	IncludeUntracked bool
+	Paths            []string
+	Branch           string
+	ShowSecrets      bool
+}
+
 // GitStash manages git stash in the specified app directory
-func GitStash(appName string, action string, message string) (GitStashResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitStashResult{}, err
+func GitStash(ctx context.Context, appName string, action string, options GitStashOptions) (GitStashResult, error) {
+	if options.Index < 0 {
+		return GitStashResult{}, fmt.Errorf("index must not be negative")
 	}
-
-	if err := helpers.ValidateAppName(appName); err != nil {
+	if action == "branch" && options.Branch == "" {
+		return GitStashResult{}, fmt.Errorf("branch is required for the branch action")
+	}
+	if err := validateRef(options.Branch); err != nil {
 		return GitStashResult{}, err
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	repo, err := OpenRepo(appName)
 	if err != nil {
-		return GitStashResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
 		return GitStashResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitStashResult{
 			IsRepo:  false,
 			Success: false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
@@ -64,87 +78,111 @@ func GitStash(appName string, action string, message string) (GitStashResult, er
 		IsRepo: true,
 		Action: action,
 	}
+	stash := fmt.Sprintf("stash@{%d}", options.Index)
 
 	// Handle different stash actions
 	switch action {
 	case "push", "save":
 		// Stash changes
 		args := []string{"stash", "push"}
-		if message != "" {
-			args = append(args, "-m", message)
+		if options.IncludeUntracked {
+			args = append(args, "--include-untracked")
 		}
-		
-		cmd := exec.Command("git", args...)
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		if options.Message != "" {
+			args = append(args, "-m", options.Message)
+		}
+		if len(options.Paths) > 0 {
+			args = append(args, "--")
+			for _, path := range options.Paths {
+				rel, err := repo.Path.RelNoFollow(path)
+				if err != nil {
+					return GitStashResult{}, fmt.Errorf("invalid path: %w", err)
+				}
+				args = append(args, rel)
+			}
+		}
+
+		output, err := repo.Run(ctx, args...)
 		if err != nil {
-			return GitStashResult{}, fmt.Errorf("git stash push failed: %w\nError output: %s", err, strings.TrimSpace(errBuf.String()))
+			return GitStashResult{}, err
 		}
 		result.Success = true
 		result.Message = "Changes stashed successfully"
+		if strings.Contains(output, "No local changes to save") {
+			result.Message = "No local changes to save"
+		}
 
-	case "pop":
-		// Pop the latest stash
-		cmd := exec.Command("git", "stash", "pop")
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+	case "pop", "apply":
+		// Apply the stash, removing it on pop; conflicts keep it and are left to resolve
+		_, errOutput, err := repo.Exec(ctx, nil, slices.Concat(conflictStyle, []string{"stash", action, stash})...)
 		if err != nil {
-			return GitStashResult{}, fmt.Errorf("git stash pop failed: %w\nError output: %s", err, strings.TrimSpace(errBuf.String()))
+			result.Conflicts = conflictedFiles(ctx, repo)
+			if len(result.Conflicts) == 0 && !errors.Is(err, ErrConflict) {
+				return GitStashResult{}, err
+			}
+			result.ErrorOutput = strings.TrimSpace(errOutput)
+			result.Message = fmt.Sprintf("%s applied with conflicts in %d file(s); resolve them with git_conflicts. The stash was kept", stash, len(result.Conflicts))
+			break
 		}
 		result.Success = true
-		result.Message = "Stash applied and removed"
-
-	case "apply":
-		// Apply the latest stash without removing it
-		cmd := exec.Command("git", "stash", "apply")
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
-		if err != nil {
-			return GitStashResult{}, fmt.Errorf("git stash apply failed: %w\nError output: %s", err, strings.TrimSpace(errBuf.String()))
+		if action == "pop" {
+			result.Message = fmt.Sprintf("%s applied and removed", stash)
+		} else {
+			result.Message = fmt.Sprintf("%s applied", stash)
 		}
-		result.Success = true
-		result.Message = "Stash applied"
 
 	case "drop":
-		// Drop the latest stash
-		cmd := exec.Command("git", "stash", "drop")
-		cmd.Dir = appPath
-		var outBuf, errBuf bytes.Buffer
-		cmd.Stdout = &outBuf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
+		if _, err := repo.Run(ctx, "stash", "drop", stash); err != nil {
+			return GitStashResult{}, err
+		}
+		result.Success = true
+		result.Message = fmt.Sprintf("%s dropped", stash)
+
+	case "show":
+		// Include the untracked files a push with include_untracked saved
+		diff, err := repo.Run(ctx, "-c", "core.quotePath=off", "stash", "show", "-p", "--no-color", "--no-ext-diff", "--include-untracked", stash)
 		if err != nil {
-			return GitStashResult{}, fmt.Errorf("git stash drop failed: %w\nError output: %s", err, strings.TrimSpace(errBuf.String()))
+			return GitStashResult{}, err
+		}
+		if !options.ShowSecrets {
+			diff, result.Redacted = redactDiff(repo.Path, diff)
+		}
+		result.Success = true
+		result.Diff = diff
+		result.Message = fmt.Sprintf("Changes in %s", stash)
+
+	case "branch":
+		if _, err := repo.Run(ctx, "stash", "branch", options.Branch, stash); err != nil {
+			return GitStashResult{}, err
+		}
+		result.Success = true
+		result.Message = fmt.Sprintf("Created and switched to branch '%s' with %s applied and removed", options.Branch, stash)
+
+	case "clear":
+		if _, err := repo.Run(ctx, "stash", "clear"); err != nil {
+			return GitStashResult{}, err
 		}
 		result.Success = true
-		result.Message = "Stash dropped"
+		result.Message = "All stashes cleared"
 
 	case "list", "":
 		// List stashes (default action)
 		result.Success = true
 		result.Action = "list"
+
+	default:
+		return GitStashResult{}, fmt.Errorf("invalid action: %s (must be push, pop, apply, drop, show, branch, clear or list)", action)
 	}
 
 	// Always get the current stash list
-	listCmd := exec.Command("git", "stash", "list")
-	listCmd.Dir = appPath
-	listOutput, err := listCmd.Output()
+	listOutput, err := repo.Run(ctx, "stash", "list")
 	if err == nil {
-		lines := strings.Split(strings.TrimSpace(string(listOutput)), "\n")
+		lines := strings.Split(strings.TrimSpace(listOutput), "\n")
 		for _, line := range lines {
 			if line == "" {
 				continue
 			}
-			
+
 			// Parse stash entry (format: stash@{0}: message)
 			parts := strings.SplitN(line, ": ", 2)
 			if len(parts) >= 2 {
@@ -159,97 +197,77 @@ func GitStash(appName string, action string, message string) (GitStashResult, er
 	return result, nil
 }
 
-// CLI
-func GitStashCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 {
-		return fmt.Errorf("git_stash requires at least 1 argument: app_name\nUsage: layered-code tool git_stash <app_name> [push|pop|apply|drop|list] [-m \"message\"]")
-	}
-
-	appName := args[0]
-	action := "list"
-	message := ""
-
-	// Parse arguments
-	if len(args) > 1 {
-		switch args[1] {
-		case "push", "save", "pop", "apply", "drop", "list":
-			action = args[1]
-		}
-	}
-
-	// Look for message flag
-	for i := 1; i < len(args)-1; i++ {
-		if args[i] == "-m" || args[i] == "--message" {
-			message = args[i+1]
-			break
-		}
-	}
-
-	result, err := GitStash(appName, action, message)
-	if err != nil {
-		return fmt.Errorf("failed to manage stash: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
-	}
+// Tool
+func gitStashTool() registry.Tool {
+	return registry.New(registry.Spec[GitStashParams, GitStashResult]{
+		Name:        "git_stash",
+		Group:       "git",
+		Description: "Stash the changes in a dirty working directory (requires git to be installed)",
+		Summary:     "Stash changes in working directory",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"pop, apply, drop, show and branch act on --index as numbered by list (stash@{N}); the default is the latest stash",
+			"push stashes only --paths when given, and untracked files too with --include-untracked",
+			"branch creates a branch at the commit the stash was made on and applies the stash there, avoiding conflicts",
+			"clear deletes every stash and needs the user's confirmation",
+			"Changes to protected files (files.protected) are hidden from show and secrets are redacted unless --show-secrets is set",
+		},
+		Examples: []string{
+			"# Stash one directory, new files included\nlayered-code tool git_stash myapp push -m \"WIP header\" -u --paths src/header",
+			"# Look at the second stash, then apply it\nlayered-code tool git_stash myapp show --index 1\nlayered-code tool git_stash myapp apply --index 1",
+		},
+		Run: func(ctx context.Context, params GitStashParams) (GitStashResult, error) {
+			// Default to list if no action specified
+			if params.Action == "" {
+				params.Action = "list"
+			}
+			return GitStash(ctx, params.AppName, params.Action, GitStashOptions{
+				Message:          params.Message,
+				Index:            params.Index,
+				IncludeUntracked: params.IncludeUntracked,
+				Paths:            params.Paths,
+				Branch:           params.Branch,
+				ShowSecrets:      params.ShowSecrets,
+			})
+		},
+		Confirm: func(params GitStashParams) string {
+			if params.Action != "clear" {
+				return ""
+			}
+			return fmt.Sprintf("Are you sure you want to delete every stash of '%s'? This action cannot be undone.", params.AppName)
+		},
+		Render: func(w io.Writer, params GitStashParams, result GitStashResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	// Show action result
-	if action != "list" && action != "" {
-		if result.Success {
-			fmt.Println(result.Message)
-		} else {
-			fmt.Printf("Failed: %s\n", result.Message)
-			return nil
-		}
-	}
+			// Show action result
+			if params.Action != "list" && params.Action != "" {
+				if !result.Success {
+					fmt.Fprintf(w, "Failed: %s\n", result.Message)
+					for _, file := range result.Conflicts {
+						fmt.Fprintf(w, "  %s\n", file)
+					}
+					return
+				}
+				fmt.Fprintln(w, result.Message)
+			}
 
-	// Show stash list
-	if len(result.Stashes) > 0 {
-		fmt.Println("\nStash list:")
-		for _, stash := range result.Stashes {
-			fmt.Printf("  %s: %s\n", stash.Index, stash.Message)
-		}
-	} else if action == "list" || action == "" {
-		fmt.Println("No stashes found")
-	}
+			if result.Diff != "" {
+				fmt.Fprintf(w, "\n%s", result.Diff)
+				return
+			}
 
-	return nil
+			// Show stash list
+			if len(result.Stashes) > 0 {
+				fmt.Fprintln(w, "\nStash list:")
+				for _, stash := range result.Stashes {
+					fmt.Fprintf(w, "  %s: %s\n", stash.Index, stash.Message)
+				}
+			} else if params.Action == "list" || params.Action == "" {
+				fmt.Fprintln(w, "No stashes found")
+			}
+		},
+	})
 }
-
-// MCP
-func GitStashMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-		Action  string `json:"action"`
-		Message string `json:"message"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
-
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
-
-	// Default to list if no action specified
-	if args.Action == "" {
-		args.Action = "list"
-	}
-
-	result, err := GitStash(args.AppName, args.Action, args.Message)
-	if err != nil {
-		return nil, err
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
@@ -4,12 +4,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitStash(t *testing.T) {
+	testutil.Home(t)
 	appsDir, _ := config.EnsureAppsDirectory()
 	testApp := "test-git-stash-app"
 	testAppPath := filepath.Join(appsDir, testApp)
@@ -18,7 +21,7 @@ func TestGitStash(t *testing.T) {
 	os.MkdirAll(testAppPath, 0755)
 
 	// Non-git repo
-	result, err := GitStash(testApp, "push", "")
+	result, err := GitStash(t.Context(), testApp, "push", GitStashOptions{})
 	if err != nil {
 		t.Fatalf("GitStash failed: %v", err)
 	}
@@ -27,7 +30,7 @@ func TestGitStash(t *testing.T) {
 	}
 
 	// Setup repo with commit
-	GitInit(testApp, false)
+	GitInit(t.Context(), testApp, false)
 	// Configure git user for commits
 	cmd := exec.Command("git", "config", "user.email", "test@example.com")
 	cmd.Dir = testAppPath
@@ -45,7 +48,7 @@ func TestGitStash(t *testing.T) {
 	cmd.Run()
 
 	// List empty stash
-	result, err = GitStash(testApp, "list", "")
+	result, err = GitStash(t.Context(), testApp, "list", GitStashOptions{})
 	if err != nil {
 		t.Fatalf("GitStash list failed: %v", err)
 	}
@@ -57,7 +60,7 @@ func TestGitStash(t *testing.T) {
 	os.WriteFile(testFile, []byte("modified"), 0644)
 
 	// Push stash
-	result, err = GitStash(testApp, "push", "test stash")
+	result, err = GitStash(t.Context(), testApp, "push", GitStashOptions{Message: "test stash"})
 	if err != nil {
 		t.Fatalf("GitStash push failed: %v", err)
 	}
@@ -72,7 +75,7 @@ func TestGitStash(t *testing.T) {
 	}
 
 	// Pop stash
-	result, err = GitStash(testApp, "pop", "")
+	result, err = GitStash(t.Context(), testApp, "pop", GitStashOptions{})
 	if err != nil {
 		t.Fatalf("GitStash pop failed: %v", err)
 	}
@@ -85,4 +88,76 @@ func TestGitStash(t *testing.T) {
 	if string(content) != "modified" {
 		t.Error("Expected file to be restored")
 	}
-}
\ No newline at end of file
+}
+func TestGitStashByIndex(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "stashing")
+	os.MkdirAll(filepath.Join(appPath, "src"), 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(appPath, name), []byte(content), 0644)
+	}
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(appPath, name))
+		return string(data)
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("src/app.js", "app\n")
+	write("notes.txt", "notes\n")
+	git("add", "-A")
+	git("commit", "-m", "initial")
+
+	// Only the given paths are stashed, untracked files included on request
+	write("src/app.js", "app v2\n")
+	write("notes.txt", "notes v2\n")
+	write("src/new.js", "const key = 'sk_live_abcdefghijklmnop1234'\n")
+	result, err := GitStash(t.Context(), "stashing", "push", GitStashOptions{Message: "src work", Paths: []string{"src"}, IncludeUntracked: true})
+	if err != nil || !result.Success {
+		t.Fatalf("GitStash push = %+v, %v", result, err)
+	}
+	if read("src/app.js") != "app\n" || read("src/new.js") != "" || read("notes.txt") != "notes v2\n" {
+		t.Errorf("Expected only src to be stashed")
+	}
+	if _, err := GitStash(t.Context(), "stashing", "push", GitStashOptions{Message: "notes"}); err != nil {
+		t.Fatalf("GitStash push failed: %v", err)
+	}
+
+	// stash@{1} is the src stash; show it with the secret redacted
+	show, err := GitStash(t.Context(), "stashing", "show", GitStashOptions{Index: 1})
+	if err != nil {
+		t.Fatalf("GitStash show failed: %v", err)
+	}
+	if !strings.Contains(show.Diff, "app v2") || !strings.Contains(show.Diff, "src/new.js") || strings.Contains(show.Diff, "sk_live") || show.Redacted == 0 {
+		t.Errorf("Unexpected stash diff %+v", show)
+	}
+
+	// Branch from the src stash, leaving the notes stash
+	branch, err := GitStash(t.Context(), "stashing", "branch", GitStashOptions{Index: 1, Branch: "src-work"})
+	if err != nil || !branch.Success {
+		t.Fatalf("GitStash branch = %+v, %v", branch, err)
+	}
+	if read("src/app.js") != "app v2\n" || len(branch.Stashes) != 1 || !strings.Contains(branch.Stashes[0].Message, "notes") {
+		t.Errorf("Expected the src stash applied on its branch and the notes stash kept, got %+v", branch)
+	}
+
+	if _, err := GitStash(t.Context(), "stashing", "drop", GitStashOptions{Index: 5}); err == nil {
+		t.Error("Expected dropping a missing stash to fail")
+	}
+	clear, err := GitStash(t.Context(), "stashing", "clear", GitStashOptions{})
+	if err != nil || !clear.Success || len(clear.Stashes) != 0 {
+		t.Errorf("GitStash clear = %+v, %v", clear, err)
+	}
+	if _, err := GitStash(t.Context(), "stashing", "branch", GitStashOptions{}); err == nil {
+		t.Error("Expected branch without a name to fail")
+	}
+}
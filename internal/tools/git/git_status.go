@@ -1,194 +1,290 @@
 package git
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
 type GitStatusResult struct {
-	Branch      string   `json:"branch"`
-	Staged      []string `json:"staged"`
-	Modified    []string `json:"modified"`
-	Untracked   []string `json:"untracked"`
-	IsRepo      bool     `json:"is_repo"`
-	Message     string   `json:"message,omitempty"`
-	ErrorOutput string   `json:"error_output,omitempty"`
+	Branch      string        `json:"branch"`
+	Detached    bool          `json:"detached,omitempty"`
+	Head        string        `json:"head,omitempty"` // Commit checked out, empty before the first commit
+	Upstream    string        `json:"upstream,omitempty"`
+	Ahead       int           `json:"ahead"`
+	Behind      int           `json:"behind"`
+	StashCount  int           `json:"stash_count"`
+	Operation   string        `json:"operation,omitempty"` // merge, rebase, am, cherry_pick, revert or bisect in progress
+	Entries     []StatusEntry `json:"entries"`
+	Staged      []string      `json:"staged"`
+	Modified    []string      `json:"modified"`
+	Untracked   []string      `json:"untracked"`
+	Conflicted  []string      `json:"conflicted,omitempty"`
+	IsRepo      bool          `json:"is_repo"`
+	Message     string        `json:"message,omitempty"`
+	ErrorOutput string        `json:"error_output,omitempty"`
 }
 
-// GitStatus runs git status command in the specified app directory
-func GitStatus(appName string) (GitStatusResult, error) {
-	if err := EnsureGitAvailable(); err != nil {
-		return GitStatusResult{}, err
-	}
+// StatusEntry is a changed, untracked or conflicted path. Index and Worktree are the status
+// on each side: unmodified, modified, type_changed, added, deleted, renamed, copied or updated
+// (unmerged).
+type StatusEntry struct {
+	Path       string `json:"path"`
+	OrigPath   string `json:"orig_path,omitempty"` // Source of a rename or copy
+	Index      string `json:"index,omitempty"`
+	Worktree   string `json:"worktree,omitempty"`
+	Untracked  bool   `json:"untracked,omitempty"`
+	Conflict   string `json:"conflict,omitempty"` // both_modified, both_added, both_deleted, added_by_us, added_by_them, deleted_by_us or deleted_by_them
+	Similarity int    `json:"similarity,omitempty"`
+	Submodule  bool   `json:"submodule,omitempty"`
+}
 
-	if err := helpers.ValidateAppName(appName); err != nil {
-		return GitStatusResult{}, err
-	}
+type GitStatusParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+}
 
-	appsDir, err := config.EnsureAppsDirectory()
-	if err != nil {
-		return GitStatusResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
+// statusWords names the status letters of git status --porcelain=v2
+var statusWords = map[byte]string{
+	'.': "unmodified",
+	'M': "modified",
+	'T': "type_changed",
+	'A': "added",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'U': "updated",
+}
 
-	appPath := filepath.Join(appsDir, appName)
-	if err := ValidateAppPath(appPath); err != nil {
+// conflictWords names the XY codes of unmerged entries
+var conflictWords = map[string]string{
+	"UU": "both_modified",
+	"AA": "both_added",
+	"DD": "both_deleted",
+	"AU": "added_by_us",
+	"UA": "added_by_them",
+	"DU": "deleted_by_us",
+	"UD": "deleted_by_them",
+}
+
+// GitStatus runs git status command in the specified app directory
+func GitStatus(ctx context.Context, appName string) (GitStatusResult, error) {
+	repo, err := OpenRepo(appName)
+	if err != nil {
 		return GitStatusResult{}, err
 	}
-
-	// Check if it's a git repository
-	gitDir := filepath.Join(appPath, ".git")
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+	if !repo.IsRepo() {
 		return GitStatusResult{
 			IsRepo:  false,
-			Message: fmt.Sprintf("'%s' is not a git repository", appName),
+			Message: repo.notRepoMessage(),
 		}, nil
 	}
 
-	// Get current branch
-	branchCmd := exec.Command("git", "branch", "--show-current")
-	branchCmd.Dir = appPath
-	branchOutput, branchErr := branchCmd.CombinedOutput()
-	if branchErr != nil {
-		// Try alternative method for detached HEAD
-		branchCmd = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-		branchCmd.Dir = appPath
-		branchOutput, branchErr = branchCmd.CombinedOutput()
-		if branchErr != nil {
-			return GitStatusResult{}, fmt.Errorf("failed to get current branch: %w", branchErr)
-		}
-	}
-	branch := strings.TrimSpace(string(branchOutput))
-
 	// Get git status
-	statusCmd := exec.Command("git", "status", "--porcelain=v1")
-	statusCmd.Dir = appPath
-	var statusOut, statusErr bytes.Buffer
-	statusCmd.Stdout = &statusOut
-	statusCmd.Stderr = &statusErr
-	err = statusCmd.Run()
+	statusOut, statusErr, err := repo.Exec(ctx, nil, "status", "--porcelain=v2", "--branch", "-z")
 	if err != nil {
-		return GitStatusResult{}, fmt.Errorf("failed to run git status: %w", err)
-	}
-
-	result := GitStatusResult{
-		Branch:      branch,
-		IsRepo:      true,
-		Staged:      []string{},
-		Modified:    []string{},
-		Untracked:   []string{},
-		ErrorOutput: statusErr.String(),
+		return GitStatusResult{}, err
 	}
 
-	// Parse git status output
-	lines := strings.Split(statusOut.String(), "\n")
-	for _, line := range lines {
-		if len(line) < 3 {
-			continue
-		}
+	result := parseStatus(statusOut)
+	result.IsRepo = true
+	result.ErrorOutput = statusErr
 
-		statusCode := line[:2]
-		filename := strings.TrimSpace(line[3:])
-
-		switch {
-		case statusCode[0] != ' ' && statusCode[0] != '?':
-			// Staged file
-			result.Staged = append(result.Staged, filename)
-		case statusCode[1] == 'M' || statusCode[1] == 'D':
-			// Modified or deleted in working tree
-			result.Modified = append(result.Modified, filename)
-		case statusCode == "??":
-			// Untracked file
-			result.Untracked = append(result.Untracked, filename)
-		}
+	if stashes, err := repo.Run(ctx, "stash", "list"); err == nil {
+		result.StashCount = strings.Count(stashes, "\n")
 	}
+	result.Operation = repo.operation(ctx)
 
 	return result, nil
 }
 
-// CLI
-func GitStatusCli() error {
-	args := os.Args[3:]
-
-	if len(args) != 1 {
-		return fmt.Errorf("git_status requires exactly 1 argument: app_name\nUsage: layered-code tool git_status <app_name>")
-	}
-
-	appName := args[0]
-	result, err := GitStatus(appName)
-	if err != nil {
-		return fmt.Errorf("failed to get git status: %w", err)
-	}
-
-	if !result.IsRepo {
-		fmt.Println(result.Message)
-		return nil
+// parseStatus parses the output of git status --porcelain=v2 --branch -z
+func parseStatus(output string) GitStatusResult {
+	result := GitStatusResult{
+		Entries:   []StatusEntry{},
+		Staged:    []string{},
+		Modified:  []string{},
+		Untracked: []string{},
 	}
 
-	fmt.Printf("Git Status for '%s':\n", appName)
-	fmt.Printf("Branch: %s\n", result.Branch)
+	records := strings.Split(output, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
 
-	if len(result.Staged) > 0 {
-		fmt.Println("\nStaged changes:")
-		for _, file := range result.Staged {
-			fmt.Printf("  + %s\n", file)
+		if header, ok := strings.CutPrefix(record, "# "); ok {
+			key, value, _ := strings.Cut(header, " ")
+			switch key {
+			case "branch.oid":
+				if value != "(initial)" {
+					result.Head = value
+				}
+			case "branch.head":
+				result.Branch = value
+				if value == "(detached)" {
+					result.Branch, result.Detached = "HEAD", true
+				}
+			case "branch.upstream":
+				result.Upstream = value
+			case "branch.ab":
+				fmt.Sscanf(value, "+%d -%d", &result.Ahead, &result.Behind)
+			}
+			continue
 		}
-	}
 
-	if len(result.Modified) > 0 {
-		fmt.Println("\nModified files:")
-		for _, file := range result.Modified {
-			fmt.Printf("  M %s\n", file)
+		var entry StatusEntry
+		switch record[0] {
+		case '1', '2', 'u':
+			// 1 XY sub mH mI mW hH hI path; 2 adds a score before the path and the
+			// source after it; u has three stages: u XY sub m1 m2 m3 mW h1 h2 h3 path
+			fieldCount := map[byte]int{'1': 9, '2': 10, 'u': 11}[record[0]]
+			fields := strings.SplitN(record, " ", fieldCount)
+			if len(fields) < fieldCount || len(fields[1]) != 2 {
+				continue
+			}
+			xy := fields[1]
+			entry.Path = fields[fieldCount-1]
+			entry.Submodule = strings.HasPrefix(fields[2], "S")
+			if record[0] == 'u' {
+				entry.Conflict = conflictWords[xy]
+				entry.Index, entry.Worktree = statusWords['U'], statusWords['U']
+				result.Conflicted = append(result.Conflicted, entry.Path)
+				break
+			}
+			entry.Index, entry.Worktree = statusWords[xy[0]], statusWords[xy[1]]
+			if record[0] == '2' {
+				entry.Similarity, _ = strconv.Atoi(fields[8][1:])
+				if i+1 < len(records) {
+					i++
+					entry.OrigPath = records[i]
+				}
+			}
+			if xy[0] != '.' {
+				result.Staged = append(result.Staged, entry.Path)
+			}
+			if xy[1] != '.' {
+				result.Modified = append(result.Modified, entry.Path)
+			}
+		case '?':
+			entry = StatusEntry{Path: record[2:], Untracked: true}
+			result.Untracked = append(result.Untracked, entry.Path)
+		default:
+			continue
 		}
+		result.Entries = append(result.Entries, entry)
 	}
+	return result
+}
 
-	if len(result.Untracked) > 0 {
-		fmt.Println("\nUntracked files:")
-		for _, file := range result.Untracked {
-			fmt.Printf("  ? %s\n", file)
+// operationInProgress reports the merge, rebase, cherry-pick, revert or bisect a repository is in
+// the middle of, judging by the state files git leaves in its directory
+func operationInProgress(gitDir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+	switch {
+	case exists("rebase-merge"):
+		return "rebase"
+	case exists("rebase-apply"):
+		if exists(filepath.Join("rebase-apply", "applying")) {
+			return "am"
 		}
+		return "rebase"
+	case exists("MERGE_HEAD"):
+		return "merge"
+	case exists("CHERRY_PICK_HEAD"):
+		return "cherry_pick"
+	case exists("REVERT_HEAD"):
+		return "revert"
+	case exists("BISECT_LOG"):
+		return "bisect"
 	}
+	return ""
+}
 
-	if len(result.Staged) == 0 && len(result.Modified) == 0 && len(result.Untracked) == 0 {
-		fmt.Println("\nWorking tree clean")
-	}
+// Tool
+func gitStatusTool() registry.Tool {
+	return registry.New(registry.Spec[GitStatusParams, GitStatusResult]{
+		Name:        "git_status",
+		Group:       "git",
+		Description: "Show the working tree status of a git repository (requires git to be installed)",
+		Summary:     "Show the working tree status",
+		Hints:       registry.ReadOnly,
+		Run: func(ctx context.Context, params GitStatusParams) (GitStatusResult, error) {
+			return GitStatus(ctx, params.AppName)
+		},
+		Render: func(w io.Writer, params GitStatusParams, result GitStatusResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
 
-	return nil
-}
+			fmt.Fprintf(w, "Git Status for '%s':\n", params.AppName)
+			fmt.Fprintf(w, "Branch: %s\n", result.Branch)
+			if result.Upstream != "" {
+				fmt.Fprintf(w, "Upstream: %s (ahead %d, behind %d)\n", result.Upstream, result.Ahead, result.Behind)
+			}
+			if result.Operation != "" {
+				fmt.Fprintf(w, "In progress: %s\n", strings.ReplaceAll(result.Operation, "_", "-"))
+			}
+			if result.StashCount > 0 {
+				fmt.Fprintf(w, "Stashes: %d\n", result.StashCount)
+			}
 
-// MCP
-func GitStatusMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-	}
+			if len(result.Conflicted) > 0 {
+				fmt.Fprintln(w, "\nConflicts:")
+				for _, entry := range result.Entries {
+					if entry.Conflict != "" {
+						fmt.Fprintf(w, "  U %s (%s)\n", entry.Path, strings.ReplaceAll(entry.Conflict, "_", " "))
+					}
+				}
+			}
 
-	if err := request.BindArguments(&args); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %w", err)
-	}
+			if len(result.Staged) > 0 {
+				fmt.Fprintln(w, "\nStaged changes:")
+				for _, entry := range result.Entries {
+					if entry.Index != "" && entry.Index != "unmodified" && entry.Conflict == "" {
+						fmt.Fprintf(w, "  %s\n", describeEntry(entry, entry.Index))
+					}
+				}
+			}
 
-	if args.AppName == "" {
-		return nil, fmt.Errorf("app_name is required")
-	}
+			if len(result.Modified) > 0 {
+				fmt.Fprintln(w, "\nModified files:")
+				for _, entry := range result.Entries {
+					if entry.Worktree != "" && entry.Worktree != "unmodified" && entry.Conflict == "" {
+						fmt.Fprintf(w, "  %s\n", describeEntry(entry, entry.Worktree))
+					}
+				}
+			}
 
-	result, err := GitStatus(args.AppName)
-	if err != nil {
-		return nil, err
-	}
+			if len(result.Untracked) > 0 {
+				fmt.Fprintln(w, "\nUntracked files:")
+				for _, file := range result.Untracked {
+					fmt.Fprintf(w, "  ? %s\n", file)
+				}
+			}
 
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
+			if len(result.Entries) == 0 {
+				fmt.Fprintln(w, "\nWorking tree clean")
+			}
+		},
+	})
+}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+// describeEntry formats one side of a status entry: "renamed: old -> new"
+func describeEntry(entry StatusEntry, status string) string {
+	if entry.OrigPath != "" && (status == "renamed" || status == "copied") {
+		return fmt.Sprintf("%s: %s -> %s", strings.ReplaceAll(status, "_", " "), entry.OrigPath, entry.Path)
+	}
+	return fmt.Sprintf("%s: %s", strings.ReplaceAll(status, "_", " "), entry.Path)
+}
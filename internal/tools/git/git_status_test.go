@@ -7,10 +7,12 @@ import (
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestGitStatus(t *testing.T) {
 	// Setup test environment
+	testutil.Home(t)
 	appsDir, err := config.EnsureAppsDirectory()
 	if err != nil {
 		t.Fatalf("Failed to ensure apps directory: %v", err)
@@ -28,7 +30,7 @@ func TestGitStatus(t *testing.T) {
 
 	// Test 1: Non-git repository
 	t.Run("NonGitRepo", func(t *testing.T) {
-		result, err := GitStatus(testApp)
+		result, err := GitStatus(t.Context(), testApp)
 		if err != nil {
 			t.Fatalf("GitStatus failed: %v", err)
 		}
@@ -60,7 +62,7 @@ func TestGitStatus(t *testing.T) {
 
 	// Test 2: Clean repository
 	t.Run("CleanRepo", func(t *testing.T) {
-		result, err := GitStatus(testApp)
+		result, err := GitStatus(t.Context(), testApp)
 		if err != nil {
 			t.Fatalf("GitStatus failed: %v", err)
 		}
@@ -82,7 +84,7 @@ func TestGitStatus(t *testing.T) {
 			t.Fatalf("Failed to create test file: %v", err)
 		}
 
-		result, err := GitStatus(testApp)
+		result, err := GitStatus(t.Context(), testApp)
 		if err != nil {
 			t.Fatalf("GitStatus failed: %v", err)
 		}
@@ -101,7 +103,7 @@ func TestGitStatus(t *testing.T) {
 			t.Fatalf("Failed to stage file: %v", err)
 		}
 
-		result, err := GitStatus(testApp)
+		result, err := GitStatus(t.Context(), testApp)
 		if err != nil {
 			t.Fatalf("GitStatus failed: %v", err)
 		}
@@ -126,7 +128,7 @@ func TestGitStatus(t *testing.T) {
 			t.Fatalf("Failed to modify test file: %v", err)
 		}
 
-		result, err := GitStatus(testApp)
+		result, err := GitStatus(t.Context(), testApp)
 		if err != nil {
 			t.Fatalf("GitStatus failed: %v", err)
 		}
@@ -138,9 +140,122 @@ func TestGitStatus(t *testing.T) {
 
 	// Test 6: Invalid app name
 	t.Run("InvalidAppName", func(t *testing.T) {
-		_, err := GitStatus("../invalid-app")
+		_, err := GitStatus(t.Context(), "../invalid-app")
 		if err == nil {
 			t.Error("Expected error for invalid app name")
 		}
 	})
-}
\ No newline at end of file
+}
+func TestParseStatus(t *testing.T) {
+	output := "# branch.oid 1234567890abcdef\x00" +
+		"# branch.head main\x00" +
+		"# branch.upstream origin/main\x00" +
+		"# branch.ab +2 -1\x00" +
+		"1 M. N... 100644 100644 100644 aaaa bbbb src/app.js\x00" +
+		"1 .D N... 100644 100644 000000 cccc cccc gone.txt\x00" +
+		"2 R. N... 100644 100644 100644 dddd dddd R95 new name.js\x00old name.js\x00" +
+		"u UU N... 100644 100644 100644 100644 eeee ffff 1111 conflict.txt\x00" +
+		"? notes.txt\x00" +
+		"! dist/\x00"
+
+	result := parseStatus(output)
+	if result.Branch != "main" || result.Head != "1234567890abcdef" || result.Upstream != "origin/main" || result.Ahead != 2 || result.Behind != 1 {
+		t.Errorf("branch info = %+v", result)
+	}
+
+	want := []StatusEntry{
+		{Path: "src/app.js", Index: "modified", Worktree: "unmodified"},
+		{Path: "gone.txt", Index: "unmodified", Worktree: "deleted"},
+		{Path: "new name.js", OrigPath: "old name.js", Index: "renamed", Worktree: "unmodified", Similarity: 95},
+		{Path: "conflict.txt", Index: "updated", Worktree: "updated", Conflict: "both_modified"},
+		{Path: "notes.txt", Untracked: true},
+	}
+	if len(result.Entries) != len(want) {
+		t.Fatalf("parseStatus() entries = %+v; want %+v", result.Entries, want)
+	}
+	for i := range want {
+		if result.Entries[i] != want[i] {
+			t.Errorf("entry %d = %+v; want %+v", i, result.Entries[i], want[i])
+		}
+	}
+
+	if len(result.Staged) != 2 || len(result.Modified) != 1 || len(result.Untracked) != 1 || len(result.Conflicted) != 1 {
+		t.Errorf("staged %v, modified %v, untracked %v, conflicted %v", result.Staged, result.Modified, result.Untracked, result.Conflicted)
+	}
+
+	detached := parseStatus("# branch.oid (initial)\x00# branch.head (detached)\x00")
+	if !detached.Detached || detached.Branch != "HEAD" || detached.Head != "" {
+		t.Errorf("detached = %+v", detached)
+	}
+}
+
+func TestGitStatusState(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "state")
+	remoteDir := filepath.Join(homeDir, "remote.git")
+	os.MkdirAll(appPath, 0755)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		// A failed merge exits non-zero but still leaves the state we want
+		if out, err := cmd.CombinedOutput(); err != nil && args[0] != "merge" {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(appPath, name), []byte(content), 0644)
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	git("init", "--bare", remoteDir)
+	git("remote", "add", "origin", remoteDir)
+	write("a.txt", "one\n")
+	git("add", "a.txt")
+	git("commit", "-m", "First")
+	git("push", "-u", "origin", "main")
+
+	// A commit ahead of the remote, a stash and a staged rename
+	write("a.txt", "two\n")
+	git("commit", "-am", "Second")
+	write("a.txt", "stashed\n")
+	git("stash")
+	git("mv", "a.txt", "b.txt")
+
+	result, err := GitStatus(t.Context(), "state")
+	if err != nil {
+		t.Fatalf("GitStatus failed: %v", err)
+	}
+	if result.Upstream != "origin/main" || result.Ahead != 1 || result.Behind != 0 || result.StashCount != 1 {
+		t.Errorf("Expected 1 ahead of origin/main with 1 stash, got %+v", result)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Index != "renamed" || result.Entries[0].OrigPath != "a.txt" {
+		t.Errorf("Expected a staged rename of a.txt, got %+v", result.Entries)
+	}
+
+	// A conflicting merge
+	git("commit", "-m", "Rename")
+	git("checkout", "-b", "other")
+	write("b.txt", "other\n")
+	git("commit", "-am", "Other")
+	git("checkout", "main")
+	write("b.txt", "main\n")
+	git("commit", "-am", "Main")
+	git("merge", "other")
+
+	result, err = GitStatus(t.Context(), "state")
+	if err != nil {
+		t.Fatalf("GitStatus failed: %v", err)
+	}
+	if result.Operation != "merge" {
+		t.Errorf("Expected a merge in progress, got %q", result.Operation)
+	}
+	if len(result.Conflicted) != 1 || result.Conflicted[0] != "b.txt" {
+		t.Errorf("Expected b.txt to be conflicted, got %+v", result.Entries)
+	} else if conflict := result.Entries[0].Conflict; conflict != "both_modified" {
+		t.Errorf("Expected both_modified, got %q", conflict)
+	}
+}
@@ -0,0 +1,322 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// Types
+type GitTagEntry struct {
+	Name      string `json:"name"`
+	Commit    string `json:"commit"` // Short hash of the tagged commit
+	Annotated bool   `json:"annotated"`
+	Tagger    string `json:"tagger,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Message   string `json:"message,omitempty"` // Annotation of an annotated tag
+}
+
+type GitTagResult struct {
+	Success     bool          `json:"success"`
+	IsRepo      bool          `json:"is_repo"`
+	Action      string        `json:"action"`
+	Tags        []GitTagEntry `json:"tags,omitempty"`
+	Changelog   *Changelog    `json:"changelog,omitempty"`
+	Issues      []CheckIssue  `json:"issues,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	Output      string        `json:"output,omitempty"`
+	ErrorOutput string        `json:"error_output,omitempty"`
+}
+
+type GitTagParams struct {
+	AppName    string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Action     string `json:"action" pos:"true" desc:"Action to perform: list, create, delete, push, changelog (default: list)"`
+	Name       string `json:"name" pos:"true" desc:"Tag to create, delete or push; for changelog, the tag to end at (default: HEAD)"`
+	Target     string `json:"target" desc:"Commit to tag (for create; default: HEAD)"`
+	Message    string `json:"message" short:"m" desc:"Annotation; creates an annotated tag instead of a lightweight one"`
+	Remote     string `json:"remote" desc:"Remote to push to, or to delete the tag from as well (default for push: origin)"`
+	From       string `json:"from" desc:"Tag to start the changelog after (default: the tag before name)"`
+	Pattern    string `json:"pattern" desc:"Only list tags matching this glob, e.g. v1.*"`
+	SkipChecks bool   `json:"skip_checks" desc:"Push even if the tagged commits contain secrets, large files or paths matching git.deny_commit"`
+}
+
+// GitTagOptions configures GitTag
+type GitTagOptions struct {
+	Action     string
+	Name       string
+	Target     string
+	Message    string
+	Remote     string
+	From       string
+	Pattern    string
+	SkipChecks bool
+}
+
+// GitTag lists, creates, deletes or pushes tags, or builds the changelog between two tags from
+// the commits' conventional-commit types
+func GitTag(ctx context.Context, appName string, options GitTagOptions) (GitTagResult, error) {
+	if options.Action == "" {
+		options.Action = "list"
+	}
+	for _, ref := range []string{options.Name, options.Target, options.From, options.Remote} {
+		if err := validateRef(ref); err != nil {
+			return GitTagResult{}, err
+		}
+	}
+	if (options.Action == "create" || options.Action == "delete") && options.Name == "" {
+		return GitTagResult{}, fmt.Errorf("name is required to %s a tag", options.Action)
+	}
+
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitTagResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitTagResult{
+			IsRepo:  false,
+			Success: false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	result := GitTagResult{IsRepo: true, Action: options.Action}
+	switch options.Action {
+	case "list":
+		tags, err := listTags(ctx, repo, options.Pattern)
+		if err != nil {
+			return GitTagResult{}, err
+		}
+		result.Success = true
+		result.Tags = tags
+		result.Message = fmt.Sprintf("%d tag(s)", len(tags))
+
+	case "create":
+		args := []string{"tag"}
+		kind := "lightweight"
+		if options.Message != "" {
+			args = append(args, "-a", "-m", options.Message)
+			kind = "annotated"
+		}
+		args = append(args, options.Name)
+		if options.Target != "" {
+			args = append(args, options.Target)
+		}
+		if _, err := repo.Run(ctx, args...); err != nil {
+			return GitTagResult{}, err
+		}
+		result.Success = true
+		result.Message = fmt.Sprintf("Created %s tag '%s'", kind, options.Name)
+		result.Tags, _ = listTags(ctx, repo, options.Name)
+
+	case "delete":
+		if _, err := repo.Run(ctx, "tag", "-d", options.Name); err != nil {
+			return GitTagResult{}, err
+		}
+		result.Message = fmt.Sprintf("Deleted tag '%s'", options.Name)
+		if options.Remote != "" {
+			output, errOutput, err := repo.Exec(ctx, nil, "push", options.Remote, ":refs/tags/"+options.Name)
+			result.Output = strings.TrimSpace(output)
+			result.ErrorOutput = strings.TrimSpace(errOutput)
+			if err != nil {
+				result.Message = fmt.Sprintf("Deleted tag '%s' locally, but not from %s", options.Name, options.Remote)
+				return result, err
+			}
+			result.Message = fmt.Sprintf("Deleted tag '%s' locally and from %s", options.Name, options.Remote)
+		}
+		result.Success = true
+
+	case "push":
+		return pushTags(ctx, repo, result, options)
+
+	case "changelog":
+		changelog, err := tagChangelog(ctx, repo, options.From, options.Name)
+		if err != nil {
+			return GitTagResult{}, err
+		}
+		result.Success = true
+		result.Changelog = &changelog
+		result.Message = changelog.Markdown()
+
+	default:
+		return GitTagResult{}, fmt.Errorf("invalid action: %s (must be list, create, delete, push or changelog)", options.Action)
+	}
+	return result, nil
+}
+
+// listTags lists the tags matching pattern, newest first
+func listTags(ctx context.Context, repo *Repo, pattern string) ([]GitTagEntry, error) {
+	args := []string{"for-each-ref", "--sort=-creatordate",
+		"--format=%(refname:short)%1f%(objecttype)%1f%(objectname:short)%1f%(*objectname:short)%1f%(taggername)%1f%(creatordate:short)%1f%(contents)%1e"}
+	if pattern != "" {
+		args = append(args, "refs/tags/"+pattern)
+	} else {
+		args = append(args, "refs/tags")
+	}
+	output, err := repo.Run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []GitTagEntry{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) < 7 {
+			continue
+		}
+		tag := GitTagEntry{Name: fields[0], Commit: fields[2], Date: fields[5]}
+		if fields[1] == "tag" {
+			tag.Annotated = true
+			tag.Commit = fields[3]
+			tag.Tagger = fields[4]
+			tag.Message = strings.TrimSpace(fields[6])
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// pushTags pushes one tag, or every tag when no name is given. Unless SkipChecks is set, commits
+// the remote doesn't have yet are checked as git_push checks them.
+func pushTags(ctx context.Context, repo *Repo, result GitTagResult, options GitTagOptions) (GitTagResult, error) {
+	remote := options.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	ref, refspec := "--tags", "--tags" // rev-list and push both take --tags for every tag
+	if options.Name != "" {
+		ref = "refs/tags/" + options.Name
+		refspec = ref
+	}
+
+	if !options.SkipChecks {
+		issues, err := checkOutgoing(ctx, repo, remote, ref)
+		if err != nil {
+			return GitTagResult{}, fmt.Errorf("failed to check outgoing commits: %w", err)
+		}
+		if len(issues) > 0 {
+			result.Issues = issues
+			result.Message = fmt.Sprintf("Push blocked: %d problem(s) in tagged commits; fix them, or set skip_checks", len(issues))
+			return result, nil
+		}
+	}
+
+	output, errOutput, err := repo.Exec(ctx, nil, "push", remote, refspec)
+	result.Output = strings.TrimSpace(output)
+	result.ErrorOutput = strings.TrimSpace(errOutput)
+	if err != nil {
+		result.Message = "Git push failed"
+		return result, err
+	}
+	result.Success = true
+	if options.Name != "" {
+		result.Message = fmt.Sprintf("Pushed tag '%s' to %s", options.Name, remote)
+	} else {
+		result.Message = fmt.Sprintf("Pushed tags to %s", remote)
+	}
+	return result, nil
+}
+
+// tagChangelog builds the changelog of the commits after from up to to. Without from it starts
+// after the tag before to, or at the first commit when there is none.
+func tagChangelog(ctx context.Context, repo *Repo, from, to string) (Changelog, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	if from == "" {
+		previous, err := repo.Run(ctx, "describe", "--tags", "--abbrev=0", to+"^")
+		var gitErr *Error
+		switch {
+		case err == nil:
+			from = strings.TrimSpace(previous)
+		case errors.As(err, &gitErr) && !errors.Is(err, ErrTimeout):
+			// No earlier tag, or to is the first commit
+		default:
+			return Changelog{}, err
+		}
+	}
+
+	rangeSpec := to
+	if from != "" {
+		rangeSpec = from + ".." + to
+	}
+	output, err := repo.Run(ctx, append([]string{"log", "--no-merges"}, append(logFormat, rangeSpec)...)...)
+	if err != nil {
+		return Changelog{}, err
+	}
+	return buildChangelog(from, to, parseLog(output)), nil
+}
+
+// Tool
+func gitTagTool() registry.Tool {
+	return registry.New(registry.Spec[GitTagParams, GitTagResult]{
+		Name:        "git_tag",
+		Group:       "git",
+		Description: "List, create, delete and push tags, and generate the changelog between two tags (requires git to be installed)",
+		Summary:     "Manage tags and release changelogs",
+		Hints:       registry.Hints{Destructive: true, OpenWorld: true},
+		Notes: []string{
+			"list shows each tag's commit and, for annotated tags, tagger, date and annotation",
+			"create makes a lightweight tag, or an annotated one when --message is given",
+			"delete removes the tag locally, and from --remote too when given",
+			"push sends --name, or every tag, to --remote (default: origin); tagged commits the remote lacks are checked like git_push, use --skip-checks to push anyway",
+			"changelog groups the commits after --from (default: the previous tag) up to --name (default: HEAD) by conventional-commit type (feat, fix, ...)",
+		},
+		Examples: []string{
+			"# List tags\nlayered-code tool git_tag myapp",
+			"# Create and push an annotated release tag\nlayered-code tool git_tag myapp create v1.2.0 -m \"Release 1.2.0\"\nlayered-code tool git_tag myapp push v1.2.0",
+			"# Changelog since the previous release\nlayered-code tool git_tag myapp changelog v1.2.0",
+		},
+		Run: func(ctx context.Context, params GitTagParams) (GitTagResult, error) {
+			return GitTag(ctx, params.AppName, GitTagOptions{
+				Action:     params.Action,
+				Name:       params.Name,
+				Target:     params.Target,
+				Message:    params.Message,
+				Remote:     params.Remote,
+				From:       params.From,
+				Pattern:    params.Pattern,
+				SkipChecks: params.SkipChecks,
+			})
+		},
+		Render: func(w io.Writer, _ GitTagParams, result GitTagResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
+
+			switch {
+			case result.Action == "changelog":
+				fmt.Fprint(w, result.Message)
+				return
+			case result.Action == "list" && len(result.Tags) == 0:
+				fmt.Fprintln(w, "No tags found")
+				return
+			case result.Action != "list":
+				if !result.Success {
+					fmt.Fprintf(w, "Failed: %s\n", result.Message)
+					renderIssues(w, result.Issues)
+				} else {
+					fmt.Fprintln(w, result.Message)
+				}
+				if result.Output != "" {
+					fmt.Fprintln(w, result.Output)
+				}
+			}
+
+			for _, tag := range result.Tags {
+				if !tag.Annotated {
+					fmt.Fprintf(w, "%s -> %s\n", tag.Name, tag.Commit)
+					continue
+				}
+				fmt.Fprintf(w, "%s -> %s (%s, %s)\n", tag.Name, tag.Commit, tag.Tagger, tag.Date)
+				for _, line := range strings.Split(tag.Message, "\n") {
+					fmt.Fprintf(w, "    %s\n", line)
+				}
+			}
+		},
+	})
+}
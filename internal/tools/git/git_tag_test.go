@@ -0,0 +1,142 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    ConventionalCommit
+		ok      bool
+	}{
+		{"feat: add login", ConventionalCommit{Type: "feat", Description: "add login"}, true},
+		{"fix(api): handle timeouts", ConventionalCommit{Type: "fix", Scope: "api", Description: "handle timeouts"}, true},
+		{"Refactor(ui)!: drop old theme", ConventionalCommit{Type: "refactor", Scope: "ui", Breaking: true, Description: "drop old theme"}, true},
+		{"Update README", ConventionalCommit{}, false},
+		{"feat:missing space", ConventionalCommit{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseConventional(tt.subject)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseConventional(%q) = %+v, %v; want %+v, %v", tt.subject, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBuildChangelog(t *testing.T) {
+	changelog := buildChangelog("v1.0.0", "v1.1.0", []GitLogEntry{
+		{Hash: "aaaaaaa", Message: "fix(api): handle timeouts"},
+		{Hash: "bbbbbbb", Message: "Tweak copy"},
+		{Hash: "ccccccc", Message: "feat!: new config format"},
+		{Hash: "ddddddd", Message: "wip: experiment"},
+	})
+
+	var types []string
+	for _, section := range changelog.Sections {
+		types = append(types, section.Type)
+	}
+	if strings.Join(types, ",") != "feat,fix,other" {
+		t.Errorf("sections = %v; want feat, fix, other", types)
+	}
+	if len(changelog.Breaking) != 1 || changelog.Breaking[0].Hash != "ccccccc" {
+		t.Errorf("breaking = %+v; want the feat! commit", changelog.Breaking)
+	}
+	if other := changelog.Sections[2].Entries; len(other) != 2 || other[0].Description != "Tweak copy" || other[1].Description != "experiment" {
+		t.Errorf("other = %+v; want the unconventional and unknown-type commits", other)
+	}
+
+	markdown := changelog.Markdown()
+	for _, want := range []string{"## v1.1.0 (since v1.0.0)", "### Breaking Changes", "### Bug Fixes", "- **api:** handle timeouts (aaaaaaa)"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() = %q; want it to contain %q", markdown, want)
+		}
+	}
+}
+
+func TestGitTag(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "tagged")
+	remoteDir := filepath.Join(homeDir, "remote.git")
+	os.MkdirAll(appPath, 0755)
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(message string) {
+		os.WriteFile(filepath.Join(appPath, "log.txt"), []byte(message), 0644)
+		git(appPath, "add", "-A")
+		git(appPath, "commit", "-m", message)
+	}
+	git(homeDir, "init", "--bare", remoteDir)
+	git(appPath, "init", "-b", "main")
+	git(appPath, "config", "user.email", "test@example.com")
+	git(appPath, "config", "user.name", "Test User")
+	git(appPath, "remote", "add", "origin", remoteDir)
+
+	commit("feat: first release")
+	if result, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "create", Name: "v1.0.0"}); err != nil || !result.Success {
+		t.Fatalf("GitTag create = %+v, %v", result, err)
+	}
+	commit("fix(ui): button color")
+	commit("feat: dark mode")
+	result, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "create", Name: "v1.1.0", Message: "Release 1.1.0"})
+	if err != nil || !result.Success {
+		t.Fatalf("GitTag create annotated = %+v, %v", result, err)
+	}
+
+	list, err := GitTag(t.Context(), "tagged", GitTagOptions{})
+	if err != nil {
+		t.Fatalf("GitTag list failed: %v", err)
+	}
+	tags := make(map[string]GitTagEntry)
+	for _, tag := range list.Tags {
+		tags[tag.Name] = tag
+	}
+	if len(tags) != 2 || tags["v1.0.0"].Annotated || !tags["v1.1.0"].Annotated || tags["v1.1.0"].Message != "Release 1.1.0" || tags["v1.1.0"].Commit == "" {
+		t.Errorf("Unexpected tags %+v", list.Tags)
+	}
+
+	changelog, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "changelog", Name: "v1.1.0"})
+	if err != nil {
+		t.Fatalf("GitTag changelog failed: %v", err)
+	}
+	if changelog.Changelog.From != "v1.0.0" || len(changelog.Changelog.Sections) != 2 {
+		t.Errorf("Unexpected changelog %+v", changelog.Changelog)
+	}
+	if strings.Contains(changelog.Message, "first release") {
+		t.Errorf("Changelog includes commits before v1.0.0: %s", changelog.Message)
+	}
+
+	if push, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "push", Name: "v1.1.0"}); err != nil || !push.Success {
+		t.Fatalf("GitTag push = %+v, %v", push, err)
+	}
+	if out, _ := exec.Command("git", "-C", remoteDir, "tag").Output(); strings.TrimSpace(string(out)) != "v1.1.0" {
+		t.Errorf("Expected the remote to have v1.1.0, got %q", out)
+	}
+
+	if del, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "delete", Name: "v1.1.0", Remote: "origin"}); err != nil || !del.Success {
+		t.Fatalf("GitTag delete = %+v, %v", del, err)
+	}
+	if out, _ := exec.Command("git", "-C", remoteDir, "tag").Output(); strings.TrimSpace(string(out)) != "" {
+		t.Errorf("Expected the remote tag to be deleted, got %q", out)
+	}
+
+	if _, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "create"}); err == nil {
+		t.Error("Expected create without a name to fail")
+	}
+	if _, err := GitTag(t.Context(), "tagged", GitTagOptions{Action: "create", Name: "--force"}); err == nil {
+		t.Error("Expected an option-like name to be rejected")
+	}
+}
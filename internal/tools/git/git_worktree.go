@@ -0,0 +1,374 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+)
+
+// Types
+type GitWorktreeEntry struct {
+	AppName     string `json:"app_name,omitempty"` // Empty for worktrees outside the apps directory
+	Path        string `json:"path"`
+	Branch      string `json:"branch,omitempty"` // Empty when the HEAD is detached
+	Head        string `json:"head,omitempty"`   // Short hash of the checked out commit
+	Main        bool   `json:"main,omitempty"`
+	Locked      bool   `json:"locked,omitempty"`
+	Prunable    bool   `json:"prunable,omitempty"` // Its directory is gone; prune forgets it
+	PreviewPort int    `json:"preview_port,omitempty"`
+}
+
+type GitWorktreeResult struct {
+	Success        bool               `json:"success"`
+	IsRepo         bool               `json:"is_repo"`
+	Action         string             `json:"action"`
+	Worktrees      []GitWorktreeEntry `json:"worktrees,omitempty"`
+	AppName        string             `json:"app_name,omitempty"` // App the created worktree is addressed as
+	PreviewPort    int                `json:"preview_port,omitempty"`
+	Message        string             `json:"message,omitempty"`
+	StoppedProcess bool               `json:"stopped_process,omitempty"` // The removed worktree's PM2 process was deleted first
+	Output         string             `json:"output,omitempty"`
+}
+
+type GitWorktreeParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Action  string `json:"action" pos:"true" desc:"Action to perform: list, create, remove, prune (default: list)"`
+	Name    string `json:"name" pos:"true" desc:"Worktree to create or remove; it is addressed as the app <app_name>@<name>"`
+	Branch  string `json:"branch" short:"b" desc:"Branch to check out in the new worktree, created if it doesn't exist (default: name)"`
+	Ref     string `json:"ref" desc:"Commit to start a new branch at (default: HEAD)"`
+	Force   bool   `json:"force" desc:"Remove the worktree even if it has uncommitted changes"`
+}
+
+// GitWorktreeOptions configures GitWorktree
+type GitWorktreeOptions struct {
+	Action string
+	Name   string
+	Branch string // Defaults to Name
+	Ref    string
+	Force  bool
+}
+
+// GitWorktree lists, creates and removes linked worktrees of an app. A worktree named name
+// is created next to the app as the app <app>@<name>, with the dev server port of its manifest
+// moved to one no other worktree of the app uses, so both can run side by side.
+func GitWorktree(ctx context.Context, appName string, options GitWorktreeOptions) (GitWorktreeResult, error) {
+	if options.Action == "" {
+		options.Action = "list"
+	}
+	if options.Action == "create" || options.Action == "remove" {
+		if err := validateWorktreeName(options.Name); err != nil {
+			return GitWorktreeResult{}, err
+		}
+	}
+	for _, ref := range []string{options.Branch, options.Ref} {
+		if err := validateRef(ref); err != nil {
+			return GitWorktreeResult{}, err
+		}
+	}
+
+	// Worktrees belong to the main app, whichever of its worktrees is named
+	appName, _ = config.SplitWorktreeName(appName)
+	repo, err := OpenRepo(appName)
+	if err != nil {
+		return GitWorktreeResult{}, err
+	}
+	if !repo.IsRepo() {
+		return GitWorktreeResult{
+			IsRepo:  false,
+			Success: false,
+			Message: repo.notRepoMessage(),
+		}, nil
+	}
+
+	ws, app, err := config.FindApp(appName)
+	if err != nil {
+		return GitWorktreeResult{}, err
+	}
+	appDir, err := config.AppDirectory(appName)
+	if err != nil {
+		return GitWorktreeResult{}, err
+	}
+	worktreeApp := config.QualifiedAppName(ws.Name, config.WorktreeAppName(app, options.Name))
+	worktreeDir := appDir + config.WorktreeSeparator + options.Name
+
+	result := GitWorktreeResult{IsRepo: true, Action: options.Action}
+	switch options.Action {
+	case "list":
+		result.Message = "Worktrees"
+
+	case "create":
+		if _, err := os.Lstat(worktreeDir); err == nil {
+			return GitWorktreeResult{}, fmt.Errorf("app '%s' already exists", worktreeApp)
+		}
+		branch := options.Branch
+		if branch == "" {
+			branch = options.Name
+		}
+
+		args := []string{"worktree", "add"}
+		if _, err := repo.Run(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			if options.Ref != "" {
+				return GitWorktreeResult{}, fmt.Errorf("branch '%s' already exists; ref only applies to new branches", branch)
+			}
+			args = append(args, worktreeDir, branch)
+		} else {
+			args = append(args, "-b", branch, worktreeDir)
+			if options.Ref != "" {
+				args = append(args, options.Ref)
+			}
+		}
+		output, err := repo.Run(ctx, args...)
+		if err != nil {
+			return GitWorktreeResult{}, err
+		}
+		result.Output = strings.TrimSpace(output)
+		result.AppName = worktreeApp
+
+		worktrees, err := listWorktrees(ctx, repo, ws, appDir)
+		if err != nil {
+			return GitWorktreeResult{}, err
+		}
+		result.PreviewPort, err = separatePreviewPort(ctx, appDir, worktreeApp, worktreeDir, worktrees)
+		if err != nil {
+			result.Message = fmt.Sprintf("Created worktree '%s' on branch '%s', but couldn't set up its manifest: %v", worktreeApp, branch, err)
+			break
+		}
+		result.Message = fmt.Sprintf("Created worktree '%s' on branch '%s'; its dev server uses port %d", worktreeApp, branch, result.PreviewPort)
+
+	case "remove":
+		// Its dev server would keep running from the removed directory
+		result.StoppedProcess, err = pnpm.StopApp(worktreeApp)
+		if err != nil {
+			return GitWorktreeResult{}, err
+		}
+		args := []string{"worktree", "remove"}
+		if options.Force {
+			args = append(args, "--force")
+		}
+		if _, err := repo.Run(ctx, append(args, worktreeDir)...); err != nil {
+			return GitWorktreeResult{}, err
+		}
+		result.Message = fmt.Sprintf("Removed worktree '%s'; its branch was kept", worktreeApp)
+		if result.StoppedProcess {
+			result.Message = fmt.Sprintf("Stopped PM2 process and removed worktree '%s'; its branch was kept", worktreeApp)
+		}
+
+	case "prune":
+		output, err := repo.Run(ctx, "worktree", "prune", "--verbose")
+		if err != nil {
+			return GitWorktreeResult{}, err
+		}
+		result.Output = strings.TrimSpace(output)
+		result.Message = "Pruned worktrees whose directories are gone"
+
+	default:
+		return GitWorktreeResult{}, fmt.Errorf("invalid action: %s (must be list, create, remove or prune)", options.Action)
+	}
+
+	// Always report the worktrees as they are now
+	result.Worktrees, err = listWorktrees(ctx, repo, ws, appDir)
+	if err != nil {
+		return GitWorktreeResult{}, err
+	}
+	result.Success = true
+	return result, nil
+}
+
+// validateWorktreeName checks that a worktree name can be part of an app name
+func validateWorktreeName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("worktree name %q may not start with '.' or '-'", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("worktree name %q may only contain letters, digits, '-', '_' and '.'", name)
+		}
+	}
+	return nil
+}
+
+// listWorktrees parses git worktree list --porcelain. Worktrees next to the app are named as
+// the apps they are addressed as.
+func listWorktrees(ctx context.Context, repo *Repo, ws config.Workspace, appDir string) ([]GitWorktreeEntry, error) {
+	output, err := repo.Run(ctx, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	root, prefix := realPath(filepath.Dir(appDir)), filepath.Base(appDir)+config.WorktreeSeparator
+	worktrees := []GitWorktreeEntry{}
+	for _, record := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		var entry GitWorktreeEntry
+		for _, line := range strings.Split(record, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				entry.Path = value
+			case "HEAD":
+				entry.Head = value[:min(len(value), 7)]
+			case "branch":
+				entry.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "locked":
+				entry.Locked = true
+			case "prunable":
+				entry.Prunable = true
+			}
+		}
+		if entry.Path == "" {
+			continue
+		}
+
+		entry.Main = len(worktrees) == 0
+		if entry.Main {
+			entry.AppName = config.QualifiedAppName(ws.Name, filepath.Base(appDir))
+		} else if base := filepath.Base(entry.Path); realPath(filepath.Dir(entry.Path)) == root && strings.HasPrefix(base, prefix) {
+			entry.AppName = config.QualifiedAppName(ws.Name, base)
+		}
+		if manifest, _, err := config.ReadAppManifest(entry.Path); err == nil {
+			entry.PreviewPort = manifest.PreviewPort
+		}
+		worktrees = append(worktrees, entry)
+	}
+	return worktrees, nil
+}
+
+// realPath resolves symlinks in a path if it exists
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+// separatePreviewPort gives a new worktree the app's manifest with the first port after the
+// app's dev server port that no other worktree uses and nothing listens on. Without a
+// preview_port the app's port is its framework's default. A manifest git tracks is marked
+// skip-worktree, so the changed port isn't committed from the worktree.
+func separatePreviewPort(ctx context.Context, appDir, worktreeApp, worktreeDir string, worktrees []GitWorktreeEntry) (int, error) {
+	manifest, _, err := config.ReadAppManifest(appDir)
+	if err != nil {
+		return 0, err
+	}
+
+	used := make(map[int]bool)
+	for _, worktree := range worktrees {
+		if filepath.Base(worktree.Path) != filepath.Base(worktreeDir) {
+			used[worktree.PreviewPort] = true
+		}
+	}
+	appPort := manifest.DevPort(appDir)
+	used[appPort] = true
+
+	port := appPort + 1
+	for ; port <= 65535 && (used[port] || !portFree(port)); port++ {
+	}
+	if port > 65535 {
+		return 0, fmt.Errorf("no free port after %d", appPort)
+	}
+	manifest.PreviewPort = port
+	manifest.PreviewURL = "" // Derived from the port instead
+	if err := config.WriteAppManifest(worktreeDir, manifest); err != nil {
+		return 0, err
+	}
+
+	worktreeRepo, err := OpenRepo(worktreeApp)
+	if err != nil {
+		return 0, err
+	}
+	manifestPath := filepath.ToSlash(filepath.Join(constants.AppManifestDir, constants.AppManifestFile))
+	if _, err := worktreeRepo.Run(ctx, "ls-files", "--error-unmatch", "--", manifestPath); err == nil {
+		if _, err := worktreeRepo.Run(ctx, "update-index", "--skip-worktree", "--", manifestPath); err != nil {
+			return 0, err
+		}
+	}
+	return manifest.PreviewPort, nil
+}
+
+// portFree reports whether nothing listens on a local port
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// Tool
+func gitWorktreeTool() registry.Tool {
+	return registry.New(registry.Spec[GitWorktreeParams, GitWorktreeResult]{
+		Name:        "git_worktree",
+		Group:       "git",
+		Description: "List, create and remove linked worktrees of an app, each addressable as the app <app>@<name> by every tool (requires git to be installed)",
+		Summary:     "Manage worktrees for parallel experiments",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"create checks out --branch (default: the worktree name, created at --ref if new) in a new directory next to the app, addressed as <app>@<name> by lc, git and pnpm tools",
+			"A new worktree gets the app's manifest, or a new one, with its own preview_port after the app's (its framework's default if the app declares none), so pnpm_pm2 can run its dev server alongside the app's",
+			"remove stops the worktree's PM2 process and deletes its directory but keeps its branch. Uncommitted changes need --force, which needs the user's confirmation",
+			"prune forgets worktrees whose directories were deleted by other means",
+		},
+		Examples: []string{
+			"# Try a second design next to the first\nlayered-code tool git_worktree myapp create sidebar-nav\nlayered-code tool pnpm_pm2 start myapp@sidebar-nav",
+			"# Drop the experiment\nlayered-code tool git_worktree myapp remove sidebar-nav",
+		},
+		Run: func(ctx context.Context, params GitWorktreeParams) (GitWorktreeResult, error) {
+			return GitWorktree(ctx, params.AppName, GitWorktreeOptions{
+				Action: params.Action,
+				Name:   params.Name,
+				Branch: params.Branch,
+				Ref:    params.Ref,
+				Force:  params.Force,
+			})
+		},
+		Render: func(w io.Writer, _ GitWorktreeParams, result GitWorktreeResult) {
+			if !result.IsRepo {
+				fmt.Fprintln(w, result.Message)
+				return
+			}
+
+			if result.Action != "list" {
+				fmt.Fprintln(w, result.Message)
+				if result.Output != "" {
+					fmt.Fprintln(w, result.Output)
+				}
+				fmt.Fprintln(w)
+			}
+			for _, worktree := range result.Worktrees {
+				name := worktree.AppName
+				if name == "" {
+					name = worktree.Path
+				}
+				branch := worktree.Branch
+				if branch == "" {
+					branch = "(detached)"
+				}
+				fmt.Fprintf(w, "%-30s %s %s", name, worktree.Head, branch)
+				if worktree.PreviewPort != 0 {
+					fmt.Fprintf(w, "  port %d", worktree.PreviewPort)
+				}
+				switch {
+				case worktree.Main:
+					fmt.Fprint(w, "  (main)")
+				case worktree.Prunable:
+					fmt.Fprint(w, "  (prunable)")
+				case worktree.Locked:
+					fmt.Fprint(w, "  (locked)")
+				}
+				fmt.Fprintln(w)
+			}
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestGitWorktree(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "designs")
+	os.MkdirAll(appPath, 0755)
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	git(appPath, "init", "-b", "main")
+	git(appPath, "config", "user.email", "test@example.com")
+	git(appPath, "config", "user.name", "Test User")
+
+	os.WriteFile(filepath.Join(appPath, "index.html"), []byte("<h1>One</h1>\n"), 0644)
+	if err := config.WriteAppManifest(appPath, config.AppManifest{DevCommand: "pnpm run dev", PreviewPort: 41730}); err != nil {
+		t.Fatal(err)
+	}
+	git(appPath, "add", "-A")
+	git(appPath, "commit", "-m", "Initial commit")
+
+	result, err := GitWorktree(t.Context(), "designs", GitWorktreeOptions{Action: "create", Name: "sidebar"})
+	if err != nil {
+		t.Fatalf("GitWorktree create failed: %v", err)
+	}
+	if result.AppName != "designs@sidebar" || result.PreviewPort <= 41730 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	worktreePath := filepath.Join(homeDir, "apps", "designs@sidebar")
+	if branch := strings.TrimSpace(git(worktreePath, "branch", "--show-current")); branch != "sidebar" {
+		t.Errorf("Expected the worktree on branch sidebar, got %q", branch)
+	}
+
+	// The worktree is an app of its own, with its own port that isn't a change to commit
+	manifest, err := config.LoadAppManifest("designs@sidebar")
+	if err != nil || manifest.PreviewPort != result.PreviewPort {
+		t.Errorf("Expected the worktree manifest to have port %d, got %+v, %v", result.PreviewPort, manifest, err)
+	}
+	status, err := GitStatus(t.Context(), "designs@sidebar")
+	if err != nil || len(status.Entries) != 0 || status.Branch != "sidebar" {
+		t.Errorf("Expected a clean worktree, got %+v, %v", status, err)
+	}
+
+	// A second worktree gets another port
+	second, err := GitWorktree(t.Context(), "designs@sidebar", GitWorktreeOptions{Action: "create", Name: "tabs", Branch: "tabs-layout", Ref: "main"})
+	if err != nil {
+		t.Fatalf("GitWorktree create from a worktree failed: %v", err)
+	}
+	if second.AppName != "designs@tabs" || second.PreviewPort == result.PreviewPort {
+		t.Errorf("Unexpected second worktree %+v", second)
+	}
+	if len(second.Worktrees) != 3 || !second.Worktrees[0].Main || second.Worktrees[0].AppName != "designs" || second.Worktrees[2].Branch != "tabs-layout" {
+		t.Errorf("Unexpected worktrees %+v", second.Worktrees)
+	}
+
+	if _, err := GitWorktree(t.Context(), "designs", GitWorktreeOptions{Action: "create", Name: "tabs"}); err == nil {
+		t.Error("Expected creating an existing worktree to fail")
+	}
+	if _, err := GitWorktree(t.Context(), "designs", GitWorktreeOptions{Action: "create", Name: "../escape"}); err == nil {
+		t.Error("Expected an invalid worktree name to fail")
+	}
+
+	// Uncommitted changes need force
+	os.WriteFile(filepath.Join(worktreePath, "index.html"), []byte("<h1>Two</h1>\n"), 0644)
+	if _, err := GitWorktree(t.Context(), "designs", GitWorktreeOptions{Action: "remove", Name: "sidebar"}); err == nil {
+		t.Error("Expected removing a dirty worktree to fail")
+	}
+
+	// Its dev server is stopped first
+	calls := testutil.FakePm2(t, `[{"name":"designs","pm2_env":{"status":"online"}},{"name":"designs@sidebar","pm2_env":{"status":"online"}}]`)
+	removed, err := GitWorktree(t.Context(), "designs", GitWorktreeOptions{Action: "remove", Name: "sidebar", Force: true})
+	if err != nil {
+		t.Fatalf("GitWorktree remove failed: %v", err)
+	}
+	if len(removed.Worktrees) != 2 || !removed.StoppedProcess {
+		t.Errorf("Expected 2 worktrees left and the PM2 process stopped, got %+v", removed)
+	}
+	if got := calls(); len(got) != 1 || got[0] != "dlx pm2 delete designs@sidebar" {
+		t.Errorf("Expected pm2 delete designs@sidebar, got %q", got)
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree directory to be gone, got %v", err)
+	}
+	if branches := git(appPath, "branch"); !strings.Contains(branches, "sidebar") {
+		t.Errorf("Expected the branch to be kept, got:\n%s", branches)
+	}
+}
+
+// TestGitWorktreeDefaultPort checks that a worktree of an app without a manifest gets a port
+// after its framework's default
+func TestGitWorktreeDefaultPort(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	appPath := filepath.Join(homeDir, "apps", "shop")
+	os.MkdirAll(appPath, 0755)
+	os.WriteFile(filepath.Join(appPath, "package.json"), []byte(`{"dependencies":{"next":"15.0.0"}}`), 0644)
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"add", "-A"},
+		{"-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = appPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	result, err := GitWorktree(t.Context(), "shop", GitWorktreeOptions{Action: "create", Name: "checkout"})
+	if err != nil {
+		t.Fatalf("GitWorktree create failed: %v", err)
+	}
+	if result.PreviewPort <= 3000 || !strings.Contains(result.Message, "port") {
+		t.Errorf("Expected a port after Next's 3000, got %+v", result)
+	}
+	manifest, err := config.LoadAppManifest("shop@checkout")
+	if err != nil || manifest.PreviewPort != result.PreviewPort {
+		t.Errorf("Expected the worktree manifest to have port %d, got %+v, %v", result.PreviewPort, manifest, err)
+	}
+}
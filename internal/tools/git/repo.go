@@ -0,0 +1,238 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/helpers"
+)
+
+// Kinds of git failure the tools handle; test for them with errors.Is
+var (
+	ErrNotRepo        = errors.New("not a git repository")
+	ErrNoCommits      = errors.New("no commits yet")
+	ErrConflict       = errors.New("conflict")
+	ErrAuthFailed     = errors.New("authentication failed")
+	ErrNonFastForward = errors.New("rejected: not a fast-forward")
+	ErrTimeout        = errors.New("timed out")
+)
+
+// failureKinds maps lower-cased stderr fragments to the kind of failure they report. The first
+// kind with a matching fragment wins.
+var failureKinds = []struct {
+	kind      error
+	fragments []string
+}{
+	{ErrNotRepo, []string{"not a git repository"}},
+	{ErrNoCommits, []string{"does not have any commits yet", "bad default revision 'head'", "ambiguous argument 'head'", "invalid reference: head", "bad revision 'head'"}},
+	{ErrAuthFailed, []string{"authentication failed", "could not read username", "could not read password",
+		"permission denied (publickey", "terminal prompts disabled", "invalid username or password", "access denied"}},
+	{ErrNonFastForward, []string{"non-fast-forward", "fetch first", "not possible to fast-forward"}},
+	{ErrConflict, []string{"conflict (", "automatic merge failed", "fix conflicts", "resolve your current index first",
+		"could not apply", "needs merge", "mark them as resolved"}},
+}
+
+// environmentOverrides are variables that make git work on another repository than the one in
+// the app directory; they are dropped from the environment git runs in
+var environmentOverrides = []string{
+	"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES",
+	"GIT_COMMON_DIR", "GIT_NAMESPACE", "GIT_CEILING_DIRECTORIES", "GIT_DISCOVERY_ACROSS_FILESYSTEM",
+}
+
+// Error is a failed git command. Kind is one of the Err values above when stderr says why git
+// failed, and errors.Is matches both Kind and Err.
+type Error struct {
+	Command  string // Subcommand, e.g. push
+	Stderr   string
+	ExitCode int // -1 if git didn't exit on its own
+	Kind     error
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("git %s failed: %v", e.Command, e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += " - " + stderr
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Repo is the git repository of an app. Its methods run git in the app directory within
+// git.timeout, in the C locale, without credential prompts or editors and without GIT_*
+// variables that point git elsewhere, and fail with *Error. Commands that talk to remotes use
+// the credential of git.credentials for the remote, and credentials are masked in their output.
+type Repo struct {
+	App  string
+	Dir  string
+	Path *helpers.SafePath
+}
+
+// OpenRepo checks that git is available and returns the repository of an existing app. The app
+// doesn't have to be a repository yet; see IsRepo.
+func OpenRepo(appName string) (*Repo, error) {
+	if err := EnsureGitAvailable(); err != nil {
+		return nil, err
+	}
+
+	safePath, err := helpers.NewSafePath(appName)
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{App: appName, Dir: safePath.Dir, Path: safePath}, nil
+}
+
+// IsRepo reports whether the app directory holds a git repository
+func (r *Repo) IsRepo() bool {
+	_, err := os.Stat(filepath.Join(r.Dir, ".git"))
+	return err == nil
+}
+
+// notRepoMessage is the message of results for apps that aren't git repositories
+func (r *Repo) notRepoMessage() string {
+	return fmt.Sprintf("'%s' is not a git repository", r.App)
+}
+
+// Run runs git and returns its output
+func (r *Repo) Run(ctx context.Context, args ...string) (string, error) {
+	stdout, _, err := r.Exec(ctx, nil, args...)
+	return stdout, err
+}
+
+// RunInput runs git with stdin and returns its output
+func (r *Repo) RunInput(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	stdout, _, err := r.Exec(ctx, stdin, args...)
+	return stdout, err
+}
+
+// Exec runs git and returns its output and error output, also when it fails
+func (r *Repo) Exec(ctx context.Context, stdin io.Reader, args ...string) (string, string, error) {
+	timeout := time.Duration(config.GitSettings().Timeout)
+	env := gitEnvironment(r.Dir)
+
+	// Network commands authenticate with the credential of git.credentials for their remote
+	var token string
+	if slices.Contains(networkCommands, subcommand(args)) {
+		credential, err := r.credential(ctx, args)
+		if err != nil {
+			return "", "", &Error{Command: subcommand(args), ExitCode: -1, Kind: ErrAuthFailed, Err: err}
+		}
+		if credential != nil {
+			args = slices.Concat(credential.args, args)
+			env = append(env, credential.env...)
+			token = credential.token
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = env
+	cmd.Stdin = stdin
+	// Children git leaves behind, such as ssh, may hold the pipes open after git is killed
+	cmd.WaitDelay = time.Second
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err := cmd.Run()
+	stdout, stderr := outBuf.String(), errBuf.String()
+	if redactsCredentials(subcommand(args)) {
+		stdout, stderr = redactCredentials(stdout, token), redactCredentials(stderr, token)
+	}
+	if err == nil {
+		return stdout, stderr, nil
+	}
+
+	gitErr := &Error{Command: subcommand(args), Stderr: stderr, ExitCode: -1, Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		gitErr.ExitCode = exitErr.ExitCode()
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		gitErr.Kind = ErrTimeout
+		gitErr.Err = fmt.Errorf("timed out after %s (git.timeout)", timeout)
+	case ctx.Err() != nil:
+		gitErr.Err = ctx.Err()
+	default:
+		gitErr.Kind = failureKind(gitErr.Stderr)
+	}
+	return stdout, stderr, gitErr
+}
+
+// gitEnvironment returns the environment git runs in dir. Git doesn't look for a repository
+// above dir, so apps that aren't repositories can't reach one they are in.
+func gitEnvironment(dir string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch {
+		case name == "LANG", name == "LANGUAGE", strings.HasPrefix(name, "LC_"):
+		case name == "GIT_TERMINAL_PROMPT", name == "GIT_EDITOR", name == "GIT_SEQUENCE_EDITOR", name == "GIT_MERGE_AUTOEDIT":
+		default:
+			if !slices.Contains(environmentOverrides, name) {
+				env = append(env, kv)
+			}
+		}
+	}
+	env = append(env, "LC_ALL=C", "LANG=C", "GIT_TERMINAL_PROMPT=0", "GIT_CEILING_DIRECTORIES="+filepath.Dir(dir))
+	// Keep the messages git proposes for merges, rebases and cherry-picks instead of opening an editor
+	env = append(env, "GIT_EDITOR=true", "GIT_SEQUENCE_EDITOR=true", "GIT_MERGE_AUTOEDIT=no")
+	// Make ssh fail instead of asking for a passphrase or to trust a host
+	if os.Getenv("GIT_SSH_COMMAND") == "" && os.Getenv("GIT_SSH") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	return env
+}
+
+// failureKind returns the kind of failure stderr reports, or nil
+func failureKind(stderr string) error {
+	stderr = strings.ToLower(stderr)
+	for _, f := range failureKinds {
+		for _, fragment := range f.fragments {
+			if strings.Contains(stderr, fragment) {
+				return f.kind
+			}
+		}
+	}
+	return nil
+}
+
+// subcommand returns the git subcommand in args, skipping -c options
+func subcommand(args []string) string {
+	if i := subcommandIndex(args); i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// subcommandIndex returns the index of the git subcommand in args, or len(args) if there is none
+func subcommandIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return i
+	}
+	return len(args)
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestFailureKind(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"fatal: not a git repository (or any of the parent directories): .git", ErrNotRepo},
+		{"fatal: your current branch 'main' does not have any commits yet", ErrNoCommits},
+		{"fatal: Authentication failed for 'https://example.com/app.git/'", ErrAuthFailed},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", ErrAuthFailed},
+		{"git@github.com: Permission denied (publickey).", ErrAuthFailed},
+		{" ! [rejected]        main -> main (non-fast-forward)", ErrNonFastForward},
+		{" ! [rejected]        main -> main (fetch first)", ErrNonFastForward},
+		{"CONFLICT (content): Merge conflict in index.js\nAutomatic merge failed; fix conflicts and then commit the result.", ErrConflict},
+		{"error: pathspec 'nope' did not match any file(s) known to git", nil},
+	}
+	for _, tt := range tests {
+		if got := failureKind(tt.stderr); got != tt.want {
+			t.Errorf("failureKind(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestGitEnvironment(t *testing.T) {
+	t.Setenv("GIT_DIR", "/elsewhere/.git")
+	t.Setenv("GIT_WORK_TREE", "/elsewhere")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	t.Setenv("LC_MESSAGES", "de_DE.UTF-8")
+	t.Setenv("GIT_TERMINAL_PROMPT", "1")
+	t.Setenv("GIT_SSH_COMMAND", "")
+	t.Setenv("GIT_SSH", "")
+
+	env := gitEnvironment(filepath.Join("apps", "myapp"))
+	for _, want := range []string{"LC_ALL=C", "LANG=C", "GIT_TERMINAL_PROMPT=0", "GIT_CEILING_DIRECTORIES=apps", "GIT_SSH_COMMAND=ssh -o BatchMode=yes"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("environment is missing %s", want)
+		}
+	}
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if name == "GIT_DIR" || name == "GIT_WORK_TREE" || name == "LC_MESSAGES" || kv == "GIT_TERMINAL_PROMPT=1" {
+			t.Errorf("environment still has %s", kv)
+		}
+	}
+}
+
+func TestRepoErrors(t *testing.T) {
+	homeDir := testutil.Home(t)
+	os.MkdirAll(filepath.Join(homeDir, "apps", "plain"), 0755)
+	os.MkdirAll(filepath.Join(homeDir, "apps", "empty"), 0755)
+
+	// A repository above the app isn't found
+	if _, err := (&Repo{Dir: homeDir}).Run(t.Context(), "init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	plain, err := OpenRepo("plain")
+	if err != nil {
+		t.Fatalf("OpenRepo failed: %v", err)
+	}
+	if plain.IsRepo() {
+		t.Error("expected plain not to be a repository")
+	}
+	_, err = plain.Run(t.Context(), "status")
+	var gitErr *Error
+	if !errors.Is(err, ErrNotRepo) || !errors.As(err, &gitErr) || gitErr.Command != "status" || gitErr.ExitCode != 128 {
+		t.Errorf("expected ErrNotRepo from git status, got %v", err)
+	}
+
+	empty, _ := OpenRepo("empty")
+	if _, err := empty.Run(t.Context(), "init", "-q"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	if _, err := empty.Run(t.Context(), "log"); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("expected ErrNoCommits from git log, got %v", err)
+	}
+	if _, err := empty.Run(t.Context(), "rev-parse", "--absolute-git-dir"); err != nil {
+		t.Errorf("expected git rev-parse to succeed, got %v", err)
+	}
+
+	t.Setenv("LAYERED_GIT_TIMEOUT", "1ns")
+	if _, err := empty.Run(t.Context(), "status"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+}
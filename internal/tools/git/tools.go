@@ -0,0 +1,32 @@
+package git
+
+import "github.com/layered-flow/layered-code/internal/registry"
+
+// Tools returns the git tools in the order they are listed
+func Tools() []registry.Tool {
+	return []registry.Tool{
+		gitStatusTool(),
+		gitDiffTool(),
+		gitCommitTool(),
+		gitLogTool(),
+		gitBranchTool(),
+		gitAddTool(),
+		gitRestoreTool(),
+		gitStashTool(),
+		gitPushTool(),
+		gitPullTool(),
+		gitMergeTool(),
+		gitRebaseTool(),
+		gitCherryPickTool(),
+		gitConflictsTool(),
+		gitInitTool(),
+		gitTagTool(),
+		gitRemoteTool(),
+		gitResetTool(),
+		gitRevertTool(),
+		gitCheckoutTool(),
+		gitShowTool(),
+		gitBlameTool(),
+		gitWorktreeTool(),
+	}
+}
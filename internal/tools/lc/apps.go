@@ -0,0 +1,189 @@
+package lc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/helpers"
+)
+
+// Directories left out when an app is duplicated or archived: dependencies and build output
+// that can be recreated
+var regenerableDirs = []string{"node_modules", "dist", ".next", ".nuxt", ".svelte-kit", ".turbo"}
+
+// existingApp validates an app name and returns the app's workspace root and directory,
+// checking that the app exists and is a real directory inside the root
+func existingApp(appName string) (string, string, error) {
+	if _, err := helpers.NewSafePath(appName); err != nil {
+		return "", "", err
+	}
+
+	appDir, err := config.AppDirectory(appName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get app directory: %w", err)
+	}
+
+	// A symlink to another app is refused rather than acting on its target
+	info, err := os.Lstat(appDir)
+	if err != nil {
+		return "", "", fmt.Errorf("error accessing app: %w", err)
+	}
+	if !info.IsDir() {
+		return "", "", fmt.Errorf("app '%s' is not a directory", appName)
+	}
+
+	return filepath.Dir(appDir), appDir, nil
+}
+
+// newApp validates the name for a new app and returns its directory, which must not exist yet.
+// An unqualified name is placed in the workspace of the app it is made from.
+func newApp(fromApp, newName string) (string, string, error) {
+	if err := helpers.ValidateAppName(newName); err != nil {
+		return "", "", fmt.Errorf("invalid new app name: %w", err)
+	}
+	if workspace, _ := config.SplitAppName(newName); workspace == "" {
+		ws, _, err := config.FindApp(fromApp)
+		if err != nil {
+			return "", "", err
+		}
+		newName = config.QualifiedAppName(ws.Name, newName)
+	}
+
+	appDir, err := config.AppDirectory(newName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get app directory: %w", err)
+	}
+	if _, err := os.Lstat(appDir); err == nil {
+		return "", "", fmt.Errorf("app '%s' already exists", newName)
+	}
+	return newName, appDir, nil
+}
+
+// worktreeLinks reports how an app takes part in git worktrees: whether it is a worktree of
+// another app, from its .git file, and the worktrees of its own in .git/worktrees. Git links
+// both sides by path, so moving or removing either one breaks the links.
+func worktreeLinks(appDir string) (bool, []string) {
+	gitPath := filepath.Join(appDir, ".git")
+	info, err := os.Lstat(gitPath)
+	if err != nil {
+		return false, nil
+	}
+	if info.Mode().IsRegular() {
+		data, err := os.ReadFile(gitPath)
+		if err != nil {
+			return false, nil
+		}
+		gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		return ok && filepath.Base(filepath.Dir(gitDir)) == "worktrees", nil
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(gitPath, "worktrees"))
+	var worktrees []string
+	for _, entry := range entries {
+		worktrees = append(worktrees, entry.Name())
+	}
+	return false, worktrees
+}
+
+// checkWorktreeLinks refuses to move or remove an app that is a git worktree or has worktrees;
+// done says what would happen to it, e.g. "renamed"
+func checkWorktreeLinks(appName, appDir, done string) error {
+	isWorktree, worktrees := worktreeLinks(appDir)
+	if isWorktree {
+		return fmt.Errorf("'%s' is a git worktree and can't be %s; remove it with git_worktree instead", appName, done)
+	}
+	if len(worktrees) > 0 {
+		return fmt.Errorf("'%s' has git worktrees (%s) and can't be %s; remove them with git_worktree remove or prune first", appName, strings.Join(worktrees, ", "), done)
+	}
+	return nil
+}
+
+// skipDirs returns the app's directories, relative to the app, that can be recreated and are
+// left out of copies: the regenerable directories plus the manifest's build output
+func skipDirs(appDir string) map[string]bool {
+	skip := make(map[string]bool)
+	for _, dir := range regenerableDirs {
+		skip[dir] = true
+	}
+	if manifest, _, err := config.ReadAppManifest(appDir); err == nil && manifest.OutputDir != "" {
+		skip[filepath.ToSlash(filepath.Clean(manifest.OutputDir))] = true
+	}
+	return skip
+}
+
+// walkApp calls fn for every file, directory and symlink in the app except the skipped
+// directories and any node_modules, with paths relative to the app
+func walkApp(appDir string, skip map[string]bool, fn func(rel string, info os.FileInfo) error) error {
+	return filepath.Walk(appDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(appDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		// Nested packages have their own node_modules
+		if info.IsDir() && (skip[filepath.ToSlash(rel)] || info.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		return fn(rel, info)
+	})
+}
+
+// copyApp copies an app to a new directory, leaving out the skipped directories and keeping
+// file modes and symlinks
+func copyApp(srcDir, destDir string, skip map[string]bool) error {
+	info, err := os.Stat(srcDir)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(destDir, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return walkApp(srcDir, skip, func(rel string, info os.FileInfo) error {
+		src := filepath.Join(srcDir, rel)
+		dest := filepath.Join(destDir, rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(src)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dest)
+		case info.IsDir():
+			return os.Mkdir(dest, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return copyRegularFile(src, dest, info.Mode().Perm())
+		}
+		// Sockets, pipes and devices aren't part of an app
+		return nil
+	})
+}
+
+// copyRegularFile copies a file's contents and permissions
+func copyRegularFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,135 @@
+package lc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/helpers"
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// Types
+type LcAppConfigParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Key     string `json:"key,omitempty" pos:"true" desc:"Manifest key to show or change: description, framework, dev_command, preview_url, preview_port, build_command, output_dir, deploy_target, ignore, tools (shown only). Omit to show the whole manifest"`
+	Value   string `json:"value,omitempty" pos:"true" desc:"New value for the key; lists (ignore, tools) are comma-separated. Omit to show the current value"`
+	Unset   bool   `json:"unset,omitempty" desc:"Remove the key from the manifest"`
+}
+
+type LcAppConfigResult struct {
+	AppName  string             `json:"app_name"`
+	Path     string             `json:"path"`
+	Exists   bool               `json:"exists"`
+	Manifest config.AppManifest `json:"manifest"`
+	Key      string             `json:"key,omitempty"`
+	Value    string             `json:"value,omitempty"`
+	Changed  bool               `json:"changed"`
+}
+
+// LcAppConfig shows or changes an app's manifest (.layered/app.json)
+func LcAppConfig(params LcAppConfigParams) (LcAppConfigResult, error) {
+	if params.AppName == "" {
+		return LcAppConfigResult{}, errors.New("app_name is required")
+	}
+	if params.Key == "" && (params.Value != "" || params.Unset) {
+		return LcAppConfigResult{}, errors.New("key is required to change the manifest")
+	}
+	if params.Value != "" && params.Unset {
+		return LcAppConfigResult{}, errors.New("value and unset cannot be used together")
+	}
+
+	safePath, err := helpers.NewSafePath(params.AppName)
+	if err != nil {
+		return LcAppConfigResult{}, err
+	}
+	appDir := safePath.Dir
+
+	// The tool list restricts the tools themselves, so they mustn't be able to widen it
+	if params.Key == "tools" && (params.Value != "" || params.Unset) {
+		return LcAppConfigResult{}, fmt.Errorf("tools can't be changed by tools; edit %s by hand", config.AppManifestPath(appDir))
+	}
+
+	manifest, exists, err := config.ReadAppManifest(appDir)
+	if err != nil {
+		return LcAppConfigResult{}, err
+	}
+	result := LcAppConfigResult{
+		AppName:  params.AppName,
+		Path:     config.AppManifestPath(appDir),
+		Exists:   exists,
+		Manifest: manifest,
+		Key:      params.Key,
+	}
+	if params.Key == "" {
+		return result, nil
+	}
+
+	if params.Value == "" && !params.Unset {
+		if result.Value, err = manifest.Get(params.Key); err != nil {
+			return LcAppConfigResult{}, err
+		}
+		return result, nil
+	}
+
+	if err := manifest.Set(params.Key, params.Value); err != nil {
+		return LcAppConfigResult{}, err
+	}
+	if err := config.WriteAppManifest(appDir, manifest); err != nil {
+		return LcAppConfigResult{}, err
+	}
+
+	result.Exists = true
+	result.Manifest = manifest
+	result.Value, _ = manifest.Get(params.Key)
+	result.Changed = true
+	return result, nil
+}
+
+// Tool
+func lcAppConfigTool() registry.Tool {
+	var keys []string
+	for _, key := range config.ManifestKeys() {
+		keys = append(keys, fmt.Sprintf("%s: %s", key[0], key[1]))
+	}
+
+	return registry.New(registry.Spec[LcAppConfigParams, LcAppConfigResult]{
+		Name:        "lc_app_config",
+		Group:       "lc",
+		Description: "Show or change an app's manifest (.layered/app.json), which declares its description, framework, dev and build commands, preview URL or port, build output directory, deploy target, paths ignored for live reload and the tools allowed on it",
+		Summary:     "Show or change an app's manifest",
+		Hints:       registry.Hints{Idempotent: true},
+		Notes:       append([]string{"tools can only be changed by editing .layered/app.json by hand, not by tools", "Manifest keys:"}, keys...),
+		Examples: []string{
+			"layered-code tool lc_app_config myapp",
+			"layered-code tool lc_app_config myapp dev_command \"pnpm run dev --host\"",
+			"layered-code tool lc_app_config myapp ignore node_modules,dist,*.log",
+			"layered-code tool lc_app_config myapp deploy_target --unset",
+		},
+		Run: func(ctx context.Context, params LcAppConfigParams) (LcAppConfigResult, error) {
+			return LcAppConfig(params)
+		},
+		Render: func(w io.Writer, params LcAppConfigParams, result LcAppConfigResult) {
+			switch {
+			case result.Changed && params.Unset:
+				fmt.Fprintf(w, "Removed %s from %s\n", result.Key, result.Path)
+			case result.Changed:
+				fmt.Fprintf(w, "Set %s = %s in %s\n", result.Key, result.Value, result.Path)
+			case result.Key != "":
+				fmt.Fprintln(w, result.Value)
+			case !result.Exists:
+				fmt.Fprintf(w, "No manifest for '%s' (%s)\n", result.AppName, result.Path)
+			default:
+				fmt.Fprintf(w, "Manifest of '%s' (%s):\n", result.AppName, result.Path)
+				for _, key := range config.ManifestKeys() {
+					if value, _ := result.Manifest.Get(key[0]); value != "" {
+						fmt.Fprintf(w, "  %-14s %s\n", key[0], strings.ReplaceAll(value, ",", ", "))
+					}
+				}
+			}
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package lc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcAppConfig tests showing and changing an app manifest
+func TestLcAppConfig(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appDir := filepath.Join(homeDir, "apps", "testapp")
+	os.MkdirAll(appDir, 0755)
+
+	result, err := LcAppConfig(LcAppConfigParams{AppName: "testapp"})
+	if err != nil {
+		t.Fatalf("LcAppConfig() failed: %v", err)
+	}
+	if result.Exists {
+		t.Error("Expected no manifest yet")
+	}
+
+	result, err = LcAppConfig(LcAppConfigParams{AppName: "testapp", Key: "dev_command", Value: "pnpm run dev"})
+	if err != nil {
+		t.Fatalf("LcAppConfig() set failed: %v", err)
+	}
+	if !result.Changed || result.Manifest.DevCommand != "pnpm run dev" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(appDir, ".layered", "app.json")); err != nil {
+		t.Errorf("Expected manifest file to be written: %v", err)
+	}
+
+	result, err = LcAppConfig(LcAppConfigParams{AppName: "testapp", Key: "dev_command"})
+	if err != nil || result.Value != "pnpm run dev" {
+		t.Errorf("Expected dev_command value, got %q (err %v)", result.Value, err)
+	}
+
+	result, err = LcAppConfig(LcAppConfigParams{AppName: "testapp", Key: "dev_command", Unset: true})
+	if err != nil || result.Manifest.DevCommand != "" {
+		t.Errorf("Expected dev_command to be removed, got %+v (err %v)", result.Manifest, err)
+	}
+
+	errorTests := []struct {
+		name   string
+		params LcAppConfigParams
+		errMsg string
+	}{
+		{"missing app", LcAppConfigParams{AppName: "nope"}, "not found"},
+		{"value without key", LcAppConfigParams{AppName: "testapp", Value: "x"}, "key is required"},
+		{"unknown key", LcAppConfigParams{AppName: "testapp", Key: "bogus", Value: "x"}, "unknown key"},
+		{"invalid value", LcAppConfigParams{AppName: "testapp", Key: "preview_port", Value: "http"}, "whole number"},
+		{"set tools", LcAppConfigParams{AppName: "testapp", Key: "tools", Value: "lc,git"}, "tools can't be changed by tools"},
+		{"unset tools", LcAppConfigParams{AppName: "testapp", Key: "tools", Unset: true}, "tools can't be changed by tools"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LcAppConfig(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
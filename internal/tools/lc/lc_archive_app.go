@@ -0,0 +1,173 @@
+package lc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+)
+
+// LcArchiveAppParams represents the parameters for archiving an app
+type LcArchiveAppParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Keep    bool   `json:"keep,omitempty" desc:"Keep the app after archiving it (default: false, the app is removed)"`
+}
+
+// LcArchiveAppResult represents the result of archiving an app
+type LcArchiveAppResult struct {
+	AppName        string `json:"app_name"`
+	Archive        string `json:"archive"` // Path of the .tar.gz file
+	Size           int64  `json:"size"`
+	Removed        bool   `json:"removed"`
+	StoppedProcess bool   `json:"stopped_process"`
+}
+
+// LcArchiveApp packs an app into a tar.gz in its workspace's archive folder and removes it
+// unless asked to keep it
+func LcArchiveApp(params LcArchiveAppParams) (LcArchiveAppResult, error) {
+	if params.AppName == "" {
+		return LcArchiveAppResult{}, errors.New("app_name is required")
+	}
+
+	root, appDir, err := existingApp(params.AppName)
+	if err != nil {
+		return LcArchiveAppResult{}, err
+	}
+
+	result := LcArchiveAppResult{AppName: params.AppName}
+	if !params.Keep {
+		if err := checkWorktreeLinks(params.AppName, appDir, "removed"); err != nil {
+			return LcArchiveAppResult{}, err
+		}
+		if result.StoppedProcess, err = pnpm.StopApp(params.AppName); err != nil {
+			return LcArchiveAppResult{}, err
+		}
+	}
+
+	archiveDir := filepath.Join(root, constants.AppArchiveDir)
+	if err := os.MkdirAll(archiveDir, constants.AppsDirectoryPerms); err != nil {
+		return LcArchiveAppResult{}, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	_, app := config.SplitAppName(params.AppName)
+	result.Archive = filepath.Join(archiveDir, fmt.Sprintf("%s-%s.tar.gz", app, time.Now().Format("20060102-150405")))
+
+	if result.Size, err = writeArchive(result.Archive, appDir, skipDirs(appDir)); err != nil {
+		os.Remove(result.Archive)
+		return LcArchiveAppResult{}, fmt.Errorf("failed to archive app: %w", err)
+	}
+
+	if !params.Keep {
+		if err := os.RemoveAll(appDir); err != nil {
+			return LcArchiveAppResult{}, fmt.Errorf("app archived to %s but could not be removed: %w", result.Archive, err)
+		}
+		result.Removed = true
+	}
+	return result, nil
+}
+
+// writeArchive writes the app to a new tar.gz file with its entries under the app's directory
+// name, leaving out the skipped directories, and returns the file's size
+func writeArchive(archivePath, appDir string, skip map[string]bool) (int64, error) {
+	f, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	base := filepath.Base(appDir)
+
+	err = walkApp(appDir, skip, func(rel string, info os.FileInfo) error {
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			var err error
+			if link, err = os.Readlink(filepath.Join(appDir, rel)); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			// Sockets, pipes and devices aren't part of an app
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(base, rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(filepath.Join(appDir, rel))
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+// Tool
+func lcArchiveAppTool() registry.Tool {
+	return registry.New(registry.Spec[LcArchiveAppParams, LcArchiveAppResult]{
+		Name:        "lc_archive_app",
+		Group:       "lc",
+		Description: fmt.Sprintf("Pack an application into a .tar.gz in the %s folder of its workspace and remove it, leaving out node_modules and build output. Its PM2 process is stopped first", constants.AppArchiveDir),
+		Summary:     "Archive an app to a .tar.gz and remove it",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			fmt.Sprintf("Archives are named <app>-<date>-<time>.tar.gz, e.g. ~/LayeredApps/%s/myapp-20250101-120000.tar.gz", constants.AppArchiveDir),
+			"Use --keep to archive an app without removing it; git worktrees and apps that have them can only be archived with --keep",
+			"Restore an app with: tar -xzf <archive> -C <apps directory>",
+		},
+		Examples: []string{
+			"layered-code tool lc_archive_app myapp",
+			"layered-code tool lc_archive_app myapp --keep",
+		},
+		Run: func(ctx context.Context, params LcArchiveAppParams) (LcArchiveAppResult, error) {
+			return LcArchiveApp(params)
+		},
+		Render: func(w io.Writer, _ LcArchiveAppParams, result LcArchiveAppResult) {
+			if result.StoppedProcess {
+				fmt.Fprintf(w, "Stopped PM2 process for '%s'\n", result.AppName)
+			}
+			fmt.Fprintf(w, "Archived '%s' to %s (%s)\n", result.AppName, result.Archive, formatSize(result.Size))
+			if result.Removed {
+				fmt.Fprintf(w, "Removed '%s'\n", result.AppName)
+			}
+		},
+	})
+}
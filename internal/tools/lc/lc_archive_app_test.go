@@ -0,0 +1,90 @@
+package lc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcArchiveApp tests packing an app into the archive folder
+func TestLcArchiveApp(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appsDir := filepath.Join(homeDir, "apps")
+	appDir := filepath.Join(appsDir, "testapp")
+	os.MkdirAll(filepath.Join(appDir, "src"), 0755)
+	os.MkdirAll(filepath.Join(appDir, "node_modules", "react"), 0755)
+	os.WriteFile(filepath.Join(appDir, "src", "main.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(appDir, "node_modules", "react", "index.js"), []byte("react"), 0644)
+
+	result, err := LcArchiveApp(LcArchiveAppParams{AppName: "testapp", Keep: true})
+	if err != nil {
+		t.Fatalf("LcArchiveApp() failed: %v", err)
+	}
+	if result.Removed || filepath.Dir(result.Archive) != filepath.Join(appsDir, ".archive") || result.Size == 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(appDir); err != nil {
+		t.Error("Expected the app to be kept")
+	}
+	if got := strings.Join(archiveEntries(t, result.Archive), ","); got != "testapp/src/,testapp/src/main.js" {
+		t.Errorf("Unexpected archive entries: %s", got)
+	}
+
+	// The archive folder isn't an app
+	apps, err := LcListApps(LcListAppsParams{})
+	if err != nil || strings.Join(apps.Apps, ",") != "testapp" {
+		t.Errorf("Expected only testapp to be listed, got %v (err %v)", apps.Apps, err)
+	}
+
+	os.Remove(result.Archive)
+	result, err = LcArchiveApp(LcArchiveAppParams{AppName: "testapp"})
+	if err != nil {
+		t.Fatalf("LcArchiveApp() failed: %v", err)
+	}
+	if !result.Removed {
+		t.Error("Expected the app to be removed")
+	}
+	if _, err := os.Stat(appDir); !os.IsNotExist(err) {
+		t.Error("Expected the app directory to be gone")
+	}
+	if _, err := os.Stat(result.Archive); err != nil {
+		t.Errorf("Expected the archive to exist: %v", err)
+	}
+
+	if _, err := LcArchiveApp(LcArchiveAppParams{AppName: "testapp"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+// archiveEntries returns the sorted entry names of a tar.gz file
+func archiveEntries(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
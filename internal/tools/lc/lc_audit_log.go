@@ -0,0 +1,114 @@
+package lc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/audit"
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// Types
+type LcAuditLogParams struct {
+	AppName string `json:"app_name,omitempty" desc:"Only show calls for this app"`
+	Tool    string `json:"tool,omitempty" desc:"Only show calls to this tool (e.g. git_push)"`
+	Since   string `json:"since,omitempty" desc:"Only show calls at or after this time: RFC 3339 timestamp or a duration ago such as '24h'"`
+	Until   string `json:"until,omitempty" desc:"Only show calls at or before this time: RFC 3339 timestamp or a duration ago such as '1h'"`
+	Limit   int    `json:"limit,omitempty" short:"n" desc:"Maximum number of entries to return, newest first (default: 50)"`
+}
+
+type LcAuditLogResult struct {
+	Entries []audit.Entry `json:"entries"`
+	Total   int           `json:"total"`
+	Path    string        `json:"path"`
+}
+
+// LcAuditLog queries the MCP server's audit log of tool calls
+func LcAuditLog(params LcAuditLogParams) (LcAuditLogResult, error) {
+	log, err := audit.Configured()
+	if err != nil {
+		return LcAuditLogResult{}, err
+	}
+
+	filter := audit.Filter{AppName: params.AppName, Tool: params.Tool}
+	now := time.Now()
+	if params.Since != "" {
+		if filter.Since, err = parseAuditTime(params.Since, now); err != nil {
+			return LcAuditLogResult{}, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if params.Until != "" {
+		if filter.Until, err = parseAuditTime(params.Until, now); err != nil {
+			return LcAuditLogResult{}, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	entries, err := log.Read(filter)
+	if err != nil {
+		return LcAuditLogResult{}, err
+	}
+
+	result := LcAuditLogResult{Entries: entries, Total: len(entries), Path: log.Path}
+	if len(entries) > limit {
+		result.Entries = entries[:limit]
+	}
+	return result, nil
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp or a duration before now
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 timestamp nor a duration", value)
+	}
+	return now.Add(-d), nil
+}
+
+// Tool
+func lcAuditLogTool() registry.Tool {
+	return registry.New(registry.Spec[LcAuditLogParams, LcAuditLogResult]{
+		Name:        "lc_audit_log",
+		Group:       "lc",
+		Description: "Query the audit log of tool calls made through the MCP server, filtered by app, tool and time range",
+		Summary:     "Show the audit log of MCP tool calls",
+		Hints:       registry.ReadOnly,
+		Examples: []string{
+			"layered-code tool lc_audit_log --app-name myapp --since 24h",
+			"layered-code tool lc_audit_log --tool git_push -n 10",
+		},
+		Run: func(ctx context.Context, params LcAuditLogParams) (LcAuditLogResult, error) {
+			return LcAuditLog(params)
+		},
+		Render: func(w io.Writer, _ LcAuditLogParams, result LcAuditLogResult) {
+			if len(result.Entries) == 0 {
+				fmt.Fprintf(w, "No matching entries in: %s\n", result.Path)
+				return
+			}
+
+			for _, entry := range result.Entries {
+				status := "ok"
+				if entry.Error != "" {
+					status = "error: " + entry.Error
+				}
+				target := entry.Tool
+				if entry.AppName != "" {
+					target += " [" + entry.AppName + "]"
+				}
+				fmt.Fprintf(w, "%s  %-40s %6dms  %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), target, entry.DurationMs, status)
+			}
+			if result.Total > len(result.Entries) {
+				fmt.Fprintf(w, "\nShowing %d of %d entries\n", len(result.Entries), result.Total)
+			}
+		},
+	})
+}
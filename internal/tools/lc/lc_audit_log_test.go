@@ -0,0 +1,71 @@
+package lc
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/audit"
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcAuditLog tests querying the audit log with filters and limits
+func TestLcAuditLog(t *testing.T) {
+	testutil.Home(t)
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("LAYERED_AUDIT_LOG", path)
+
+	log := audit.New(path)
+	now := time.Now().UTC()
+	log.Write(audit.Entry{Time: now.Add(-48 * time.Hour), Tool: "git_push", AppName: "app1"})
+	log.Write(audit.Entry{Time: now.Add(-time.Hour), Tool: "git_push", AppName: "app1"})
+	log.Write(audit.Entry{Time: now.Add(-time.Minute), Tool: "lc_write_file", AppName: "app2"})
+
+	t.Run("all entries", func(t *testing.T) {
+		result, err := LcAuditLog(LcAuditLogParams{})
+		if err != nil {
+			t.Fatalf("LcAuditLog() failed: %v", err)
+		}
+		if result.Total != 3 || len(result.Entries) != 3 || result.Path != path {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if result.Entries[0].Tool != "lc_write_file" {
+			t.Errorf("expected newest entry first, got %s", result.Entries[0].Tool)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		result, err := LcAuditLog(LcAuditLogParams{AppName: "app1", Tool: "git_push", Since: "24h"})
+		if err != nil {
+			t.Fatalf("LcAuditLog() failed: %v", err)
+		}
+		if result.Total != 1 {
+			t.Errorf("expected 1 entry, got %d", result.Total)
+		}
+
+		result, err = LcAuditLog(LcAuditLogParams{Until: now.Add(-2 * time.Hour).Format(time.RFC3339)})
+		if err != nil {
+			t.Fatalf("LcAuditLog() failed: %v", err)
+		}
+		if result.Total != 1 {
+			t.Errorf("expected 1 entry before the until time, got %d", result.Total)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		result, err := LcAuditLog(LcAuditLogParams{Limit: 2})
+		if err != nil {
+			t.Fatalf("LcAuditLog() failed: %v", err)
+		}
+		if result.Total != 3 || len(result.Entries) != 2 {
+			t.Errorf("expected 2 of 3 entries, got %d of %d", len(result.Entries), result.Total)
+		}
+	})
+
+	t.Run("invalid time", func(t *testing.T) {
+		if _, err := LcAuditLog(LcAuditLogParams{Since: "yesterday"}); err == nil {
+			t.Error("expected error for invalid since")
+		}
+	})
+}
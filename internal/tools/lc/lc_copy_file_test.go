@@ -2,30 +2,22 @@ package lc
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcCopyFile tests the core LcCopyFile functionality
 func TestLcCopyFile(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful copy", func(t *testing.T) {
 		// Create test file
 		sourceFile := filepath.Join(appDir, "source.txt")
@@ -223,26 +215,16 @@ func TestLcCopyFile(t *testing.T) {
 
 // TestLcCopyFileCli tests the CLI interface
 func TestLcCopyFileCli(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	oldArgs := os.Args
 	defer func() { os.Args = oldArgs }()
 
 	t.Run("help flag", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_copy_file", "--help"}
-		err := LcCopyFileCli()
+		err := lcCopyFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("Help flag should not return error: %v", err)
 		}
@@ -250,7 +232,7 @@ func TestLcCopyFileCli(t *testing.T) {
 
 	t.Run("missing arguments", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_copy_file", "--app-name", "testapp"}
-		err := LcCopyFileCli()
+		err := lcCopyFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err == nil {
 			t.Error("Expected error for missing arguments")
 		}
@@ -266,7 +248,7 @@ func TestLcCopyFileCli(t *testing.T) {
 			"--source", "cli-source.txt",
 			"--dest", "cli-dest.txt"}
 
-		err := LcCopyFileCli()
+		err := lcCopyFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("Copy failed: %v", err)
 		}
@@ -281,6 +263,8 @@ func TestLcCopyFileCli(t *testing.T) {
 
 // TestLcCopyFileMcp tests the MCP interface
 func TestLcCopyFileMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "lc_copy_file"
@@ -290,7 +274,7 @@ func TestLcCopyFileMcp(t *testing.T) {
 		"dest_path":   "copy.txt",
 	}
 
-	_, err := LcCopyFileMcp(ctx, request)
+	_, err := lcCopyFileTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
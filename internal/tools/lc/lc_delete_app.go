@@ -0,0 +1,87 @@
+package lc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+)
+
+// LcDeleteAppParams represents the parameters for deleting an app
+type LcDeleteAppParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+}
+
+// LcDeleteAppResult represents the result of deleting an app
+type LcDeleteAppResult struct {
+	AppName        string `json:"app_name"`
+	Path           string `json:"path"`
+	Deleted        bool   `json:"deleted"`
+	StoppedProcess bool   `json:"stopped_process"`
+}
+
+// LcDeleteApp stops an app's PM2 process and deletes the app directory with everything in it
+func LcDeleteApp(params LcDeleteAppParams) (LcDeleteAppResult, error) {
+	if params.AppName == "" {
+		return LcDeleteAppResult{}, errors.New("app_name is required")
+	}
+
+	_, appDir, err := existingApp(params.AppName)
+	if err != nil {
+		return LcDeleteAppResult{}, err
+	}
+	if err := checkWorktreeLinks(params.AppName, appDir, "deleted"); err != nil {
+		return LcDeleteAppResult{}, err
+	}
+
+	stopped, err := pnpm.StopApp(params.AppName)
+	if err != nil {
+		return LcDeleteAppResult{}, err
+	}
+	if err := os.RemoveAll(appDir); err != nil {
+		return LcDeleteAppResult{}, fmt.Errorf("failed to delete app: %w", err)
+	}
+
+	return LcDeleteAppResult{
+		AppName:        params.AppName,
+		Path:           appDir,
+		Deleted:        true,
+		StoppedProcess: stopped,
+	}, nil
+}
+
+// Tool
+func lcDeleteAppTool() registry.Tool {
+	return registry.New(registry.Spec[LcDeleteAppParams, LcDeleteAppResult]{
+		Name:        "lc_delete_app",
+		Group:       "lc",
+		Description: "Delete an application directory and everything in it. Its PM2 process is stopped first. Consider lc_archive_app to keep a copy",
+		Summary:     "Delete an app",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"This action cannot be undone; lc_archive_app removes the app but keeps a .tar.gz",
+			"Without --force, you will be prompted to confirm",
+			"Git worktrees and apps that have them are removed with git_worktree instead",
+		},
+		Examples: []string{
+			"# Delete an app with confirmation\nlayered-code tool lc_delete_app myapp",
+			"# Delete an app without confirmation\nlayered-code tool lc_delete_app myapp --force",
+		},
+		Run: func(ctx context.Context, params LcDeleteAppParams) (LcDeleteAppResult, error) {
+			return LcDeleteApp(params)
+		},
+		Render: func(w io.Writer, _ LcDeleteAppParams, result LcDeleteAppResult) {
+			if result.StoppedProcess {
+				fmt.Fprintf(w, "Stopped PM2 process for '%s'\n", result.AppName)
+			}
+			fmt.Fprintf(w, "Deleted: %s (%s)\n", result.AppName, result.Path)
+		},
+		Confirm: func(params LcDeleteAppParams) string {
+			return fmt.Sprintf("Are you sure you want to delete the app '%s' and all its files? This action cannot be undone.", params.AppName)
+		},
+	})
+}
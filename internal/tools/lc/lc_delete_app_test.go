@@ -0,0 +1,60 @@
+package lc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcDeleteApp tests deleting an app and the checks that keep it inside the apps directory
+func TestLcDeleteApp(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appsDir := filepath.Join(homeDir, "apps")
+	os.MkdirAll(filepath.Join(appsDir, "testapp", "src"), 0755)
+	os.WriteFile(filepath.Join(appsDir, "testapp", "src", "main.js"), []byte("console.log(1)"), 0644)
+
+	// A symlinked app must not delete what it points to
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "keep.txt"), []byte("keep"), 0644)
+	os.Symlink(outside, filepath.Join(appsDir, "linked"))
+	os.MkdirAll(filepath.Join(appsDir, "other"), 0755)
+	os.Symlink("other", filepath.Join(appsDir, "alias"))
+
+	result, err := LcDeleteApp(LcDeleteAppParams{AppName: "testapp"})
+	if err != nil {
+		t.Fatalf("LcDeleteApp() failed: %v", err)
+	}
+	if !result.Deleted || result.StoppedProcess {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "testapp")); !os.IsNotExist(err) {
+		t.Error("Expected the app directory to be gone")
+	}
+
+	errorTests := []struct {
+		name    string
+		appName string
+		errMsg  string
+	}{
+		{"empty", "", "app_name is required"},
+		{"missing", "testapp", "not found"},
+		{"traversal", "..", "cannot contain"},
+		{"symlink", "linked", "outside app directory"},
+		{"symlink to an app", "alias", "is not a directory"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LcDeleteApp(LcDeleteAppParams{AppName: tt.appName})
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(outside, "keep.txt")); err != nil {
+		t.Error("Expected the symlink target to be untouched")
+	}
+}
+
@@ -2,29 +2,21 @@ package lc
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcDeleteFile tests the core LcDeleteFile functionality
 func TestLcDeleteFile(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful delete", func(t *testing.T) {
 		// Create test file
 		testFile := filepath.Join(appDir, "delete-me.txt")
@@ -127,26 +119,16 @@ func TestLcDeleteFile(t *testing.T) {
 
 // TestLcDeleteFileCli tests the CLI interface
 func TestLcDeleteFileCli(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	oldArgs := os.Args
 	defer func() { os.Args = oldArgs }()
 
 	t.Run("help flag", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_delete_file", "--help"}
-		err := LcDeleteFileCli()
+		err := lcDeleteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("Help flag should not return error: %v", err)
 		}
@@ -154,7 +136,7 @@ func TestLcDeleteFileCli(t *testing.T) {
 
 	t.Run("missing arguments", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_delete_file", "--app-name", "testapp"}
-		err := LcDeleteFileCli()
+		err := lcDeleteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err == nil {
 			t.Error("Expected error for missing arguments")
 		}
@@ -171,7 +153,7 @@ func TestLcDeleteFileCli(t *testing.T) {
 			"--file-path", "force-delete.txt",
 			"--force"}
 		
-		err := LcDeleteFileCli()
+		err := lcDeleteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("Delete with force failed: %v", err)
 		}
@@ -185,6 +167,8 @@ func TestLcDeleteFileCli(t *testing.T) {
 
 // TestLcDeleteFileMcp tests the MCP interface
 func TestLcDeleteFileMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "lc_delete_file"
@@ -193,7 +177,7 @@ func TestLcDeleteFileMcp(t *testing.T) {
 		"file_path": "file.txt",
 	}
 
-	_, err := LcDeleteFileMcp(ctx, request)
+	_, err := lcDeleteFileTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
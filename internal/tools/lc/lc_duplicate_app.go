@@ -0,0 +1,112 @@
+package lc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// LcDuplicateAppParams represents the parameters for duplicating an app
+type LcDuplicateAppParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	NewName string `json:"new_name" required:"true" pos:"true" alias:"to" app:"true" desc:"Name of the copy; qualify it as workspace/app to put it in another workspace"`
+}
+
+// LcDuplicateAppResult represents the result of duplicating an app
+type LcDuplicateAppResult struct {
+	AppName string   `json:"app_name"`
+	NewName string   `json:"new_name"`
+	Path    string   `json:"path"`
+	Skipped []string `json:"skipped"` // Paths left out of the copy
+}
+
+// LcDuplicateApp copies an app to a new directory without its dependencies and build output
+func LcDuplicateApp(params LcDuplicateAppParams) (LcDuplicateAppResult, error) {
+	if params.AppName == "" {
+		return LcDuplicateAppResult{}, errors.New("app_name is required")
+	}
+	if params.NewName == "" {
+		return LcDuplicateAppResult{}, errors.New("new_name is required")
+	}
+
+	_, appDir, err := existingApp(params.AppName)
+	if err != nil {
+		return LcDuplicateAppResult{}, err
+	}
+	newName, newDir, err := newApp(params.AppName, params.NewName)
+	if err != nil {
+		return LcDuplicateAppResult{}, err
+	}
+
+	skip := skipDirs(appDir)
+	if err := copyApp(appDir, newDir, skip); err != nil {
+		// Don't leave a partial copy behind
+		os.RemoveAll(newDir)
+		return LcDuplicateAppResult{}, fmt.Errorf("failed to duplicate app: %w", err)
+	}
+
+	var skipped []string
+	for dir := range skip {
+		if _, err := os.Stat(filepath.Join(appDir, filepath.FromSlash(dir))); err == nil {
+			skipped = append(skipped, dir)
+		}
+	}
+
+	// The copy can't share the app's worktree links: a worktree's copy isn't a repository and
+	// the copy of an app with worktrees has none of its own
+	unlink := ""
+	if isWorktree, worktrees := worktreeLinks(appDir); isWorktree {
+		unlink = ".git"
+	} else if len(worktrees) > 0 {
+		unlink = ".git/worktrees"
+	}
+	if unlink != "" {
+		if err := os.RemoveAll(filepath.Join(newDir, filepath.FromSlash(unlink))); err != nil {
+			os.RemoveAll(newDir)
+			return LcDuplicateAppResult{}, fmt.Errorf("failed to duplicate app: %w", err)
+		}
+		skipped = append(skipped, unlink)
+	}
+	sort.Strings(skipped)
+
+	return LcDuplicateAppResult{
+		AppName: params.AppName,
+		NewName: newName,
+		Path:    newDir,
+		Skipped: skipped,
+	}, nil
+}
+
+// Tool
+func lcDuplicateAppTool() registry.Tool {
+	return registry.New(registry.Spec[LcDuplicateAppParams, LcDuplicateAppResult]{
+		Name:        "lc_duplicate_app",
+		Group:       "lc",
+		Description: "Copy an application to a new directory, leaving out node_modules and build output (dist, .next, the manifest's output_dir, ...). Install dependencies in the copy before running it",
+		Summary:     "Copy an app under a new name",
+		Notes: []string{
+			"The new name must not be taken; an unqualified name goes in the app's workspace",
+			"Symlinks and file permissions are kept; the app's PM2 process keeps running",
+			"The copy of a git worktree is left without its .git link, and the copy of an app with worktrees without them",
+		},
+		Examples: []string{
+			"layered-code tool lc_duplicate_app myapp myapp-v2",
+		},
+		Run: func(ctx context.Context, params LcDuplicateAppParams) (LcDuplicateAppResult, error) {
+			return LcDuplicateApp(params)
+		},
+		Render: func(w io.Writer, _ LcDuplicateAppParams, result LcDuplicateAppResult) {
+			fmt.Fprintf(w, "Duplicated: %s -> %s (%s)\n", result.AppName, result.NewName, result.Path)
+			if len(result.Skipped) > 0 {
+				fmt.Fprintf(w, "Left out: %s\n", strings.Join(result.Skipped, ", "))
+			}
+		},
+	})
+}
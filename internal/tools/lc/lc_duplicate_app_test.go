@@ -0,0 +1,59 @@
+package lc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcDuplicateApp tests copying an app without its dependencies and build output
+func TestLcDuplicateApp(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appDir := filepath.Join(homeDir, "apps", "testapp")
+	for _, dir := range []string{"src", "node_modules/react", "dist", "build", "packages/ui/node_modules/x"} {
+		os.MkdirAll(filepath.Join(appDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(appDir, "src", "main.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(appDir, "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(appDir, "build", "out.js"), []byte("built"), 0644)
+	os.WriteFile(filepath.Join(appDir, "packages", "ui", "index.js"), []byte("ui"), 0644)
+	os.Symlink("src/main.js", filepath.Join(appDir, "entry.js"))
+	os.MkdirAll(filepath.Join(appDir, ".layered"), 0755)
+	os.WriteFile(filepath.Join(appDir, ".layered", "app.json"), []byte(`{"output_dir": "build"}`), 0644)
+
+	result, err := LcDuplicateApp(LcDuplicateAppParams{AppName: "testapp", NewName: "copy"})
+	if err != nil {
+		t.Fatalf("LcDuplicateApp() failed: %v", err)
+	}
+	copyDir := filepath.Join(homeDir, "apps", "copy")
+	if result.Path != copyDir || strings.Join(result.Skipped, ",") != "build,dist,node_modules" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	for _, path := range []string{"src/main.js", "packages/ui/index.js", ".layered/app.json"} {
+		if _, err := os.Stat(filepath.Join(copyDir, path)); err != nil {
+			t.Errorf("Expected %s to be copied: %v", path, err)
+		}
+	}
+	for _, path := range []string{"node_modules", "dist", "build", "packages/ui/node_modules"} {
+		if _, err := os.Stat(filepath.Join(copyDir, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be left out", path)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(copyDir, "run.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("Expected run.sh to keep its permissions, got %v (err %v)", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(copyDir, "entry.js")); err != nil || target != "src/main.js" {
+		t.Errorf("Expected entry.js to stay a symlink, got %q (err %v)", target, err)
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "node_modules", "react")); err != nil {
+		t.Error("Expected the original app to be untouched")
+	}
+
+	if _, err := LcDuplicateApp(LcDuplicateAppParams{AppName: "testapp", NewName: "copy"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected already exists error, got %v", err)
+	}
+}
@@ -2,27 +2,26 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/helpers"
 	"github.com/layered-flow/layered-code/internal/notifications"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
 )
 
 // LcEditFileParams represents the parameters for editing a file
 type LcEditFileParams struct {
-	AppName      string `json:"app_name"`
-	FilePath     string `json:"file_path"`
-	OldString    string `json:"old_string"`
-	NewString    string `json:"new_string"`
-	Occurrences  int    `json:"occurrences"`  // Number of occurrences to replace (0 = all)
+	AppName     string `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	FilePath    string `json:"file_path" required:"true" desc:"Path to the file relative to the app directory"`
+	OldString   string `json:"old_string" required:"true" desc:"Text to find and replace"`
+	NewString   string `json:"new_string" required:"true" empty:"true" desc:"Text to replace with (can be empty for deletion)"`
+	Occurrences int    `json:"occurrences" desc:"Number of occurrences to replace (0 = all, default: 0)"`
 }
 
 // LcEditFileResult represents the result of editing a file
@@ -48,31 +47,25 @@ func LcEditFile(params LcEditFileParams) (LcEditFileResult, error) {
 		return LcEditFileResult{}, errors.New("occurrences must be non-negative")
 	}
 
-	// Get and validate the apps directory
-	appsDir, err := config.EnsureAppsDirectory()
-	if err != nil {
-		return LcEditFileResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
+	// Redacted output from lc_read_file must not replace the secrets it hid
+	if secrets.HasRedactions(params.NewString) {
+		return LcEditFileResult{}, ErrRedactedContent
 	}
 
-	// Construct and validate the full file path
-	fullPath := filepath.Join(appsDir, params.AppName, params.FilePath)
-	cleanPath := filepath.Clean(fullPath)
-
-	// Ensure the file is within the app directory
-	appDir := filepath.Join(appsDir, params.AppName)
-	if !config.IsWithinDirectory(cleanPath, appDir) {
-		return LcEditFileResult{}, fmt.Errorf("file path attempts to access file outside app directory")
+	safePath, err := helpers.NewSafePath(params.AppName)
+	if err != nil {
+		return LcEditFileResult{}, err
 	}
 
 	// Read the file
-	content, err := os.ReadFile(cleanPath)
+	content, err := safePath.ReadFile(params.FilePath)
 	if err != nil {
 		return LcEditFileResult{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Check file size
-	if len(content) > int(constants.MaxFileSize) {
-		return LcEditFileResult{}, fmt.Errorf("file exceeds maximum size of %s", constants.MaxFileSizeInWords)
+	if maxSize := config.MaxFileSize(); int64(len(content)) > int64(maxSize) {
+		return LcEditFileResult{}, fmt.Errorf("file exceeds maximum size of %s", maxSize)
 	}
 
 	// Convert to string for editing
@@ -114,16 +107,15 @@ func LcEditFile(params LcEditFileParams) (LcEditFileResult, error) {
 	}
 
 	// Write the modified content back
-	if err := os.WriteFile(cleanPath, []byte(fileContent), 0644); err != nil {
+	if err := safePath.WriteFile(params.FilePath, []byte(fileContent), 0644); err != nil {
 		return LcEditFileResult{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// Send WebSocket notification
-	notificationPath := filepath.Join(params.AppName, params.FilePath)
-	notifications.NotifyFileChange(notificationPath, "edit")
+	notifications.NotifyAppFileChange(params.AppName, params.FilePath, "edit")
 
 	// Get file info for the result
-	info, err := os.Stat(cleanPath)
+	info, err := safePath.Stat(params.FilePath)
 	if err != nil {
 		return LcEditFileResult{}, fmt.Errorf("failed to stat edited file: %w", err)
 	}
@@ -137,139 +129,30 @@ func LcEditFile(params LcEditFileParams) (LcEditFileResult, error) {
 	}, nil
 }
 
-// CLI
-func LcEditFileCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printEditFileHelp()
-			return nil
-		}
-	}
-
-	var params LcEditFileParams
-
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				params.AppName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
-			}
-		case "--file-path":
-			if i+1 < len(args) {
-				params.FilePath = args[i+1]
-				i++
-			} else {
-				return errors.New("--file-path requires a value")
-			}
-		case "--old-string":
-			if i+1 < len(args) {
-				params.OldString = args[i+1]
-				i++
-			} else {
-				return errors.New("--old-string requires a value")
-			}
-		case "--new-string":
-			if i+1 < len(args) {
-				params.NewString = args[i+1]
-				i++
-			} else {
-				return errors.New("--new-string requires a value")
-			}
-		case "--occurrences":
-			if i+1 < len(args) {
-				var err error
-				fmt.Sscanf(args[i+1], "%d", &params.Occurrences)
-				if err != nil {
-					return fmt.Errorf("--occurrences must be a number: %v", err)
-				}
-				i++
-			} else {
-				return errors.New("--occurrences requires a value")
-			}
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_edit_file --help' for usage", args[i])
-			}
-		}
-	}
-
-	if params.AppName == "" {
-		return errors.New("--app-name is required")
-	}
-	if params.FilePath == "" {
-		return errors.New("--file-path is required")
-	}
-	if params.OldString == "" {
-		return errors.New("--old-string is required")
-	}
-	// Note: new-string can be empty (for deletion)
-
-	result, err := LcEditFile(params)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("Edited file: %s/%s\n", result.AppName, result.FilePath)
-	fmt.Printf("Replacements made: %d\n", result.Replacements)
-	return nil
+// Tool
+func lcEditFileTool() registry.Tool {
+	return registry.New(registry.Spec[LcEditFileParams, LcEditFileResult]{
+		Name:        "lc_edit_file",
+		Group:       "lc",
+		Description: "Edit a file by performing find-and-replace operations",
+		Summary:     "Edit a file using find-and-replace",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"The file must be a text file",
+			"Maximum file size is " + config.MaxFileSize().String(),
+			"Use --new-string \"\" to delete text",
+		},
+		Examples: []string{
+			"# Replace all occurrences\nlayered-code tool lc_edit_file --app-name myapp --file-path config.json \\\n  --old-string 'localhost' --new-string '127.0.0.1'",
+			"# Replace first 2 occurrences\nlayered-code tool lc_edit_file --app-name myapp --file-path src/main.go \\\n  --old-string 'fmt.Println' --new-string 'log.Println' --occurrences 2",
+			"# Delete text\nlayered-code tool lc_edit_file --app-name myapp --file-path README.md \\\n  --old-string 'TODO: ' --new-string ''",
+		},
+		Run: func(ctx context.Context, params LcEditFileParams) (LcEditFileResult, error) {
+			return LcEditFile(params)
+		},
+		Render: func(w io.Writer, _ LcEditFileParams, result LcEditFileResult) {
+			fmt.Fprintf(w, "Edited file: %s/%s\n", result.AppName, result.FilePath)
+			fmt.Fprintf(w, "Replacements made: %d\n", result.Replacements)
+		},
+	})
 }
-
-func printEditFileHelp() {
-	fmt.Println("Usage: layered-code tool lc_edit_file [options]")
-	fmt.Println()
-	fmt.Println("Edit a file by performing find-and-replace operations")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>      Name of the app directory")
-	fmt.Println("  --file-path <path>     Path to the file relative to the app directory")
-	fmt.Println("  --old-string <text>    Text to find and replace")
-	fmt.Println("  --new-string <text>    Text to replace with (can be empty for deletion)")
-	fmt.Println()
-	fmt.Println("Optional:")
-	fmt.Println("  --occurrences <num>    Number of occurrences to replace (0 = all, default: 0)")
-	fmt.Println()
-	fmt.Println("Notes:")
-	fmt.Println("  - The file must be a text file")
-	fmt.Printf("  - Maximum file size is %s\n", constants.MaxFileSizeInWords)
-	fmt.Println("  - Use --new-string \"\" to delete text")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Replace all occurrences")
-	fmt.Println("  layered-code tool lc_edit_file --app-name myapp --file-path config.json \\")
-	fmt.Println("    --old-string 'localhost' --new-string '127.0.0.1'")
-	fmt.Println()
-	fmt.Println("  # Replace first 2 occurrences")
-	fmt.Println("  layered-code tool lc_edit_file --app-name myapp --file-path src/main.go \\")
-	fmt.Println("    --old-string 'fmt.Println' --new-string 'log.Println' --occurrences 2")
-	fmt.Println()
-	fmt.Println("  # Delete text")
-	fmt.Println("  layered-code tool lc_edit_file --app-name myapp --file-path README.md \\")
-	fmt.Println("    --old-string 'TODO: ' --new-string ''")
-}
-
-// MCP
-func LcEditFileMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var params LcEditFileParams
-
-	if err := request.BindArguments(&params); err != nil {
-		return nil, err
-	}
-
-	result, err := LcEditFile(params)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
-
-	return mcp.NewToolResultText(string(content)), nil
-}
\ No newline at end of file
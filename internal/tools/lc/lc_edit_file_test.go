@@ -2,31 +2,23 @@ package lc
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcEditFile tests the core LcEditFile functionality
 func TestLcEditFile(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful replace all", func(t *testing.T) {
 		testFile := filepath.Join(appDir, "test1.txt")
 		os.WriteFile(testFile, []byte("hello world\nhello universe\nhello cosmos"), 0644)
@@ -228,21 +220,11 @@ func TestLcEditFile(t *testing.T) {
 
 // TestLcEditFileCli tests the CLI interface
 func TestLcEditFileCli(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 	os.WriteFile(filepath.Join(appDir, "test.txt"), []byte("hello world"), 0644)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	// Save original os.Args and restore after test
 	origArgs := os.Args
 	defer func() { os.Args = origArgs }()
@@ -264,7 +246,7 @@ func TestLcEditFileCli(t *testing.T) {
 		}
 		for _, tt := range tests {
 			os.Args = tt.args
-			err := LcEditFileCli()
+			err := lcEditFileTool().RunCLI(os.Args[3:], io.Discard)
 			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 				t.Errorf("EditFileCli() with args %v expected error containing %q, got: %v",
 					tt.args[3:], tt.wantErr, err)
@@ -275,7 +257,7 @@ func TestLcEditFileCli(t *testing.T) {
 	t.Run("help flag", func(t *testing.T) {
 		for _, helpFlag := range []string{"--help", "-h"} {
 			os.Args = []string{"cmd", "tool", "edit_file", helpFlag}
-			err := LcEditFileCli()
+			err := lcEditFileTool().RunCLI(os.Args[3:], io.Discard)
 			if err != nil {
 				t.Errorf("EditFileCli() with %s should not error, got: %v", helpFlag, err)
 			}
@@ -285,7 +267,7 @@ func TestLcEditFileCli(t *testing.T) {
 	t.Run("successful execution", func(t *testing.T) {
 		os.Args = []string{"cmd", "tool", "edit_file", "--app-name", "testapp",
 			"--file-path", "test.txt", "--old-string", "hello", "--new-string", "goodbye"}
-		err := LcEditFileCli()
+		err := lcEditFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("EditFileCli() failed: %v", err)
 		}
@@ -303,7 +285,7 @@ func TestLcEditFileCli(t *testing.T) {
 
 		os.Args = []string{"cmd", "tool", "edit_file", "--app-name", "testapp",
 			"--file-path", "multi.txt", "--old-string", "a", "--new-string", "x", "--occurrences", "2"}
-		err := LcEditFileCli()
+		err := lcEditFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("EditFileCli() failed: %v", err)
 		}
@@ -321,7 +303,7 @@ func TestLcEditFileCli(t *testing.T) {
 
 		os.Args = []string{"cmd", "tool", "edit_file", "--app-name", "testapp",
 			"--file-path", "delete.txt", "--old-string", "prefix-", "--new-string", ""}
-		err := LcEditFileCli()
+		err := lcEditFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("EditFileCli() failed: %v", err)
 		}
@@ -336,6 +318,8 @@ func TestLcEditFileCli(t *testing.T) {
 
 // TestLcEditFileMcp tests the MCP interface wrapper
 func TestLcEditFileMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "edit_file"
@@ -346,7 +330,7 @@ func TestLcEditFileMcp(t *testing.T) {
 		"new_string": "new",
 	}
 
-	_, err := LcEditFileMcp(ctx, request)
+	_, err := lcEditFileTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
@@ -2,40 +2,101 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/layered-flow/layered-code/internal/config"
-
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
+type LcListAppsParams struct {
+	Detailed bool `json:"detailed,omitempty" short:"l" desc:"Also report each app's framework, package manager, git branch and dirty state, whether PM2 is running it, last modified time and disk size"`
+}
+
 type LcListAppsResult struct {
-	Apps      []string `json:"apps"`
+	Apps       []string          `json:"apps"`      // Apps outside the default workspace are qualified as workspace/app
+	Directory  string            `json:"directory"` // Apps directory of the default workspace
+	Workspaces []LcWorkspaceApps `json:"workspaces"`
+
+	// Manifests of the apps that have one (.layered/app.json), by app name as listed in Apps
+	Manifests map[string]config.AppManifest `json:"manifests,omitempty"`
+
+	// Details of every app in detailed mode, by app name as listed in Apps
+	Details map[string]LcAppDetails `json:"details,omitempty"`
+}
+
+// LcWorkspaceApps lists the apps of one workspace
+type LcWorkspaceApps struct {
+	Name      string   `json:"name"`
 	Directory string   `json:"directory"`
+	Apps      []string `json:"apps"`
 }
 
-// LcListApps lists all applications (folders)
-func LcListApps() (LcListAppsResult, error) {
-	// Ensure the apps directory exists and get its path
-	appsDir, err := config.EnsureAppsDirectory()
-	if err != nil {
+// LcListApps lists all applications (folders) in every workspace, with their details if requested
+func LcListApps(params LcListAppsParams) (LcListAppsResult, error) {
+	// Ensure the apps directory exists
+	if _, err := config.EnsureAppsDirectory(); err != nil {
 		return LcListAppsResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
 	}
 
+	workspaces, err := config.Workspaces()
+	if err != nil {
+		return LcListAppsResult{}, fmt.Errorf("failed to get workspaces: %w", err)
+	}
+
+	result := LcListAppsResult{Directory: workspaces[0].Root}
+	paths := make(map[string]string)
+	for _, ws := range workspaces {
+		apps, err := listApps(ws.Root)
+		if err != nil {
+			return LcListAppsResult{}, fmt.Errorf("failed to read apps directory of workspace %s: %w", ws.Name, err)
+		}
+
+		result.Workspaces = append(result.Workspaces, LcWorkspaceApps{Name: ws.Name, Directory: ws.Root, Apps: apps})
+		for _, app := range apps {
+			name := config.QualifiedAppName(ws.Name, app)
+			result.Apps = append(result.Apps, name)
+			paths[name] = filepath.Join(ws.Root, app)
+
+			// A broken manifest shouldn't hide the app; tools using it report the error
+			manifest, ok, err := config.ReadAppManifest(filepath.Join(ws.Root, app))
+			if err != nil || !ok {
+				continue
+			}
+			if result.Manifests == nil {
+				result.Manifests = make(map[string]config.AppManifest)
+			}
+			result.Manifests[name] = manifest
+		}
+	}
+
+	if params.Detailed && len(paths) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.AppDetailsTimeout)
+		defer cancel()
+		result.Details = appDetails(ctx, paths)
+	}
+
+	return result, nil
+}
+
+// listApps returns the app directories in an apps directory, sorted alphabetically
+func listApps(dir string) ([]string, error) {
 	// Read directory entries
-	entries, err := os.ReadDir(appsDir)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return LcListAppsResult{}, fmt.Errorf("failed to read apps directory: %w", err)
+		return nil, err
 	}
 
-	// Filter directories only
+	// Filter directories only, leaving out hidden ones such as the archive folder
 	var apps []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
 			apps = append(apps, entry.Name())
 		}
 	}
@@ -43,47 +104,96 @@ func LcListApps() (LcListAppsResult, error) {
 	// Sort apps alphabetically
 	sort.Strings(apps)
 
-	return LcListAppsResult{Apps: apps, Directory: appsDir}, nil
+	return apps, nil
 }
 
-// CLI
-func LcListAppsCli() error {
-	args := os.Args[3:]
+// Tool
+func lcListAppsTool() registry.Tool {
+	return registry.New(registry.Spec[LcListAppsParams, LcListAppsResult]{
+		Name:        "lc_list_apps",
+		Group:       "lc",
+		Description: "List all available applications in every workspace. Apps outside the default workspace are named workspace/app",
+		Summary:     "List all available apps",
+		Hints:       registry.ReadOnly,
+		Examples: []string{
+			"layered-code tool lc_list_apps",
+			"layered-code tool lc_list_apps -l",
+		},
+		Run: func(ctx context.Context, params LcListAppsParams) (LcListAppsResult, error) {
+			return LcListApps(params)
+		},
+		Render: func(w io.Writer, _ LcListAppsParams, result LcListAppsResult) {
+			if len(result.Workspaces) <= 1 {
+				if len(result.Apps) == 0 {
+					fmt.Fprintf(w, "No apps found in: %s\n", result.Directory)
+					return
+				}
+
+				fmt.Fprintf(w, "Apps in '%s':\n", result.Directory)
+				for _, app := range result.Apps {
+					fmt.Fprintf(w, "  %s%s\n", app, describeApp(result, app))
+				}
+				return
+			}
+
+			for _, ws := range result.Workspaces {
+				fmt.Fprintf(w, "Workspace %s (%s):\n", ws.Name, ws.Directory)
+				if len(ws.Apps) == 0 {
+					fmt.Fprintf(w, "  (no apps)\n")
+				}
+				for _, app := range ws.Apps {
+					name := config.QualifiedAppName(ws.Name, app)
+					fmt.Fprintf(w, "  %s%s\n", name, describeApp(result, name))
+				}
+			}
+		},
+	})
+}
 
-	// Check for any arguments (list_apps doesn't take any)
-	if len(args) > 0 {
-		return fmt.Errorf("lc_list_apps does not accept any arguments, got: %v", args)
+// describeApp returns what is known about an app to show after its name: the framework and
+// description from its manifest, and its details in detailed mode
+func describeApp(result LcListAppsResult, name string) string {
+	m := result.Manifests[name]
+	d, detailed := result.Details[name]
+
+	var text string
+	framework := m.Framework
+	if detailed {
+		framework = d.Framework
+		if d.Vite {
+			framework = strings.TrimPrefix(framework+", vite", ", ")
+		}
 	}
-
-	result, err := LcListApps()
-	if err != nil {
-		return fmt.Errorf("failed to list apps: %w", err)
+	if framework != "" {
+		text += " [" + framework + "]"
 	}
-
-	if len(result.Apps) == 0 {
-		fmt.Printf("No apps found in: %s\n", result.Directory)
-		return nil
+	if m.Description != "" {
+		text += " - " + m.Description
 	}
-
-	fmt.Printf("Apps in '%s':\n", result.Directory)
-	for _, app := range result.Apps {
-		fmt.Printf("  %s\n", app)
+	if !detailed {
+		return text
 	}
-	return nil
-}
 
-// MCP
-func LcListAppsMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	result, err := LcListApps()
-	if err != nil {
-		return nil, err
+	var parts []string
+	if d.PackageManager != "" {
+		parts = append(parts, d.PackageManager)
 	}
-
-	// Convert result to JSON
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	if d.GitBranch != "" {
+		branch := d.GitBranch
+		if d.GitDirty {
+			branch += " (modified)"
+		}
+		parts = append(parts, "git:"+branch)
 	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
+	if d.Running {
+		parts = append(parts, "running")
+	}
+	parts = append(parts, formatSize(d.Size))
+	if d.LastModified != nil {
+		parts = append(parts, "modified "+d.LastModified.Local().Format("2006-01-02 15:04"))
+	}
+	if d.Incomplete {
+		parts = append(parts, "incomplete (timed out)")
+	}
+	return text + "\n      " + strings.Join(parts, ", ")
 }
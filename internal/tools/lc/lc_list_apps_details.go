@@ -0,0 +1,196 @@
+package lc
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/tools/git"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+)
+
+// LcAppDetails describes an app for the detailed lc_list_apps mode. Fields that couldn't be
+// determined before the timeout are left empty and Incomplete is set.
+type LcAppDetails struct {
+	Framework      string     `json:"framework,omitempty"`       // From the manifest, else package.json dependencies
+	Vite           bool       `json:"vite"`                      // Has a vite.config file
+	PackageManager string     `json:"package_manager,omitempty"` // From the lockfile
+	Lockfile       string     `json:"lockfile,omitempty"`
+	GitRepo        bool       `json:"git_repo"`
+	GitBranch      string     `json:"git_branch,omitempty"`
+	GitDirty       bool       `json:"git_dirty"`
+	Running        bool       `json:"running"` // Running under PM2
+	LastModified   *time.Time `json:"last_modified,omitempty"`
+	Size           int64      `json:"size"` // Bytes on disk, including dependencies
+	Incomplete     bool       `json:"incomplete,omitempty"`
+}
+
+// Lockfiles and their package managers, in order of preference
+var lockfiles = []struct {
+	name    string
+	manager string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"package-lock.json", "npm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lockb", "bun"},
+	{"bun.lock", "bun"},
+}
+
+var viteConfigs = []string{"vite.config.ts", "vite.config.js", "vite.config.mts", "vite.config.mjs", "vite.config.cjs"}
+
+// appDetails gathers the details of the apps at the given paths concurrently, keyed like paths.
+// Gathering stops when ctx is done; unfinished apps are marked incomplete.
+func appDetails(ctx context.Context, paths map[string]string) map[string]LcAppDetails {
+	// PM2 is asked alongside the inspection; apps are incomplete if it doesn't answer in time
+	pm2Done := make(chan struct{})
+	var processes map[string]pnpm.Pm2Process
+	var pm2Err error
+	go func() {
+		defer close(pm2Done)
+		processes, pm2Err = pnpm.Pm2Processes(ctx)
+	}()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		details = make(map[string]LcAppDetails, len(paths))
+		limit   = make(chan struct{}, constants.AppDetailsConcurrency)
+	)
+	for name, appPath := range paths {
+		wg.Add(1)
+		go func(name, appPath string) {
+			defer wg.Done()
+
+			var d LcAppDetails
+			select {
+			case limit <- struct{}{}:
+				d = inspectApp(ctx, name, appPath)
+				<-limit
+			case <-ctx.Done():
+				d.Incomplete = true
+			}
+
+			mu.Lock()
+			details[name] = d
+			mu.Unlock()
+		}(name, appPath)
+	}
+	wg.Wait()
+	<-pm2Done
+
+	// Processes are found by the name pnpm_pm2 start registered, which is the app name
+	for name, d := range details {
+		d.Running = processes[name].Running()
+		if pm2Err != nil && ctx.Err() != nil {
+			d.Incomplete = true
+		}
+		details[name] = d
+	}
+
+	return details
+}
+
+// inspectApp gathers the details of one app
+func inspectApp(ctx context.Context, appName, appPath string) LcAppDetails {
+	var d LcAppDetails
+
+	manifest, _, _ := config.ReadAppManifest(appPath)
+	d.Framework = manifest.Framework
+	if d.Framework == "" {
+		d.Framework = config.DetectFramework(appPath)
+	}
+	for _, name := range viteConfigs {
+		if _, err := os.Stat(filepath.Join(appPath, name)); err == nil {
+			d.Vite = true
+			break
+		}
+	}
+	for _, lock := range lockfiles {
+		if _, err := os.Stat(filepath.Join(appPath, lock.name)); err == nil {
+			d.PackageManager = lock.manager
+			d.Lockfile = lock.name
+			break
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(appPath, ".git")); err == nil {
+		d.GitRepo = true
+		if !gitState(ctx, appName, &d) {
+			d.Incomplete = true
+		}
+	}
+
+	if !diskUsage(ctx, appPath, &d) {
+		d.Incomplete = true
+	}
+	return d
+}
+
+// gitState reads the current branch and whether the working tree has changes; it reports
+// false if git couldn't be run in time
+func gitState(ctx context.Context, appName string, d *LcAppDetails) bool {
+	repo, err := git.OpenRepo(appName)
+	if err != nil {
+		return false
+	}
+	output, err := repo.Run(ctx, "status", "--porcelain=v1", "--branch")
+	if err != nil {
+		return false
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if header, ok := strings.CutPrefix(lines[0], "## "); ok {
+		// "main...origin/main [ahead 1]", "No commits yet on main" or "HEAD (no branch)"
+		branch, _, _ := strings.Cut(header, "...")
+		branch, _, _ = strings.Cut(branch, " [")
+		branch = strings.TrimPrefix(branch, "No commits yet on ")
+		if branch != "HEAD (no branch)" {
+			d.GitBranch = branch
+		}
+		lines = lines[1:]
+	}
+	d.GitDirty = len(lines) > 0 && lines[0] != ""
+	return true
+}
+
+// diskUsage totals the app's size on disk and finds its newest file, not counting
+// dependencies and git history towards the modification time; it reports false if ctx ended
+// before the walk finished
+func diskUsage(ctx context.Context, appPath string, d *LcAppDetails) bool {
+	var newest time.Time
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		d.Size += info.Size()
+		rel, _ := filepath.Rel(appPath, path)
+		top, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		if top != "node_modules" && top != ".git" && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+
+	if !newest.IsZero() {
+		d.LastModified = &newest
+	}
+	return err == nil
+}
@@ -2,10 +2,13 @@ package lc
 
 import (
 	"context"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -20,21 +23,11 @@ func TestLcListAppsResult(t *testing.T) {
 
 // TestLcListApps tests the core LcListApps functionality with real directory structures
 func TestLcListApps(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	os.MkdirAll(appsDir, 0755)
 
 	t.Run("empty directory", func(t *testing.T) {
-		t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
-		result, err := LcListApps()
+		result, err := LcListApps(LcListAppsParams{})
 		if err != nil {
 			t.Fatalf("LcListApps() failed: %v", err)
 		}
@@ -54,9 +47,7 @@ func TestLcListApps(t *testing.T) {
 		// Create a file (should be ignored)
 		os.WriteFile(filepath.Join(appsDir, "not-an-app.txt"), []byte("test"), 0644)
 
-		t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
-		result, err := LcListApps()
+		result, err := LcListApps(LcListAppsParams{})
 		if err != nil {
 			t.Fatalf("LcListApps() failed: %v", err)
 		}
@@ -75,30 +66,101 @@ func TestLcListApps(t *testing.T) {
 	})
 }
 
-// TestLcListAppsMcp tests the MCP interface wrapper for proper JSON marshaling
-// and error handling
-func TestLcListAppsMcp(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
+// TestLcListAppsWorkspaces tests that apps are reported with the workspace they belong to
+func TestLcListAppsWorkspaces(t *testing.T) {
+	appsDir := filepath.Join(testutil.Home(t), "apps")
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(appsDir, "personal"), 0755)
+	os.MkdirAll(filepath.Join(workDir, "api"), 0755)
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(configPath, []byte("[workspaces.work]\nroot = \""+filepath.ToSlash(workDir)+"\"\n"), 0644)
+	t.Setenv("LAYERED_CONFIG", configPath)
+
+	result, err := LcListApps(LcListAppsParams{})
 	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
+		t.Fatalf("LcListApps() failed: %v", err)
+	}
+
+	if len(result.Apps) != 2 || result.Apps[0] != "personal" || result.Apps[1] != "work/api" {
+		t.Errorf("Expected [personal work/api], got %v", result.Apps)
+	}
+	if len(result.Workspaces) != 2 {
+		t.Fatalf("Expected 2 workspaces, got %d", len(result.Workspaces))
+	}
+	if ws := result.Workspaces[1]; ws.Name != "work" || ws.Directory != workDir || len(ws.Apps) != 1 || ws.Apps[0] != "api" {
+		t.Errorf("Unexpected work workspace: %+v", ws)
 	}
+}
 
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
+// TestLcListAppsDetailed tests the per-app details of the detailed mode
+func TestLcListAppsDetailed(t *testing.T) {
+	homeDir := testutil.Home(t)
+
+	webApp := filepath.Join(homeDir, "apps", "web")
+	os.MkdirAll(webApp, 0755)
+	os.WriteFile(filepath.Join(webApp, "package.json"), []byte(`{"dependencies": {"react": "^18"}, "devDependencies": {"vite": "^5"}}`), 0644)
+	os.WriteFile(filepath.Join(webApp, "vite.config.ts"), []byte("export default {}\n"), 0644)
+	os.WriteFile(filepath.Join(webApp, "pnpm-lock.yaml"), []byte("lockfileVersion: 9\n"), 0644)
+	os.MkdirAll(filepath.Join(homeDir, "apps", "plain"), 0755)
+
+	if _, err := exec.LookPath("git"); err == nil {
+		cmd := exec.Command("git", "init", "-q", "-b", "main")
+		cmd.Dir = webApp
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git init failed: %v", err)
+		}
+	}
 
-	appsDir := filepath.Join(tempDir, "apps")
+	testutil.FakePm2(t, `[{"name":"web","pm2_env":{"status":"online"}},{"name":"plain","pm2_env":{"status":"stopped"}}]`)
+
+	result, err := LcListApps(LcListAppsParams{Detailed: true})
+	if err != nil {
+		t.Fatalf("LcListApps() failed: %v", err)
+	}
+
+	web, ok := result.Details["web"]
+	if !ok {
+		t.Fatalf("Expected details for web, got %v", result.Details)
+	}
+	if web.Framework != "react" || !web.Vite || web.PackageManager != "pnpm" || web.Lockfile != "pnpm-lock.yaml" {
+		t.Errorf("Unexpected project details: %+v", web)
+	}
+	if !web.Running || web.Size == 0 || web.LastModified == nil || web.Incomplete {
+		t.Errorf("Unexpected runtime details: %+v", web)
+	}
+	if web.GitRepo && (web.GitBranch != "main" || !web.GitDirty) {
+		t.Errorf("Expected dirty main branch, got %+v", web)
+	}
+
+	plain := result.Details["plain"]
+	if plain.Framework != "" || plain.Running || plain.GitRepo {
+		t.Errorf("Unexpected details for plain: %+v", plain)
+	}
+
+	result, err = LcListApps(LcListAppsParams{})
+	if err != nil {
+		t.Fatalf("LcListApps() failed: %v", err)
+	}
+	if result.Details != nil {
+		t.Error("Expected no details without detailed mode")
+	}
+}
+
+// TestLcListAppsMcp tests the MCP interface wrapper for proper JSON marshaling
+// and error handling
+func TestLcListAppsMcp(t *testing.T) {
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	os.MkdirAll(appsDir, 0755)
 	os.Mkdir(filepath.Join(appsDir, "testapp"), 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "lc_list_apps"
 
-	result, err := LcListAppsMcp(ctx, request)
+	result, err := lcListAppsTool().HandleMCP(ctx, request)
 	if err != nil {
-		t.Fatalf("LcListAppsMcp() failed: %v", err)
+		t.Fatalf("HandleMCP() failed: %v", err)
 	}
 
 	if result == nil {
@@ -109,17 +171,19 @@ func TestLcListAppsMcp(t *testing.T) {
 // TestFunctionExecutions tests that all exported functions execute without panicking
 // and verifies basic error handling for missing directories
 func TestFunctionExecutions(t *testing.T) {
+	testutil.Home(t)
+
 	tests := []struct {
 		name string
 		fn   func() error
 	}{
-		{"LcListApps", func() error { _, err := LcListApps(); return err }},
-		{"LcListAppsCli", func() error { return LcListAppsCli() }},
+		{"LcListApps", func() error { _, err := LcListApps(LcListAppsParams{}); return err }},
+		{"LcListAppsCli", func() error { return lcListAppsTool().RunCLI(nil, io.Discard) }},
 		{"LcListAppsMcp", func() error {
 			ctx := context.Background()
 			request := mcp.CallToolRequest{}
 			request.Params.Name = "lc_list_apps"
-			_, err := LcListAppsMcp(ctx, request)
+			_, err := lcListAppsTool().HandleMCP(ctx, request)
 			return err
 		}},
 	}
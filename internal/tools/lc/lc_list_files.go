@@ -2,20 +2,29 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/constants"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/helpers"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
+// LcListFilesParams represents the parameters for listing files
+type LcListFilesParams struct {
+	AppName             string  `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Pattern             *string `json:"pattern,omitempty" desc:"Glob pattern to filter files (e.g. '*.txt', 'src/*.js', '**/*.test.js')"`
+	IncludeLastModified bool    `json:"include_last_modified" desc:"Include last modification timestamps"`
+	IncludeSize         bool    `json:"include_size" desc:"Include file and directory sizes"`
+	IncludeChildCount   bool    `json:"include_child_count" desc:"Include count of immediate children for each entry"`
+}
+
 type LcListFilesResult struct {
 	AppName string      `json:"app_name"`
 	AppPath string      `json:"app_path"`
@@ -29,6 +38,7 @@ type FileEntry struct {
 	LastModified *time.Time `json:"last_modified,omitempty"`
 	Size         *string    `json:"size,omitempty"`
 	ChildCount   *int       `json:"child_count,omitempty"`
+	Protected    bool       `json:"protected,omitempty"`
 }
 
 var sizeCache = make(map[string]int64)
@@ -39,15 +49,11 @@ func LcListFiles(appName string, pattern *string, includeLastModified, includeSi
 		return LcListFilesResult{}, errors.New("app_name is required")
 	}
 
-	appsDir, err := config.EnsureAppsDirectory()
+	safePath, err := helpers.NewSafePath(appName)
 	if err != nil {
-		return LcListFilesResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
-	}
-	appPath := filepath.Join(appsDir, appName)
-
-	if _, err := os.Stat(appPath); os.IsNotExist(err) {
-		return LcListFilesResult{}, fmt.Errorf("app '%s' not found in apps directory", appName)
+		return LcListFilesResult{}, err
 	}
+	appPath := safePath.Dir
 
 	// Validate pattern if provided
 	if pattern != nil && *pattern != "" {
@@ -82,10 +88,19 @@ func LcListFiles(appName string, pattern *string, includeLastModified, includeSi
 			return err
 		}
 
+		// Skip paths denied by the files.deny setting
+		if path != appPath && safePath.Denied(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		entry := FileEntry{
 			Path:        relPath,
 			Name:        info.Name(),
 			IsDirectory: info.IsDir(),
+			Protected:   path != appPath && safePath.Protected(relPath),
 		}
 
 		if includeLastModified {
@@ -254,150 +269,63 @@ func formatSize(bytes int64) string {
 	}
 }
 
-func LcListFilesCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printLcListFilesHelp()
-			return nil
-		}
-	}
+// Tool
+func lcListFilesTool() registry.Tool {
+	return registry.New(registry.Spec[LcListFilesParams, LcListFilesResult]{
+		Name:        "lc_list_files",
+		Group:       "lc",
+		Description: "List files and directories within an application (max depth: 10,000 levels)",
+		Summary:     "List files and directories within an app",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Hidden files/folders (starting with '.') and symlinks are automatically skipped",
+			fmt.Sprintf("Maximum directory depth is limited to %d levels for safety", constants.MaxDirectoryDepth),
+			"Directory sizes are cached for performance",
+			"Files matching files.protected are listed but marked protected; lc_read_file needs --show-secrets to read them",
+		},
+		Examples: []string{
+			"# List basic files\nlayered-code tool lc_list_files --app-name myapp",
+			"# List files with all metadata\nlayered-code tool lc_list_files --app-name myapp --include-size --include-last-modified --include-child-count",
+			"# List files matching a pattern\nlayered-code tool lc_list_files --app-name myapp --pattern '*.js'",
+			"# List files in specific subdirectory\nlayered-code tool lc_list_files --app-name myapp --pattern 'src/*.go'",
+		},
+		Run: func(ctx context.Context, params LcListFilesParams) (LcListFilesResult, error) {
+			return LcListFiles(params.AppName, params.Pattern, params.IncludeLastModified, params.IncludeSize, params.IncludeChildCount)
+		},
+		Render: func(w io.Writer, _ LcListFilesParams, result LcListFilesResult) {
+			fmt.Fprintf(w, "App: %s\nPath: %s\n\n", result.AppName, result.AppPath)
+
+			for _, file := range result.Files {
+				// Indent based on path depth
+				depth := strings.Count(file.Path, string(os.PathSeparator))
+				indent := strings.Repeat("  ", depth)
+
+				if file.IsDirectory {
+					fmt.Fprintf(w, "%s%s/ ", indent, file.Name)
+				} else {
+					fmt.Fprintf(w, "%s%s ", indent, file.Name)
+				}
 
-	var appName string
-	var pattern *string
-	var includeLastModified, includeSize, includeChildCount bool
+				// Add optional metadata
+				var metadata []string
+				if file.Size != nil {
+					metadata = append(metadata, *file.Size)
+				}
+				if file.LastModified != nil {
+					metadata = append(metadata, file.LastModified.Format("2006-01-02 15:04:05"))
+				}
+				if file.ChildCount != nil && file.IsDirectory {
+					metadata = append(metadata, fmt.Sprintf("%d items", *file.ChildCount))
+				}
+				if file.Protected {
+					metadata = append(metadata, "protected")
+				}
 
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				appName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
-			}
-		case "--pattern":
-			if i+1 < len(args) {
-				pattern = &args[i+1]
-				i++
-			} else {
-				return errors.New("--pattern requires a value")
-			}
-		case "--include-last-modified":
-			includeLastModified = true
-		case "--include-size":
-			includeSize = true
-		case "--include-child-count":
-			includeChildCount = true
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_list_files --help' for usage", args[i])
+				if len(metadata) > 0 {
+					fmt.Fprintf(w, "(%s)", strings.Join(metadata, ", "))
+				}
+				fmt.Fprintln(w)
 			}
-		}
-	}
-
-	if appName == "" {
-		return errors.New("--app-name is required")
-	}
-
-	result, err := LcListFiles(appName, pattern, includeLastModified, includeSize, includeChildCount)
-	if err != nil {
-		return err
-	}
-
-	// Output in human-readable format
-	fmt.Printf("App: %s\nPath: %s\n\n", result.AppName, result.AppPath)
-
-	for _, file := range result.Files {
-		// Indent based on path depth
-		depth := strings.Count(file.Path, string(os.PathSeparator))
-		indent := strings.Repeat("  ", depth)
-
-		if file.IsDirectory {
-			fmt.Printf("%s%s/ ", indent, file.Name)
-		} else {
-			fmt.Printf("%s%s ", indent, file.Name)
-		}
-
-		// Add optional metadata
-		var metadata []string
-		if file.Size != nil {
-			metadata = append(metadata, *file.Size)
-		}
-		if file.LastModified != nil {
-			metadata = append(metadata, file.LastModified.Format("2006-01-02 15:04:05"))
-		}
-		if file.ChildCount != nil && file.IsDirectory {
-			metadata = append(metadata, fmt.Sprintf("%d items", *file.ChildCount))
-		}
-
-		if len(metadata) > 0 {
-			fmt.Printf("(%s)", strings.Join(metadata, ", "))
-		}
-		fmt.Println()
-	}
-
-	return nil
-}
-
-func printLcListFilesHelp() {
-	fmt.Println("Usage: layered-code tool lc_list_files [options]")
-	fmt.Println()
-	fmt.Println("List files and directories within an application")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>          Name of the app directory to analyze")
-	fmt.Println()
-	fmt.Println("Optional options:")
-	fmt.Println("  --pattern <glob>           Filter files using glob pattern (e.g. '*.txt', 'src/*.js')")
-	fmt.Println("  --include-last-modified    Include last modification timestamps")
-	fmt.Println("  --include-size             Include file/directory sizes in human-readable format")
-	fmt.Println("  --include-child-count      Include count of immediate children for directories")
-	fmt.Println("  --help, -h                 Show this help message")
-	fmt.Println()
-	fmt.Println("Notes:")
-	fmt.Println("  - Hidden files/folders (starting with '.') and symlinks are automatically skipped")
-	fmt.Printf("  - Maximum directory depth is limited to %d levels for safety\n", constants.MaxDirectoryDepth)
-	fmt.Println("  - Directory sizes are cached for performance")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # List basic files")
-	fmt.Println("  layered-code tool lc_list_files --app-name myapp")
-	fmt.Println()
-	fmt.Println("  # List files with all metadata")
-	fmt.Println("  layered-code tool lc_list_files --app-name myapp --include-size --include-last-modified --include-child-count")
-	fmt.Println()
-	fmt.Println("  # List files matching a pattern")
-	fmt.Println("  layered-code tool lc_list_files --app-name myapp --pattern '*.js'")
-	fmt.Println()
-	fmt.Println("  # List files in specific subdirectory")
-	fmt.Println("  layered-code tool lc_list_files --app-name myapp --pattern 'src/*.go'")
-}
-
-func LcListFilesMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName             string  `json:"app_name"`
-		Pattern             *string `json:"pattern"`
-		IncludeLastModified bool    `json:"include_last_modified"`
-		IncludeSize         bool    `json:"include_size"`
-		IncludeChildCount   bool    `json:"include_child_count"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	result, err := LcListFiles(args.AppName, args.Pattern, args.IncludeLastModified, args.IncludeSize, args.IncludeChildCount)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
-
-	return mcp.NewToolResultText(string(content)), nil
+		},
+	})
 }
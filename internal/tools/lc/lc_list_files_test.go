@@ -6,21 +6,14 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcListFiles tests the core LcListFiles functionality including basic listing,
 // pattern matching, and error handling
 func TestLcListFiles(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
@@ -28,10 +21,9 @@ func TestLcListFiles(t *testing.T) {
 	os.WriteFile(filepath.Join(appDir, "main.go"), []byte("package main"), 0644)
 	os.WriteFile(filepath.Join(appDir, "README.md"), []byte("# Test"), 0644)
 	os.WriteFile(filepath.Join(appDir, ".hidden"), []byte("hidden"), 0644)
+	os.WriteFile(filepath.Join(appDir, "server.pem"), []byte("key"), 0644)
 	os.Mkdir(filepath.Join(appDir, "src"), 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("basic listing", func(t *testing.T) {
 		result, err := LcListFiles("testapp", nil, false, false, false)
 		if err != nil {
@@ -40,9 +32,14 @@ func TestLcListFiles(t *testing.T) {
 		if result.AppName != "testapp" {
 			t.Errorf("AppName = %s; want testapp", result.AppName)
 		}
-		// Should find root, main.go, README.md, src (but not .hidden)
-		if len(result.Files) != 4 {
-			t.Errorf("Found %d files; want 4", len(result.Files))
+		// Should find root, main.go, README.md, server.pem, src (but not .hidden)
+		if len(result.Files) != 5 {
+			t.Errorf("Found %d files; want 5", len(result.Files))
+		}
+		for _, file := range result.Files {
+			if file.Protected != (file.Name == "server.pem") {
+				t.Errorf("%s: Protected = %v", file.Path, file.Protected)
+			}
 		}
 	})
 
@@ -77,12 +74,14 @@ func TestLcListFiles(t *testing.T) {
 // TestLcListFilesMcp tests the MCP interface wrapper to ensure it properly
 // handles requests and returns appropriate errors
 func TestLcListFilesMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "lc_list_files"
 	request.Params.Arguments = map[string]any{"app_name": "nonexistent"}
 
-	_, err := LcListFilesMcp(ctx, request)
+	_, err := lcListFilesTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
@@ -2,24 +2,23 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"path/filepath"
-	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
+	"github.com/layered-flow/layered-code/internal/helpers"
 	"github.com/layered-flow/layered-code/internal/notifications"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // LcMoveFileParams represents the parameters for moving/renaming a file
 type LcMoveFileParams struct {
-	AppName     string `json:"app_name"`
-	SourcePath  string `json:"source_path"`
-	DestPath    string `json:"dest_path"`
-	Overwrite   bool   `json:"overwrite,omitempty"`
+	AppName    string `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	SourcePath string `json:"source_path" required:"true" alias:"source,from" desc:"Source file path relative to the app directory"`
+	DestPath   string `json:"dest_path" required:"true" alias:"dest,to" desc:"Destination file path relative to the app directory"`
+	Overwrite  bool   `json:"overwrite,omitempty" desc:"Overwrite destination if it already exists (default: false)"`
 }
 
 // LcMoveFileResult represents the result of a move/rename operation
@@ -42,33 +41,15 @@ func LcMoveFile(params LcMoveFileParams) (LcMoveFileResult, error) {
 		return LcMoveFileResult{}, errors.New("dest_path is required")
 	}
 
-	// Validate paths don't contain directory traversal
-	if strings.Contains(params.SourcePath, "..") || strings.Contains(params.DestPath, "..") {
-		return LcMoveFileResult{}, errors.New("directory traversal is not allowed")
-	}
-
-	// Get and validate the apps directory
-	appsDir, err := config.EnsureAppsDirectory()
+	safePath, err := helpers.NewSafePath(params.AppName)
 	if err != nil {
-		return LcMoveFileResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
-	}
-
-	// Construct full paths
-	appPath := filepath.Join(appsDir, params.AppName)
-	sourcePath := filepath.Join(appPath, params.SourcePath)
-	destPath := filepath.Join(appPath, params.DestPath)
-
-	// Ensure paths are within the app directory
-	cleanSourcePath := filepath.Clean(sourcePath)
-	cleanDestPath := filepath.Clean(destPath)
-	if !config.IsWithinDirectory(cleanSourcePath, appPath) || !config.IsWithinDirectory(cleanDestPath, appPath) {
-		return LcMoveFileResult{}, errors.New("paths must be within the app directory")
+		return LcMoveFileResult{}, err
 	}
 
 	// Check if source file exists
-	sourceInfo, err := os.Stat(cleanSourcePath)
+	sourceInfo, err := safePath.Lstat(params.SourcePath)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return LcMoveFileResult{}, fmt.Errorf("source file not found: %s", params.SourcePath)
 		}
 		return LcMoveFileResult{}, fmt.Errorf("error accessing source file: %w", err)
@@ -81,28 +62,29 @@ func LcMoveFile(params LcMoveFileParams) (LcMoveFileResult, error) {
 
 	// Check if destination exists
 	destExists := false
-	if _, err := os.Stat(cleanDestPath); err == nil {
+	if _, err := safePath.Lstat(params.DestPath); err == nil {
 		if !params.Overwrite {
 			return LcMoveFileResult{}, fmt.Errorf("destination already exists: %s", params.DestPath)
 		}
 		destExists = true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return LcMoveFileResult{}, fmt.Errorf("error accessing destination: %w", err)
 	}
 
 	// Create destination directory if needed
-	destDir := filepath.Dir(cleanDestPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := safePath.MkdirAll(filepath.Dir(params.DestPath), 0755); err != nil {
 		return LcMoveFileResult{}, fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
 	// If overwriting, remove the destination file first
 	if destExists {
-		if err := os.Remove(cleanDestPath); err != nil {
+		if err := safePath.Remove(params.DestPath); err != nil {
 			return LcMoveFileResult{}, fmt.Errorf("failed to remove existing destination file: %w", err)
 		}
 	}
 
 	// Perform the move/rename
-	if err := os.Rename(cleanSourcePath, cleanDestPath); err != nil {
+	if err := safePath.Rename(params.SourcePath, params.DestPath); err != nil {
 		return LcMoveFileResult{}, fmt.Errorf("failed to move file: %w", err)
 	}
 
@@ -110,10 +92,8 @@ func LcMoveFile(params LcMoveFileParams) (LcMoveFileResult, error) {
 	isRename := filepath.Dir(params.SourcePath) == filepath.Dir(params.DestPath)
 
 	// Send notifications
-	sourceNotificationPath := filepath.Join(params.AppName, params.SourcePath)
-	destNotificationPath := filepath.Join(params.AppName, params.DestPath)
-	notifications.NotifyFileChange(sourceNotificationPath, "deleted")
-	notifications.NotifyFileChange(destNotificationPath, "created")
+	notifications.NotifyAppFileChange(params.AppName, params.SourcePath, "deleted")
+	notifications.NotifyAppFileChange(params.AppName, params.DestPath, "created")
 
 	return LcMoveFileResult{
 		AppName:    params.AppName,
@@ -123,115 +103,33 @@ func LcMoveFile(params LcMoveFileParams) (LcMoveFileResult, error) {
 	}, nil
 }
 
-// CLI
-func LcMoveFileCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printLcMoveFileHelp()
-			return nil
-		}
-	}
-
-	var params LcMoveFileParams
-
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				params.AppName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
-			}
-		case "--source", "--from":
-			if i+1 < len(args) {
-				params.SourcePath = args[i+1]
-				i++
+// Tool
+func lcMoveFileTool() registry.Tool {
+	return registry.New(registry.Spec[LcMoveFileParams, LcMoveFileResult]{
+		Name:        "lc_move_file",
+		Group:       "lc",
+		Description: "Move or rename a file within an application directory",
+		Summary:     "Move or rename a file within an app",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"Moving directories is not supported",
+			"Destination must not already exist (unless --overwrite is used)",
+			"Parent directories will be created if needed",
+		},
+		Examples: []string{
+			"# Rename a file in the same directory\nlayered-code tool lc_move_file --app-name myapp --source old.txt --dest new.txt",
+			"# Move a file to a different directory\nlayered-code tool lc_move_file --app-name myapp --from src/old.js --to archive/old.js",
+			"# Move and overwrite existing file\nlayered-code tool lc_move_file --app-name myapp --source temp.txt --dest final.txt --overwrite",
+		},
+		Run: func(ctx context.Context, params LcMoveFileParams) (LcMoveFileResult, error) {
+			return LcMoveFile(params)
+		},
+		Render: func(w io.Writer, _ LcMoveFileParams, result LcMoveFileResult) {
+			if result.IsRename {
+				fmt.Fprintf(w, "Renamed: %s/%s -> %s\n", result.AppName, result.SourcePath, result.DestPath)
 			} else {
-				return errors.New("--source requires a value")
+				fmt.Fprintf(w, "Moved: %s/%s -> %s/%s\n", result.AppName, result.SourcePath, result.AppName, result.DestPath)
 			}
-		case "--dest", "--to":
-			if i+1 < len(args) {
-				params.DestPath = args[i+1]
-				i++
-			} else {
-				return errors.New("--dest requires a value")
-			}
-		case "--overwrite":
-			params.Overwrite = true
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_move_file --help' for usage", args[i])
-			}
-		}
-	}
-
-	result, err := LcMoveFile(params)
-	if err != nil {
-		return err
-	}
-
-	if result.IsRename {
-		fmt.Printf("Renamed: %s/%s -> %s\n", result.AppName, result.SourcePath, result.DestPath)
-	} else {
-		fmt.Printf("Moved: %s/%s -> %s/%s\n", result.AppName, result.SourcePath, result.AppName, result.DestPath)
-	}
-	return nil
-}
-
-func printLcMoveFileHelp() {
-	fmt.Println("Usage: layered-code tool lc_move_file [options]")
-	fmt.Println()
-	fmt.Println("Move or rename a file within an application directory")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>    Name of the app directory")
-	fmt.Println("  --source <path>      Source file path relative to the app directory")
-	fmt.Println("  --dest <path>        Destination file path relative to the app directory")
-	fmt.Println()
-	fmt.Println("Optional options:")
-	fmt.Println("  --overwrite          Overwrite destination if it already exists")
-	fmt.Println()
-	fmt.Println("Aliases:")
-	fmt.Println("  --from               Alias for --source")
-	fmt.Println("  --to                 Alias for --dest")
-	fmt.Println()
-	fmt.Println("Notes:")
-	fmt.Println("  - Moving directories is not supported")
-	fmt.Println("  - Destination must not already exist (unless --overwrite is used)")
-	fmt.Println("  - Parent directories will be created if needed")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Rename a file in the same directory")
-	fmt.Println("  layered-code tool lc_move_file --app-name myapp --source old.txt --dest new.txt")
-	fmt.Println()
-	fmt.Println("  # Move a file to a different directory")
-	fmt.Println("  layered-code tool lc_move_file --app-name myapp --from src/old.js --to archive/old.js")
-	fmt.Println()
-	fmt.Println("  # Move and overwrite existing file")
-	fmt.Println("  layered-code tool lc_move_file --app-name myapp --source temp.txt --dest final.txt --overwrite")
+		},
+	})
 }
-
-// MCP
-func LcMoveFileMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var params LcMoveFileParams
-
-	if err := request.BindArguments(&params); err != nil {
-		return nil, err
-	}
-
-	result, err := LcMoveFile(params)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
-
-	return mcp.NewToolResultText(string(content)), nil
-}
\ No newline at end of file
@@ -2,29 +2,21 @@ package lc
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcMoveFile tests the core LcMoveFile functionality
 func TestLcMoveFile(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful rename", func(t *testing.T) {
 		// Create test file
 		testFile := filepath.Join(appDir, "old-name.txt")
@@ -209,26 +201,16 @@ func TestLcMoveFile(t *testing.T) {
 
 // TestLcMoveFileCli tests the CLI interface
 func TestLcMoveFileCli(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	oldArgs := os.Args
 	defer func() { os.Args = oldArgs }()
 
 	t.Run("help flag", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_move_file", "--help"}
-		err := LcMoveFileCli()
+		err := lcMoveFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("Help flag should not return error: %v", err)
 		}
@@ -236,7 +218,7 @@ func TestLcMoveFileCli(t *testing.T) {
 
 	t.Run("missing arguments", func(t *testing.T) {
 		os.Args = []string{"layered-code", "tool", "lc_move_file", "--app-name", "testapp"}
-		err := LcMoveFileCli()
+		err := lcMoveFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err == nil {
 			t.Error("Expected error for missing arguments")
 		}
@@ -245,6 +227,8 @@ func TestLcMoveFileCli(t *testing.T) {
 
 // TestLcMoveFileMcp tests the MCP interface
 func TestLcMoveFileMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "lc_move_file"
@@ -254,7 +238,7 @@ func TestLcMoveFileMcp(t *testing.T) {
 		"dest_path":   "new.txt",
 	}
 
-	_, err := LcMoveFileMcp(ctx, request)
+	_, err := lcMoveFileTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
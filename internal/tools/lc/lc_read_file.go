@@ -2,36 +2,46 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/constants"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/helpers"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
 )
 
 var (
 	ErrSymlink      = errors.New("file is a symlink")
 	ErrBinaryFile   = errors.New("file appears to be binary")
-	ErrFileTooLarge = errors.New("file exceeds maximum size of " + constants.MaxFileSizeInWords)
+	ErrFileTooLarge = errors.New("file exceeds maximum size")
+	ErrProtected    = errors.New("file is protected by the files.protected setting (set show_secrets to read it)")
 )
 
+// LcReadFileParams represents the parameters for reading a file
+type LcReadFileParams struct {
+	AppName     string `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	FilePath    string `json:"file_path" required:"true" desc:"Path to the file relative to the app directory"`
+	ShowSecrets bool   `json:"show_secrets" desc:"Read protected files and show secrets instead of redacting them"`
+}
+
 // LcReadFileResult represents the result of reading a file
 type LcReadFileResult struct {
 	AppName      string     `json:"app_name"`
 	FilePath     string     `json:"file_path"`
 	Content      string     `json:"content"`
+	Redacted     int        `json:"redacted,omitempty"`
 	LastModified *time.Time `json:"last_modified,omitempty"`
 }
 
-// LcReadFile reads the content of a file within an app directory
-func LcReadFile(appName, filePath string) (LcReadFileResult, error) {
+// LcReadFile reads the content of a file within an app directory. Unless showSecrets is set,
+// protected files are refused and secrets in the content are redacted.
+func LcReadFile(appName, filePath string, showSecrets bool) (LcReadFileResult, error) {
 	if appName == "" {
 		return LcReadFileResult{}, errors.New("app_name is required")
 	}
@@ -39,24 +49,17 @@ func LcReadFile(appName, filePath string) (LcReadFileResult, error) {
 		return LcReadFileResult{}, errors.New("file_path is required")
 	}
 
-	// Get and validate the apps directory
-	appsDir, err := config.EnsureAppsDirectory()
+	safePath, err := helpers.NewSafePath(appName)
 	if err != nil {
-		return LcReadFileResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
+		return LcReadFileResult{}, err
 	}
 
-	// Construct and validate the full file path
-	fullPath := filepath.Join(appsDir, appName, filePath)
-	cleanPath := filepath.Clean(fullPath)
-
-	// Ensure the file is within the app directory
-	appDir := filepath.Join(appsDir, appName)
-	if !config.IsWithinDirectory(cleanPath, appDir) {
-		return LcReadFileResult{}, fmt.Errorf("file path attempts to access file outside app directory")
+	if !showSecrets && safePath.Protected(filePath) {
+		return LcReadFileResult{}, ErrProtected
 	}
 
 	// Get file info
-	info, err := os.Lstat(cleanPath) // Use Lstat to detect symlinks
+	info, err := safePath.Lstat(filePath) // Use Lstat to detect symlinks
 	if err != nil {
 		return LcReadFileResult{}, err
 	}
@@ -67,12 +70,12 @@ func LcReadFile(appName, filePath string) (LcReadFileResult, error) {
 	}
 
 	// Check file size
-	if info.Size() > constants.MaxFileSize {
-		return LcReadFileResult{}, ErrFileTooLarge
+	if maxSize := config.MaxFileSize(); info.Size() > int64(maxSize) {
+		return LcReadFileResult{}, fmt.Errorf("%w of %s", ErrFileTooLarge, maxSize)
 	}
 
 	// Read file content and check if binary in one operation
-	content, err := os.ReadFile(cleanPath)
+	content, err := safePath.ReadFile(filePath)
 	if err != nil {
 		return LcReadFileResult{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -90,110 +93,47 @@ func LcReadFile(appName, filePath string) (LcReadFileResult, error) {
 		}
 	}
 
+	text, redacted := string(content), 0
+	if !showSecrets {
+		text, redacted = secrets.Redact(text)
+	}
+
 	modTime := info.ModTime()
 	return LcReadFileResult{
 		AppName:      appName,
 		FilePath:     filePath,
-		Content:      string(content),
+		Content:      text,
+		Redacted:     redacted,
 		LastModified: &modTime,
 	}, nil
 }
 
-// CLI
-func LcReadFileCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printReadFileHelp()
-			return nil
-		}
-	}
-
-	var appName, filePath string
-
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				appName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
-			}
-		case "--file-path":
-			if i+1 < len(args) {
-				filePath = args[i+1]
-				i++
-			} else {
-				return errors.New("--file-path requires a value")
-			}
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_read_file --help' for usage", args[i])
+// Tool
+func lcReadFileTool() registry.Tool {
+	return registry.New(registry.Spec[LcReadFileParams, LcReadFileResult]{
+		Name:        "lc_read_file",
+		Group:       "lc",
+		Description: "Read the contents of a file within an application directory (must be a text file, cannot be a symlink or binary file, max size " + config.MaxFileSize().String() + ")",
+		Summary:     "Read the contents of a file within an app",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Symlinks are not followed",
+			"Binary files are not supported",
+			"Maximum file size is " + config.MaxFileSize().String(),
+			"Protected files (files.protected) are refused and secrets are redacted unless --show-secrets is set",
+		},
+		Examples: []string{
+			"# Read a source file\nlayered-code tool lc_read_file --app-name myapp --file-path src/main.go",
+			"# Read a configuration file\nlayered-code tool lc_read_file --app-name myapp --file-path config/settings.json",
+		},
+		Run: func(ctx context.Context, params LcReadFileParams) (LcReadFileResult, error) {
+			return LcReadFile(params.AppName, params.FilePath, params.ShowSecrets)
+		},
+		Render: func(w io.Writer, _ LcReadFileParams, result LcReadFileResult) {
+			fmt.Fprintf(w, "App: %s\nFile: %s\n\nContent:\n%s\n", result.AppName, result.FilePath, result.Content)
+			if result.Redacted > 0 {
+				fmt.Fprintf(w, "\n%d secret(s) redacted; use --show-secrets to show them\n", result.Redacted)
 			}
-		}
-	}
-
-	if appName == "" {
-		return errors.New("--app-name is required")
-	}
-	if filePath == "" {
-		return errors.New("--file-path is required")
-	}
-
-	result, err := LcReadFile(appName, filePath)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("App: %s\nFile: %s\n\nContent:\n%s\n", result.AppName, result.FilePath, result.Content)
-	return nil
-}
-
-func printReadFileHelp() {
-	fmt.Println("Usage: layered-code tool lc_read_file [options]")
-	fmt.Println()
-	fmt.Println("Read the contents of a file within an application directory")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>    Name of the app directory")
-	fmt.Println("  --file-path <path>   Path to the file relative to the app directory")
-	fmt.Println()
-	fmt.Println("Notes:")
-	fmt.Println("  - Symlinks are not followed")
-	fmt.Println("  - Binary files are not supported")
-	fmt.Printf("  - Maximum file size is %s\n", constants.MaxFileSizeInWords)
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Read a source file")
-	fmt.Println("  layered-code tool lc_read_file --app-name myapp --file-path src/main.go")
-	fmt.Println()
-	fmt.Println("  # Read a configuration file")
-	fmt.Println("  layered-code tool lc_read_file --app-name myapp --file-path config/settings.json")
-}
-
-// MCP
-func LcReadFileMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName  string `json:"app_name"`
-		FilePath string `json:"file_path"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	result, err := LcReadFile(args.AppName, args.FilePath)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
-
-	return mcp.NewToolResultText(string(content)), nil
+		},
+	})
 }
@@ -0,0 +1,92 @@
+package lc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+)
+
+// LcRenameAppParams represents the parameters for renaming an app
+type LcRenameAppParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	NewName string `json:"new_name" required:"true" pos:"true" alias:"to" app:"true" desc:"New name for the app; qualify it as workspace/app to move it to another workspace"`
+}
+
+// LcRenameAppResult represents the result of renaming an app
+type LcRenameAppResult struct {
+	AppName        string `json:"app_name"`
+	NewName        string `json:"new_name"`
+	Path           string `json:"path"`
+	StoppedProcess bool   `json:"stopped_process"` // Its PM2 process was removed first
+}
+
+// LcRenameApp renames an app directory, stopping its PM2 process first
+func LcRenameApp(params LcRenameAppParams) (LcRenameAppResult, error) {
+	if params.AppName == "" {
+		return LcRenameAppResult{}, errors.New("app_name is required")
+	}
+	if params.NewName == "" {
+		return LcRenameAppResult{}, errors.New("new_name is required")
+	}
+
+	_, appDir, err := existingApp(params.AppName)
+	if err != nil {
+		return LcRenameAppResult{}, err
+	}
+	newName, newDir, err := newApp(params.AppName, params.NewName)
+	if err != nil {
+		return LcRenameAppResult{}, err
+	}
+	if err := checkWorktreeLinks(params.AppName, appDir, "renamed"); err != nil {
+		return LcRenameAppResult{}, err
+	}
+
+	stopped, err := pnpm.StopApp(params.AppName)
+	if err != nil {
+		return LcRenameAppResult{}, err
+	}
+	if err := os.Rename(appDir, newDir); err != nil {
+		return LcRenameAppResult{}, fmt.Errorf("failed to rename app: %w", err)
+	}
+
+	return LcRenameAppResult{
+		AppName:        params.AppName,
+		NewName:        newName,
+		Path:           newDir,
+		StoppedProcess: stopped,
+	}, nil
+}
+
+// Tool
+func lcRenameAppTool() registry.Tool {
+	return registry.New(registry.Spec[LcRenameAppParams, LcRenameAppResult]{
+		Name:        "lc_rename_app",
+		Group:       "lc",
+		Description: "Rename an application directory, or move it to another workspace. Its PM2 process is stopped first and must be started again under the new name",
+		Summary:     "Rename an app",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"The new name must not be taken; an unqualified name stays in the app's workspace",
+			"A running PM2 process for the app is deleted before the rename",
+			"Git worktrees (see git_worktree) and apps that have them can't be renamed, since git links them by path",
+		},
+		Examples: []string{
+			"layered-code tool lc_rename_app myapp shop",
+			"layered-code tool lc_rename_app myapp client-a/myapp",
+		},
+		Run: func(ctx context.Context, params LcRenameAppParams) (LcRenameAppResult, error) {
+			return LcRenameApp(params)
+		},
+		Render: func(w io.Writer, _ LcRenameAppParams, result LcRenameAppResult) {
+			if result.StoppedProcess {
+				fmt.Fprintf(w, "Stopped PM2 process for '%s'\n", result.AppName)
+			}
+			fmt.Fprintf(w, "Renamed: %s -> %s (%s)\n", result.AppName, result.NewName, result.Path)
+		},
+	})
+}
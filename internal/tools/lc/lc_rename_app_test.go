@@ -0,0 +1,123 @@
+package lc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+// TestLcRenameApp tests renaming an app within and across workspaces
+func TestLcRenameApp(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appsDir := filepath.Join(homeDir, "apps")
+	os.MkdirAll(filepath.Join(appsDir, "testapp", "src"), 0755)
+	os.WriteFile(filepath.Join(appsDir, "testapp", "src", "main.js"), []byte("console.log(1)"), 0644)
+	os.MkdirAll(filepath.Join(appsDir, "other"), 0755)
+
+	result, err := LcRenameApp(LcRenameAppParams{AppName: "testapp", NewName: "renamed"})
+	if err != nil {
+		t.Fatalf("LcRenameApp() failed: %v", err)
+	}
+	if result.NewName != "renamed" || result.Path != filepath.Join(appsDir, "renamed") || result.StoppedProcess {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "renamed", "src", "main.js")); err != nil {
+		t.Errorf("Expected files to move with the app: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "testapp")); !os.IsNotExist(err) {
+		t.Error("Expected the old app directory to be gone")
+	}
+
+	errorTests := []struct {
+		name   string
+		params LcRenameAppParams
+		errMsg string
+	}{
+		{"missing new name", LcRenameAppParams{AppName: "renamed"}, "new_name is required"},
+		{"missing app", LcRenameAppParams{AppName: "nope", NewName: "x"}, "not found"},
+		{"name taken", LcRenameAppParams{AppName: "renamed", NewName: "other"}, "already exists"},
+		{"traversal", LcRenameAppParams{AppName: "renamed", NewName: "../escaped"}, "invalid new app name"},
+		{"unknown workspace", LcRenameAppParams{AppName: "renamed", NewName: "nope/x"}, "unknown workspace"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LcRenameApp(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+// TestLcAppWorktreeLinks checks that app tools don't break the links between an app and its
+// git worktrees
+func TestLcAppWorktreeLinks(t *testing.T) {
+	homeDir := testutil.Home(t)
+	appsDir := filepath.Join(homeDir, "apps")
+	adminDir := filepath.Join(appsDir, "site", ".git", "worktrees", "site@exp")
+	os.MkdirAll(adminDir, 0755)
+	os.MkdirAll(filepath.Join(appsDir, "site@exp"), 0755)
+	os.WriteFile(filepath.Join(appsDir, "site@exp", ".git"), []byte("gitdir: "+adminDir+"\n"), 0644)
+	os.WriteFile(filepath.Join(appsDir, "site@exp", "index.html"), []byte("<h1>Exp</h1>"), 0644)
+
+	rename := func(app string) error {
+		_, err := LcRenameApp(LcRenameAppParams{AppName: app, NewName: "shop"})
+		return err
+	}
+	remove := func(app string) error {
+		_, err := LcDeleteApp(LcDeleteAppParams{AppName: app})
+		return err
+	}
+	archive := func(app string) error {
+		_, err := LcArchiveApp(LcArchiveAppParams{AppName: app})
+		return err
+	}
+	refused := []struct {
+		name    string
+		fn      func(string) error
+		appName string
+		errMsg  string
+	}{
+		{"rename app", rename, "site", "has git worktrees (site@exp)"},
+		{"rename worktree", rename, "site@exp", "is a git worktree"},
+		{"delete app", remove, "site", "has git worktrees"},
+		{"delete worktree", remove, "site@exp", "is a git worktree"},
+		{"archive app", archive, "site", "has git worktrees"},
+		{"archive worktree", archive, "site@exp", "is a git worktree"},
+	}
+	for _, tt := range refused {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(tt.appName); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+	for _, dir := range []string{adminDir, filepath.Join(appsDir, "site@exp", ".git")} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("Expected %s to be kept: %v", dir, err)
+		}
+	}
+
+	// Copies leave the links behind
+	copied, err := LcDuplicateApp(LcDuplicateAppParams{AppName: "site", NewName: "site-copy"})
+	if err != nil || !slices.Contains(copied.Skipped, ".git/worktrees") {
+		t.Fatalf("Expected the copy without worktrees, got %+v, %v", copied, err)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "site-copy", ".git", "worktrees")); !os.IsNotExist(err) {
+		t.Errorf("Expected no worktrees in the copy, got %v", err)
+	}
+	copied, err = LcDuplicateApp(LcDuplicateAppParams{AppName: "site@exp", NewName: "exp-copy"})
+	if err != nil || !slices.Contains(copied.Skipped, ".git") {
+		t.Fatalf("Expected the copy without its .git link, got %+v, %v", copied, err)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "exp-copy", ".git")); !os.IsNotExist(err) {
+		t.Errorf("Expected no .git link in the copy, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, "exp-copy", "index.html")); err != nil {
+		t.Errorf("Expected the worktree's files in the copy: %v", err)
+	}
+}
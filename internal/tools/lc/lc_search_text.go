@@ -6,22 +6,37 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/helpers"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
 )
 
+// LcSearchTextParams represents the parameters for searching text
+type LcSearchTextParams struct {
+	AppName       string `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	Pattern       string `json:"pattern" required:"true" desc:"Search pattern (supports regular expressions)"`
+	CaseSensitive bool   `json:"case_sensitive" desc:"Perform case-sensitive search (default: false)"`
+	WholeWord     bool   `json:"whole_word" desc:"Match whole words only"`
+	FilePattern   string `json:"file_pattern" desc:"Only search files matching this glob pattern (e.g. '*.go', '*.js')"`
+	MaxResults    int    `json:"max_results" desc:"Maximum number of results to return (default: 100)"`
+	IncludeHidden bool   `json:"include_hidden" desc:"Include hidden files and directories in search"`
+	ShowSecrets   bool   `json:"show_secrets" desc:"Include matches in protected files and show secrets in matches instead of redacting them"`
+}
+
 // LcSearchTextResult represents the result of searching for text in files
 type LcSearchTextResult struct {
 	AppName     string        `json:"app_name"`
 	Pattern     string        `json:"pattern"`
 	Matches     []LcSearchMatch `json:"matches"`
 	Total       int           `json:"total_matches"`
+	Redacted    int           `json:"redacted,omitempty"`
 	ErrorOutput string        `json:"error_output,omitempty"`
 }
 
@@ -40,6 +55,7 @@ type LcSearchTextOptions struct {
 	FilePattern   string
 	MaxResults    int
 	IncludeHidden bool
+	ShowSecrets   bool // Include protected files and don't redact secrets in matching lines
 }
 
 // LcSearchText searches for a pattern in files within an app directory using ripgrep
@@ -51,17 +67,11 @@ func LcSearchText(appName, pattern string, options LcSearchTextOptions) (LcSearc
 		return LcSearchTextResult{}, errors.New("pattern is required")
 	}
 
-	// Get and validate the apps directory
-	appsDir, err := config.EnsureAppsDirectory()
+	safePath, err := helpers.NewSafePath(appName)
 	if err != nil {
-		return LcSearchTextResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
-	}
-
-	// Construct and validate the app directory path
-	appDir := filepath.Join(appsDir, appName)
-	if _, err := os.Stat(appDir); os.IsNotExist(err) {
-		return LcSearchTextResult{}, fmt.Errorf("app '%s' not found in apps directory", appName)
+		return LcSearchTextResult{}, err
 	}
+	appDir := safePath.Dir
 
 	// Get ripgrep binary path
 	rgPath, err := getRipgrepPath()
@@ -124,20 +134,48 @@ func LcSearchText(appName, pattern string, options LcSearchTextOptions) (LcSearc
 		return LcSearchTextResult{}, fmt.Errorf("failed to parse ripgrep output: %w", err)
 	}
 
+	// Leave out files denied by the files.deny setting, and protected files unless secrets are
+	// shown: even without the line, a match tells whether a guessed secret is right
+	allowed := matches[:0]
+	for _, match := range matches {
+		if safePath.Denied(match.FilePath) || (!options.ShowSecrets && safePath.Protected(match.FilePath)) {
+			continue
+		}
+		allowed = append(allowed, match)
+	}
+	matches = allowed
+
 	// Apply max results limit if needed
 	if options.MaxResults > 0 && len(matches) > options.MaxResults {
 		matches = matches[:options.MaxResults]
 	}
 
+	// Redact secrets in matching lines
+	redacted := 0
+	if !options.ShowSecrets {
+		for i := range matches {
+			redacted += redactMatch(&matches[i])
+		}
+	}
+
 	return LcSearchTextResult{
 		AppName:     appName,
 		Pattern:     pattern,
 		Matches:     matches,
 		Total:       len(matches),
+		Redacted:    redacted,
 		ErrorOutput: stderr.String(),
 	}, nil
 }
 
+// redactMatch redacts secrets from a match, returning the number of secrets hidden
+func redactMatch(match *LcSearchMatch) int {
+	var n int
+	match.LineText, n = secrets.Redact(match.LineText)
+	match.Match, _ = secrets.Redact(match.Match)
+	return n
+}
+
 // parseRipgrepOutput parses the JSON output from ripgrep
 func parseRipgrepOutput(output string, appDir string) ([]LcSearchMatch, error) {
 	var matches []LcSearchMatch
@@ -252,157 +290,45 @@ func getRipgrepPath() (string, error) {
 	return "", errors.New("ripgrep is required but not found. Please install ripgrep: https://github.com/BurntSushi/ripgrep#installation")
 }
 
-// CLI
-func LcSearchTextCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printSearchTextHelp()
-			return nil
-		}
-	}
-
-	var appName, pattern string
-	var options LcSearchTextOptions
-	options.MaxResults = 100 // Default limit
-
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				appName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
+// Tool
+func lcSearchTextTool() registry.Tool {
+	return registry.New(registry.Spec[LcSearchTextParams, LcSearchTextResult]{
+		Name:        "lc_search_text",
+		Group:       "lc",
+		Description: "Search for text patterns in files within an application directory using ripgrep",
+		Summary:     "Search for text patterns in files using ripgrep",
+		Hints:       registry.ReadOnly,
+		Notes: []string{
+			"Matches in protected files (files.protected) are left out and secrets in other lines are redacted unless --show-secrets is set",
+		},
+		Examples: []string{
+			"# Search for a simple pattern\nlayered-code tool lc_search_text --app-name myapp --pattern 'TODO'",
+			"# Case-sensitive search in specific file types\nlayered-code tool lc_search_text --app-name myapp --pattern 'Config' --case-sensitive --file-pattern '*.go'",
+			"# Search for whole words with limited results\nlayered-code tool lc_search_text --app-name myapp --pattern 'test' --whole-word --max-results 50",
+		},
+		Run: func(ctx context.Context, params LcSearchTextParams) (LcSearchTextResult, error) {
+			// Set default max results if not specified
+			if params.MaxResults == 0 {
+				params.MaxResults = 100
 			}
-		case "--pattern":
-			if i+1 < len(args) {
-				pattern = args[i+1]
-				i++
-			} else {
-				return errors.New("--pattern requires a value")
-			}
-		case "--case-sensitive":
-			options.CaseSensitive = true
-		case "--whole-word":
-			options.WholeWord = true
-		case "--file-pattern":
-			if i+1 < len(args) {
-				options.FilePattern = args[i+1]
-				i++
-			} else {
-				return errors.New("--file-pattern requires a value")
-			}
-		case "--max-results":
-			if i+1 < len(args) {
-				var err error
-				_, err = fmt.Sscanf(args[i+1], "%d", &options.MaxResults)
-				if err != nil {
-					return fmt.Errorf("--max-results must be a number: %w", err)
-				}
-				i++
-			} else {
-				return errors.New("--max-results requires a value")
-			}
-		case "--include-hidden":
-			options.IncludeHidden = true
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_search_text --help' for usage", args[i])
-			}
-		}
-	}
-
-	if appName == "" {
-		return errors.New("--app-name is required")
-	}
-	if pattern == "" {
-		return errors.New("--pattern is required")
-	}
-
-	result, err := LcSearchText(appName, pattern, options)
-	if err != nil {
-		return err
-	}
-
-	// Output results
-	fmt.Printf("App: %s\nPattern: %s\nTotal matches: %d\n\n", result.AppName, result.Pattern, result.Total)
 
-	for _, match := range result.Matches {
-		fmt.Printf("%s:%d: %s\n", match.FilePath, match.LineNumber, match.LineText)
-	}
-
-	return nil
-}
-
-func printSearchTextHelp() {
-	fmt.Println("Usage: layered-code tool lc_search_text [options]")
-	fmt.Println()
-	fmt.Println("Search for text patterns in files within an application directory using ripgrep")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>        Name of the app directory")
-	fmt.Println("  --pattern <pattern>      Search pattern (supports regular expressions)")
-	fmt.Println()
-	fmt.Println("Optional options:")
-	fmt.Println("  --case-sensitive         Perform case-sensitive search (default: case-insensitive)")
-	fmt.Println("  --whole-word             Match whole words only")
-	fmt.Println("  --file-pattern <glob>    Only search files matching this glob pattern")
-	fmt.Println("  --max-results <n>        Maximum number of results to return (default: 100)")
-	fmt.Println("  --include-hidden         Include hidden files and directories in search")
-	fmt.Println("  --help, -h               Show this help message")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Search for a simple pattern")
-	fmt.Println("  layered-code tool lc_search_text --app-name myapp --pattern 'TODO'")
-	fmt.Println()
-	fmt.Println("  # Case-sensitive search in specific file types")
-	fmt.Println("  layered-code tool lc_search_text --app-name myapp --pattern 'Config' --case-sensitive --file-pattern '*.go'")
-	fmt.Println()
-	fmt.Println("  # Search for whole words with limited results")
-	fmt.Println("  layered-code tool lc_search_text --app-name myapp --pattern 'test' --whole-word --max-results 50")
-}
-
-// MCP
-func LcSearchTextMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName       string `json:"app_name"`
-		Pattern       string `json:"pattern"`
-		CaseSensitive bool   `json:"case_sensitive"`
-		WholeWord     bool   `json:"whole_word"`
-		FilePattern   string `json:"file_pattern"`
-		MaxResults    int    `json:"max_results"`
-		IncludeHidden bool   `json:"include_hidden"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	// Set default max results if not specified
-	if args.MaxResults == 0 {
-		args.MaxResults = 100
-	}
-
-	options := LcSearchTextOptions{
-		CaseSensitive: args.CaseSensitive,
-		WholeWord:     args.WholeWord,
-		FilePattern:   args.FilePattern,
-		MaxResults:    args.MaxResults,
-		IncludeHidden: args.IncludeHidden,
-	}
-
-	result, err := LcSearchText(args.AppName, args.Pattern, options)
-	if err != nil {
-		return nil, err
-	}
+			options := LcSearchTextOptions{
+				CaseSensitive: params.CaseSensitive,
+				WholeWord:     params.WholeWord,
+				FilePattern:   params.FilePattern,
+				MaxResults:    params.MaxResults,
+				IncludeHidden: params.IncludeHidden,
+				ShowSecrets:   params.ShowSecrets,
+			}
 
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
+			return LcSearchText(params.AppName, params.Pattern, options)
+		},
+		Render: func(w io.Writer, _ LcSearchTextParams, result LcSearchTextResult) {
+			fmt.Fprintf(w, "App: %s\nPattern: %s\nTotal matches: %d\n\n", result.AppName, result.Pattern, result.Total)
 
-	return mcp.NewToolResultText(string(content)), nil
+			for _, match := range result.Matches {
+				fmt.Fprintf(w, "%s:%d: %s\n", match.FilePath, match.LineNumber, match.LineText)
+			}
+		},
+	})
 }
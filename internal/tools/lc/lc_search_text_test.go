@@ -3,11 +3,13 @@ package lc
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -18,15 +20,7 @@ func TestLcSearchText(t *testing.T) {
 		t.Skip("Skipping test: ripgrep not available")
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	srcDir := filepath.Join(appDir, "src")
 	os.MkdirAll(srcDir, 0755)
@@ -66,7 +60,9 @@ This is a test application for searching.
 - Add documentation
 - Write tests
 - Deploy to production`,
-		".hidden": `This is a hidden file with TODO items`,
+		".hidden":    `This is a hidden file with TODO items`,
+		"deploy.pem": `SECRETVALUE in a protected file`,
+		"deploy.sh":  `STRIPE_SECRET=SECRETVALUE123`,
 	}
 
 	for path, content := range testFiles {
@@ -80,8 +76,6 @@ This is a test application for searching.
 		}
 	}
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	tests := []struct {
 		name         string
 		pattern      string
@@ -153,6 +147,37 @@ This is a test application for searching.
 			},
 			wantMinCount: 5, // Should include .hidden file
 		},
+		{
+			name:         "secrets masked",
+			pattern:      "SECRETVALUE",
+			options:      LcSearchTextOptions{},
+			wantMinCount: 1,
+			wantMaxCount: 1,
+			checkMatches: func(t *testing.T, matches []LcSearchMatch) {
+				for _, match := range matches {
+					if strings.Contains(match.LineText, "SECRETVALUE") || strings.Contains(match.Match, "SECRETVALUE") {
+						t.Errorf("Expected %s to be masked, got: %+v", match.FilePath, match)
+					}
+					if match.FilePath == "deploy.pem" {
+						t.Errorf("Expected matches in protected files to be left out, got: %+v", match)
+					}
+				}
+			},
+		},
+		{
+			name:         "secrets shown",
+			pattern:      "SECRETVALUE",
+			options:      LcSearchTextOptions{ShowSecrets: true},
+			wantMinCount: 2,
+			wantMaxCount: 2,
+			checkMatches: func(t *testing.T, matches []LcSearchMatch) {
+				for _, match := range matches {
+					if !strings.Contains(match.LineText, "SECRETVALUE") {
+						t.Errorf("Expected %s to be shown with show_secrets, got: %q", match.FilePath, match.LineText)
+					}
+				}
+			},
+		},
 		{
 			name:         "no matches",
 			pattern:      "NONEXISTENT",
@@ -204,19 +229,28 @@ This is a test application for searching.
 	}
 }
 
-// TestLcSearchTextErrors tests error conditions
-func TestLcSearchTextErrors(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
+// TestRedactMatch tests the redaction of search matches, which doesn't need ripgrep
+func TestRedactMatch(t *testing.T) {
+	tests := []struct {
+		match    LcSearchMatch
+		wantLine string
+		want     int
+	}{
+		{LcSearchMatch{FilePath: "deploy.sh", LineText: "export API_KEY=abcd1234", Match: "API_KEY"}, "export API_KEY=[REDACTED:secret-assignment]", 1},
+		{LcSearchMatch{FilePath: "main.go", LineText: "// TODO: add more", Match: "TODO"}, "// TODO: add more", 0},
 	}
+	for _, tt := range tests {
+		match := tt.match
+		if n := redactMatch(&match); n != tt.want || match.LineText != tt.wantLine {
+			t.Errorf("redactMatch(%s) = %q, %d; want %q, %d", tt.match.FilePath, match.LineText, n, tt.wantLine, tt.want)
+		}
+	}
+}
 
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+// TestLcSearchTextErrors tests error conditions
+func TestLcSearchTextErrors(t *testing.T) {
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	os.MkdirAll(appsDir, 0755)
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
 
 	tests := []struct {
 		name    string
@@ -264,23 +298,13 @@ func TestLcSearchTextCli(t *testing.T) {
 		t.Skip("Skipping test: ripgrep not available")
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
 	testContent := "// TODO: Test content"
 	os.WriteFile(filepath.Join(appDir, "test.go"), []byte(testContent), 0644)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	tests := []struct {
 		name    string
 		args    []string
@@ -330,7 +354,7 @@ func TestLcSearchTextCli(t *testing.T) {
 			defer func() { os.Args = oldArgs }()
 
 			os.Args = tt.args
-			err := LcSearchTextCli()
+			err := lcSearchTextTool().RunCLI(os.Args[3:], io.Discard)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchTextCli() error = %v, wantErr %v", err, tt.wantErr)
@@ -341,20 +365,14 @@ func TestLcSearchTextCli(t *testing.T) {
 
 // TestLcSearchTextMcp tests the MCP interface
 func TestLcSearchTextMcp(t *testing.T) {
+	testutil.Home(t)
+
 	// Skip if ripgrep is not available
 	if _, err := getRipgrepPath(); err != nil {
 		t.Skip("Skipping test: ripgrep not available")
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
@@ -363,8 +381,6 @@ func TestLcSearchTextMcp(t *testing.T) {
 // TODO: Implement feature`
 	os.WriteFile(filepath.Join(appDir, "main.go"), []byte(testContent), 0644)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful search via MCP", func(t *testing.T) {
 		args := map[string]interface{}{
 			"app_name": "testapp",
@@ -374,7 +390,7 @@ func TestLcSearchTextMcp(t *testing.T) {
 		request := mcp.CallToolRequest{}
 		data, _ := json.Marshal(args)
 		json.Unmarshal(data, &request.Params.Arguments)
-		result, err := LcSearchTextMcp(context.Background(), request)
+		result, err := lcSearchTextTool().HandleMCP(context.Background(), request)
 		if err != nil {
 			t.Fatalf("SearchTextMcp() failed: %v", err)
 		}
@@ -414,7 +430,7 @@ func TestLcSearchTextMcp(t *testing.T) {
 		request := mcp.CallToolRequest{}
 		data, _ := json.Marshal(args)
 		json.Unmarshal(data, &request.Params.Arguments)
-		result, err := LcSearchTextMcp(context.Background(), request)
+		result, err := lcSearchTextTool().HandleMCP(context.Background(), request)
 		if err != nil {
 			t.Fatalf("SearchTextMcp() failed: %v", err)
 		}
@@ -2,26 +2,29 @@ package lc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/layered-flow/layered-code/internal/config"
-	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/helpers"
 	"github.com/layered-flow/layered-code/internal/notifications"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/secrets"
 )
 
+// ErrRedactedContent is returned for writes containing [REDACTED:...] markers
+var ErrRedactedContent = errors.New("content contains redacted secrets; read the file with show_secrets or leave those lines unchanged")
+
 // LcWriteFileParams represents the parameters for writing a file
 type LcWriteFileParams struct {
-	AppName  string `json:"app_name"`
-	FilePath string `json:"file_path"`
-	Content  string `json:"content"`
-	Mode     string `json:"mode"` // "create" or "overwrite"
+	AppName  string `json:"app_name" required:"true" desc:"Name of the app directory (must exactly match an app name from lc_list_apps)"`
+	FilePath string `json:"file_path" required:"true" desc:"Path to the file relative to the app directory"`
+	Content  string `json:"content" required:"true" empty:"true" file:"true" desc:"Content to write to the file"`
+	Mode     string `json:"mode" desc:"Write mode: 'create' (default, fails if file exists) or 'overwrite' (replaces existing file)"`
 }
 
 // LcWriteFileResult represents the result of writing a file
@@ -48,39 +51,32 @@ func LcWriteFile(params LcWriteFileParams) (LcWriteFileResult, error) {
 		return LcWriteFileResult{}, fmt.Errorf("invalid mode: %s (must be 'create' or 'overwrite')", params.Mode)
 	}
 
-	// Check file size limit
-	if len(params.Content) > int(constants.MaxFileSize) {
-		return LcWriteFileResult{}, fmt.Errorf("content exceeds maximum file size of %s", constants.MaxFileSizeInWords)
-	}
-
-	// Get and validate the apps directory
-	appsDir, err := config.EnsureAppsDirectory()
-	if err != nil {
-		return LcWriteFileResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
+	// Redacted output from lc_read_file must not replace the secrets it hid
+	if secrets.HasRedactions(params.Content) {
+		return LcWriteFileResult{}, ErrRedactedContent
 	}
 
-	// Construct and validate the full file path
-	fullPath := filepath.Join(appsDir, params.AppName, params.FilePath)
-	cleanPath := filepath.Clean(fullPath)
-
-	// Ensure the file is within the app directory
-	appDir := filepath.Join(appsDir, params.AppName)
-	if !config.IsWithinDirectory(cleanPath, appDir) {
-		return LcWriteFileResult{}, fmt.Errorf("file path attempts to access file outside app directory")
+	// Check file size limit
+	if maxSize := config.MaxFileSize(); int64(len(params.Content)) > int64(maxSize) {
+		return LcWriteFileResult{}, fmt.Errorf("content exceeds maximum file size of %s", maxSize)
 	}
 
-	// Check if app directory exists
-	if _, err := os.Stat(appDir); os.IsNotExist(err) {
-		return LcWriteFileResult{}, fmt.Errorf("app directory does not exist: %s", params.AppName)
+	safePath, err := helpers.NewSafePath(params.AppName)
+	if err != nil {
+		return LcWriteFileResult{}, err
 	}
 
 	// Check if file exists
 	fileExists := false
-	if info, err := os.Stat(cleanPath); err == nil {
+	info, err := safePath.Stat(params.FilePath)
+	switch {
+	case err == nil:
 		if info.IsDir() {
 			return LcWriteFileResult{}, fmt.Errorf("path is a directory, not a file")
 		}
 		fileExists = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return LcWriteFileResult{}, err
 	}
 
 	// Handle create vs overwrite mode
@@ -89,18 +85,17 @@ func LcWriteFile(params LcWriteFileParams) (LcWriteFileResult, error) {
 	}
 
 	// Create parent directories if needed
-	dir := filepath.Dir(cleanPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := safePath.MkdirAll(filepath.Dir(params.FilePath), 0755); err != nil {
 		return LcWriteFileResult{}, fmt.Errorf("failed to create parent directories: %w", err)
 	}
 
 	// Write the file
-	if err := os.WriteFile(cleanPath, []byte(params.Content), 0644); err != nil {
+	if err := safePath.WriteFile(params.FilePath, []byte(params.Content), 0644); err != nil {
 		return LcWriteFileResult{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// Get file info for the result
-	info, err := os.Stat(cleanPath)
+	info, err = safePath.Stat(params.FilePath)
 	if err != nil {
 		return LcWriteFileResult{}, fmt.Errorf("failed to stat written file: %w", err)
 	}
@@ -110,8 +105,7 @@ func LcWriteFile(params LcWriteFileParams) (LcWriteFileResult, error) {
 	if !fileExists {
 		action = "create"
 	}
-	notificationPath := filepath.Join(params.AppName, params.FilePath)
-	notifications.NotifyFileChange(notificationPath, action)
+	notifications.NotifyAppFileChange(params.AppName, params.FilePath, action)
 
 	modTime := info.ModTime()
 	return LcWriteFileResult{
@@ -123,153 +117,33 @@ func LcWriteFile(params LcWriteFileParams) (LcWriteFileResult, error) {
 	}, nil
 }
 
-// CLI
-func LcWriteFileCli() error {
-	args := os.Args[3:]
-
-	// Check for help flag
-	for _, arg := range args {
-		if arg == "--help" || arg == "-h" {
-			printWriteFileHelp()
-			return nil
-		}
-	}
-
-	var params LcWriteFileParams
-	var contentFile string
-
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--app-name":
-			if i+1 < len(args) {
-				params.AppName = args[i+1]
-				i++
-			} else {
-				return errors.New("--app-name requires a value")
-			}
-		case "--file-path":
-			if i+1 < len(args) {
-				params.FilePath = args[i+1]
-				i++
-			} else {
-				return errors.New("--file-path requires a value")
-			}
-		case "--content":
-			if i+1 < len(args) {
-				params.Content = args[i+1]
-				i++
-			} else {
-				return errors.New("--content requires a value")
-			}
-		case "--content-file":
-			if i+1 < len(args) {
-				contentFile = args[i+1]
-				i++
-			} else {
-				return errors.New("--content-file requires a value")
-			}
-		case "--mode":
-			if i+1 < len(args) {
-				params.Mode = args[i+1]
-				i++
-			} else {
-				return errors.New("--mode requires a value")
-			}
-		default:
-			if strings.HasPrefix(args[i], "--") {
-				return fmt.Errorf("unknown option: %s\nRun 'layered-code tool lc_write_file --help' for usage", args[i])
+// Tool
+func lcWriteFileTool() registry.Tool {
+	return registry.New(registry.Spec[LcWriteFileParams, LcWriteFileResult]{
+		Name:        "lc_write_file",
+		Group:       "lc",
+		Description: "Write or create a file within an application directory (max size " + config.MaxFileSize().String() + ")",
+		Summary:     "Write or create a file within an app",
+		Hints:       registry.Hints{Destructive: true},
+		Notes: []string{
+			"Parent directories will be created automatically",
+			"Maximum file size is " + config.MaxFileSize().String(),
+		},
+		Examples: []string{
+			"# Create a new file\nlayered-code tool lc_write_file --app-name myapp --file-path src/new.go --content 'package main'",
+			"# Overwrite an existing file\nlayered-code tool lc_write_file --app-name myapp --file-path config.json --mode overwrite --content '{}'",
+			"# Write content from another file\nlayered-code tool lc_write_file --app-name myapp --file-path data.txt --content-file /tmp/source.txt",
+		},
+		Run: func(ctx context.Context, params LcWriteFileParams) (LcWriteFileResult, error) {
+			return LcWriteFile(params)
+		},
+		Render: func(w io.Writer, _ LcWriteFileParams, result LcWriteFileResult) {
+			action := "Updated"
+			if result.Created {
+				action = "Created"
 			}
-		}
-	}
-
-	if params.AppName == "" {
-		return errors.New("--app-name is required")
-	}
-	if params.FilePath == "" {
-		return errors.New("--file-path is required")
-	}
-
-	// Handle content source
-	if params.Content == "" && contentFile == "" {
-		return errors.New("either --content or --content-file is required")
-	}
-	if params.Content != "" && contentFile != "" {
-		return errors.New("cannot use both --content and --content-file")
-	}
-
-	// Read content from file if specified
-	if contentFile != "" {
-		content, err := os.ReadFile(contentFile)
-		if err != nil {
-			return fmt.Errorf("failed to read content file: %w", err)
-		}
-		params.Content = string(content)
-	}
-
-	result, err := LcWriteFile(params)
-	if err != nil {
-		return err
-	}
-
-	action := "Updated"
-	if result.Created {
-		action = "Created"
-	}
-	fmt.Printf("%s file: %s/%s\n", action, result.AppName, result.FilePath)
-	fmt.Printf("Bytes written: %d\n", result.BytesWritten)
-	return nil
+			fmt.Fprintf(w, "%s file: %s/%s\n", action, result.AppName, result.FilePath)
+			fmt.Fprintf(w, "Bytes written: %d\n", result.BytesWritten)
+		},
+	})
 }
-
-func printWriteFileHelp() {
-	fmt.Println("Usage: layered-code tool lc_write_file [options]")
-	fmt.Println()
-	fmt.Println("Write or create a file within an application directory")
-	fmt.Println()
-	fmt.Println("Required options:")
-	fmt.Println("  --app-name <name>    Name of the app directory")
-	fmt.Println("  --file-path <path>   Path to the file relative to the app directory")
-	fmt.Println()
-	fmt.Println("Content options (one required):")
-	fmt.Println("  --content <text>     Content to write to the file")
-	fmt.Println("  --content-file <path> Read content from the specified file")
-	fmt.Println()
-	fmt.Println("Optional:")
-	fmt.Println("  --mode <mode>        Write mode: 'create' (default) or 'overwrite'")
-	fmt.Println("                       'create' fails if file exists")
-	fmt.Println("                       'overwrite' replaces existing file")
-	fmt.Println()
-	fmt.Println("Notes:")
-	fmt.Println("  - Parent directories will be created automatically")
-	fmt.Printf("  - Maximum file size is %s\n", constants.MaxFileSizeInWords)
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  # Create a new file")
-	fmt.Println("  layered-code tool lc_write_file --app-name myapp --file-path src/new.go --content 'package main'")
-	fmt.Println()
-	fmt.Println("  # Overwrite an existing file")
-	fmt.Println("  layered-code tool lc_write_file --app-name myapp --file-path config.json --mode overwrite --content '{}'")
-	fmt.Println()
-	fmt.Println("  # Write content from another file")
-	fmt.Println("  layered-code tool lc_write_file --app-name myapp --file-path data.txt --content-file /tmp/source.txt")
-}
-
-// MCP
-func LcWriteFileMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var params LcWriteFileParams
-
-	if err := request.BindArguments(&params); err != nil {
-		return nil, err
-	}
-
-	result, err := LcWriteFile(params)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := json.Marshal(result)
-	if err != nil {
-		return nil, err
-	}
-
-	return mcp.NewToolResultText(string(content)), nil
-}
\ No newline at end of file
@@ -2,27 +2,22 @@ package lc
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/layered-flow/layered-code/internal/constants"
+	"github.com/layered-flow/layered-code/internal/testutil"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestLcWriteFile tests the core LcWriteFile functionality including successful writes
 // and various error conditions
 func TestLcWriteFile(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
@@ -33,8 +28,6 @@ func TestLcWriteFile(t *testing.T) {
 	// Create a directory for conflict tests
 	os.MkdirAll(filepath.Join(appDir, "testdir"), 0755)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	t.Run("successful create", func(t *testing.T) {
 		params := LcWriteFileParams{
 			AppName:  "testapp",
@@ -118,7 +111,7 @@ func TestLcWriteFile(t *testing.T) {
 			{LcWriteFileParams{FilePath: "test.txt", Content: "test"}, "app_name is required"},
 			{LcWriteFileParams{AppName: "testapp", Content: "test"}, "file_path is required"},
 			{LcWriteFileParams{AppName: "testapp", FilePath: "test.txt", Content: "test", Mode: "invalid"}, "invalid mode"},
-			{LcWriteFileParams{AppName: "nonexistent", FilePath: "test.txt", Content: "test"}, "app directory does not exist"},
+			{LcWriteFileParams{AppName: "nonexistent", FilePath: "test.txt", Content: "test"}, "not found in apps directory"},
 		}
 		for _, tt := range tests {
 			_, err := LcWriteFile(tt.params)
@@ -181,6 +174,17 @@ func TestLcWriteFile(t *testing.T) {
 		}
 	})
 
+	t.Run("redacted content", func(t *testing.T) {
+		params := LcWriteFileParams{
+			AppName:  "testapp",
+			FilePath: "keys.txt",
+			Content:  "AWS_ACCESS_KEY=[REDACTED:aws-access-key]",
+		}
+		if _, err := LcWriteFile(params); !errors.Is(err, ErrRedactedContent) {
+			t.Errorf("Expected ErrRedactedContent, got: %v", err)
+		}
+	})
+
 	t.Run("default mode", func(t *testing.T) {
 		params := LcWriteFileParams{
 			AppName:  "testapp",
@@ -200,24 +204,14 @@ func TestLcWriteFile(t *testing.T) {
 
 // TestLcWriteFileCli tests the CLI interface
 func TestLcWriteFileCli(t *testing.T) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get home directory: %v", err)
-	}
-
-	tempDir := filepath.Join(homeDir, ".layered-test-"+t.Name())
-	defer os.RemoveAll(tempDir)
-
-	appsDir := filepath.Join(tempDir, "apps")
+	appsDir := filepath.Join(testutil.Home(t), "apps")
 	appDir := filepath.Join(appsDir, "testapp")
 	os.MkdirAll(appDir, 0755)
 
 	// Create a file with content for --content-file tests
-	contentFile := filepath.Join(tempDir, "content.txt")
+	contentFile := filepath.Join(t.TempDir(), "content.txt")
 	os.WriteFile(contentFile, []byte("file content"), 0644)
 
-	t.Setenv("LAYERED_APPS_DIRECTORY", appsDir)
-
 	// Save original os.Args and restore after test
 	origArgs := os.Args
 	defer func() { os.Args = origArgs }()
@@ -239,7 +233,7 @@ func TestLcWriteFileCli(t *testing.T) {
 		}
 		for _, tt := range tests {
 			os.Args = tt.args
-			err := LcWriteFileCli()
+			err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 				t.Errorf("WriteFileCli() with args %v expected error containing %q, got: %v",
 					tt.args[3:], tt.wantErr, err)
@@ -250,7 +244,7 @@ func TestLcWriteFileCli(t *testing.T) {
 	t.Run("both content options", func(t *testing.T) {
 		os.Args = []string{"cmd", "tool", "write_file", "--app-name", "testapp", "--file-path", "test.txt",
 			"--content", "inline", "--content-file", contentFile}
-		err := LcWriteFileCli()
+		err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err == nil || !strings.Contains(err.Error(), "cannot use both --content and --content-file") {
 			t.Errorf("Expected error for using both content options, got: %v", err)
 		}
@@ -259,7 +253,7 @@ func TestLcWriteFileCli(t *testing.T) {
 	t.Run("help flag", func(t *testing.T) {
 		for _, helpFlag := range []string{"--help", "-h"} {
 			os.Args = []string{"cmd", "tool", "write_file", helpFlag}
-			err := LcWriteFileCli()
+			err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 			if err != nil {
 				t.Errorf("WriteFileCli() with %s should not error, got: %v", helpFlag, err)
 			}
@@ -269,7 +263,7 @@ func TestLcWriteFileCli(t *testing.T) {
 	t.Run("successful with inline content", func(t *testing.T) {
 		os.Args = []string{"cmd", "tool", "write_file", "--app-name", "testapp",
 			"--file-path", "cli-test.txt", "--content", "test content"}
-		err := LcWriteFileCli()
+		err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("WriteFileCli() failed: %v", err)
 		}
@@ -284,7 +278,7 @@ func TestLcWriteFileCli(t *testing.T) {
 	t.Run("successful with content file", func(t *testing.T) {
 		os.Args = []string{"cmd", "tool", "write_file", "--app-name", "testapp",
 			"--file-path", "cli-file-test.txt", "--content-file", contentFile}
-		err := LcWriteFileCli()
+		err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("WriteFileCli() failed: %v", err)
 		}
@@ -300,12 +294,12 @@ func TestLcWriteFileCli(t *testing.T) {
 		// Create file first
 		os.Args = []string{"cmd", "tool", "write_file", "--app-name", "testapp",
 			"--file-path", "mode-test.txt", "--content", "initial"}
-		LcWriteFileCli()
+		lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 
 		// Try to overwrite
 		os.Args = []string{"cmd", "tool", "write_file", "--app-name", "testapp",
 			"--file-path", "mode-test.txt", "--content", "updated", "--mode", "overwrite"}
-		err := LcWriteFileCli()
+		err := lcWriteFileTool().RunCLI(os.Args[3:], io.Discard)
 		if err != nil {
 			t.Errorf("WriteFileCli() with overwrite failed: %v", err)
 		}
@@ -320,6 +314,8 @@ func TestLcWriteFileCli(t *testing.T) {
 
 // TestLcWriteFileMcp tests the MCP interface wrapper
 func TestLcWriteFileMcp(t *testing.T) {
+	testutil.Home(t)
+
 	ctx := context.Background()
 	request := mcp.CallToolRequest{}
 	request.Params.Name = "write_file"
@@ -330,7 +326,7 @@ func TestLcWriteFileMcp(t *testing.T) {
 		"mode":      "create",
 	}
 
-	_, err := LcWriteFileMcp(ctx, request)
+	_, err := lcWriteFileTool().HandleMCP(ctx, request)
 	if err == nil {
 		t.Error("Expected error for non-existent app")
 	}
@@ -0,0 +1,26 @@
+package lc
+
+import (
+	"github.com/layered-flow/layered-code/internal/registry"
+)
+
+// Tools returns the file management tools in the order they are listed
+func Tools() []registry.Tool {
+	return []registry.Tool{
+		lcListAppsTool(),
+		lcAppConfigTool(),
+		lcRenameAppTool(),
+		lcDuplicateAppTool(),
+		lcArchiveAppTool(),
+		lcDeleteAppTool(),
+		lcListFilesTool(),
+		lcSearchTextTool(),
+		lcReadFileTool(),
+		lcWriteFileTool(),
+		lcEditFileTool(),
+		lcMoveFileTool(),
+		lcDeleteFileTool(),
+		lcCopyFileTool(),
+		lcAuditLogTool(),
+	}
+}
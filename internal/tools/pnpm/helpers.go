@@ -3,6 +3,7 @@ package pnpm
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 // DetectPackageManager detects which package manager is available (pnpm or npm)
@@ -19,4 +20,10 @@ func DetectPackageManager() (string, error) {
 	}
 	
 	return "", fmt.Errorf("neither pnpm nor npm is available. Please install Node.js and npm or pnpm")
-}
\ No newline at end of file
+}
+
+// shellQuote quotes s as a single word for the POSIX shell PM2 commands run in, so app names
+// and manifest commands can't end the word and run commands of their own
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
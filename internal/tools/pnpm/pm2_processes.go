@@ -0,0 +1,144 @@
+package pnpm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/layered-flow/layered-code/internal/constants"
+)
+
+// Pm2Process is a process PM2 manages, by the name it was started under
+type Pm2Process struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // online, stopping, stopped, launching, errored or one-launch-status
+}
+
+// Running reports whether the process is up or coming up
+func (p Pm2Process) Running() bool {
+	return p.Status == "online" || p.Status == "launching"
+}
+
+// Pm2Processes asks PM2 for the processes it manages, keyed by the name pnpm_pm2 start
+// registered them under. PM2 is only asked while its daemon is up, so this never starts one;
+// without a daemon there are no processes.
+func Pm2Processes(ctx context.Context) (map[string]Pm2Process, error) {
+	processes := make(map[string]Pm2Process)
+	if !pm2DaemonUp() {
+		return processes, nil
+	}
+
+	packageManager, err := DetectPackageManager()
+	if err != nil {
+		return nil, err
+	}
+	cmd := pm2Cmd(ctx, packageManager, "jlist")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to list PM2 processes: %w\nError output: %s", err, stderr.String())
+	}
+
+	list, err := parseJlist(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		processes[p.Name] = p
+	}
+	return processes, nil
+}
+
+// StopApp removes the app's PM2 process, if it has one, so it doesn't keep serving files that
+// are about to move or disappear. It reports whether a process was stopped. Only the name
+// pnpm_pm2 start registers is stopped: for work/app the unqualified app is another
+// workspace's process.
+func StopApp(appName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.Pm2StatusTimeout)
+	defer cancel()
+	processes, err := Pm2Processes(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a PM2 process for '%s': %w", appName, err)
+	}
+	if _, ok := processes[appName]; !ok {
+		return false, nil
+	}
+	if _, err := PnpmPm2("delete", appName, false); err != nil {
+		return false, fmt.Errorf("failed to stop PM2 process for '%s': %w", appName, err)
+	}
+	return true, nil
+}
+
+// parseJlist reads the processes from pm2 jlist output, which package manager or login shell
+// messages may precede
+func parseJlist(output []byte) ([]Pm2Process, error) {
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("[")) {
+			continue
+		}
+		var entries []struct {
+			Name   string `json:"name"`
+			Pm2Env struct {
+				Status string `json:"status"`
+			} `json:"pm2_env"`
+		}
+		if err := json.Unmarshal(line, &entries); err != nil {
+			continue
+		}
+		list := make([]Pm2Process, 0, len(entries))
+		for _, e := range entries {
+			list = append(list, Pm2Process{Name: e.Name, Status: e.Pm2Env.Status})
+		}
+		return list, nil
+	}
+	return nil, errors.New("failed to parse the PM2 process list")
+}
+
+// pm2DaemonUp reports whether PM2's daemon looks to be running, by the pid file it keeps in
+// $PM2_HOME while it runs
+func pm2DaemonUp() bool {
+	pm2Home := os.Getenv("PM2_HOME")
+	if pm2Home == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pm2Home = filepath.Join(homeDir, ".pm2")
+	}
+	_, err := os.Stat(filepath.Join(pm2Home, "pm2.pid"))
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// pm2Cmd returns a command running PM2 with args through the package manager. On Unix it runs
+// in a login shell so version managers like asdf and nvm are set up.
+func pm2Cmd(ctx context.Context, packageManager string, args ...string) *exec.Cmd {
+	runner := []string{"npx", "pm2"}
+	if packageManager == "pnpm" {
+		runner = []string{"pnpm", "dlx", "pm2"}
+	}
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, runner[0], append(runner[1:], args...)...)
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	words := append([]string(nil), runner...)
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	return exec.CommandContext(ctx, shell, "-l", "-c", strings.Join(words, " "))
+}
@@ -0,0 +1,62 @@
+package pnpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
+)
+
+func TestParseJlist(t *testing.T) {
+	output := "Progress: resolved 1, reused 1, downloaded 0, added 0\n" +
+		`[{"name":"work/app","pm2_env":{"status":"online"}},{"name":"app@exp","pm2_env":{"status":"stopped"}}]` + "\n"
+
+	list, err := parseJlist([]byte(output))
+	if err != nil {
+		t.Fatalf("parseJlist() failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "work/app" || !list[0].Running() || list[1].Name != "app@exp" || list[1].Running() {
+		t.Errorf("Unexpected processes: %+v", list)
+	}
+
+	if list, err := parseJlist([]byte("[]\n")); err != nil || len(list) != 0 {
+		t.Errorf("Expected no processes, got %+v, %v", list, err)
+	}
+	if _, err := parseJlist([]byte("command not found: pm2\n")); err == nil {
+		t.Error("Expected an error for output without a process list")
+	}
+}
+
+func TestPm2ProcessesWithoutDaemon(t *testing.T) {
+	t.Setenv("PM2_HOME", t.TempDir())
+
+	processes, err := Pm2Processes(t.Context())
+	if err != nil || len(processes) != 0 {
+		t.Errorf("Expected no processes without a PM2 daemon, got %v, %v", processes, err)
+	}
+
+	os.WriteFile(filepath.Join(os.Getenv("PM2_HOME"), "pm2.pid"), []byte("1"), 0644)
+	if !pm2DaemonUp() {
+		t.Error("Expected the pid file to mark the daemon as up")
+	}
+}
+
+// TestStopApp checks that only the PM2 process registered under the exact app name is stopped
+func TestStopApp(t *testing.T) {
+	calls := testutil.FakePm2(t, `[{"name":"app","pm2_env":{"status":"online"}},{"name":"app@exp","pm2_env":{"status":"online"}}]`)
+
+	if stopped, err := StopApp("work/app"); err != nil || stopped {
+		t.Errorf("StopApp(work/app) = %v, %v; want the default workspace's app left running", stopped, err)
+	}
+	if got := calls(); len(got) != 0 {
+		t.Errorf("Expected no PM2 commands, got %q", got)
+	}
+
+	if stopped, err := StopApp("app@exp"); err != nil || !stopped {
+		t.Errorf("StopApp(app@exp) = %v, %v; want the worktree's process stopped", stopped, err)
+	}
+	if got := calls(); len(got) != 1 || got[0] != "dlx pm2 delete app@exp" {
+		t.Errorf("Expected pm2 delete app@exp, got %q", got)
+	}
+}
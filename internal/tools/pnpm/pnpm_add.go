@@ -3,17 +3,14 @@ package pnpm
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
-	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -27,6 +24,11 @@ type PnpmAddResult struct {
 	ErrorOutput    string `json:"error_output,omitempty"`
 }
 
+type PnpmAddParams struct {
+	AppName     string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory to add package to (must exactly match an app name from lc_list_apps)"`
+	PackageName string `json:"package_name" required:"true" pos:"true" desc:"Name of the package to add (e.g. 'express', 'react@18', '@types/node')"`
+}
+
 // PnpmAdd adds a package to an app directory using pnpm or npm
 func PnpmAdd(appName string, packageName string, showOutput bool) (PnpmAddResult, error) {
 	// Validate app name
@@ -43,19 +45,12 @@ func PnpmAdd(appName string, packageName string, showOutput bool) (PnpmAddResult
 		return PnpmAddResult{}, fmt.Errorf("package name is required")
 	}
 
-	// Get apps directory
-	appsDir, err := config.GetAppsDirectory()
+	// Resolve the app directory, which must exist inside its workspace
+	safePath, err := helpers.NewSafePath(appName)
 	if err != nil {
-		return PnpmAddResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	// Create full app path
-	appPath := filepath.Join(appsDir, appName)
-
-	// Check if app exists
-	if _, err := os.Stat(appPath); os.IsNotExist(err) {
-		return PnpmAddResult{}, fmt.Errorf("app '%s' does not exist", appName)
+		return PnpmAddResult{}, err
 	}
+	appPath := safePath.Dir
 
 	// Check if package.json exists
 	packageJsonPath := filepath.Join(appPath, "package.json")
@@ -106,49 +101,20 @@ func PnpmAdd(appName string, packageName string, showOutput bool) (PnpmAddResult
 	}, nil
 }
 
-// CLI
-func PnpmAddCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 2 {
-		return fmt.Errorf("usage: layered-code tool pnpm_add <app_name> <package_name>")
-	}
-
-	appName := args[0]
-	packageName := strings.Join(args[1:], " ") // Join remaining args to support scoped packages
-	
-	result, err := PnpmAdd(appName, packageName, true) // showOutput = true for CLI
-	if err != nil {
-		return fmt.Errorf("failed to add package: %w", err)
-	}
-
-	fmt.Printf("\n%s\n", result.Message)
-	fmt.Printf("Location: %s\n", result.AppPath)
-
-	return nil
+// Tool
+func pnpmAddTool() registry.Tool {
+	return registry.New(registry.Spec[PnpmAddParams, PnpmAddResult]{
+		Name:        "pnpm_add",
+		Group:       "pnpm",
+		Description: "Add a package to an app directory using pnpm (preferred) or npm",
+		Summary:     "Add a package using pnpm (preferred) or npm",
+		Hints:       registry.Hints{Idempotent: true, OpenWorld: true},
+		Run: func(ctx context.Context, params PnpmAddParams) (PnpmAddResult, error) {
+			return PnpmAdd(params.AppName, params.PackageName, registry.Streaming(ctx))
+		},
+		Render: func(w io.Writer, _ PnpmAddParams, result PnpmAddResult) {
+			fmt.Fprintf(w, "\n%s\n", result.Message)
+			fmt.Fprintf(w, "Location: %s\n", result.AppPath)
+		},
+	})
 }
-
-// MCP
-func PnpmAddMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName     string `json:"app_name"`
-		PackageName string `json:"package_name"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	result, err := PnpmAdd(args.AppName, args.PackageName, false) // showOutput = false for MCP
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert result to JSON
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
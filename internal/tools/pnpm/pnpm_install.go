@@ -3,16 +3,14 @@ package pnpm
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 
-	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -25,6 +23,10 @@ type PnpmInstallResult struct {
 	ErrorOutput    string `json:"error_output,omitempty"`
 }
 
+type PnpmInstallParams struct {
+	AppName string `json:"app_name" required:"true" pos:"true" desc:"Name of the app directory to install dependencies in (must exactly match an app name from lc_list_apps)"`
+}
+
 // PnpmInstall installs dependencies in an app directory using pnpm or npm
 func PnpmInstall(appName string, showOutput bool) (PnpmInstallResult, error) {
 	// Validate app name
@@ -36,19 +38,12 @@ func PnpmInstall(appName string, showOutput bool) (PnpmInstallResult, error) {
 		return PnpmInstallResult{}, fmt.Errorf("invalid app name: %w", err)
 	}
 
-	// Get apps directory
-	appsDir, err := config.GetAppsDirectory()
+	// Resolve the app directory, which must exist inside its workspace
+	safePath, err := helpers.NewSafePath(appName)
 	if err != nil {
-		return PnpmInstallResult{}, fmt.Errorf("failed to get apps directory: %w", err)
-	}
-
-	// Create full app path
-	appPath := filepath.Join(appsDir, appName)
-
-	// Check if app exists
-	if _, err := os.Stat(appPath); os.IsNotExist(err) {
-		return PnpmInstallResult{}, fmt.Errorf("app '%s' does not exist", appName)
+		return PnpmInstallResult{}, err
 	}
+	appPath := safePath.Dir
 
 	// Check if package.json exists
 	packageJsonPath := filepath.Join(appPath, "package.json")
@@ -93,49 +88,23 @@ func PnpmInstall(appName string, showOutput bool) (PnpmInstallResult, error) {
 	}, nil
 }
 
-// CLI
-func PnpmInstallCli() error {
-	args := os.Args[3:]
-
-	if len(args) != 1 {
-		return fmt.Errorf("usage: layered-code tool pnpm_install <app_name>")
-	}
-
-	appName := args[0]
-	result, err := PnpmInstall(appName, true) // showOutput = true for CLI
-	if err != nil {
-		return fmt.Errorf("failed to install dependencies: %w", err)
-	}
-
-	fmt.Printf("\n%s\n", result.Message)
-	fmt.Printf("Location: %s\n", result.AppPath)
-	fmt.Printf("\nNext steps:\n")
-	fmt.Printf("  cd %s\n", result.AppPath)
-	fmt.Printf("  %s run dev\n", result.PackageManager)
-
-	return nil
+// Tool
+func pnpmInstallTool() registry.Tool {
+	return registry.New(registry.Spec[PnpmInstallParams, PnpmInstallResult]{
+		Name:        "pnpm_install",
+		Group:       "pnpm",
+		Description: "Install dependencies in an app directory using pnpm (preferred) or npm",
+		Summary:     "Install dependencies using pnpm (preferred) or npm",
+		Hints:       registry.Hints{Idempotent: true, OpenWorld: true},
+		Run: func(ctx context.Context, params PnpmInstallParams) (PnpmInstallResult, error) {
+			return PnpmInstall(params.AppName, registry.Streaming(ctx))
+		},
+		Render: func(w io.Writer, _ PnpmInstallParams, result PnpmInstallResult) {
+			fmt.Fprintf(w, "\n%s\n", result.Message)
+			fmt.Fprintf(w, "Location: %s\n", result.AppPath)
+			fmt.Fprintf(w, "\nNext steps:\n")
+			fmt.Fprintf(w, "  cd %s\n", result.AppPath)
+			fmt.Fprintf(w, "  %s run dev\n", result.PackageManager)
+		},
+	})
 }
-
-// MCP
-func PnpmInstallMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName string `json:"app_name"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	result, err := PnpmInstall(args.AppName, false) // showOutput = false for MCP
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert result to JSON
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
@@ -3,9 +3,13 @@ package pnpm
 import (
 	"strings"
 	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestPnpmInstallValidation(t *testing.T) {
+	testutil.Home(t)
+
 	// Test cases for validation only
 	tests := []struct {
 		name      string
@@ -23,7 +27,7 @@ func TestPnpmInstallValidation(t *testing.T) {
 			name:      "non-existent app",
 			appName:   "definitely-does-not-exist-app-12345",
 			wantErr:   true,
-			errMsg:    "does not exist",
+			errMsg:    "not found in apps directory",
 		},
 	}
 
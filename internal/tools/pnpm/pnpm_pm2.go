@@ -13,7 +13,7 @@ import (
 
 	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
 // Types
@@ -27,6 +27,11 @@ type PnpmPm2Result struct {
 	Message        string `json:"message"`
 }
 
+type PnpmPm2Params struct {
+	Command string `json:"command" required:"true" pos:"true" desc:"Command: 'start', 'stop', 'restart', 'delete', 'list', 'logs'"`
+	Target  string `json:"target,omitempty" pos:"true" app:"all" desc:"App name for start/stop/restart/delete, or 'all' for stop/restart/delete. Not needed for 'list'"`
+}
+
 // PackageJSON represents the structure of package.json
 type PackageJSON struct {
 	Scripts map[string]string `json:"scripts"`
@@ -51,6 +56,7 @@ func PnpmPm2(command string, target string, showOutput bool) (PnpmPm2Result, err
 	var pm2Command string
 	var appPath string
 	var appName string
+	var env []string
 
 	switch command {
 	case "start":
@@ -63,49 +69,59 @@ func PnpmPm2(command string, target string, showOutput bool) (PnpmPm2Result, err
 			return PnpmPm2Result{}, fmt.Errorf("invalid app name: %w", err)
 		}
 		
-		// Get apps directory
-		appsDir, err := config.GetAppsDirectory()
-		if err != nil {
-			return PnpmPm2Result{}, fmt.Errorf("failed to get apps directory: %w", err)
-		}
-		
 		appName = target
-		appPath = filepath.Join(appsDir, appName)
-		
-		// Check if app exists
-		if _, err := os.Stat(appPath); os.IsNotExist(err) {
-			return PnpmPm2Result{}, fmt.Errorf("app '%s' does not exist", appName)
+
+		// Resolve the app directory, which must exist inside its workspace
+		safePath, err := helpers.NewSafePath(appName)
+		if err != nil {
+			return PnpmPm2Result{}, err
 		}
+		appPath = safePath.Dir
 		
 		// Check for ecosystem.config.js
 		ecosystemPath := filepath.Join(appPath, "ecosystem.config.js")
+		manifest, err := config.LoadAppManifest(appName)
+		if err != nil {
+			return PnpmPm2Result{}, err
+		}
+		packageJsonPath := filepath.Join(appPath, "package.json")
 		if _, err := os.Stat(ecosystemPath); err == nil {
 			// Use ecosystem file
 			pm2Command = fmt.Sprintf("%s start ecosystem.config.js", pm2Prefix)
+		} else if manifest.DevCommand != "" {
+			// Use the dev command declared in the app manifest
+			_, script, _ := strings.Cut(manifest.DevCommand, " run ")
+			devCommand := withPort(manifest.DevCommand, script, packageJsonPath, manifest.PreviewPort)
+			pm2Command = fmt.Sprintf("%s start %s --name %s", pm2Prefix, shellQuote(devCommand), shellQuote(appName))
 		} else {
 			// No ecosystem file, determine script to run
-			packageJsonPath := filepath.Join(appPath, "package.json")
 			scriptToRun, err := getScriptToRun(packageJsonPath)
 			if err != nil {
 				return PnpmPm2Result{}, fmt.Errorf("failed to determine script to run: %w", err)
 			}
 			
 			// Build PM2 start command with the detected script
-			pm2Command = fmt.Sprintf("%s start \"%s run %s\" --name %s", pm2Prefix, packageManager, scriptToRun, appName)
+			devCommand := withPort(fmt.Sprintf("%s run %s", packageManager, scriptToRun), scriptToRun, packageJsonPath, manifest.PreviewPort)
+			pm2Command = fmt.Sprintf("%s start %s --name %s", pm2Prefix, shellQuote(devCommand), shellQuote(appName))
+		}
+
+		// Most dev servers listen on PORT; worktrees of an app each have their own
+		if manifest.PreviewPort != 0 {
+			env = append(os.Environ(), fmt.Sprintf("PORT=%d", manifest.PreviewPort))
 		}
 		
 	case "stop", "restart", "delete":
 		if target == "" {
 			return PnpmPm2Result{}, fmt.Errorf("target (app name or 'all') is required for %s command", command)
 		}
-		pm2Command = fmt.Sprintf("%s %s %s", pm2Prefix, command, target)
+		pm2Command = fmt.Sprintf("%s %s %s", pm2Prefix, command, shellQuote(target))
 		
 	case "list", "status", "ls":
 		pm2Command = fmt.Sprintf("%s list", pm2Prefix)
 		
 	case "logs":
 		if target != "" {
-			pm2Command = fmt.Sprintf("%s logs %s", pm2Prefix, target)
+			pm2Command = fmt.Sprintf("%s logs %s", pm2Prefix, shellQuote(target))
 		} else {
 			pm2Command = fmt.Sprintf("%s logs", pm2Prefix)
 		}
@@ -125,6 +141,7 @@ func PnpmPm2(command string, target string, showOutput bool) (PnpmPm2Result, err
 	if appPath != "" {
 		cmd.Dir = appPath
 	}
+	cmd.Env = env
 	
 	// Capture output
 	var outBuf, errBuf bytes.Buffer
@@ -190,66 +207,51 @@ func getScriptToRun(packageJsonPath string) (string, error) {
 	return "", fmt.Errorf("no suitable script found in package.json (looked for 'dev' or 'start' scripts)")
 }
 
-// CLI
-func PnpmPm2Cli() error {
-	args := os.Args[3:]
-	
-	if len(args) < 1 {
-		return fmt.Errorf("usage: layered-code tool pnpm_pm2 <command> [target]\n" +
-			"Commands:\n" +
-			"  start <app-name>    Start an app (uses ecosystem.config.js or package.json scripts)\n" +
-			"  stop <app-name|all> Stop a specific app or all apps\n" +
-			"  restart <app-name|all> Restart a specific app or all apps\n" +
-			"  delete <app-name|all>  Delete a specific app or all apps\n" +
-			"  list                Show all PM2 processes\n" +
-			"  logs [app-name]     Show logs for all apps or a specific app")
+// withPort makes a command running a package.json script listen on port when the script starts
+// Vite, which doesn't read PORT like most dev servers
+func withPort(command, script, packageJsonPath string, port int) string {
+	if port == 0 || script == "" {
+		return command
 	}
-	
-	command := args[0]
-	var target string
-	if len(args) > 1 {
-		target = args[1]
-	}
-	
-	result, err := PnpmPm2(command, target, true)
+	data, err := os.ReadFile(packageJsonPath)
 	if err != nil {
-		return fmt.Errorf("failed to execute pm2 command: %w", err)
+		return command
 	}
-	
-	// Output already printed by PnpmPm2 when showOutput=true
-	// Just print the success message if not already shown
-	if !strings.Contains(result.Output, result.Message) {
-		fmt.Printf("\n%s\n", result.Message)
+	var pkg PackageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil || !strings.HasPrefix(pkg.Scripts[script], "vite") {
+		return command
 	}
-	
-	return nil
-}
 
-// MCP
-func PnpmPm2Mcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		Command string `json:"command"`
-		Target  string `json:"target,omitempty"`
-	}
-	
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-	
-	if args.Command == "" {
-		return nil, fmt.Errorf("command is required")
-	}
-	
-	result, err := PnpmPm2(args.Command, args.Target, false)
-	if err != nil {
-		return nil, err
+	// npm passes arguments on to the script only after --
+	if strings.HasPrefix(command, "npm ") {
+		return fmt.Sprintf("%s -- --port %d", command, port)
 	}
-	
-	// Convert result to JSON
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-	
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
+	return fmt.Sprintf("%s --port %d", command, port)
+}
+
+// Tool
+func pnpmPm2Tool() registry.Tool {
+	return registry.New(registry.Spec[PnpmPm2Params, PnpmPm2Result]{
+		Name:        "pnpm_pm2",
+		Group:       "pnpm",
+		Description: "Manage Node.js apps with PM2. Smart defaults: 'start' uses ecosystem.config.js if present, then the dev_command from the app manifest (.layered/app.json), then auto-detects dev/start scripts",
+		Summary:     "Manage Node.js processes with PM2 (auto-detects scripts)",
+		Hints:       registry.Hints{Destructive: true, OpenWorld: true},
+		Notes: []string{
+			"start <app-name> starts an app (uses ecosystem.config.js, the app manifest's dev_command or package.json scripts)",
+			"The dev server gets the manifest's preview_port as PORT (and --port for Vite), so worktrees of an app (<app>@<name>) run side by side",
+			"stop, restart and delete accept an app name or 'all'",
+			"list shows all PM2 processes",
+			"logs shows logs for all apps or a specific app",
+		},
+		Run: func(ctx context.Context, params PnpmPm2Params) (PnpmPm2Result, error) {
+			return PnpmPm2(params.Command, params.Target, registry.Streaming(ctx))
+		},
+		Render: func(w io.Writer, _ PnpmPm2Params, result PnpmPm2Result) {
+			// Output is already streamed, so only print the message if it wasn't part of it
+			if !strings.Contains(result.Output, result.Message) {
+				fmt.Fprintf(w, "\n%s\n", result.Message)
+			}
+		},
+	})
+}
@@ -2,7 +2,9 @@ package pnpm
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -132,3 +134,46 @@ func TestPnpmPm2Result(t *testing.T) {
 	}
 }
 
+
+func TestWithPort(t *testing.T) {
+	dir := t.TempDir()
+	packageJsonPath := filepath.Join(dir, "package.json")
+	os.WriteFile(packageJsonPath, []byte(`{"scripts": {"dev": "vite", "start": "node server.js"}}`), 0644)
+
+	tests := []struct {
+		command, script string
+		port            int
+		want            string
+	}{
+		{"pnpm run dev", "dev", 5174, "pnpm run dev --port 5174"},
+		{"npm run dev", "dev", 5174, "npm run dev -- --port 5174"},
+		{"pnpm run start", "start", 5174, "pnpm run start"}, // Reads PORT instead
+		{"pnpm run dev", "dev", 0, "pnpm run dev"},
+	}
+	for _, tt := range tests {
+		if got := withPort(tt.command, tt.script, packageJsonPath, tt.port); got != tt.want {
+			t.Errorf("withPort(%q, %q, %d) = %q; want %q", tt.command, tt.script, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	for _, value := range []string{
+		"pnpm run dev",
+		`pnpm run dev "$(touch pwned)"`,
+		"pnpm run dev'; touch pwned; echo '",
+		"pnpm run dev `touch pwned`",
+		"work/app",
+	} {
+		out, err := exec.Command("/bin/sh", "-c", "printf %s "+shellQuote(value)).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", value, err)
+		}
+		if string(out) != value {
+			t.Errorf("shellQuote(%q) came out of the shell as %q", value, out)
+		}
+	}
+}
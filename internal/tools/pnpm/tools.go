@@ -0,0 +1,12 @@
+package pnpm
+
+import "github.com/layered-flow/layered-code/internal/registry"
+
+// Tools returns the package manager tools in the order they are listed
+func Tools() []registry.Tool {
+	return []registry.Tool{
+		pnpmInstallTool(),
+		pnpmAddTool(),
+		pnpmPm2Tool(),
+	}
+}
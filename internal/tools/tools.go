@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"github.com/layered-flow/layered-code/internal/registry"
+	"github.com/layered-flow/layered-code/internal/tools/git"
+	"github.com/layered-flow/layered-code/internal/tools/lc"
+	"github.com/layered-flow/layered-code/internal/tools/pnpm"
+	"github.com/layered-flow/layered-code/internal/tools/vite"
+)
+
+// Group describes a family of tools as shown in the CLI usage
+type Group struct {
+	Name  string
+	Title string
+}
+
+// Groups lists the tool groups in display order
+var Groups = []Group{
+	{Name: "lc", Title: "File Management Tools"},
+	{Name: "vite", Title: "Vite Tools"},
+	{Name: "pnpm", Title: "Package Manager Tools"},
+	{Name: "git", Title: "Git Tools"},
+}
+
+// All returns every tool exposed by the CLI and the MCP server
+func All() []registry.Tool {
+	var all []registry.Tool
+	all = append(all, lc.Tools()...)
+	all = append(all, vite.Tools()...)
+	all = append(all, pnpm.Tools()...)
+	all = append(all, git.Tools()...)
+	return all
+}
+
+// Find returns the tool with the given name
+func Find(name string) (registry.Tool, bool) {
+	for _, tool := range All() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return registry.Tool{}, false
+}
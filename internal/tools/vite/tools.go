@@ -0,0 +1,10 @@
+package vite
+
+import "github.com/layered-flow/layered-code/internal/registry"
+
+// Tools returns the Vite tools in the order they are listed
+func Tools() []registry.Tool {
+	return []registry.Tool{
+		viteCreateAppTool(),
+	}
+}
@@ -3,7 +3,6 @@ package vite
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -12,9 +11,12 @@ import (
 
 	"github.com/layered-flow/layered-code/internal/config"
 	"github.com/layered-flow/layered-code/internal/helpers"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/layered-flow/layered-code/internal/registry"
 )
 
+// viteDevPort is the port the Vite dev server listens on by default
+const viteDevPort = 5173
+
 // Types
 type ViteCreateAppResult struct {
 	AppName     string `json:"app_name"`
@@ -25,6 +27,11 @@ type ViteCreateAppResult struct {
 	ErrorOutput string `json:"error_output,omitempty"`
 }
 
+type ViteCreateAppParams struct {
+	AppName  string `json:"app_name" required:"true" pos:"true" desc:"Name of the app to create (must be unique, cannot contain special characters or '..')"`
+	Template string `json:"template,omitempty" pos:"true" desc:"Vite template to use (default: react-ts). Options: vanilla, vanilla-ts, vue, vue-ts, react, react-ts, react-swc, react-swc-ts, preact, preact-ts, lit, lit-ts, svelte, svelte-ts, solid, solid-ts, qwik, qwik-ts"`
+}
+
 // ViteCreateApp creates a new Vite app in the apps directory with the specified template
 func ViteCreateApp(appName string, template string, showOutput bool) (ViteCreateAppResult, error) {
 	// Validate app name
@@ -54,16 +61,12 @@ func ViteCreateApp(appName string, template string, showOutput bool) (ViteCreate
 		return ViteCreateAppResult{}, fmt.Errorf("invalid template '%s'. Valid templates are: vanilla, vanilla-ts, vue, vue-ts, react, react-ts, react-swc, react-swc-ts, preact, preact-ts, lit, lit-ts, svelte, svelte-ts, solid, solid-ts, qwik, qwik-ts", template)
 	}
 
-
-	// Ensure apps directory exists
-	appsDir, err := config.EnsureAppsDirectory()
+	// Get the app directory in its workspace
+	appPath, err := config.AppDirectory(appName)
 	if err != nil {
-		return ViteCreateAppResult{}, fmt.Errorf("failed to ensure apps directory: %w", err)
+		return ViteCreateAppResult{}, fmt.Errorf("failed to get app directory: %w", err)
 	}
 
-	// Create full app path
-	appPath := filepath.Join(appsDir, appName)
-
 	// Check if app already exists
 	if _, err := os.Stat(appPath); err == nil {
 		return ViteCreateAppResult{}, fmt.Errorf("app '%s' already exists", appName)
@@ -80,12 +83,12 @@ func ViteCreateApp(appName string, template string, showOutput bool) (ViteCreate
 	// Create the Vite app
 	var cmd *exec.Cmd
 	if packageManager == "pnpm" {
-		cmd = exec.Command("pnpm", "create", "vite", appName, "--template", template, "--", "--yes")
+		cmd = exec.Command("pnpm", "create", "vite", filepath.Base(appPath), "--template", template, "--", "--yes")
 	} else {
-		cmd = exec.Command("npm", "create", "vite@latest", appName, "--", "--template", template)
+		cmd = exec.Command("npm", "create", "vite@latest", filepath.Base(appPath), "--", "--template", template)
 	}
 	
-	cmd.Dir = appsDir
+	cmd.Dir = filepath.Dir(appPath)
 	
 	// Capture output
 	var outBuf, errBuf bytes.Buffer
@@ -106,6 +109,19 @@ func ViteCreateApp(appName string, template string, showOutput bool) (ViteCreate
 		return ViteCreateAppResult{}, fmt.Errorf("failed to create Vite app: %w\nError output: %s", err, errBuf.String())
 	}
 
+	// Record how to run and build the app so other tools don't have to guess
+	manifest := config.AppManifest{
+		Framework:    template,
+		DevCommand:   packageManager + " run dev",
+		PreviewPort:  viteDevPort,
+		BuildCommand: packageManager + " run build",
+		OutputDir:    "dist",
+		Ignore:       []string{"node_modules", "dist"},
+	}
+	if err := config.WriteAppManifest(appPath, manifest); err != nil {
+		return ViteCreateAppResult{}, fmt.Errorf("created Vite app but failed to write its manifest: %w", err)
+	}
+
 	return ViteCreateAppResult{
 		AppName:     appName,
 		AppPath:     appPath,
@@ -116,55 +132,24 @@ func ViteCreateApp(appName string, template string, showOutput bool) (ViteCreate
 	}, nil
 }
 
-// CLI
-func ViteCreateAppCli() error {
-	args := os.Args[3:]
-
-	if len(args) < 1 || len(args) > 2 {
-		return fmt.Errorf("usage: layered-code tool vite_create_app <app_name> [template]")
-	}
-
-	appName := args[0]
-	template := "react-ts" // Default template
-	if len(args) == 2 {
-		template = args[1]
-	}
-	result, err := ViteCreateApp(appName, template, true) // showOutput = true for CLI
-	if err != nil {
-		return fmt.Errorf("failed to create Vite app: %w", err)
-	}
-
-	fmt.Printf("\n%s\n", result.Message)
-	fmt.Printf("Location: %s\n", result.AppPath)
-	fmt.Printf("\nNext steps:\n")
-	fmt.Printf("  layered-code tool pnpm_install %s\n", result.AppName)
-	fmt.Printf("  cd %s\n", result.AppPath)
-	fmt.Printf("  %s run dev\n", result.Manager)
-
-	return nil
+// Tool
+func viteCreateAppTool() registry.Tool {
+	return registry.New(registry.Spec[ViteCreateAppParams, ViteCreateAppResult]{
+		Name:        "vite_create_app",
+		Group:       "vite",
+		Description: "Create a new Vite app in the apps directory with specified template (dependencies not installed - use pnpm_install)",
+		Summary:     "Create a new Vite app with template",
+		Hints:       registry.Hints{OpenWorld: true},
+		Run: func(ctx context.Context, params ViteCreateAppParams) (ViteCreateAppResult, error) {
+			return ViteCreateApp(params.AppName, params.Template, registry.Streaming(ctx))
+		},
+		Render: func(w io.Writer, _ ViteCreateAppParams, result ViteCreateAppResult) {
+			fmt.Fprintf(w, "\n%s\n", result.Message)
+			fmt.Fprintf(w, "Location: %s\n", result.AppPath)
+			fmt.Fprintf(w, "\nNext steps:\n")
+			fmt.Fprintf(w, "  layered-code tool pnpm_install %s\n", result.AppName)
+			fmt.Fprintf(w, "  cd %s\n", result.AppPath)
+			fmt.Fprintf(w, "  %s run dev\n", result.Manager)
+		},
+	})
 }
-
-// MCP
-func ViteCreateAppMcp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var args struct {
-		AppName  string `json:"app_name"`
-		Template string `json:"template,omitempty"`
-	}
-
-	if err := request.BindArguments(&args); err != nil {
-		return nil, err
-	}
-
-	result, err := ViteCreateApp(args.AppName, args.Template, false) // showOutput = false for MCP
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert result to JSON
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
-}
\ No newline at end of file
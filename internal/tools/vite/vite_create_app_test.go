@@ -3,9 +3,13 @@ package vite
 import (
 	"strings"
 	"testing"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestViteCreateAppValidation(t *testing.T) {
+	testutil.Home(t)
+
 	// Test cases for validation only
 	tests := []struct {
 		name      string
@@ -72,6 +76,8 @@ func TestViteCreateAppValidation(t *testing.T) {
 }
 
 func TestViteCreateAppTemplateValidation(t *testing.T) {
+	testutil.Home(t)
+
 	tests := []struct {
 		name     string
 		appName  string
@@ -91,14 +91,14 @@ func writeCache(cache *UpdateCache) error {
 	return os.WriteFile(cachePath, data, 0644)
 }
 
-func CheckForUpdate(currentVersion string) (bool, string, error) {
+func CheckForUpdate(currentVersion string, interval time.Duration) (bool, string, error) {
 	if currentVersion == "dev" {
 		return false, "", nil
 	}
 
 	// Check if we have a recent cache
 	cache, err := readCache()
-	if err == nil && time.Since(cache.LastChecked) < constants.UpdateCheckInterval {
+	if err == nil && time.Since(cache.LastChecked) < interval {
 		return cache.HasUpdate, cache.LatestVersion, nil
 	}
 
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/layered-flow/layered-code/internal/testutil"
 )
 
 func TestCompareVersions(t *testing.T) {
@@ -50,13 +52,15 @@ func getTestCacheDir() (string, error) {
 }
 
 func TestCheckForUpdate(t *testing.T) {
+	testutil.Home(t)
+
 	// Clean up any existing cache for test
 	cacheDir, _ := getTestCacheDir()
 	cachePath := filepath.Join(cacheDir, "update_check.json")
 	os.Remove(cachePath)
 
 	// Test with dev version
-	hasUpdate, _, err := CheckForUpdate("dev")
+	hasUpdate, _, err := CheckForUpdate("dev", 24*time.Hour)
 	if err != nil {
 		t.Errorf("CheckForUpdate(dev) returned error: %v", err)
 	}
@@ -66,6 +70,8 @@ func TestCheckForUpdate(t *testing.T) {
 }
 
 func TestCacheReadWrite(t *testing.T) {
+	testutil.Home(t)
+
 	// Clean up any existing cache
 	cacheDir, _ := getTestCacheDir()
 	cachePath := filepath.Join(cacheDir, "update_check.json")
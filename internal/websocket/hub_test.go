@@ -62,4 +62,23 @@ func TestHub(t *testing.T) {
 			t.Error("Client channel should be closed")
 		}
 	}
-}
\ No newline at end of file
+}
+func TestNotifyFileChange(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	client := &Client{hub: hub, send: make(chan []byte, 256)}
+	hub.register <- client
+
+	hub.NotifyFileChange(`my"app/index.html`, "edit", "http://localhost:5173")
+
+	select {
+	case msg := <-client.send:
+		want := `{"type":"file-changed","filename":"my\"app/index.html","action":"edit","preview_url":"http://localhost:5173"}`
+		if string(msg) != want {
+			t.Errorf("Expected %s, got %s", want, msg)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Expected to receive file change message")
+	}
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"sync"
@@ -73,8 +74,17 @@ func (h *Hub) Run() {
 	}
 }
 
-func (h *Hub) NotifyFileChange(filename string, action string) {
-	message := []byte(`{"type":"file-changed","filename":"` + filename + `","action":"` + action + `"}`)
+// fileChange is the message sent to clients when a file changes
+type fileChange struct {
+	Type       string `json:"type"`
+	Filename   string `json:"filename"`
+	Action     string `json:"action"`
+	PreviewURL string `json:"preview_url,omitempty"`
+}
+
+// NotifyFileChange tells clients a file changed; previewURL is where the app is served, if known
+func (h *Hub) NotifyFileChange(filename string, action string, previewURL string) {
+	message, _ := json.Marshal(fileChange{Type: "file-changed", Filename: filename, Action: action, PreviewURL: previewURL})
 	h.broadcast <- message
 }
 